@@ -207,6 +207,10 @@ func (c *importChecker) checkPreImportingJob(job ImportJob) {
 	if len(lacks) == 0 {
 		return
 	}
+	if importutilv2.IsValidateOnly(job.GetOptions()) {
+		c.finishValidation(job, lacks)
+		return
+	}
 
 	requestSize, err := CheckDiskQuota(job, c.meta, c.imeta)
 	if err != nil {
@@ -238,6 +242,36 @@ func (c *importChecker) checkPreImportingJob(job ImportJob) {
 	}
 }
 
+// finishValidation completes the validate only job once all the files are validated by preimport without importing,
+// or fails it if any file has invalid rows.
+func (c *importChecker) finishValidation(job ImportJob, fileStats []*datapb.ImportFileStats) {
+	var invalidRows int64
+	samplesPaths := make([]string, 0)
+	for _, stat := range fileStats {
+		invalidRows += stat.GetInvalidRows()
+		if stat.GetInvalidRows() > 0 && stat.GetErrorSamplesPath() != "" {
+			samplesPaths = append(samplesPaths, stat.GetErrorSamplesPath())
+		}
+	}
+	if invalidRows > 0 {
+		reason := fmt.Sprintf("validation found %d invalid rows, error samples are saved to %v", invalidRows, samplesPaths)
+		err := c.imeta.UpdateJob(job.GetJobID(), UpdateJobState(internalpb.ImportJobState_Failed), UpdateJobReason(reason))
+		if err != nil {
+			log.Warn("failed to update job state to Failed", zap.Int64("jobID", job.GetJobID()), zap.Error(err))
+			return
+		}
+		log.Info("import job validation failed", zap.Int64("jobID", job.GetJobID()), zap.String("reason", reason))
+		return
+	}
+	completeTime := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	err := c.imeta.UpdateJob(job.GetJobID(), UpdateJobState(internalpb.ImportJobState_Completed), UpdateJobCompleteTime(completeTime))
+	if err != nil {
+		log.Warn("failed to update job state to Completed", zap.Int64("jobID", job.GetJobID()), zap.Error(err))
+		return
+	}
+	log.Info("import job validated", zap.Int64("jobID", job.GetJobID()))
+}
+
 func (c *importChecker) checkImportingJob(job ImportJob) {
 	log := log.With(zap.Int64("jobID", job.GetJobID()),
 		zap.Int64("collectionID", job.GetCollectionID()))
@@ -18,11 +18,13 @@ package datacoord
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"testing"
 	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
@@ -32,6 +34,7 @@ import (
 	"github.com/milvus-io/milvus/internal/metastore/mocks"
 	"github.com/milvus-io/milvus/internal/proto/datapb"
 	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/util/importutilv2"
 	"github.com/milvus-io/milvus/pkg/util/tsoutil"
 )
 
@@ -273,6 +276,46 @@ func (s *ImportCheckerSuite) TestCheckJob_Failed() {
 	s.Equal(internalpb.ImportJobState_Importing, s.imeta.GetJob(job.GetJobID()).GetState())
 }
 
+func (s *ImportCheckerSuite) TestCheckJob_ValidateOnly() {
+	job := s.imeta.GetJob(s.jobID)
+	job.(*importJob).ImportJob.Options = []*commonpb.KeyValuePair{{Key: importutilv2.ValidateOnly, Value: "true"}}
+
+	alloc := s.checker.alloc.(*NMockAllocator)
+	alloc.EXPECT().allocN(mock.Anything).Return(0, 0, nil)
+	catalog := s.imeta.(*importMeta).catalog.(*mocks.DataCoordCatalog)
+	catalog.EXPECT().SavePreImportTask(mock.Anything).Return(nil)
+	s.checker.checkPendingJob(job)
+	preimportTasks := s.imeta.GetTaskBy(WithJob(job.GetJobID()), WithType(PreImportTaskType))
+	s.Equal(2, len(preimportTasks))
+
+	completePreImports := func(invalidRows int64) {
+		for i, t := range preimportTasks {
+			fileStats := lo.Map(t.GetFileStats(), func(stat *datapb.ImportFileStats, _ int) *datapb.ImportFileStats {
+				return &datapb.ImportFileStats{
+					ImportFile:       stat.GetImportFile(),
+					InvalidRows:      invalidRows * int64(i),
+					ErrorSamplesPath: fmt.Sprintf("samples/%d", i),
+				}
+			})
+			err := s.imeta.UpdateTask(t.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Completed), UpdateFileStats(fileStats))
+			s.NoError(err)
+		}
+	}
+
+	// the invalid rows fail the job, and nothing is imported.
+	completePreImports(2)
+	s.checker.checkPreImportingJob(job)
+	s.Equal(0, len(s.imeta.GetTaskBy(WithJob(job.GetJobID()), WithType(ImportTaskType))))
+	s.Equal(internalpb.ImportJobState_Failed, s.imeta.GetJob(job.GetJobID()).GetState())
+	s.Contains(s.imeta.GetJob(job.GetJobID()).GetReason(), "samples/1")
+	s.NotContains(s.imeta.GetJob(job.GetJobID()).GetReason(), "samples/0")
+
+	completePreImports(0)
+	s.checker.checkPreImportingJob(job)
+	s.Equal(0, len(s.imeta.GetTaskBy(WithJob(job.GetJobID()), WithType(ImportTaskType))))
+	s.Equal(internalpb.ImportJobState_Completed, s.imeta.GetJob(job.GetJobID()).GetState())
+}
+
 func (s *ImportCheckerSuite) TestCheckTimeout() {
 	catalog := s.imeta.(*importMeta).catalog.(*mocks.DataCoordCatalog)
 	catalog.EXPECT().SavePreImportTask(mock.Anything).Return(nil)
@@ -121,6 +121,9 @@ type DataNode struct {
 	chunkManager storage.ChunkManager
 	allocator    allocator.Allocator
 
+	// importStorageBackends are the extra storages which import files can be read from.
+	importStorageBackends importv2.StorageBackends
+
 	closer io.Closer
 
 	dispClient msgdispatcher.Client
@@ -280,6 +283,12 @@ func (node *DataNode) Init() error {
 		}
 
 		node.chunkManager = chunkManager
+		node.importStorageBackends, err = importv2.NewStorageBackends(node.ctx, Params.DataNodeCfg.ImportStorageBackends.GetValue())
+		if err != nil {
+			initError = err
+			log.Error("failed to create import storage backends", zap.Error(err))
+			return
+		}
 		syncMgr, err := syncmgr.NewSyncManager(node.chunkManager, node.allocator)
 		if err != nil {
 			initError = err
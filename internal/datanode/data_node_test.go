@@ -33,6 +33,7 @@ import (
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/datanode/broker"
 	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
 	"github.com/milvus-io/milvus/internal/storage"
 	"github.com/milvus-io/milvus/internal/types"
 	"github.com/milvus-io/milvus/internal/util/sessionutil"
@@ -79,6 +80,40 @@ func TestMain(t *testing.M) {
 	os.Exit(code)
 }
 
+func TestDataNodeImportStorageBackends(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rootPath := t.TempDir()
+	paramtable.Get().SaveGroup(map[string]string{
+		"dataNode.import.storageBackends.archive.storageType": "local",
+		"dataNode.import.storageBackends.archive.rootPath":    rootPath,
+	})
+	defer paramtable.Get().SaveGroup(map[string]string{
+		"dataNode.import.storageBackends.archive.storageType": "",
+		"dataNode.import.storageBackends.archive.rootPath":    "",
+	})
+
+	node := newIDLEDataNodeMock(ctx, schemapb.DataType_Int64)
+	etcdCli, err := etcd.GetEtcdClient(
+		Params.EtcdCfg.UseEmbedEtcd.GetAsBool(),
+		Params.EtcdCfg.EtcdUseSSL.GetAsBool(),
+		Params.EtcdCfg.Endpoints.GetAsStrings(),
+		Params.EtcdCfg.EtcdTLSCert.GetValue(),
+		Params.EtcdCfg.EtcdTLSKey.GetValue(),
+		Params.EtcdCfg.EtcdTLSCACert.GetValue(),
+		Params.EtcdCfg.EtcdTLSMinVersion.GetValue())
+	assert.NoError(t, err)
+	defer etcdCli.Close()
+	node.SetEtcdClient(etcdCli)
+	assert.NoError(t, node.Init())
+
+	// the import files of configured backend are read from its own storage.
+	cm, err := node.importStorageBackends.Resolve(node.chunkManager, &internalpb.ImportFile{StorageBackend: "archive"})
+	assert.NoError(t, err)
+	assert.Equal(t, rootPath, cm.RootPath())
+}
+
 func TestDataNode(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,134 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+const errorSamplesDir = "import_error_samples"
+
+// ErrorSample is a bad row sampled from import file for debugging.
+type ErrorSample struct {
+	Path   string        `json:"path"`
+	Offset int64         `json:"offset"`
+	Reason string        `json:"reason"`
+	Row    map[int64]any `json:"row,omitempty"`
+}
+
+// errorSampler keeps at most limit samples in memory, and saves them into a side artifact.
+type errorSampler struct {
+	limit   int
+	path    string
+	samples []*ErrorSample
+}
+
+func newErrorSampler(limit int, path string) *errorSampler {
+	return &errorSampler{
+		limit:   limit,
+		path:    path,
+		samples: make([]*ErrorSample, 0, limit),
+	}
+}
+
+func (s *errorSampler) Full() bool {
+	return len(s.samples) >= s.limit
+}
+
+// SampleError records an error which has no concrete row, such as parsing failure.
+func (s *errorSampler) SampleError(offset int64, reason error) {
+	if s.Full() {
+		return
+	}
+	s.samples = append(s.samples, &ErrorSample{
+		Path:   s.path,
+		Offset: offset,
+		Reason: reason.Error(),
+	})
+}
+
+// SampleRow records a bad row, offset is the row offset in file.
+func (s *errorSampler) SampleRow(row map[int64]any, offset int64, reason error) {
+	if s.Full() {
+		return
+	}
+	s.samples = append(s.samples, &ErrorSample{
+		Path:   s.path,
+		Offset: offset,
+		Reason: reason.Error(),
+		Row:    row,
+	})
+}
+
+// SampleRows records the rows of a bad batch, offset is the row offset of the batch in file.
+// The fields which are not long enough are omitted since the batch may be unaligned.
+func (s *errorSampler) SampleRows(data *storage.InsertData, offset int64, reason error) {
+	rowNum := 0
+	for _, fd := range data.Data {
+		rowNum = max(rowNum, fd.RowNum())
+	}
+	for i := 0; i < rowNum && !s.Full(); i++ {
+		row := make(map[int64]any, len(data.Data))
+		for fieldID, fd := range data.Data {
+			if i < fd.RowNum() {
+				row[fieldID] = fd.GetRow(i)
+			}
+		}
+		s.samples = append(s.samples, &ErrorSample{
+			Path:   s.path,
+			Offset: offset + int64(i),
+			Reason: reason.Error(),
+			Row:    row,
+		})
+	}
+}
+
+// Merge appends the samples of other until full, which collects the samples of batches processed concurrently.
+func (s *errorSampler) Merge(other *errorSampler) {
+	for _, sample := range other.samples {
+		if s.Full() {
+			return
+		}
+		s.samples = append(s.samples, sample)
+	}
+}
+
+// Save writes the samples as json lines into chunk manager and returns the artifact path.
+func (s *errorSampler) Save(ctx context.Context, cm storage.ChunkManager, task Task, fileIdx int) (string, error) {
+	if len(s.samples) == 0 {
+		return "", nil
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, sample := range s.samples {
+		if err := encoder.Encode(sample); err != nil {
+			return "", err
+		}
+	}
+	filePath := path.Join(cm.RootPath(), errorSamplesDir,
+		fmt.Sprint(task.GetJobID()), fmt.Sprint(task.GetTaskID()), fmt.Sprintf("%d.json", fileIdx))
+	if err := cm.Write(ctx, filePath, buf.Bytes()); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
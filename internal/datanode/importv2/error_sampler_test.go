@@ -0,0 +1,65 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+func Test_ErrorSampler(t *testing.T) {
+	data := &storage.InsertData{
+		Data: map[int64]storage.FieldData{
+			100: &storage.Int64FieldData{Data: []int64{1, 2, 3, 4}},
+			101: &storage.Int64FieldData{Data: []int64{10, 20}},
+		},
+	}
+	reason := errors.New("imported rows are not aligned")
+
+	sampler := newErrorSampler(3, "a.json")
+	sampler.SampleRows(data, 100, reason)
+	assert.True(t, sampler.Full())
+	assert.Len(t, sampler.samples, 3)
+	assert.Equal(t, int64(102), sampler.samples[2].Offset)
+	assert.Equal(t, "a.json", sampler.samples[2].Path)
+	assert.Len(t, sampler.samples[0].Row, 2)
+	assert.Len(t, sampler.samples[2].Row, 1)
+
+	// no more samples after full
+	sampler.SampleError(200, reason)
+	assert.Len(t, sampler.samples, 3)
+
+	cm := storage.NewLocalChunkManager(storage.RootPath(t.TempDir()))
+	task := &PreImportTask{PreImportTask: &datapb.PreImportTask{JobID: 1, TaskID: 2}}
+	samplesPath, err := sampler.Save(context.Background(), cm, task, 0)
+	assert.NoError(t, err)
+	content, err := cm.Read(context.Background(), samplesPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(content), "\n"))
+
+	// nothing to save
+	samplesPath, err = newErrorSampler(1, "b.json").Save(context.Background(), cm, task, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, samplesPath)
+}
@@ -0,0 +1,243 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"cmp"
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"math/bits"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+// hllPrecision is the number of hash bits used to pick the register,
+// 2^12 registers take 4KB per field with a standard error of about 1.6%.
+const hllPrecision = 12
+
+var hllSeed = maphash.MakeSeed()
+
+// hyperLogLog estimates the number of distinct values in bounded memory.
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func (h *hyperLogLog) Add(hash uint64) {
+	idx := hash >> (64 - hllPrecision)
+	// the guard bit caps the rank when the remaining bits are all zero.
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Estimate returns the estimated cardinality, the linear counting is used for small cardinalities.
+func (h *hyperLogLog) Estimate() int64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// hashScalar hashes the value of scalar field, values of different types never meet in one sketch.
+func hashScalar(v any) uint64 {
+	buf := make([]byte, 8)
+	switch val := v.(type) {
+	case string:
+		return maphash.String(hllSeed, val)
+	case bool:
+		if val {
+			buf[0] = 1
+		}
+	case int8:
+		binary.LittleEndian.PutUint64(buf, uint64(val))
+	case int16:
+		binary.LittleEndian.PutUint64(buf, uint64(val))
+	case int32:
+		binary.LittleEndian.PutUint64(buf, uint64(val))
+	case int64:
+		binary.LittleEndian.PutUint64(buf, uint64(val))
+	case float32:
+		binary.LittleEndian.PutUint64(buf, uint64(math.Float32bits(val)))
+	case float64:
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(val))
+	}
+	return maphash.Bytes(hllSeed, buf)
+}
+
+// compareScalar compares values of the same scalar type, false is less than true.
+func compareScalar(a, b any) int {
+	switch av := a.(type) {
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		if !av {
+			return -1
+		}
+		return 1
+	case int8:
+		return cmp.Compare(av, b.(int8))
+	case int16:
+		return cmp.Compare(av, b.(int16))
+	case int32:
+		return cmp.Compare(av, b.(int32))
+	case int64:
+		return cmp.Compare(av, b.(int64))
+	case float32:
+		return cmp.Compare(av, b.(float32))
+	case float64:
+		return cmp.Compare(av, b.(float64))
+	case string:
+		return cmp.Compare(av, b.(string))
+	}
+	return 0
+}
+
+func isNaN(v any) bool {
+	switch val := v.(type) {
+	case float32:
+		return math.IsNaN(float64(val))
+	case float64:
+		return math.IsNaN(val)
+	}
+	return false
+}
+
+func toValueField(v any) *schemapb.ValueField {
+	switch val := v.(type) {
+	case bool:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_BoolData{BoolData: val}}
+	case int8:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_IntData{IntData: int32(val)}}
+	case int16:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_IntData{IntData: int32(val)}}
+	case int32:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_IntData{IntData: val}}
+	case int64:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_LongData{LongData: val}}
+	case float32:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_FloatData{FloatData: val}}
+	case float64:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_DoubleData{DoubleData: val}}
+	case string:
+		return &schemapb.ValueField{Data: &schemapb.ValueField_StringData{StringData: val}}
+	}
+	return nil
+}
+
+type fieldStats struct {
+	fieldID  int64
+	min, max any
+	sketch   *hyperLogLog
+}
+
+func (s *fieldStats) observe(fieldData storage.FieldData) {
+	for i := 0; i < fieldData.RowNum(); i++ {
+		v := fieldData.GetRow(i)
+		s.sketch.Add(hashScalar(v))
+		if isNaN(v) {
+			continue
+		}
+		if s.min == nil || compareScalar(v, s.min) < 0 {
+			s.min = v
+		}
+		if s.max == nil || compareScalar(v, s.max) > 0 {
+			s.max = v
+		}
+	}
+}
+
+// fieldStatsCollector accumulates the value range and estimated distinct count of the orderable scalar fields
+// as the rows are read. Vector, json and array fields are skipped.
+type fieldStatsCollector struct {
+	stats []*fieldStats
+}
+
+func newFieldStatsCollector(schema *schemapb.CollectionSchema) *fieldStatsCollector {
+	c := &fieldStatsCollector{}
+	for _, field := range schema.GetFields() {
+		switch field.GetDataType() {
+		case schemapb.DataType_Bool, schemapb.DataType_Int8, schemapb.DataType_Int16, schemapb.DataType_Int32,
+			schemapb.DataType_Int64, schemapb.DataType_Float, schemapb.DataType_Double,
+			schemapb.DataType_String, schemapb.DataType_VarChar:
+			c.stats = append(c.stats, &fieldStats{fieldID: field.GetFieldID(), sketch: &hyperLogLog{}})
+		}
+	}
+	return c
+}
+
+// Observe records the next batch of rows.
+func (c *fieldStatsCollector) Observe(data *storage.InsertData) {
+	for _, s := range c.stats {
+		if fieldData, ok := data.Data[s.fieldID]; ok {
+			s.observe(fieldData)
+		}
+	}
+}
+
+// Merge merges the stats collected by other collector of the same schema, e.g. of another batch.
+func (c *fieldStatsCollector) Merge(other *fieldStatsCollector) {
+	for i, s := range c.stats {
+		o := other.stats[i]
+		for j, r := range o.sketch.registers {
+			if r > s.sketch.registers[j] {
+				s.sketch.registers[j] = r
+			}
+		}
+		if o.min == nil {
+			continue
+		}
+		if s.min == nil || compareScalar(o.min, s.min) < 0 {
+			s.min = o.min
+		}
+		if s.max == nil || compareScalar(o.max, s.max) > 0 {
+			s.max = o.max
+		}
+	}
+}
+
+// Stats returns the stats of fields in schema order, min and max are unset if the field has no comparable value.
+func (c *fieldStatsCollector) Stats() []*datapb.FieldImportStats {
+	stats := make([]*datapb.FieldImportStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		stat := &datapb.FieldImportStats{
+			FieldID:       s.fieldID,
+			DistinctCount: s.sketch.Estimate(),
+		}
+		if s.min != nil {
+			stat.Min = toValueField(s.min)
+			stat.Max = toValueField(s.max)
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
@@ -0,0 +1,121 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+func Test_HyperLogLog(t *testing.T) {
+	h := &hyperLogLog{}
+	assert.Equal(t, int64(0), h.Estimate())
+	for i := 0; i < 100000; i++ {
+		// every value is added twice.
+		h.Add(hashScalar(int64(i)))
+		h.Add(hashScalar(int64(i)))
+	}
+	assert.InDelta(t, 100000, h.Estimate(), 100000*0.05)
+}
+
+func Test_FieldStatsCollector(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "str", DataType: schemapb.DataType_VarChar},
+			{FieldID: 102, Name: "f", DataType: schemapb.DataType_Float},
+			{FieldID: 103, Name: "b", DataType: schemapb.DataType_Bool},
+			{FieldID: 104, Name: "json", DataType: schemapb.DataType_JSON},
+			{FieldID: 105, Name: "vec", DataType: schemapb.DataType_FloatVector},
+		},
+	}
+	c := newFieldStatsCollector(schema)
+	c.Observe(&storage.InsertData{Data: map[int64]storage.FieldData{
+		100: &storage.Int64FieldData{Data: []int64{5, 3}},
+		101: &storage.StringFieldData{Data: []string{"b", "b"}},
+		102: &storage.FloatFieldData{Data: []float32{float32(math.NaN()), 1.5}},
+	}})
+	c.Observe(&storage.InsertData{Data: map[int64]storage.FieldData{
+		100: &storage.Int64FieldData{Data: []int64{9}},
+		101: &storage.StringFieldData{Data: []string{"a"}},
+		102: &storage.FloatFieldData{Data: []float32{-2}},
+	}})
+
+	// json and vector fields are skipped.
+	stats := c.Stats()
+	assert.Len(t, stats, 4)
+
+	assert.Equal(t, int64(100), stats[0].GetFieldID())
+	assert.Equal(t, int64(3), stats[0].GetMin().GetLongData())
+	assert.Equal(t, int64(9), stats[0].GetMax().GetLongData())
+	// the hashes of few values may hit the same register, which underestimates by one.
+	assert.InDelta(t, 3, stats[0].GetDistinctCount(), 1)
+
+	assert.Equal(t, "a", stats[1].GetMin().GetStringData())
+	assert.Equal(t, "b", stats[1].GetMax().GetStringData())
+	assert.InDelta(t, 2, stats[1].GetDistinctCount(), 1)
+
+	// NaN is counted as a distinct value but out of the range.
+	assert.Equal(t, float32(-2), stats[2].GetMin().GetFloatData())
+	assert.Equal(t, float32(1.5), stats[2].GetMax().GetFloatData())
+	assert.InDelta(t, 3, stats[2].GetDistinctCount(), 1)
+
+	// no value is read for the bool field.
+	assert.Equal(t, int64(103), stats[3].GetFieldID())
+	assert.Nil(t, stats[3].GetMin())
+	assert.Nil(t, stats[3].GetMax())
+	assert.Equal(t, int64(0), stats[3].GetDistinctCount())
+}
+
+func Test_FieldStatsCollectorMerge(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "str", DataType: schemapb.DataType_VarChar},
+		},
+	}
+	batches := []*storage.InsertData{
+		{Data: map[int64]storage.FieldData{
+			100: &storage.Int64FieldData{Data: []int64{5, 3}},
+			101: &storage.StringFieldData{Data: []string{"b", "c"}},
+		}},
+		{Data: map[int64]storage.FieldData{
+			100: &storage.Int64FieldData{Data: []int64{9, 4}},
+		}},
+	}
+	// the stats collected by batch and merged are the same as collected at once.
+	expected := newFieldStatsCollector(schema)
+	merged := newFieldStatsCollector(schema)
+	for _, batch := range batches {
+		expected.Observe(batch)
+		c := newFieldStatsCollector(schema)
+		c.Observe(batch)
+		merged.Merge(c)
+	}
+	for i, stat := range merged.Stats() {
+		assert.Equal(t, expected.Stats()[i].GetDistinctCount(), stat.GetDistinctCount())
+		assert.Equal(t, expected.Stats()[i].GetMin().GetData(), stat.GetMin().GetData())
+		assert.Equal(t, expected.Stats()[i].GetMax().GetData(), stat.GetMax().GetData())
+	}
+	assert.Equal(t, int64(3), merged.Stats()[0].GetMin().GetLongData())
+	assert.Equal(t, int64(9), merged.Stats()[0].GetMax().GetLongData())
+}
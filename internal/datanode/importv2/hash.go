@@ -17,6 +17,12 @@
 package importv2
 
 import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+
 	"github.com/samber/lo"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
@@ -42,7 +48,8 @@ func newHashedData(schema *schemapb.CollectionSchema, channelNum, partitionNum i
 	return res, nil
 }
 
-func HashData(task Task, rows *storage.InsertData) (HashedData, error) {
+// HashData splits rows by vchannel and partition, the rows are routed to targetPartition if it's not 0.
+func HashData(task Task, rows *storage.InsertData, targetPartition int64) (HashedData, error) {
 	var (
 		schema       = typeutil.AppendSystemFields(task.GetSchema())
 		channelNum   = len(task.GetVchannels())
@@ -59,7 +66,7 @@ func HashData(task Task, rows *storage.InsertData) (HashedData, error) {
 	id2 := partKeyField.GetFieldID()
 
 	f1 := hashByVChannel(int64(channelNum), pkField)
-	f2 := hashByPartition(int64(partitionNum), partKeyField)
+	f2 := routeToPartition(task, partKeyField, targetPartition)
 
 	res, err := newHashedData(schema, channelNum, partitionNum)
 	if err != nil {
@@ -104,7 +111,10 @@ func HashDeleteData(task Task, delData *storage.DeleteData) ([]*storage.DeleteDa
 	return res, nil
 }
 
-func GetRowsStats(task Task, rows *storage.InsertData) (map[string]*datapb.PartitionImportStats, error) {
+// GetRowsStats returns the rows stats by vchannel and partition, the rows are routed to targetPartition if it's not 0.
+// The rows may be a sub-chunk of a batch starting at offset, the stats of sub-chunks merged by `MergeHashedStats`
+// are the same as the stats of the whole batch.
+func GetRowsStats(task Task, rows *storage.InsertData, targetPartition int64, offset int) (map[string]*datapb.PartitionImportStats, error) {
 	var (
 		schema       = task.GetSchema()
 		channelNum   = len(task.GetVchannels())
@@ -122,34 +132,39 @@ func GetRowsStats(task Task, rows *storage.InsertData) (map[string]*datapb.Parti
 
 	hashRowsCount := make([][]int, channelNum)
 	hashDataSize := make([][]int, channelNum)
+	hashChecksum := make([][]uint64, channelNum)
 	for i := 0; i < channelNum; i++ {
 		hashRowsCount[i] = make([]int, partitionNum)
 		hashDataSize[i] = make([]int, partitionNum)
+		hashChecksum[i] = make([]uint64, partitionNum)
 	}
 
 	rowNum := GetInsertDataRowCount(rows, schema)
 	if pkField.GetAutoID() {
-		id := int64(0)
+		id := int64(offset)
 		num := int64(channelNum)
 		fn1 := hashByID()
-		fn2 := hashByPartition(int64(partitionNum), partKeyField)
+		fn2 := routeToPartition(task, partKeyField, targetPartition)
 		rows.Data = lo.PickBy(rows.Data, func(fieldID int64, _ storage.FieldData) bool {
 			return fieldID != pkField.GetFieldID()
 		})
 		for i := 0; i < rowNum; i++ {
-			p1, p2 := fn1(id, num), fn2(rows.GetRow(i)[id2])
+			row := rows.GetRow(i)
+			p1, p2 := fn1(id, num), fn2(row[id2])
 			hashRowsCount[p1][p2]++
 			hashDataSize[p1][p2] += rows.GetRowSize(i)
+			hashChecksum[p1][p2] += HashRow(row)
 			id++
 		}
 	} else {
 		f1 := hashByVChannel(int64(channelNum), pkField)
-		f2 := hashByPartition(int64(partitionNum), partKeyField)
+		f2 := routeToPartition(task, partKeyField, targetPartition)
 		for i := 0; i < rowNum; i++ {
 			row := rows.GetRow(i)
 			p1, p2 := f1(row[id1]), f2(row[id2])
 			hashRowsCount[p1][p2]++
 			hashDataSize[p1][p2] += rows.GetRowSize(i)
+			hashChecksum[p1][p2] += HashRow(row)
 		}
 	}
 
@@ -158,6 +173,7 @@ func GetRowsStats(task Task, rows *storage.InsertData) (map[string]*datapb.Parti
 		res[channel] = &datapb.PartitionImportStats{
 			PartitionRows:     make(map[int64]int64),
 			PartitionDataSize: make(map[int64]int64),
+			PartitionChecksum: make(map[int64]uint64),
 		}
 	}
 	for i := range hashRowsCount {
@@ -166,6 +182,7 @@ func GetRowsStats(task Task, rows *storage.InsertData) (map[string]*datapb.Parti
 			partition := task.GetPartitionIDs()[j]
 			res[channel].PartitionRows[partition] = int64(hashRowsCount[i][j])
 			res[channel].PartitionDataSize[partition] = int64(hashDataSize[i][j])
+			res[channel].PartitionChecksum[partition] = hashChecksum[i][j]
 		}
 	}
 	return res, nil
@@ -257,6 +274,19 @@ func hashByPartition(partitionNum int64, partField *schemapb.FieldSchema) func(k
 	}
 }
 
+// routeToPartition returns the function picking the partition index of row, all rows are routed to
+// the target partition if it's not 0, or else hashed by partition key.
+// The target partition must be one of the partitions of task, see CheckFilePartition.
+func routeToPartition(task Task, partField *schemapb.FieldSchema, targetPartition int64) func(key any) int64 {
+	if targetPartition != 0 {
+		idx := int64(lo.IndexOf(task.GetPartitionIDs(), targetPartition))
+		return func(_ any) int64 {
+			return idx
+		}
+	}
+	return hashByPartition(int64(len(task.GetPartitionIDs())), partField)
+}
+
 func hashByID() func(id int64, shardNum int64) int64 {
 	return func(id int64, shardNum int64) int64 {
 		hash, _ := typeutil.Hash32Int64(id)
@@ -264,6 +294,7 @@ func hashByID() func(id int64, shardNum int64) int64 {
 	}
 }
 
+// MergeHashedStats adds the stats of src to dst, which accumulates the stats of batches or sub-chunks fed one by one.
 func MergeHashedStats(src, dst map[string]*datapb.PartitionImportStats) {
 	for channel, partitionStats := range src {
 		for partitionID := range partitionStats.GetPartitionRows() {
@@ -271,10 +302,58 @@ func MergeHashedStats(src, dst map[string]*datapb.PartitionImportStats) {
 				dst[channel] = &datapb.PartitionImportStats{
 					PartitionRows:     make(map[int64]int64),
 					PartitionDataSize: make(map[int64]int64),
+					PartitionChecksum: make(map[int64]uint64),
 				}
 			}
 			dst[channel].PartitionRows[partitionID] += partitionStats.GetPartitionRows()[partitionID]
 			dst[channel].PartitionDataSize[partitionID] += partitionStats.GetPartitionDataSize()[partitionID]
+			if checksum, ok := partitionStats.GetPartitionChecksum()[partitionID]; ok {
+				if dst[channel].PartitionChecksum == nil {
+					dst[channel].PartitionChecksum = make(map[int64]uint64)
+				}
+				// sum of row hashes doesn't depend on the order of rows.
+				dst[channel].PartitionChecksum[partitionID] += checksum
+			}
+		}
+	}
+}
+
+// HashRow returns the hash of the canonicalized row, which doesn't depend on the order of fields.
+// Summing up the hashes of rows gives an order-independent checksum of the rows.
+func HashRow(row map[int64]any) uint64 {
+	h := fnv.New64a()
+	fieldIDs := lo.Keys(row)
+	sort.Slice(fieldIDs, func(i, j int) bool { return fieldIDs[i] < fieldIDs[j] })
+	for _, fieldID := range fieldIDs {
+		binary.Write(h, binary.LittleEndian, fieldID)
+		writeHashValue(h, row[fieldID])
+	}
+	return h.Sum64()
+}
+
+func writeHashValue(w io.Writer, value any) {
+	switch v := value.(type) {
+	case nil:
+	case string:
+		binary.Write(w, binary.LittleEndian, int64(len(v)))
+		io.WriteString(w, v)
+	case []byte:
+		binary.Write(w, binary.LittleEndian, int64(len(v)))
+		w.Write(v)
+	case *schemapb.ScalarField:
+		// array field
+		binary.Write(w, binary.LittleEndian, v.GetBoolData().GetData())
+		binary.Write(w, binary.LittleEndian, v.GetIntData().GetData())
+		binary.Write(w, binary.LittleEndian, v.GetLongData().GetData())
+		binary.Write(w, binary.LittleEndian, v.GetFloatData().GetData())
+		binary.Write(w, binary.LittleEndian, v.GetDoubleData().GetData())
+		for _, s := range v.GetStringData().GetData() {
+			writeHashValue(w, s)
+		}
+	default:
+		// fixed-size values, such as numbers and float vectors.
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			fmt.Fprint(w, v)
 		}
 	}
 }
@@ -0,0 +1,225 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/pkg/common"
+)
+
+func Test_RowsStatsChecksum(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{
+				FieldID:      100,
+				Name:         "pk",
+				IsPrimaryKey: true,
+				DataType:     schemapb.DataType_Int64,
+			},
+			{
+				FieldID:  101,
+				Name:     "vec",
+				DataType: schemapb.DataType_FloatVector,
+				TypeParams: []*commonpb.KeyValuePair{
+					{Key: common.DimKey, Value: "2"},
+				},
+			},
+			{
+				FieldID:  102,
+				Name:     "str",
+				DataType: schemapb.DataType_VarChar,
+			},
+		},
+	}
+	task := &PreImportTask{
+		PreImportTask: &datapb.PreImportTask{},
+		schema:        schema,
+		vchannels:     []string{"ch0", "ch1"},
+		partitionIDs:  []int64{10},
+	}
+	newFile := func(pks []int64) *storage.InsertData {
+		vectors := make([]float32, 0, len(pks)*2)
+		strs := make([]string, 0, len(pks))
+		for _, pk := range pks {
+			vectors = append(vectors, float32(pk), float32(pk)*2)
+			strs = append(strs, string(rune('a'+pk)))
+		}
+		return &storage.InsertData{Data: map[int64]storage.FieldData{
+			100: &storage.Int64FieldData{Data: pks},
+			101: &storage.FloatVectorFieldData{Data: vectors, Dim: 2},
+			102: &storage.StringFieldData{Data: strs},
+		}}
+	}
+	checksum := func(files [][]int64) map[string]*datapb.PartitionImportStats {
+		hashedStats := make(map[string]*datapb.PartitionImportStats)
+		for _, pks := range files {
+			stats, err := GetRowsStats(task, newFile(pks), 0, 0)
+			assert.NoError(t, err)
+			MergeHashedStats(stats, hashedStats)
+		}
+		return hashedStats
+	}
+
+	files := [][]int64{{1, 2, 3, 4}, {5, 6}, {7, 8, 9}}
+	expected := checksum(files)
+	assert.NotZero(t, expected["ch0"].GetPartitionChecksum()[10]+expected["ch1"].GetPartitionChecksum()[10])
+
+	// shuffle the order of files and the order of rows in files.
+	for i := 0; i < 5; i++ {
+		shuffled := make([][]int64, 0, len(files))
+		for _, j := range rand.Perm(len(files)) {
+			pks := append([]int64{}, files[j]...)
+			rand.Shuffle(len(pks), func(a, b int) { pks[a], pks[b] = pks[b], pks[a] })
+			shuffled = append(shuffled, pks)
+		}
+		actual := checksum(shuffled)
+		for _, channel := range task.GetVchannels() {
+			assert.Equal(t, expected[channel].GetPartitionChecksum(), actual[channel].GetPartitionChecksum())
+		}
+	}
+
+	// different content gives different checksum.
+	actual := checksum([][]int64{{1, 2, 3, 4}, {5, 6}, {7, 8, 10}})
+	assert.NotEqual(t,
+		expected["ch0"].GetPartitionChecksum()[10]+expected["ch1"].GetPartitionChecksum()[10],
+		actual["ch0"].GetPartitionChecksum()[10]+actual["ch1"].GetPartitionChecksum()[10])
+}
+
+func Test_FilePartition(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{
+				FieldID:      100,
+				Name:         "pk",
+				IsPrimaryKey: true,
+				DataType:     schemapb.DataType_Int64,
+			},
+			{
+				FieldID:  101,
+				Name:     "str",
+				DataType: schemapb.DataType_VarChar,
+			},
+		},
+	}
+	task := &PreImportTask{
+		PreImportTask: &datapb.PreImportTask{},
+		schema:        schema,
+		vchannels:     []string{"ch0", "ch1"},
+		partitionIDs:  []int64{10, 11},
+	}
+	data := &storage.InsertData{Data: map[int64]storage.FieldData{
+		100: &storage.Int64FieldData{Data: []int64{1, 2, 3, 4}},
+		101: &storage.StringFieldData{Data: []string{"a", "b", "c", "d"}},
+	}}
+
+	assert.NoError(t, CheckFilePartition(task, &internalpb.ImportFile{PartitionID: 11}))
+	assert.NoError(t, CheckFilePartition(task, &internalpb.ImportFile{}))
+	assert.Error(t, CheckFilePartition(task, &internalpb.ImportFile{PartitionID: 12}))
+
+	// all rows are routed to the target partition.
+	stats, err := GetRowsStats(task, data, 11, 0)
+	assert.NoError(t, err)
+	rows := 0
+	for _, channel := range task.GetVchannels() {
+		assert.Equal(t, int64(0), stats[channel].GetPartitionRows()[10])
+		rows += int(stats[channel].GetPartitionRows()[11])
+	}
+	assert.Equal(t, 4, rows)
+
+	hashedData, err := HashData(task, data, 11)
+	assert.NoError(t, err)
+	rows = 0
+	for _, partitions := range hashedData {
+		assert.Equal(t, 0, partitions[0].GetRowNum())
+		rows += partitions[1].GetRowNum()
+	}
+	assert.Equal(t, 4, rows)
+}
+
+func Test_RowsStatsOfSubBatches(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{
+				FieldID:      100,
+				Name:         "pk",
+				IsPrimaryKey: true,
+				AutoID:       true,
+				DataType:     schemapb.DataType_Int64,
+			},
+			{
+				FieldID:  101,
+				Name:     "str",
+				DataType: schemapb.DataType_VarChar,
+			},
+		},
+	}
+	task := &PreImportTask{
+		PreImportTask: &datapb.PreImportTask{},
+		schema:        schema,
+		vchannels:     []string{"ch0", "ch1", "ch2"},
+		partitionIDs:  []int64{10},
+	}
+	newData := func() *storage.InsertData {
+		return &storage.InsertData{Data: map[int64]storage.FieldData{
+			100: &storage.Int64FieldData{Data: []int64{}},
+			101: &storage.StringFieldData{Data: []string{"a", "b", "c", "d", "e", "f", "g"}},
+		}}
+	}
+	expected, err := GetRowsStats(task, newData(), 0, 0)
+	assert.NoError(t, err)
+
+	// the stats of sub-chunks fed incrementally are the same as the whole batch.
+	data := newData()
+	actual := make(map[string]*datapb.PartitionImportStats)
+	chunks := 0
+	err = forEachSubBatch(schema, data, data.GetMemorySize()/3, func(chunk *storage.InsertData, offset int) error {
+		chunks++
+		assert.Equal(t, 0, chunk.Data[100].RowNum())
+		stats, err := GetRowsStats(task, chunk, 0, offset)
+		if err != nil {
+			return err
+		}
+		MergeHashedStats(stats, actual)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, chunks, 1)
+	for _, channel := range task.GetVchannels() {
+		assert.Equal(t, expected[channel].GetPartitionRows(), actual[channel].GetPartitionRows())
+		assert.Equal(t, expected[channel].GetPartitionDataSize(), actual[channel].GetPartitionDataSize())
+		assert.Equal(t, expected[channel].GetPartitionChecksum(), actual[channel].GetPartitionChecksum())
+	}
+
+	// the batch fitting in the cap is fed as is.
+	chunks = 0
+	err = forEachSubBatch(schema, data, 0, func(chunk *storage.InsertData, offset int) error {
+		chunks++
+		assert.Same(t, data, chunk)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, chunks)
+}
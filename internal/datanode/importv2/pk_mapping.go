@@ -0,0 +1,85 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+const pkMappingsDir = "import_pk_mappings"
+
+// PKMapping maps the original primary key of a row to the reassigned one.
+type PKMapping struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// pkMappingWriter writes the mappings of an import file as json lines, one part for each batch,
+// so that the references from other collections can be remapped after the import.
+type pkMappingWriter struct {
+	cm      storage.ChunkManager
+	dir     string
+	fileIdx int
+	part    int
+}
+
+func newPKMappingWriter(cm storage.ChunkManager, task Task, fileIdx int) *pkMappingWriter {
+	return &pkMappingWriter{
+		cm:      cm,
+		dir:     PKMappingsPath(cm, task),
+		fileIdx: fileIdx,
+	}
+}
+
+// PKMappingsPath returns the directory of primary key mappings written by the import task.
+func PKMappingsPath(cm storage.ChunkManager, task Task) string {
+	return path.Join(cm.RootPath(), pkMappingsDir, fmt.Sprint(task.GetJobID()), fmt.Sprint(task.GetTaskID()))
+}
+
+// Write saves the mappings of a batch, the original and reassigned primary keys are aligned by rows.
+func (w *pkMappingWriter) Write(ctx context.Context, oldPKs, newPKs storage.FieldData) error {
+	if oldPKs == nil || newPKs == nil {
+		return merr.WrapErrImportFailed("no primary key to map, the original primary keys are missing")
+	}
+	if oldPKs.RowNum() != newPKs.RowNum() {
+		return merr.WrapErrImportFailed(fmt.Sprintf("the reassigned primary keys are not aligned with the original ones, "+
+			"original=%d, reassigned=%d", oldPKs.RowNum(), newPKs.RowNum()))
+	}
+	if oldPKs.RowNum() == 0 {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for i := 0; i < oldPKs.RowNum(); i++ {
+		if err := encoder.Encode(&PKMapping{Old: oldPKs.GetRow(i), New: newPKs.GetRow(i)}); err != nil {
+			return err
+		}
+	}
+	filePath := path.Join(w.dir, fmt.Sprintf("%d-%d.json", w.fileIdx, w.part))
+	if err := w.cm.Write(ctx, filePath, buf.Bytes()); err != nil {
+		return err
+	}
+	w.part++
+	return nil
+}
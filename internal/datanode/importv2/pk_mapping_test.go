@@ -0,0 +1,58 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+func Test_PKMappingWriter(t *testing.T) {
+	ctx := context.Background()
+	cm := storage.NewLocalChunkManager(storage.RootPath(t.TempDir()))
+	task := &ImportTask{ImportTaskV2: &datapb.ImportTaskV2{JobID: 1, TaskID: 2}}
+	writer := newPKMappingWriter(cm, task, 3)
+
+	err := writer.Write(ctx, &storage.StringFieldData{Data: []string{"a", "b"}}, &storage.Int64FieldData{Data: []int64{100, 101}})
+	assert.NoError(t, err)
+	err = writer.Write(ctx, &storage.StringFieldData{Data: []string{"c"}}, &storage.Int64FieldData{Data: []int64{102}})
+	assert.NoError(t, err)
+	// empty batch writes nothing.
+	err = writer.Write(ctx, &storage.StringFieldData{}, &storage.Int64FieldData{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, writer.part)
+
+	content, err := cm.Read(ctx, path.Join(PKMappingsPath(cm, task), "3-0.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"old\":\"a\",\"new\":100}\n{\"old\":\"b\",\"new\":101}\n", string(content))
+	content, err = cm.Read(ctx, path.Join(PKMappingsPath(cm, task), "3-1.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "\n"))
+
+	// the original primary keys are missing or not aligned.
+	err = writer.Write(ctx, nil, &storage.Int64FieldData{Data: []int64{103}})
+	assert.Error(t, err)
+	err = writer.Write(ctx, &storage.Int64FieldData{Data: []int64{1, 2}}, &storage.Int64FieldData{Data: []int64{103}})
+	assert.Error(t, err)
+}
@@ -20,6 +20,7 @@ import (
 	"sync"
 
 	"github.com/milvus-io/milvus/pkg/util/conc"
+	"github.com/milvus-io/milvus/pkg/util/hardware"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
 )
 
@@ -39,3 +40,42 @@ func GetExecPool() *conc.Pool[any] {
 	execPoolInitOnce.Do(initExecPool)
 	return execPool
 }
+
+// GetTaskParallelism returns the number of files processed concurrently by a task with fileNum files,
+// which is dataNode.import.maxConcurrentFilesPerTask if configured, otherwise min(CPU num, half of exec pool),
+// so that tasks running on the same datanode share the exec pool.
+func GetTaskParallelism(fileNum int) int {
+	limit := paramtable.Get().DataNodeCfg.MaxConcurrentFilesPerTask.GetAsInt()
+	if limit <= 0 {
+		limit = min(hardware.GetCPUNum(), GetExecPool().Cap()/2)
+	}
+	return max(1, min(limit, fileNum))
+}
+
+// SubmitFiles runs fn on each file with a bounded number of workers in exec pool, see GetTaskParallelism.
+// Each worker processes the files one by one, and stops at the first failure.
+func SubmitFiles[T any](files []T, fn func(i int, file T) error) []*conc.Future[any] {
+	if len(files) == 0 {
+		return nil
+	}
+	queue := make(chan int, len(files))
+	for i := range files {
+		queue <- i
+	}
+	close(queue)
+
+	parallelism := GetTaskParallelism(len(files))
+	futures := make([]*conc.Future[any], 0, parallelism)
+	for w := 0; w < parallelism; w++ {
+		f := GetExecPool().Submit(func() (any, error) {
+			for i := range queue {
+				if err := fn(i, files[i]); err != nil {
+					return err, err
+				}
+			}
+			return nil, nil
+		})
+		futures = append(futures, f)
+	}
+	return futures
+}
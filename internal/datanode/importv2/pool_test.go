@@ -0,0 +1,69 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"github.com/milvus-io/milvus/pkg/util/conc"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func Test_SubmitFiles(t *testing.T) {
+	paramtable.Init()
+	key := paramtable.Get().DataNodeCfg.MaxConcurrentFilesPerTask.Key
+
+	assert.Equal(t, 1, GetTaskParallelism(1))
+	assert.LessOrEqual(t, GetTaskParallelism(1000), GetExecPool().Cap()/2+1)
+	paramtable.Get().Save(key, "2")
+	defer paramtable.Get().Reset(key)
+	assert.Equal(t, 2, GetTaskParallelism(1000))
+
+	files := make([]int, 10)
+	for i := range files {
+		files[i] = i
+	}
+	running := atomic.NewInt32(0)
+	maxRunning := atomic.NewInt32(0)
+	sum := atomic.NewInt32(0)
+	futures := SubmitFiles(files, func(i int, file int) error {
+		n := running.Inc()
+		defer running.Dec()
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		sum.Add(int32(file))
+		return nil
+	})
+	assert.Len(t, futures, 2)
+	assert.NoError(t, conc.AwaitAll(futures...))
+	assert.Equal(t, int32(45), sum.Load())
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+
+	futures = SubmitFiles(files, func(i int, file int) error {
+		return errors.New("mock error")
+	})
+	assert.Error(t, conc.AwaitAll(futures...))
+	assert.Empty(t, SubmitFiles([]int{}, func(i int, file int) error { return nil }))
+}
@@ -65,18 +65,20 @@ func (s *scheduler) Start() {
 		case <-exeTicker.C:
 			tasks := s.manager.GetBy(WithStates(datapb.ImportTaskStateV2_Pending))
 			futures := make(map[int64][]*conc.Future[any])
+			starts := make(map[int64]time.Time)
 			for _, task := range tasks {
+				starts[task.GetTaskID()] = time.Now()
 				fs := task.Execute()
 				futures[task.GetTaskID()] = fs
 				tryFreeFutures(futures)
 			}
 			for taskID, fs := range futures {
 				err := conc.AwaitAll(fs...)
-				if err != nil {
-					continue
+				if err == nil {
+					s.manager.Update(taskID, UpdateState(datapb.ImportTaskStateV2_Completed))
+					log.Info("preimport/import done", zap.Int64("taskID", taskID))
 				}
-				s.manager.Update(taskID, UpdateState(datapb.ImportTaskStateV2_Completed))
-				log.Info("preimport/import done", zap.Int64("taskID", taskID))
+				s.manager.Summarize(taskID, time.Since(starts[taskID]))
 			}
 		case <-logTicker.C:
 			LogStats(s.manager)
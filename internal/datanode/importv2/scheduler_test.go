@@ -125,7 +125,7 @@ func (s *SchedulerSuite) TestScheduler_Slots() {
 		Schema:       s.schema,
 		ImportFiles:  []*internalpb.ImportFile{{Paths: []string{"dummy.json"}}},
 	}
-	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm)
+	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm, nil)
 	s.manager.Add(preimportTask)
 
 	slots := s.scheduler.Slots()
@@ -162,7 +162,7 @@ func (s *SchedulerSuite) TestScheduler_Start_Preimport() {
 		Schema:       s.schema,
 		ImportFiles:  []*internalpb.ImportFile{{Paths: []string{"dummy.json"}}},
 	}
-	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm)
+	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm, nil)
 	s.manager.Add(preimportTask)
 
 	go s.scheduler.Start()
@@ -216,7 +216,7 @@ func (s *SchedulerSuite) TestScheduler_Start_Preimport_Failed() {
 		Schema:       s.schema,
 		ImportFiles:  []*internalpb.ImportFile{{Paths: []string{"dummy.json"}}},
 	}
-	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm)
+	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm, nil)
 	s.manager.Add(preimportTask)
 
 	go s.scheduler.Start()
@@ -277,7 +277,7 @@ func (s *SchedulerSuite) TestScheduler_Start_Import() {
 			},
 		},
 	}
-	importTask := NewImportTask(importReq, s.manager, s.syncMgr, s.cm)
+	importTask := NewImportTask(importReq, s.manager, s.syncMgr, s.cm, nil)
 	s.manager.Add(importTask)
 
 	go s.scheduler.Start()
@@ -338,7 +338,7 @@ func (s *SchedulerSuite) TestScheduler_Start_Import_Failed() {
 			},
 		},
 	}
-	importTask := NewImportTask(importReq, s.manager, s.syncMgr, s.cm)
+	importTask := NewImportTask(importReq, s.manager, s.syncMgr, s.cm, nil)
 	s.manager.Add(importTask)
 
 	go s.scheduler.Start()
@@ -377,10 +377,208 @@ func (s *SchedulerSuite) TestScheduler_ReadFileStat() {
 		Schema:       s.schema,
 		ImportFiles:  []*internalpb.ImportFile{importFile},
 	}
-	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm)
+	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm, nil)
 	s.manager.Add(preimportTask)
-	err = preimportTask.(*PreImportTask).readFileStat(s.reader, preimportTask, 0)
+	err = preimportTask.(*PreImportTask).readFileStat(s.reader, preimportTask, 0, nil)
 	s.NoError(err)
+
+	// rows of all files exceed the max import rows
+	paramtable.Get().Save(paramtable.Get().DataNodeCfg.MaxImportRows.Key, strconv.Itoa(s.numRows*2-1))
+	defer paramtable.Get().Reset(paramtable.Get().DataNodeCfg.MaxImportRows.Key)
+	s.NoError(preimportTask.(*PreImportTask).checkTotalRows(int64(s.numRows - 1)))
+	s.Error(preimportTask.(*PreImportTask).checkTotalRows(1))
+}
+
+func (s *SchedulerSuite) TestScheduler_ReadFileStat_FilePartition() {
+	newReader := func() *importutilv2.MockReader {
+		data, err := testutil.CreateInsertData(s.schema, s.numRows)
+		s.NoError(err)
+		var once sync.Once
+		reader := importutilv2.NewMockReader(s.T())
+		reader.EXPECT().Size().Return(1024, nil)
+		reader.EXPECT().Read().RunAndReturn(func() (*storage.InsertData, error) {
+			var res *storage.InsertData
+			once.Do(func() {
+				res = data
+			})
+			if res != nil {
+				return res, nil
+			}
+			return nil, io.EOF
+		})
+		return reader
+	}
+	// the pre-partitioned files of collection without partition key are imported into their own partitions.
+	preimportReq := &datapb.PreImportRequest{
+		JobID:        1,
+		TaskID:       2,
+		CollectionID: 3,
+		PartitionIDs: []int64{4, 5},
+		Vchannels:    []string{"ch-0", "ch-1"},
+		Schema:       s.schema,
+		ImportFiles: []*internalpb.ImportFile{
+			{Paths: []string{"p4.json"}, PartitionID: 4},
+			{Paths: []string{"p5.json"}, PartitionID: 5},
+		},
+	}
+	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm, nil)
+	s.manager.Add(preimportTask)
+	for i, partitionID := range []int64{4, 5} {
+		s.NoError(preimportTask.(*PreImportTask).readFileStat(newReader(), preimportTask, i, nil))
+		stat := s.manager.Get(preimportTask.GetTaskID()).(*PreImportTask).GetFileStats()[i]
+		rows := int64(0)
+		for _, stats := range stat.GetHashedStats() {
+			for partition, partitionRows := range stats.GetPartitionRows() {
+				if partitionRows > 0 {
+					s.Equal(partitionID, partition)
+				}
+				rows += partitionRows
+			}
+		}
+		s.Equal(int64(s.numRows), rows)
+	}
+}
+
+func (s *SchedulerSuite) TestScheduler_ReadFileStat_DecodeConcurrency() {
+	batches := make([]*storage.InsertData, 0, 5)
+	for i := 0; i < 5; i++ {
+		data, err := testutil.CreateInsertData(s.schema, s.numRows)
+		s.NoError(err)
+		batches = append(batches, data)
+	}
+	readFileStat := func(taskID int64, concurrency int) *datapb.ImportFileStats {
+		paramtable.Get().Save(paramtable.Get().DataNodeCfg.DecodeConcurrency.Key, strconv.Itoa(concurrency))
+		defer paramtable.Get().Reset(paramtable.Get().DataNodeCfg.DecodeConcurrency.Key)
+		next := 0
+		reader := importutilv2.NewMockReader(s.T())
+		reader.EXPECT().Size().Return(1024, nil)
+		reader.EXPECT().Read().RunAndReturn(func() (*storage.InsertData, error) {
+			if next >= len(batches) {
+				return nil, io.EOF
+			}
+			// the batches are hashed in place, so a copy is returned.
+			data, err := sliceRows(s.schema, batches[next], 0, s.numRows)
+			next++
+			return data, err
+		})
+		preimportReq := &datapb.PreImportRequest{
+			JobID:        1,
+			TaskID:       taskID,
+			CollectionID: 3,
+			PartitionIDs: []int64{4},
+			Vchannels:    []string{"ch-0", "ch-1"},
+			Schema:       s.schema,
+			ImportFiles:  []*internalpb.ImportFile{{Paths: []string{"dummy.json"}}},
+		}
+		preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm, nil)
+		s.manager.Add(preimportTask)
+		s.NoError(preimportTask.(*PreImportTask).readFileStat(reader, preimportTask, 0, nil))
+		return s.manager.Get(taskID).(*PreImportTask).GetFileStats()[0]
+	}
+
+	// the batches processed concurrently give the same stats as processed one by one.
+	expected := readFileStat(10, 1)
+	s.Equal(int64(5*s.numRows), expected.GetTotalRows())
+	actual := readFileStat(11, 4)
+	s.Equal(expected.GetTotalRows(), actual.GetTotalRows())
+	s.Equal(expected.GetTotalMemorySize(), actual.GetTotalMemorySize())
+	s.Equal(expected.GetClusteringFactor(), actual.GetClusteringFactor())
+	s.Equal(len(expected.GetHashedStats()), len(actual.GetHashedStats()))
+	for channel, stats := range expected.GetHashedStats() {
+		s.Equal(stats.GetPartitionRows(), actual.GetHashedStats()[channel].GetPartitionRows())
+		s.Equal(stats.GetPartitionDataSize(), actual.GetHashedStats()[channel].GetPartitionDataSize())
+		s.Equal(stats.GetPartitionChecksum(), actual.GetHashedStats()[channel].GetPartitionChecksum())
+	}
+	s.Equal(len(expected.GetFieldStats()), len(actual.GetFieldStats()))
+}
+
+func (s *SchedulerSuite) TestScheduler_ReadFileStat_ValidateOnly() {
+	RegisterRowTransform("reject_negative", func(row map[int64]any) error {
+		if row[102].(int64) < 0 {
+			return errors.New("negative int64")
+		}
+		return nil
+	})
+	newReader := func() *importutilv2.MockReader {
+		data, err := testutil.CreateInsertData(s.schema, s.numRows)
+		s.NoError(err)
+		for _, i := range []int{3, 4, 50} {
+			data.Data[102].(*storage.Int64FieldData).Data[i] = -1
+		}
+		var once sync.Once
+		reader := importutilv2.NewMockReader(s.T())
+		reader.EXPECT().Size().Return(1024, nil)
+		reader.EXPECT().Read().RunAndReturn(func() (*storage.InsertData, error) {
+			var res *storage.InsertData
+			once.Do(func() {
+				res = data
+			})
+			if res != nil {
+				return res, nil
+			}
+			return nil, io.EOF
+		}).Maybe()
+		return reader
+	}
+	cm := storage.NewLocalChunkManager(storage.RootPath(s.T().TempDir()))
+	preimportReq := &datapb.PreImportRequest{
+		JobID:        1,
+		TaskID:       2,
+		CollectionID: 3,
+		PartitionIDs: []int64{4},
+		Vchannels:    []string{"ch-0"},
+		Schema:       s.schema,
+		ImportFiles:  []*internalpb.ImportFile{{Paths: []string{"dummy.json"}}},
+		Options:      []*commonpb.KeyValuePair{{Key: importutilv2.RowTransform, Value: "reject_negative"}},
+	}
+	// the first bad row fails the file.
+	preimportTask := NewPreImportTask(preimportReq, s.manager, cm, nil)
+	s.manager.Add(preimportTask)
+	err := preimportTask.(*PreImportTask).readFileStat(newReader(), preimportTask, 0, nil)
+	s.ErrorContains(err, "negative int64")
+
+	// all the bad rows are skipped and reported if validating only.
+	preimportReq.TaskID = 3
+	preimportReq.Options = append(preimportReq.Options, &commonpb.KeyValuePair{Key: importutilv2.ValidateOnly, Value: "true"})
+	preimportTask = NewPreImportTask(preimportReq, s.manager, cm, nil)
+	s.manager.Add(preimportTask)
+	err = preimportTask.(*PreImportTask).readFileStat(newReader(), preimportTask, 0, nil)
+	s.NoError(err)
+	stat := s.manager.Get(preimportTask.GetTaskID()).(*PreImportTask).GetFileStats()[0]
+	s.Equal(int64(3), stat.GetInvalidRows())
+	s.Equal(int64(s.numRows-3), stat.GetTotalRows())
+	content, err := cm.Read(context.Background(), stat.GetErrorSamplesPath())
+	s.NoError(err)
+	s.Equal(3, strings.Count(string(content), "\n"))
+}
+
+func (s *SchedulerSuite) TestScheduler_ReadFileStat_Empty() {
+	s.reader = importutilv2.NewMockReader(s.T())
+	s.reader.EXPECT().Size().Return(0, nil)
+	s.reader.EXPECT().Read().Return(nil, io.EOF)
+	preimportReq := &datapb.PreImportRequest{
+		JobID:        1,
+		TaskID:       2,
+		CollectionID: 3,
+		PartitionIDs: []int64{4},
+		Vchannels:    []string{"ch-0"},
+		Schema:       s.schema,
+		ImportFiles:  []*internalpb.ImportFile{{Paths: []string{"empty.json"}}},
+	}
+	preimportTask := NewPreImportTask(preimportReq, s.manager, s.cm, nil)
+	s.manager.Add(preimportTask)
+	err := preimportTask.(*PreImportTask).readFileStat(s.reader, preimportTask, 0, nil)
+	s.NoError(err)
+	s.True(s.manager.Get(preimportTask.GetTaskID()).(*PreImportTask).GetFileStats()[0].GetIsEmpty())
+
+	// empty files are rejected if required
+	preimportReq.TaskID = 3
+	preimportReq.Options = []*commonpb.KeyValuePair{{Key: importutilv2.RejectEmptyFiles, Value: "true"}}
+	preimportTask = NewPreImportTask(preimportReq, s.manager, s.cm, nil)
+	s.manager.Add(preimportTask)
+	err = preimportTask.(*PreImportTask).readFileStat(s.reader, preimportTask, 0, nil)
+	s.ErrorContains(err, "empty.json")
+	s.True(s.manager.Get(preimportTask.GetTaskID()).(*PreImportTask).GetFileStats()[0].GetIsEmpty())
 }
 
 func (s *SchedulerSuite) TestScheduler_ImportFile() {
@@ -429,9 +627,9 @@ func (s *SchedulerSuite) TestScheduler_ImportFile() {
 			},
 		},
 	}
-	importTask := NewImportTask(importReq, s.manager, s.syncMgr, s.cm)
+	importTask := NewImportTask(importReq, s.manager, s.syncMgr, s.cm, nil)
 	s.manager.Add(importTask)
-	err = importTask.(*ImportTask).importFile(s.reader, importTask)
+	err = importTask.(*ImportTask).importFile(s.reader, importTask, importReq.GetFiles()[0], 0)
 	s.NoError(err)
 }
 
@@ -0,0 +1,106 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"cmp"
+	"sort"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// comparePK compares primary keys of the same type, which are either int64 or string.
+func comparePK(a, b any) int {
+	switch av := a.(type) {
+	case int64:
+		return cmp.Compare(av, b.(int64))
+	case string:
+		return cmp.Compare(av, b.(string))
+	}
+	return 0
+}
+
+// SortByPK returns the rows sorted by primary key, the order of rows with equal primary key is kept.
+// The rows are returned as is if they are already sorted or have no primary key, e.g. auto id is not assigned yet.
+func SortByPK(schema *schemapb.CollectionSchema, data *storage.InsertData) (*storage.InsertData, error) {
+	pkField, err := typeutil.GetPrimaryFieldSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	pkData, ok := data.Data[pkField.GetFieldID()]
+	if !ok {
+		return data, nil
+	}
+	order := make([]int, pkData.RowNum())
+	for i := range order {
+		order[i] = i
+	}
+	less := func(i, j int) bool {
+		return comparePK(pkData.GetRow(order[i]), pkData.GetRow(order[j])) < 0
+	}
+	if sort.SliceIsSorted(order, less) {
+		return data, nil
+	}
+	sort.SliceStable(order, less)
+
+	sorted, err := storage.NewInsertData(typeutil.AppendSystemFields(schema))
+	if err != nil {
+		return nil, err
+	}
+	for fieldID := range sorted.Data {
+		if _, ok := data.Data[fieldID]; !ok {
+			delete(sorted.Data, fieldID)
+		}
+	}
+	for _, idx := range order {
+		if err = sorted.Append(data.GetRow(idx)); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// clusteringEstimator estimates how well the primary keys are sorted in the order they are read.
+type clusteringEstimator struct {
+	last    any
+	ordered int64
+	pairs   int64
+}
+
+// Observe records the primary keys of next batch of rows.
+func (e *clusteringEstimator) Observe(pks storage.FieldData) {
+	for i := 0; i < pks.RowNum(); i++ {
+		pk := pks.GetRow(i)
+		if e.last != nil {
+			e.pairs++
+			if comparePK(e.last, pk) <= 0 {
+				e.ordered++
+			}
+		}
+		e.last = pk
+	}
+}
+
+// Factor returns the fraction of adjacent rows in primary key order, 1 if there are less than 2 rows.
+func (e *clusteringEstimator) Factor() float32 {
+	if e.pairs == 0 {
+		return 1
+	}
+	return float32(e.ordered) / float32(e.pairs)
+}
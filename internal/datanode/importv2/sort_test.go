@@ -0,0 +1,62 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+func Test_SortByPK(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_VarChar},
+			{FieldID: 101, Name: "a", DataType: schemapb.DataType_Int64},
+		},
+	}
+	data := &storage.InsertData{
+		Data: map[int64]storage.FieldData{
+			100: &storage.StringFieldData{Data: []string{"c", "a", "b", "a"}},
+			101: &storage.Int64FieldData{Data: []int64{1, 2, 3, 4}},
+		},
+	}
+	sorted, err := SortByPK(schema, data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "a", "b", "c"}, sorted.Data[100].GetRows())
+	assert.Equal(t, []int64{2, 4, 3, 1}, sorted.Data[101].GetRows())
+
+	// already sorted rows are returned as is
+	again, err := SortByPK(schema, sorted)
+	assert.NoError(t, err)
+	assert.Same(t, sorted, again)
+
+	_, err = SortByPK(&schemapb.CollectionSchema{}, data)
+	assert.Error(t, err)
+}
+
+func Test_ClusteringEstimator(t *testing.T) {
+	e := &clusteringEstimator{}
+	assert.Equal(t, float32(1), e.Factor())
+	e.Observe(&storage.Int64FieldData{Data: []int64{1, 2, 5}})
+	e.Observe(&storage.Int64FieldData{Data: []int64{3, 4}})
+	// 1->2, 2->5, 3->4 are in order, 5->3 is not
+	assert.Equal(t, float32(0.75), e.Factor())
+}
@@ -0,0 +1,74 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+// forEachSubBatch feeds the rows of data to fn in sub-chunks of about memoryCap bytes at most, with the offset
+// of the first row of each sub-chunk in data, so that the rows derived from a batch of wide schema, e.g. by row
+// transform, are not held as a whole. The batch is fed as is if memoryCap is not positive, the batch fits in it,
+// or the rows are not aligned, which is reported by the checks of fn.
+func forEachSubBatch(schema *schemapb.CollectionSchema, data *storage.InsertData, memoryCap int,
+	fn func(chunk *storage.InsertData, offset int) error,
+) error {
+	size := data.GetMemorySize()
+	if memoryCap <= 0 || size <= memoryCap || CheckRowsEqual(schema, data) != nil {
+		return fn(data, 0)
+	}
+	rowNum := GetInsertDataRowCount(data, schema)
+	chunkRows := max(1, int(int64(rowNum)*int64(memoryCap)/int64(size)))
+	for start := 0; start < rowNum; start += chunkRows {
+		end := min(start+chunkRows, rowNum)
+		chunk, err := sliceRows(schema, data, start, end)
+		if err != nil {
+			return err
+		}
+		if err = fn(chunk, start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sliceRows copies the rows in [start, end) of data, the fields shorter than end, e.g. the auto id primary key
+// which is not assigned yet, are copied as far as they go.
+func sliceRows(schema *schemapb.CollectionSchema, data *storage.InsertData, start, end int) (*storage.InsertData, error) {
+	chunk, err := storage.NewInsertDataWithCap(schema, end-start)
+	if err != nil {
+		return nil, err
+	}
+	for fieldID := range chunk.Data {
+		if _, ok := data.Data[fieldID]; !ok {
+			delete(chunk.Data, fieldID)
+		}
+	}
+	for fieldID, fd := range data.Data {
+		target, ok := chunk.Data[fieldID]
+		if !ok {
+			continue
+		}
+		for i := start; i < min(end, fd.RowNum()); i++ {
+			if err = target.AppendRow(fd.GetRow(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return chunk, nil
+}
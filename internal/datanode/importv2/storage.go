@@ -0,0 +1,136 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+// StorageBackends are the chunk managers of the storages which import files can be read from,
+// keyed by the lower case storage backend of import file, as the config keys are case-insensitive.
+type StorageBackends map[string]storage.ChunkManager
+
+// Resolve returns the chunk manager to read the import file,
+// the default chunk manager is used if the file doesn't specify a storage backend.
+func (b StorageBackends) Resolve(defaultCM storage.ChunkManager, file *internalpb.ImportFile) (storage.ChunkManager, error) {
+	backend := file.GetStorageBackend()
+	if backend == "" {
+		return defaultCM, nil
+	}
+	cm, ok := b[strings.ToLower(backend)]
+	if !ok {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("unknown storage backend '%s' of file %v", backend, file.GetPaths()))
+	}
+	return cm, nil
+}
+
+// NewStorageBackends creates the chunk managers of storage backends by configs keyed by `<backend>.<option>`,
+// e.g. the values of `dataNode.import.storageBackends`. The options not set or empty default to the ones of the default storage.
+func NewStorageBackends(ctx context.Context, configs map[string]string) (StorageBackends, error) {
+	options := make(map[string]map[string]string)
+	for key, value := range configs {
+		if value == "" {
+			continue
+		}
+		backend, option, ok := strings.Cut(strings.ToLower(key), ".")
+		if !ok || backend == "" {
+			return nil, merr.WrapErrParameterInvalidMsg("invalid storage backend config '%s', expected <backend>.<option>", key)
+		}
+		if options[backend] == nil {
+			options[backend] = make(map[string]string)
+		}
+		options[backend][option] = value
+	}
+
+	backends := make(StorageBackends, len(options))
+	for backend, option := range options {
+		factory, err := newStorageBackendFactory(option)
+		if err != nil {
+			return nil, merr.WrapErrParameterInvalidMsg("invalid config of storage backend '%s', %s", backend, err.Error())
+		}
+		cm, err := factory.NewPersistentStorageChunkManager(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create chunk manager of storage backend '%s'", backend)
+		}
+		backends[backend] = cm
+	}
+	return backends, nil
+}
+
+// newStorageBackendFactory creates the chunk manager factory of options keyed by lower case option names.
+func newStorageBackendFactory(option map[string]string) (*storage.ChunkManagerFactory, error) {
+	params := paramtable.Get()
+	get := func(name string, defaultValue string) string {
+		if value, ok := option[strings.ToLower(name)]; ok {
+			delete(option, strings.ToLower(name))
+			return value
+		}
+		return defaultValue
+	}
+	var parseErr error
+	getBool := func(name string, defaultValue bool) bool {
+		value, err := strconv.ParseBool(get(name, strconv.FormatBool(defaultValue)))
+		if err != nil && parseErr == nil {
+			parseErr = errors.Newf("option %s should be bool", name)
+		}
+		return value
+	}
+
+	storageType := get("storageType", params.CommonCfg.StorageType.GetValue())
+	var opts []storage.Option
+	if storageType == "local" {
+		opts = append(opts, storage.RootPath(get("rootPath", params.LocalStorageCfg.Path.GetValue())))
+	} else {
+		opts = append(opts,
+			storage.RootPath(get("rootPath", params.MinioCfg.RootPath.GetValue())),
+			storage.Address(get("address", params.MinioCfg.Address.GetValue())),
+			storage.AccessKeyID(get("accessKeyID", params.MinioCfg.AccessKeyID.GetValue())),
+			storage.SecretAccessKeyID(get("secretAccessKey", params.MinioCfg.SecretAccessKey.GetValue())),
+			storage.UseSSL(getBool("useSSL", params.MinioCfg.UseSSL.GetAsBool())),
+			storage.SslCACert(get("sslCACert", params.MinioCfg.SslCACert.GetValue())),
+			storage.BucketName(get("bucketName", params.MinioCfg.BucketName.GetValue())),
+			storage.UseIAM(getBool("useIAM", params.MinioCfg.UseIAM.GetAsBool())),
+			storage.CloudProvider(get("cloudProvider", params.MinioCfg.CloudProvider.GetValue())),
+			storage.IAMEndpoint(get("iamEndpoint", params.MinioCfg.IAMEndpoint.GetValue())),
+			storage.UseVirtualHost(getBool("useVirtualHost", params.MinioCfg.UseVirtualHost.GetAsBool())),
+			storage.Region(get("region", params.MinioCfg.Region.GetValue())),
+			storage.RequestTimeout(params.MinioCfg.RequestTimeoutMs.GetAsInt64()),
+			// the import files are only read from the backends, which never create buckets.
+			storage.CreateBucket(false))
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if len(option) > 0 {
+		names := lo.Keys(option)
+		sort.Strings(names)
+		return nil, errors.Newf("unknown options %v", names)
+	}
+	return storage.NewChunkManagerFactory(storageType, opts...), nil
+}
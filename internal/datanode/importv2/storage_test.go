@@ -0,0 +1,79 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func Test_StorageBackendsResolve(t *testing.T) {
+	defaultCM := storage.NewLocalChunkManager(storage.RootPath(t.TempDir()))
+	remoteCM := storage.NewLocalChunkManager(storage.RootPath(t.TempDir()))
+
+	var backends StorageBackends
+	cm, err := backends.Resolve(defaultCM, &internalpb.ImportFile{Paths: []string{"a.json"}})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultCM, cm)
+
+	backends = StorageBackends{"remote": remoteCM}
+	cm, err = backends.Resolve(defaultCM, &internalpb.ImportFile{Paths: []string{"a.json"}, StorageBackend: "remote"})
+	assert.NoError(t, err)
+	assert.Equal(t, remoteCM, cm)
+
+	_, err = backends.Resolve(defaultCM, &internalpb.ImportFile{Paths: []string{"a.json"}, StorageBackend: "unknown"})
+	assert.Error(t, err)
+}
+
+func Test_NewStorageBackends(t *testing.T) {
+	paramtable.Init()
+	rootPath := t.TempDir()
+	backends, err := NewStorageBackends(context.Background(), map[string]string{
+		"Archive.storageType": "local",
+		"archive.rootpath":    rootPath,
+		"unset.rootpath":      "",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, backends, 1)
+	cm, err := backends.Resolve(nil, &internalpb.ImportFile{StorageBackend: "ARCHIVE"})
+	assert.NoError(t, err)
+	assert.Equal(t, rootPath, cm.RootPath())
+
+	backends, err = NewStorageBackends(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, backends)
+
+	_, err = NewStorageBackends(context.Background(), map[string]string{"archive": "local"})
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	_, err = NewStorageBackends(context.Background(), map[string]string{
+		"archive.storagetype": "local",
+		"archive.bucketname":  "backup",
+	})
+	assert.ErrorContains(t, err, "unknown options [bucketname]")
+	_, err = NewStorageBackends(context.Background(), map[string]string{
+		"archive.storagetype": "minio",
+		"archive.usessl":      "maybe",
+	})
+	assert.ErrorContains(t, err, "option useSSL should be bool")
+}
@@ -0,0 +1,262 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/pkg/log"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// TaskSummary is the consolidated summary of a finished preimport task,
+// so that the downstream systems don't need to reassemble it from the per-file stats.
+type TaskSummary struct {
+	JobID        int64
+	TaskID       int64
+	CollectionID int64
+	State        datapb.ImportTaskStateV2
+	Reason       string
+	// SchemaFingerprint identifies the schema used by the task to correlate with the target collection,
+	// it's derived from the schema content since the schema carries no version.
+	SchemaFingerprint uint64
+
+	FileNum         int
+	TotalRows       int64
+	TotalFileSize   int64
+	TotalMemorySize int64
+	// InvalidRows is the bad rows skipped by validate only import.
+	InvalidRows int64
+	// OutOfNormVectors is the float vectors of cosine metric fields out of unit norm, checked if vector_normalization is set.
+	OutOfNormVectors int64
+	// PartitionRows and PartitionChecksum are the distribution of rows over partitions of all files.
+	PartitionRows     map[int64]int64
+	PartitionChecksum map[int64]uint64
+	// ErrorSamplesPaths is the paths of sampled bad rows, the task fails on bad rows so it's empty if completed.
+	ErrorSamplesPaths []string
+	// DuplicateFiles is the groups of different files with identical rows, likely re-uploaded by accident.
+	DuplicateFiles [][]string
+	// SegmentPreviews is the segments projected to be allocated for the task, to catch bad partitioning
+	// before the import, e.g. all rows bucketed into one giant segment.
+	SegmentPreviews []SegmentPreview
+	// ReservedAutoIDs is the estimated size of ID range to be reserved for reassigning the primary keys,
+	// 0 if the primary keys are not reassigned.
+	ReservedAutoIDs int64
+	Duration        time.Duration
+}
+
+// SegmentPreview is a segment projected by the hashed stats of preimport,
+// the rows and size are approximate since the data of a partition is assumed to be split evenly.
+type SegmentPreview struct {
+	Vchannel    string
+	PartitionID int64
+	Rows        int64
+	Size        int64
+}
+
+// SummaryHandler receives the summary of finished preimport task, it should not block.
+type SummaryHandler func(summary *TaskSummary)
+
+// SummarizeTask builds the summary of preimport task, returns nil if the task is not a preimport task.
+func SummarizeTask(task Task, duration time.Duration) *TaskSummary {
+	var fileStats []*datapb.ImportFileStats
+	// the same segment size as datacoord uses to allocate the import segments.
+	segmentMaxSize := paramtable.Get().DataCoordCfg.SegmentMaxSize.GetAsInt64() * 1024 * 1024
+	switch t := task.(type) {
+	case *PreImportTask:
+		fileStats = t.GetFileStats()
+	case *L0PreImportTask:
+		fileStats = t.GetFileStats()
+		segmentMaxSize = paramtable.Get().DataNodeCfg.FlushDeleteBufferBytes.GetAsInt64()
+	default:
+		return nil
+	}
+	summary := &TaskSummary{
+		JobID:             task.GetJobID(),
+		TaskID:            task.GetTaskID(),
+		CollectionID:      task.GetCollectionID(),
+		State:             task.GetState(),
+		Reason:            task.GetReason(),
+		SchemaFingerprint: SchemaFingerprint(task.GetSchema()),
+		PartitionRows:     make(map[int64]int64),
+		PartitionChecksum: make(map[int64]uint64),
+		ErrorSamplesPaths: make([]string, 0),
+		Duration:          duration,
+	}
+	for _, stat := range fileStats {
+		summary.FileNum++
+		summary.TotalRows += stat.GetTotalRows()
+		summary.TotalFileSize += stat.GetFileSize()
+		summary.TotalMemorySize += stat.GetTotalMemorySize()
+		summary.InvalidRows += stat.GetInvalidRows()
+		summary.OutOfNormVectors += stat.GetOutOfNormVectors()
+		for _, partitionStats := range stat.GetHashedStats() {
+			for partitionID, rows := range partitionStats.GetPartitionRows() {
+				summary.PartitionRows[partitionID] += rows
+			}
+			for partitionID, checksum := range partitionStats.GetPartitionChecksum() {
+				summary.PartitionChecksum[partitionID] += checksum
+			}
+		}
+		if stat.GetErrorSamplesPath() != "" {
+			summary.ErrorSamplesPaths = append(summary.ErrorSamplesPaths, stat.GetErrorSamplesPath())
+		}
+	}
+	// Every row takes a new primary key from the ID range if the autoID is kept.
+	if pkField, err := typeutil.GetPrimaryFieldSchema(task.GetSchema()); err == nil && pkField.GetAutoID() {
+		summary.ReservedAutoIDs = summary.TotalRows
+	}
+	summary.DuplicateFiles = findDuplicateFiles(fileStats)
+	summary.SegmentPreviews = previewSegments(fileStats, segmentMaxSize)
+	return summary
+}
+
+// previewSegments projects the segments of files in the same way as datacoord assigns them,
+// each partition of each vchannel takes as many segments as its data size needs.
+func previewSegments(fileStats []*datapb.ImportFileStats, segmentMaxSize int64) []SegmentPreview {
+	type bucket struct {
+		vchannel    string
+		partitionID int64
+	}
+	rows := make(map[bucket]int64)
+	sizes := make(map[bucket]int64)
+	for _, stat := range fileStats {
+		for vchannel, partitionStats := range stat.GetHashedStats() {
+			for partitionID, size := range partitionStats.GetPartitionDataSize() {
+				sizes[bucket{vchannel, partitionID}] += size
+			}
+			for partitionID, num := range partitionStats.GetPartitionRows() {
+				rows[bucket{vchannel, partitionID}] += num
+			}
+		}
+	}
+	buckets := make([]bucket, 0, len(sizes))
+	for b, size := range sizes {
+		if size > 0 {
+			buckets = append(buckets, b)
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].vchannel != buckets[j].vchannel {
+			return buckets[i].vchannel < buckets[j].vchannel
+		}
+		return buckets[i].partitionID < buckets[j].partitionID
+	})
+
+	previews := make([]SegmentPreview, 0, len(buckets))
+	for _, b := range buckets {
+		size := sizes[b]
+		num := int64(1)
+		if segmentMaxSize > 0 {
+			num = (size + segmentMaxSize - 1) / segmentMaxSize
+		}
+		for i := int64(0); i < num; i++ {
+			// the remainder goes to the first segments.
+			preview := SegmentPreview{
+				Vchannel:    b.vchannel,
+				PartitionID: b.partitionID,
+				Rows:        rows[b] / num,
+				Size:        size / num,
+			}
+			if i < rows[b]%num {
+				preview.Rows++
+			}
+			if i < size%num {
+				preview.Size++
+			}
+			previews = append(previews, preview)
+		}
+	}
+	return previews
+}
+
+// findDuplicateFiles groups the non-empty files by the number of rows and the checksum of rows,
+// returns the groups which contain more than one file.
+func findDuplicateFiles(fileStats []*datapb.ImportFileStats) [][]string {
+	type fileKey struct {
+		rows     int64
+		checksum uint64
+	}
+	groups := make(map[fileKey][]string)
+	keys := make([]fileKey, 0)
+	for _, stat := range fileStats {
+		if stat.GetTotalRows() == 0 {
+			continue
+		}
+		key := fileKey{rows: stat.GetTotalRows()}
+		for _, partitionStats := range stat.GetHashedStats() {
+			for _, checksum := range partitionStats.GetPartitionChecksum() {
+				key.checksum += checksum
+			}
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], strings.Join(stat.GetImportFile().GetPaths(), ","))
+	}
+	duplicates := make([][]string, 0)
+	for _, key := range keys {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+	return duplicates
+}
+
+// SchemaFingerprint returns the fnv hash of serialized schema, 0 if the schema can't be serialized.
+func SchemaFingerprint(schema *schemapb.CollectionSchema) uint64 {
+	bs, err := proto.Marshal(schema)
+	if err != nil {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write(bs)
+	return h.Sum64()
+}
+
+// logSummary is the default summary handler which writes the summary into log.
+func logSummary(summary *TaskSummary) {
+	log.Info("preimport task finished",
+		zap.Int64("jobID", summary.JobID),
+		zap.Int64("taskID", summary.TaskID),
+		zap.Int64("collectionID", summary.CollectionID),
+		zap.String("state", summary.State.String()),
+		zap.String("reason", summary.Reason),
+		zap.Uint64("schemaFingerprint", summary.SchemaFingerprint),
+		zap.Int("fileNum", summary.FileNum),
+		zap.Int64("totalRows", summary.TotalRows),
+		zap.Int64("totalFileSize", summary.TotalFileSize),
+		zap.Int64("totalMemorySize", summary.TotalMemorySize),
+		zap.Int64("invalidRows", summary.InvalidRows),
+		zap.Int64("outOfNormVectors", summary.OutOfNormVectors),
+		zap.Any("partitionRows", summary.PartitionRows),
+		zap.Any("partitionChecksum", summary.PartitionChecksum),
+		zap.Strings("errorSamplesPaths", summary.ErrorSamplesPaths),
+		zap.Any("duplicateFiles", summary.DuplicateFiles),
+		zap.Any("segmentPreviews", summary.SegmentPreviews),
+		zap.Int64("reservedAutoIDs", summary.ReservedAutoIDs),
+		zap.Duration("duration", summary.Duration))
+}
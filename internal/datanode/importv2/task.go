@@ -96,6 +96,8 @@ func UpdateReason(reason string) UpdateAction {
 	}
 }
 
+// UpdateFileStat writes the stat to the slot of the idx-th import file, so the order of FileStats
+// always matches the order of import files in request regardless of the completion order of files.
 func UpdateFileStat(idx int, fileStat *datapb.ImportFileStats) UpdateAction {
 	return func(task Task) {
 		var t *datapb.PreImportTask
@@ -105,11 +107,34 @@ func UpdateFileStat(idx int, fileStat *datapb.ImportFileStats) UpdateAction {
 		case *L0PreImportTask:
 			t = it.PreImportTask
 		}
-		if t != nil {
+		if t != nil && idx < len(t.GetFileStats()) {
 			t.FileStats[idx].FileSize = fileStat.GetFileSize()
 			t.FileStats[idx].TotalRows = fileStat.GetTotalRows()
 			t.FileStats[idx].TotalMemorySize = fileStat.GetTotalMemorySize()
 			t.FileStats[idx].HashedStats = fileStat.GetHashedStats()
+			t.FileStats[idx].IsEmpty = fileStat.GetIsEmpty()
+			t.FileStats[idx].ClusteringFactor = fileStat.GetClusteringFactor()
+			t.FileStats[idx].FieldStats = fileStat.GetFieldStats()
+			t.FileStats[idx].UnknownColumns = fileStat.GetUnknownColumns()
+			t.FileStats[idx].InsertRows = fileStat.GetInsertRows()
+			t.FileStats[idx].DeleteRows = fileStat.GetDeleteRows()
+			t.FileStats[idx].UpdateRows = fileStat.GetUpdateRows()
+			t.FileStats[idx].UnmatchedDeleteRows = fileStat.GetUnmatchedDeleteRows()
+		}
+	}
+}
+
+func UpdateFileStatErrorSamples(idx int, samplesPath string) UpdateAction {
+	return func(task Task) {
+		var t *datapb.PreImportTask
+		switch it := task.(type) {
+		case *PreImportTask:
+			t = it.PreImportTask
+		case *L0PreImportTask:
+			t = it.PreImportTask
+		}
+		if t != nil && idx < len(t.GetFileStats()) {
+			t.FileStats[idx].ErrorSamplesPath = samplesPath
 		}
 	}
 }
@@ -36,6 +36,7 @@ import (
 	"github.com/milvus-io/milvus/pkg/log"
 	"github.com/milvus-io/milvus/pkg/util/conc"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 type ImportTask struct {
@@ -48,6 +49,7 @@ type ImportTask struct {
 	manager    TaskManager
 	syncMgr    syncmgr.SyncManager
 	cm         storage.ChunkManager
+	backends   StorageBackends
 	metaCaches map[string]metacache.MetaCache
 }
 
@@ -55,11 +57,13 @@ func NewImportTask(req *datapb.ImportRequest,
 	manager TaskManager,
 	syncMgr syncmgr.SyncManager,
 	cm storage.ChunkManager,
+	backends StorageBackends,
 ) Task {
 	ctx, cancel := context.WithCancel(context.Background())
 	// During binlog import, even if the primary key's autoID is set to true,
-	// the primary key from the binlog should be used instead of being reassigned.
-	if importutilv2.IsBackup(req.GetOptions()) {
+	// the primary key from the binlog should be used instead of being reassigned,
+	// unless the reassignment is requested explicitly.
+	if importutilv2.IsBackup(req.GetOptions()) && !importutilv2.IsReassignAutoID(req.GetOptions()) {
 		UnsetAutoID(req.GetSchema())
 	}
 	task := &ImportTask{
@@ -76,6 +80,7 @@ func NewImportTask(req *datapb.ImportRequest,
 		manager:      manager,
 		syncMgr:      syncMgr,
 		cm:           cm,
+		backends:     backends,
 	}
 	task.metaCaches = NewMetaCache(req)
 	return task
@@ -126,8 +131,18 @@ func (t *ImportTask) Execute() []*conc.Future[any] {
 
 	req := t.req
 
-	fn := func(file *internalpb.ImportFile) error {
-		reader, err := importutilv2.NewReader(t.ctx, t.cm, t.GetSchema(), file, t.req.GetOptions(), bufferSize)
+	fn := func(fileIdx int, file *internalpb.ImportFile) error {
+		cm, err := t.backends.Resolve(t.cm, file)
+		if err != nil {
+			log.Warn("resolve storage backend failed", WrapLogFields(t, zap.String("file", file.String()), zap.Error(err))...)
+			t.manager.Update(t.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Failed), UpdateReason(err.Error()))
+			return err
+		}
+		var reader importutilv2.Reader
+		err = RetryRead(t.ctx, func() (err error) {
+			reader, err = importutilv2.NewReader(t.ctx, cm, t.GetSchema(), file, t.req.GetOptions(), bufferSize)
+			return err
+		})
 		if err != nil {
 			log.Warn("new reader failed", WrapLogFields(t, zap.String("file", file.String()), zap.Error(err))...)
 			t.manager.Update(t.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Failed), UpdateReason(err.Error()))
@@ -135,7 +150,7 @@ func (t *ImportTask) Execute() []*conc.Future[any] {
 		}
 		defer reader.Close()
 		start := time.Now()
-		err = t.importFile(reader, t)
+		err = t.importFile(reader, t, file, fileIdx)
 		if err != nil {
 			log.Warn("do import failed", WrapLogFields(t, zap.String("file", file.String()), zap.Error(err))...)
 			t.manager.Update(t.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Failed), UpdateReason(err.Error()))
@@ -146,35 +161,55 @@ func (t *ImportTask) Execute() []*conc.Future[any] {
 		return nil
 	}
 
-	futures := make([]*conc.Future[any], 0, len(req.GetFiles()))
-	for _, file := range req.GetFiles() {
-		file := file
-		f := GetExecPool().Submit(func() (any, error) {
-			err := fn(file)
-			return err, err
-		})
-		futures = append(futures, f)
-	}
-	return futures
+	return SubmitFiles(req.GetFiles(), fn)
 }
 
-func (t *ImportTask) importFile(reader importutilv2.Reader, task Task) error {
+func (t *ImportTask) importFile(reader importutilv2.Reader, task Task, file *internalpb.ImportFile, fileIdx int) error {
 	iTask := task.(*ImportTask)
+	if err := CheckFilePartition(task, file); err != nil {
+		return err
+	}
+	transform, err := GetRowTransform(t.req.GetOptions())
+	if err != nil {
+		return err
+	}
+	pkField, err := typeutil.GetPrimaryFieldSchema(task.GetSchema())
+	if err != nil {
+		return err
+	}
+	// The primary keys are reassigned only if the autoID is kept for the backup import.
+	var mappingWriter *pkMappingWriter
+	if importutilv2.IsReassignAutoID(t.req.GetOptions()) && pkField.GetAutoID() {
+		mappingWriter = newPKMappingWriter(t.cm, task, fileIdx)
+	}
 	syncFutures := make([]*conc.Future[struct{}], 0)
 	syncTasks := make([]syncmgr.Task, 0)
 	for {
-		data, err := reader.Read()
+		data, err := ReadWithRetry(t.ctx, reader)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
 			return err
 		}
+		if transform != nil {
+			data, err = TransformRows(task.GetSchema(), data, transform)
+			if err != nil {
+				return err
+			}
+		}
+		oldPKs := data.Data[pkField.GetFieldID()]
 		err = AppendSystemFieldsData(iTask, data)
 		if err != nil {
 			return err
 		}
-		hashedData, err := HashData(iTask, data)
+		if mappingWriter != nil {
+			err = mappingWriter.Write(t.ctx, oldPKs, data.Data[pkField.GetFieldID()])
+			if err != nil {
+				return err
+			}
+		}
+		hashedData, err := HashData(iTask, data, file.GetPartitionID())
 		if err != nil {
 			return err
 		}
@@ -185,7 +220,7 @@ func (t *ImportTask) importFile(reader importutilv2.Reader, task Task) error {
 		syncFutures = append(syncFutures, fs...)
 		syncTasks = append(syncTasks, sts...)
 	}
-	err := conc.AwaitAll(syncFutures...)
+	err = conc.AwaitAll(syncFutures...)
 	if err != nil {
 		return err
 	}
@@ -197,6 +232,10 @@ func (t *ImportTask) importFile(reader importutilv2.Reader, task Task) error {
 		t.manager.Update(task.GetTaskID(), UpdateSegmentInfo(segmentInfo))
 		log.Info("sync import data done", WrapLogFields(task, zap.Any("segmentInfo", segmentInfo))...)
 	}
+	if mappingWriter != nil {
+		log.Info("primary key mappings written", WrapLogFields(task, zap.String("dir", mappingWriter.dir),
+			zap.Int("fileIdx", fileIdx), zap.Int("parts", mappingWriter.part))...)
+	}
 	return nil
 }
 
@@ -210,6 +249,13 @@ func (t *ImportTask) sync(task *ImportTask, hashedData HashedData) ([]*conc.Futu
 			if data.GetRowNum() == 0 {
 				continue
 			}
+			if importutilv2.IsSortByPK(task.req.GetOptions()) {
+				var err error
+				data, err = SortByPK(task.GetSchema(), data)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
 			partitionID := task.GetPartitionIDs()[partitionIdx]
 			segmentID := PickSegment(task.req.GetRequestSegments(), channel, partitionID)
 			syncTask, err := NewSyncTask(task.ctx, task.metaCaches, task.req.GetTs(),
@@ -18,6 +18,7 @@ package importv2
 
 import (
 	"sync"
+	"time"
 )
 
 type TaskManager interface {
@@ -26,16 +27,24 @@ type TaskManager interface {
 	Get(taskID int64) Task
 	GetBy(filters ...TaskFilter) []Task
 	Remove(taskID int64)
+	// Subscribe registers the handler to receive the summary of finished preimport tasks.
+	Subscribe(handler SummaryHandler)
+	// Summarize emits the summary of finished preimport task to the subscribers, it's a no-op for other tasks.
+	Summarize(taskID int64, duration time.Duration)
 }
 
 type taskManager struct {
 	mu    sync.RWMutex // guards tasks
 	tasks map[int64]Task
+
+	handlersMu sync.RWMutex // guards handlers
+	handlers   []SummaryHandler
 }
 
 func NewTaskManager() TaskManager {
 	return &taskManager{
-		tasks: make(map[int64]Task),
+		tasks:    make(map[int64]Task),
+		handlers: []SummaryHandler{logSummary},
 	}
 }
 
@@ -87,3 +96,25 @@ func (m *taskManager) Remove(taskID int64) {
 	}
 	delete(m.tasks, taskID)
 }
+
+func (m *taskManager) Subscribe(handler SummaryHandler) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.handlers = append(m.handlers, handler)
+}
+
+func (m *taskManager) Summarize(taskID int64, duration time.Duration) {
+	task := m.Get(taskID)
+	if task == nil {
+		return
+	}
+	summary := SummarizeTask(task, duration)
+	if summary == nil {
+		return
+	}
+	m.handlersMu.RLock()
+	defer m.handlersMu.RUnlock()
+	for _, handler := range m.handlers {
+		handler(summary)
+	}
+}
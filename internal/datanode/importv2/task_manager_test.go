@@ -18,11 +18,17 @@ package importv2
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
 )
 
 func TestImportManager(t *testing.T) {
@@ -146,3 +152,105 @@ func TestImportManager_L0(t *testing.T) {
 		assert.Equal(t, int64(100), res.(*L0ImportTask).GetSegmentsInfo()[0].GetImportedRows())
 	})
 }
+
+func TestImportManager_FileStatsOrder(t *testing.T) {
+	const fileNum = 20
+	manager := NewTaskManager()
+	importFiles := make([]*internalpb.ImportFile, 0, fileNum)
+	for i := 0; i < fileNum; i++ {
+		importFiles = append(importFiles, &internalpb.ImportFile{Id: int64(i), Paths: []string{fmt.Sprintf("%d.json", i)}})
+	}
+	task := NewPreImportTask(&datapb.PreImportRequest{
+		JobID:       1,
+		TaskID:      2,
+		ImportFiles: importFiles,
+	}, manager, nil, nil)
+	manager.Add(task)
+
+	// files complete in random order.
+	wg := &sync.WaitGroup{}
+	for i := 0; i < fileNum; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			time.Sleep(time.Duration(rand.Intn(20)) * time.Millisecond)
+			manager.Update(task.GetTaskID(), UpdateFileStat(i, &datapb.ImportFileStats{
+				TotalRows: int64(i),
+			}))
+		}(i)
+	}
+	wg.Wait()
+
+	fileStats := manager.Get(task.GetTaskID()).(*PreImportTask).GetFileStats()
+	assert.Len(t, fileStats, fileNum)
+	for i, fileStat := range fileStats {
+		assert.Equal(t, int64(i), fileStat.GetImportFile().GetId())
+		assert.Equal(t, int64(i), fileStat.GetTotalRows())
+	}
+
+	// out of range stat is ignored.
+	manager.Update(task.GetTaskID(), UpdateFileStat(fileNum, &datapb.ImportFileStats{}))
+	assert.Len(t, manager.Get(task.GetTaskID()).(*PreImportTask).GetFileStats(), fileNum)
+}
+
+func TestImportManager_Summary(t *testing.T) {
+	manager := NewTaskManager()
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+		},
+	}
+	task := NewPreImportTask(&datapb.PreImportRequest{
+		JobID:        1,
+		TaskID:       2,
+		CollectionID: 3,
+		Schema:       schema,
+		ImportFiles:  []*internalpb.ImportFile{{Id: 0, Paths: []string{"a.json"}}, {Id: 1, Paths: []string{"b.json"}}},
+	}, manager, nil, nil)
+	manager.Add(task)
+	manager.Update(task.GetTaskID(),
+		UpdateState(datapb.ImportTaskStateV2_Completed),
+		UpdateFileStat(0, &datapb.ImportFileStats{
+			FileSize:  10,
+			TotalRows: 3,
+			HashedStats: map[string]*datapb.PartitionImportStats{
+				"ch0": {PartitionRows: map[int64]int64{10: 1, 11: 1}, PartitionChecksum: map[int64]uint64{10: 1, 11: 2}},
+				"ch1": {PartitionRows: map[int64]int64{10: 1}, PartitionChecksum: map[int64]uint64{10: 4}},
+			},
+		}),
+		UpdateFileStat(1, &datapb.ImportFileStats{
+			FileSize:  20,
+			TotalRows: 2,
+			HashedStats: map[string]*datapb.PartitionImportStats{
+				"ch0": {PartitionRows: map[int64]int64{11: 2}, PartitionChecksum: map[int64]uint64{11: 8}},
+			},
+		}))
+
+	var summaries []*TaskSummary
+	manager.Subscribe(func(summary *TaskSummary) {
+		summaries = append(summaries, summary)
+	})
+	manager.Summarize(task.GetTaskID(), time.Second)
+	assert.Len(t, summaries, 1)
+	summary := summaries[0]
+	assert.Equal(t, int64(1), summary.JobID)
+	assert.Equal(t, int64(2), summary.TaskID)
+	assert.Equal(t, int64(3), summary.CollectionID)
+	assert.Equal(t, datapb.ImportTaskStateV2_Completed, summary.State)
+	assert.Equal(t, SchemaFingerprint(schema), summary.SchemaFingerprint)
+	assert.NotZero(t, summary.SchemaFingerprint)
+	assert.Equal(t, 2, summary.FileNum)
+	assert.Equal(t, int64(5), summary.TotalRows)
+	assert.Equal(t, int64(30), summary.TotalFileSize)
+	assert.Equal(t, map[int64]int64{10: 2, 11: 3}, summary.PartitionRows)
+	assert.Equal(t, map[int64]uint64{10: 5, 11: 10}, summary.PartitionChecksum)
+	assert.Empty(t, summary.ErrorSamplesPaths)
+	assert.Empty(t, summary.DuplicateFiles)
+	assert.Equal(t, time.Second, summary.Duration)
+
+	// no summary for import task and unknown task.
+	manager.Add(&ImportTask{ImportTaskV2: &datapb.ImportTaskV2{TaskID: 4}})
+	manager.Summarize(4, time.Second)
+	manager.Summarize(5, time.Second)
+	assert.Len(t, summaries, 1)
+}
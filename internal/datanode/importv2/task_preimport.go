@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/errors"
@@ -36,6 +38,7 @@ import (
 	"github.com/milvus-io/milvus/pkg/log"
 	"github.com/milvus-io/milvus/pkg/util/conc"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 type PreImportTask struct {
@@ -47,31 +50,56 @@ type PreImportTask struct {
 	schema       *schemapb.CollectionSchema
 	options      []*commonpb.KeyValuePair
 
-	manager TaskManager
-	cm      storage.ChunkManager
+	manager  TaskManager
+	cm       storage.ChunkManager
+	backends StorageBackends
+
+	// totalRows is the sum of rows of the files completed so far.
+	totalRows atomic.Int64
 }
 
 func NewPreImportTask(req *datapb.PreImportRequest,
 	manager TaskManager,
 	cm storage.ChunkManager,
+	backends StorageBackends,
 ) Task {
-	fileStats := lo.Map(req.GetImportFiles(), func(file *internalpb.ImportFile, _ int) *datapb.ImportFileStats {
+	// The same file listed more than once would be imported repeatedly.
+	files, duplicates := DedupImportFiles(req.GetImportFiles())
+	fileStats := lo.Map(files, func(file *internalpb.ImportFile, _ int) *datapb.ImportFileStats {
 		return &datapb.ImportFileStats{
 			ImportFile: file,
 		}
 	})
 	ctx, cancel := context.WithCancel(context.Background())
 	// During binlog import, even if the primary key's autoID is set to true,
-	// the primary key from the binlog should be used instead of being reassigned.
-	if importutilv2.IsBackup(req.GetOptions()) {
+	// the primary key from the binlog should be used instead of being reassigned,
+	// unless the reassignment is requested explicitly.
+	if importutilv2.IsBackup(req.GetOptions()) && !importutilv2.IsReassignAutoID(req.GetOptions()) {
 		UnsetAutoID(req.GetSchema())
 	}
+	// The task with invalid schema is failed on creation instead of executed.
+	state, reason := datapb.ImportTaskStateV2_Pending, ""
+	if err := CheckBinaryVectorDim(req.GetSchema()); err != nil {
+		log.Warn("invalid schema for preimport", zap.Int64("taskID", req.GetTaskID()), zap.Error(err))
+		state, reason = datapb.ImportTaskStateV2_Failed, err.Error()
+	}
+	if len(duplicates) > 0 {
+		if importutilv2.IsStrict(req.GetOptions()) {
+			if state != datapb.ImportTaskStateV2_Failed {
+				state, reason = datapb.ImportTaskStateV2_Failed,
+					fmt.Sprintf("duplicate import files are not allowed in strict mode, duplicates=%v", duplicates)
+			}
+		} else {
+			log.Warn("duplicate import files are ignored", zap.Int64("taskID", req.GetTaskID()), zap.Strings("duplicates", duplicates))
+		}
+	}
 	return &PreImportTask{
 		PreImportTask: &datapb.PreImportTask{
 			JobID:        req.GetJobID(),
 			TaskID:       req.GetTaskID(),
 			CollectionID: req.GetCollectionID(),
-			State:        datapb.ImportTaskStateV2_Pending,
+			State:        state,
+			Reason:       reason,
 			FileStats:    fileStats,
 		},
 		ctx:          ctx,
@@ -82,6 +110,7 @@ func NewPreImportTask(req *datapb.PreImportRequest,
 		options:      req.GetOptions(),
 		manager:      manager,
 		cm:           cm,
+		backends:     backends,
 	}
 }
 
@@ -120,6 +149,10 @@ func (p *PreImportTask) Clone() Task {
 
 func (p *PreImportTask) Execute() []*conc.Future[any] {
 	bufferSize := paramtable.Get().DataNodeCfg.ReadBufferSizeInMB.GetAsInt() * 1024 * 1024
+	// the batches are capped at the reader, so that an oversized batch is never decoded as a whole.
+	if memoryCap := paramtable.Get().DataNodeCfg.ReadBatchMemoryCapInMB.GetAsInt() * 1024 * 1024; memoryCap > 0 {
+		bufferSize = min(bufferSize, memoryCap)
+	}
 	log.Info("start to preimport", WrapLogFields(p,
 		zap.Int("bufferSize", bufferSize),
 		zap.Any("schema", p.GetSchema()))...)
@@ -130,15 +163,31 @@ func (p *PreImportTask) Execute() []*conc.Future[any] {
 		})
 
 	fn := func(i int, file *internalpb.ImportFile) error {
-		reader, err := importutilv2.NewReader(p.ctx, p.cm, p.GetSchema(), file, p.options, bufferSize)
+		cm, err := p.backends.Resolve(p.cm, file)
+		if err != nil {
+			log.Warn("resolve storage backend failed", WrapLogFields(p, zap.String("file", file.String()), zap.Error(err))...)
+			p.manager.Update(p.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Failed), UpdateReason(err.Error()))
+			return err
+		}
+		var reader importutilv2.Reader
+		err = RetryRead(p.ctx, func() (err error) {
+			reader, err = importutilv2.NewReader(p.ctx, cm, p.GetSchema(), file, p.options, bufferSize)
+			return err
+		})
 		if err != nil {
 			log.Warn("new reader failed", WrapLogFields(p, zap.String("file", file.String()), zap.Error(err))...)
 			p.manager.Update(p.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Failed), UpdateReason(err.Error()))
 			return err
 		}
 		defer reader.Close()
+		deleted, err := readTombstones(p.ctx, cm, p.GetSchema(), file, p.options, bufferSize)
+		if err != nil {
+			log.Warn("read delete file failed", WrapLogFields(p, zap.String("file", file.String()), zap.Error(err))...)
+			p.manager.Update(p.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Failed), UpdateReason(err.Error()))
+			return err
+		}
 		start := time.Now()
-		err = p.readFileStat(reader, p, i)
+		err = p.readFileStat(reader, p, i, deleted)
 		if err != nil {
 			log.Warn("preimport failed", WrapLogFields(p, zap.String("file", file.String()), zap.Error(err))...)
 			p.manager.Update(p.GetTaskID(), UpdateState(datapb.ImportTaskStateV2_Failed), UpdateReason(err.Error()))
@@ -149,20 +198,26 @@ func (p *PreImportTask) Execute() []*conc.Future[any] {
 		return nil
 	}
 
-	futures := make([]*conc.Future[any], 0, len(files))
-	for i, file := range files {
-		i := i
-		file := file
-		f := GetExecPool().Submit(func() (any, error) {
-			err := fn(i, file)
-			return err, err
-		})
-		futures = append(futures, f)
-	}
-	return futures
+	return SubmitFiles(files, fn)
+}
+
+// batchStat is the stats collected from a batch of file, which are merged into the stats of file in the order of batches.
+type batchStat struct {
+	// sampler samples the bad rows of batch, nil if the errors are not sampled.
+	sampler *errorSampler
+	// rejected is the cause of the bad rows failing the file, nil if the batch is not rejected.
+	rejected error
+
+	pks         []storage.FieldData
+	fieldStats  *fieldStatsCollector
+	hashedStats map[string]*datapb.PartitionImportStats
+	rows        int
+	size        int
+	invalidRows int
 }
 
-func (p *PreImportTask) readFileStat(reader importutilv2.Reader, task Task, fileIdx int) error {
+// readFileStat reads the file and collects the stats, deleted is the tombstones of file, nil if the file has no delete file.
+func (p *PreImportTask) readFileStat(reader importutilv2.Reader, task Task, fileIdx int, deleted *tombstones) error {
 	fileSize, err := reader.Size()
 	if err != nil {
 		return err
@@ -174,39 +229,313 @@ func (p *PreImportTask) readFileStat(reader importutilv2.Reader, task Task, file
 				"fileSize=%d, maxSize=%d", fileSize, int64(maxSize)))
 	}
 
+	file := p.GetFileStats()[fileIdx].GetImportFile()
+	err = CheckFilePartition(task, file)
+	if err != nil {
+		return err
+	}
+
+	limit, err := importutilv2.GetErrorSampleLimit(p.options)
+	if err != nil {
+		return err
+	}
+	// the validation goes on over bad rows, which are always sampled to be reported.
+	validateOnly := importutilv2.IsValidateOnly(p.options)
+	if validateOnly && limit == 0 {
+		limit = importutilv2.MaxErrorSampleLimit
+	}
+	var sampler *errorSampler
+	if limit > 0 {
+		sampler = newErrorSampler(limit, strings.Join(file.GetPaths(), ","))
+	}
+
+	transform, err := GetRowTransform(p.options)
+	if err != nil {
+		return err
+	}
+	jsonSchemas, err := importutilv2.GetJSONSchemas(p.options, task.GetSchema())
+	if err != nil {
+		return err
+	}
+
+	pkField, err := typeutil.GetPrimaryFieldSchema(task.GetSchema())
+	if err != nil {
+		return err
+	}
+	memoryCap := paramtable.Get().DataNodeCfg.ReadBatchMemoryCapInMB.GetAsInt() * 1024 * 1024
+	estimator := &clusteringEstimator{}
+	fieldStats := newFieldStatsCollector(task.GetSchema())
+
 	totalRows := 0
 	totalSize := 0
+	updateRows := 0
+	// readRows is the rows read before the current batch including the bad ones, which locates the rows in file.
+	readRows := 0
+	invalidRows := 0
 	hashedStats := make(map[string]*datapb.PartitionImportStats)
+
+	// processBatch validates and hashes the batch read at offset base of file. It's safe to run concurrently,
+	// since the stats are collected into the batch stat, which is merged into the stats of file by commit.
+	processBatch := func(data *storage.InsertData, base int) (*batchStat, error) {
+		stat := &batchStat{
+			fieldStats:  newFieldStatsCollector(task.GetSchema()),
+			hashedStats: make(map[string]*datapb.PartitionImportStats),
+		}
+		if sampler != nil {
+			stat.sampler = newErrorSampler(limit, sampler.path)
+		}
+		var process func(data *storage.InsertData, offset int) error
+		// rejectRow fails the file by the bad row, or skips it and validates the rest rows of batch if validating only.
+		rejectRow := func(data *storage.InsertData, offset, row int, sample map[int64]any, cause error) error {
+			if stat.sampler != nil {
+				stat.sampler.SampleRow(sample, int64(base+offset+row), cause)
+			}
+			if !validateOnly {
+				stat.rejected = cause
+				return cause
+			}
+			stat.invalidRows++
+			rowNum := GetInsertDataRowCount(data, task.GetSchema())
+			for _, r := range [][2]int{{0, row}, {row + 1, rowNum}} {
+				if r[0] >= r[1] {
+					continue
+				}
+				rest, err := sliceRows(task.GetSchema(), data, r[0], r[1])
+				if err != nil {
+					return err
+				}
+				if err = process(rest, offset+r[0]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		// rejectRows fails the file by the bad batch, or skips the whole batch if validating only.
+		rejectRows := func(data *storage.InsertData, offset int, cause error) error {
+			if stat.sampler != nil {
+				stat.sampler.SampleRows(data, int64(base+offset), cause)
+			}
+			if !validateOnly {
+				stat.rejected = cause
+				return cause
+			}
+			stat.invalidRows += GetInsertDataRowCount(data, task.GetSchema())
+			return nil
+		}
+		process = func(data *storage.InsertData, offset int) error {
+			transformed := data
+			if transform != nil {
+				var err error
+				transformed, err = TransformRows(task.GetSchema(), data, transform)
+				if err != nil {
+					var transformErr *rowTransformError
+					if errors.As(err, &transformErr) {
+						return rejectRow(data, offset, transformErr.offset, transformErr.row, err)
+					}
+					return err
+				}
+			}
+			err := CheckRowsEqual(task.GetSchema(), transformed)
+			if err == nil {
+				err = CheckBinaryVectorAlignment(task.GetSchema(), transformed, base+offset)
+			}
+			if err != nil {
+				return rejectRows(transformed, offset, err)
+			}
+			// the documents are validated batch by batch as read, so the memory is bounded by the read buffer.
+			if err = CheckJSONSchemas(task.GetSchema(), jsonSchemas, transformed, base+offset); err != nil {
+				var schemaErr *jsonSchemaError
+				if errors.As(err, &schemaErr) {
+					return rejectRow(data, offset, schemaErr.row, transformed.GetRow(schemaErr.row), err)
+				}
+				return err
+			}
+			data = transformed
+			if pks, ok := data.Data[pkField.GetFieldID()]; ok {
+				stat.pks = append(stat.pks, pks)
+			}
+			// the stats are collected per sub-chunk, so that the sub-chunk is released once processed.
+			// It goes before hashing, which drops the auto id primary key from data.
+			stat.fieldStats.Observe(data)
+			rowsCount, err := GetRowsStats(task, data, file.GetPartitionID(), offset)
+			if err != nil {
+				return err
+			}
+			MergeHashedStats(rowsCount, stat.hashedStats)
+			rows := data.GetRowNum()
+			size := data.GetMemorySize()
+			stat.rows += rows
+			stat.size += size
+			log.Info("reading file stat...", WrapLogFields(task, zap.Int("readRows", rows), zap.Int("readSize", size))...)
+			return nil
+		}
+		// the batch over the memory cap is processed in sub-chunks, the stats are collected incrementally.
+		return stat, forEachSubBatch(task.GetSchema(), data, memoryCap, process)
+	}
+	// commit merges the stats of batch into the stats of file in the order of batches, so the order sensitive stats,
+	// e.g. the clustering factor and the error samples, are the same no matter how many batches are processed at once.
+	commit := func(stat *batchStat, err error) error {
+		if sampler != nil {
+			sampler.Merge(stat.sampler)
+		}
+		if stat.rejected != nil {
+			if sampler != nil {
+				return p.saveErrorSamples(sampler, task, fileIdx, stat.rejected)
+			}
+			return stat.rejected
+		}
+		if err != nil {
+			return err
+		}
+		for _, pks := range stat.pks {
+			estimator.Observe(pks)
+			if deleted != nil {
+				updateRows += deleted.Match(pks)
+			}
+		}
+		fieldStats.Merge(stat.fieldStats)
+		MergeHashedStats(stat.hashedStats, hashedStats)
+		totalRows += stat.rows
+		totalSize += stat.size
+		invalidRows += stat.invalidRows
+		return nil
+	}
+
+	// the batches are processed by the decode workers while the next batch is being read.
+	concurrency := paramtable.Get().DataNodeCfg.DecodeConcurrency.GetAsInt()
+	var pool *conc.Pool[*batchStat]
+	if concurrency > 1 {
+		pool = conc.NewPool[*batchStat](concurrency)
+		defer pool.Release()
+	}
+	pending := make([]*conc.Future[*batchStat], 0, max(concurrency, 1))
+	commitPending := func(n int) error {
+		for ; n > 0; n-- {
+			future := pending[0]
+			pending = pending[1:]
+			if err := commit(future.Await()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	for {
-		data, err := reader.Read()
+		data, err := ReadWithRetry(p.ctx, reader)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
+			if err := commitPending(len(pending)); err != nil {
+				return err
+			}
+			if failures := importutilv2.GetDecodeFailures(reader); len(failures) > 0 {
+				log.Warn("failed to decode the encoded fields", WrapLogFields(task, zap.Any("decodeFailures", failures))...)
+			}
+			if sampler != nil {
+				sampler.SampleError(int64(readRows), err)
+				return p.saveErrorSamples(sampler, task, fileIdx, err)
+			}
 			return err
 		}
-		err = CheckRowsEqual(task.GetSchema(), data)
-		if err != nil {
-			return err
+		base := readRows
+		readRows += GetInsertDataRowCount(data, task.GetSchema())
+		if pool == nil {
+			if err = commit(processBatch(data, base)); err != nil {
+				return err
+			}
+			continue
 		}
-		rowsCount, err := GetRowsStats(task, data)
-		if err != nil {
-			return err
+		pending = append(pending, pool.Submit(func() (*batchStat, error) {
+			return processBatch(data, base)
+		}))
+		if len(pending) >= concurrency {
+			if err = commitPending(1); err != nil {
+				return err
+			}
 		}
-		MergeHashedStats(rowsCount, hashedStats)
-		rows := data.GetRowNum()
-		size := data.GetMemorySize()
-		totalRows += rows
-		totalSize += size
-		log.Info("reading file stat...", WrapLogFields(task, zap.Int("readRows", rows), zap.Int("readSize", size))...)
+	}
+	if err = commitPending(len(pending)); err != nil {
+		return err
+	}
+
+	err = CheckHashedStats(task, hashedStats)
+	if err != nil {
+		return err
 	}
 
+	unknownColumns := importutilv2.GetUnknownColumns(reader)
+	if len(unknownColumns) > 0 {
+		log.Warn("found columns not defined in schema", WrapLogFields(task, zap.Strings("unknownColumns", unknownColumns))...)
+	}
+	deleteRows, unmatchedDeletes := 0, 0
+	if deleted != nil {
+		deleteRows = deleted.Len()
+		unmatched := deleted.Unmatched()
+		unmatchedDeletes = len(unmatched)
+		if unmatchedDeletes > 0 {
+			// the deletes of primary keys not inserted by the file must reference the existing rows.
+			log.Warn("found deletes of primary keys not inserted by the import file", WrapLogFields(task,
+				zap.Int("unmatchedDeletes", unmatchedDeletes),
+				zap.Any("samples", unmatched[:min(unmatchedDeletes, 10)]))...)
+		}
+	}
 	stat := &datapb.ImportFileStats{
-		FileSize:        fileSize,
-		TotalRows:       int64(totalRows),
-		TotalMemorySize: int64(totalSize),
-		HashedStats:     hashedStats,
+		FileSize:         fileSize,
+		TotalRows:        int64(totalRows),
+		TotalMemorySize:  int64(totalSize),
+		HashedStats:      hashedStats,
+		IsEmpty:          totalRows == 0,
+		ClusteringFactor: estimator.Factor(),
+		FieldStats:       fieldStats.Stats(),
+		UnknownColumns:   int64(len(unknownColumns)),
+
+		InsertRows:          int64(totalRows - updateRows),
+		DeleteRows:          int64(deleteRows),
+		UpdateRows:          int64(updateRows),
+		UnmatchedDeleteRows: int64(unmatchedDeletes),
+		InvalidRows:         int64(invalidRows),
+		OutOfNormVectors:    importutilv2.GetOutOfNormVectors(reader),
 	}
 	p.manager.Update(task.GetTaskID(), UpdateFileStat(fileIdx, stat))
+	if invalidRows > 0 {
+		log.Warn("found invalid rows by validation", WrapLogFields(task, zap.Int("invalidRows", invalidRows))...)
+		samplesPath, err := sampler.Save(p.ctx, p.cm, task, fileIdx)
+		if err != nil {
+			return err
+		}
+		p.manager.Update(task.GetTaskID(), UpdateFileStatErrorSamples(fileIdx, samplesPath))
+	}
+	if stat.GetOutOfNormVectors() > 0 {
+		log.Warn("found float vectors out of unit norm", WrapLogFields(task,
+			zap.Int64("outOfNormVectors", stat.GetOutOfNormVectors()))...)
+	}
+	if stat.GetIsEmpty() && deleteRows == 0 && importutilv2.IsRejectEmptyFiles(p.options) {
+		return errors.New(fmt.Sprintf("The import file is empty, path=%s", strings.Join(file.GetPaths(), ",")))
+	}
+	return p.checkTotalRows(int64(totalRows))
+}
+
+// checkTotalRows accumulates the rows of the completed file, and fails the task as soon as
+// the rows of all files exceed the limit, rather than after reading all the files.
+func (p *PreImportTask) checkTotalRows(fileRows int64) error {
+	totalRows := p.totalRows.Add(fileRows)
+	maxRows := paramtable.Get().DataNodeCfg.MaxImportRows.GetAsInt64()
+	if maxRows > 0 && totalRows > maxRows {
+		return errors.New(fmt.Sprintf(
+			"The total rows of import task has reached the maximum limit allowed for importing, "+
+				"totalRows=%d, maxRows=%d", totalRows, maxRows))
+	}
 	return nil
 }
+
+// saveErrorSamples persists the sampled bad rows and references them from the file stats,
+// the returned error carries the path of samples so that users can find the offending records.
+func (p *PreImportTask) saveErrorSamples(sampler *errorSampler, task Task, fileIdx int, cause error) error {
+	samplesPath, err := sampler.Save(p.ctx, p.cm, task, fileIdx)
+	if err != nil {
+		log.Warn("failed to save import error samples", WrapLogFields(task, zap.Error(err))...)
+		return cause
+	}
+	p.manager.Update(task.GetTaskID(), UpdateFileStatErrorSamples(fileIdx, samplesPath))
+	return fmt.Errorf("%w, error samples are saved to %s", cause, samplesPath)
+}
@@ -0,0 +1,145 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// tombstones is the primary keys deleted by the companion delete file of an import file,
+// the inserted rows of the file with a tombstoned primary key are updates.
+type tombstones struct {
+	pks     map[any]struct{}
+	matched map[any]struct{}
+}
+
+func newTombstones() *tombstones {
+	return &tombstones{
+		pks:     make(map[any]struct{}),
+		matched: make(map[any]struct{}),
+	}
+}
+
+// Add records the primary keys of deleted rows, the duplicate ones are counted once.
+func (t *tombstones) Add(pks storage.FieldData) {
+	for i := 0; i < pks.RowNum(); i++ {
+		t.pks[pks.GetRow(i)] = struct{}{}
+	}
+}
+
+// Match returns the number of inserted rows whose primary keys are tombstoned.
+func (t *tombstones) Match(pks storage.FieldData) int {
+	matched := 0
+	for i := 0; i < pks.RowNum(); i++ {
+		pk := pks.GetRow(i)
+		if _, ok := t.pks[pk]; ok {
+			t.matched[pk] = struct{}{}
+			matched++
+		}
+	}
+	return matched
+}
+
+// Len returns the number of distinct tombstoned primary keys.
+func (t *tombstones) Len() int {
+	return len(t.pks)
+}
+
+// Unmatched returns the tombstoned primary keys which are not inserted again by the file,
+// they are the deletes of existing rows.
+func (t *tombstones) Unmatched() []any {
+	unmatched := make([]any, 0, len(t.pks)-len(t.matched))
+	for pk := range t.pks {
+		if _, ok := t.matched[pk]; !ok {
+			unmatched = append(unmatched, pk)
+		}
+	}
+	return unmatched
+}
+
+// tombstoneSchema returns the schema to read the delete file, which only has the primary key field.
+// The primary key is read from file even if it's auto id, since it references the existing rows.
+func tombstoneSchema(schema *schemapb.CollectionSchema) (*schemapb.CollectionSchema, error) {
+	pkField, err := typeutil.GetPrimaryFieldSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	pkField = proto.Clone(pkField).(*schemapb.FieldSchema)
+	pkField.AutoID = false
+	return &schemapb.CollectionSchema{
+		Name:   schema.GetName(),
+		Fields: []*schemapb.FieldSchema{pkField},
+	}, nil
+}
+
+// readTombstones reads the primary keys from the delete files of import file, which are in the same format
+// as the insert files and only have the primary key column. Returns nil if the file has no delete file.
+func readTombstones(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema,
+	file *internalpb.ImportFile, options importutilv2.Options, bufferSize int,
+) (*tombstones, error) {
+	if len(file.GetDeletePaths()) == 0 {
+		return nil, nil
+	}
+	if importutilv2.IsBackup(options) {
+		return nil, merr.WrapErrImportFailed("delete files are not supported by backup import")
+	}
+	deleteSchema, err := tombstoneSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	pkFieldID := deleteSchema.GetFields()[0].GetFieldID()
+	deleteFile := &internalpb.ImportFile{
+		Id:             file.GetId(),
+		Paths:          file.GetDeletePaths(),
+		StorageBackend: file.GetStorageBackend(),
+	}
+	var reader importutilv2.Reader
+	err = RetryRead(ctx, func() (err error) {
+		reader, err = importutilv2.NewReader(ctx, cm, deleteSchema, deleteFile, nil, bufferSize)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	t := newTombstones()
+	for {
+		data, err := ReadWithRetry(ctx, reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return t, nil
+			}
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("read delete file %v failed, err=%s", file.GetDeletePaths(), err))
+		}
+		if pks, ok := data.Data[pkFieldID]; ok {
+			t.Add(pks)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2"
+)
+
+func Test_Tombstones(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cm := storage.NewLocalChunkManager(storage.RootPath(dir))
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, AutoID: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "vec", DataType: schemapb.DataType_FloatVector},
+		},
+	}
+
+	// no delete file.
+	deleted, err := readTombstones(ctx, cm, schema, &internalpb.ImportFile{Paths: []string{"a.json"}}, nil, 1024)
+	assert.NoError(t, err)
+	assert.Nil(t, deleted)
+
+	deletePath := path.Join(dir, "a.delete.json")
+	assert.NoError(t, cm.Write(ctx, deletePath, []byte(`[{"pk": 1}, {"pk": 2}, {"pk": 2}, {"pk": 3}]`)))
+	file := &internalpb.ImportFile{Paths: []string{"a.json"}, DeletePaths: []string{deletePath}}
+	deleted, err = readTombstones(ctx, cm, schema, file, nil, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted.Len())
+
+	// pk 2 is inserted twice, both are updates.
+	assert.Equal(t, 1, deleted.Match(&storage.Int64FieldData{Data: []int64{2, 4}}))
+	assert.Equal(t, 1, deleted.Match(&storage.Int64FieldData{Data: []int64{5, 2}}))
+	assert.ElementsMatch(t, []any{int64(1), int64(3)}, deleted.Unmatched())
+
+	// the primary keys of wrong type fail the read.
+	assert.NoError(t, cm.Write(ctx, deletePath, []byte(`[{"pk": "a"}]`)))
+	_, err = readTombstones(ctx, cm, schema, file, nil, 1024)
+	assert.Error(t, err)
+
+	backup := importutilv2.Options{{Key: importutilv2.BackupFlag, Value: "true"}}
+	_, err = readTombstones(ctx, cm, schema, file, backup, 1024)
+	assert.ErrorContains(t, err, "not supported by backup import")
+}
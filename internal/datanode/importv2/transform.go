@@ -0,0 +1,95 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// RowTransform rewrites a decoded row in place, e.g. deriving a field from other fields.
+// It must be deterministic, since preimport and import apply it to the same file independently.
+type RowTransform func(row map[int64]any) error
+
+var rowTransforms = typeutil.NewConcurrentMap[string, RowTransform]()
+
+// RegisterRowTransform registers the transform by name, which can be referenced by the import option `row_transform`.
+func RegisterRowTransform(name string, transform RowTransform) {
+	rowTransforms.Insert(name, transform)
+}
+
+// GetRowTransform returns the transform referenced by import options, nil if no transform is required.
+func GetRowTransform(options importutilv2.Options) (RowTransform, error) {
+	name := importutilv2.GetRowTransformName(options)
+	if name == "" {
+		return nil, nil
+	}
+	transform, ok := rowTransforms.Get(name)
+	if !ok {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("row transform %s is not registered", name))
+	}
+	return transform, nil
+}
+
+// rowTransformError is the error of transforming a row, with the offset of row in batch.
+type rowTransformError struct {
+	offset int
+	row    map[int64]any
+	err    error
+}
+
+func (e *rowTransformError) Error() string {
+	return fmt.Sprintf("transform row %d failed, err=%s", e.offset, e.err)
+}
+
+func (e *rowTransformError) Unwrap() error {
+	return e.err
+}
+
+// TransformRows applies the transform to each row of data, and returns the transformed data.
+// The transformed rows are validated against schema by their types here, and by alignment in `CheckRowsEqual`.
+func TransformRows(schema *schemapb.CollectionSchema, data *storage.InsertData, transform RowTransform) (*storage.InsertData, error) {
+	rowNum := 0
+	for _, fd := range data.Data {
+		rowNum = max(rowNum, fd.RowNum())
+	}
+	transformed, err := storage.NewInsertDataWithCap(schema, rowNum)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < rowNum; i++ {
+		// skip the fields which are not long enough, such as the auto id primary key.
+		row := make(map[int64]any, len(data.Data))
+		for fieldID, fd := range data.Data {
+			if i < fd.RowNum() {
+				row[fieldID] = fd.GetRow(i)
+			}
+		}
+		if err = transform(row); err != nil {
+			return nil, &rowTransformError{offset: i, row: row, err: err}
+		}
+		if err = transformed.Append(row); err != nil {
+			return nil, &rowTransformError{offset: i, row: row, err: err}
+		}
+	}
+	return transformed, nil
+}
@@ -0,0 +1,92 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importv2
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2"
+)
+
+func Test_TransformRows(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, AutoID: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "a", DataType: schemapb.DataType_Int64},
+			{FieldID: 102, Name: "b", DataType: schemapb.DataType_Int64},
+			{FieldID: 103, Name: "sum", DataType: schemapb.DataType_Int64},
+		},
+	}
+	newData := func() *storage.InsertData {
+		return &storage.InsertData{
+			Data: map[int64]storage.FieldData{
+				100: &storage.Int64FieldData{Data: []int64{}},
+				101: &storage.Int64FieldData{Data: []int64{1, 2, 3}},
+				102: &storage.Int64FieldData{Data: []int64{10, 20, 30}},
+				103: &storage.Int64FieldData{Data: []int64{}},
+			},
+		}
+	}
+
+	RegisterRowTransform("sum", func(row map[int64]any) error {
+		if row[101].(int64) < 0 {
+			return errors.New("negative a")
+		}
+		row[103] = row[101].(int64) + row[102].(int64)
+		return nil
+	})
+	transform, err := GetRowTransform(importutilv2.Options{})
+	assert.NoError(t, err)
+	assert.Nil(t, transform)
+	_, err = GetRowTransform(importutilv2.Options{{Key: importutilv2.RowTransform, Value: "unknown"}})
+	assert.Error(t, err)
+	transform, err = GetRowTransform([]*commonpb.KeyValuePair{{Key: importutilv2.RowTransform, Value: "sum"}})
+	assert.NoError(t, err)
+
+	data, err := TransformRows(schema, newData(), transform)
+	assert.NoError(t, err)
+	assert.NoError(t, CheckRowsEqual(schema, data))
+	assert.Equal(t, []int64{11, 22, 33}, data.Data[103].(*storage.Int64FieldData).Data)
+	assert.Equal(t, 0, data.Data[100].RowNum())
+
+	// transform errors carry the bad row
+	bad := newData()
+	bad.Data[101].(*storage.Int64FieldData).Data[1] = -1
+	_, err = TransformRows(schema, bad, transform)
+	var transformErr *rowTransformError
+	assert.ErrorAs(t, err, &transformErr)
+	assert.Equal(t, 1, transformErr.offset)
+	assert.Equal(t, int64(-1), transformErr.row[101])
+
+	// output is validated against schema
+	_, err = TransformRows(schema, newData(), func(row map[int64]any) error {
+		row[103] = "not an int64"
+		return nil
+	})
+	assert.ErrorAs(t, err, &transformErr)
+
+	// missing derived field makes rows unaligned
+	data, err = TransformRows(schema, newData(), func(row map[int64]any) error { return nil })
+	assert.NoError(t, err)
+	assert.Error(t, CheckRowsEqual(schema, data))
+}
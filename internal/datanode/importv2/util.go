@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 
@@ -31,11 +33,16 @@ import (
 	"github.com/milvus-io/milvus/internal/datanode/metacache"
 	"github.com/milvus-io/milvus/internal/datanode/syncmgr"
 	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
 	"github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2"
+	importcommon "github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/log"
 	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/retry"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
@@ -151,6 +158,150 @@ func CheckRowsEqual(schema *schemapb.CollectionSchema, data *storage.InsertData)
 	return nil
 }
 
+// binaryVectorRowBytes returns the bytes of each binary vector of field, which is dim/8.
+func binaryVectorRowBytes(field *schemapb.FieldSchema) (int, error) {
+	dim, err := typeutil.GetDim(field)
+	if err != nil {
+		return 0, merr.WrapErrImportFailed(err.Error())
+	}
+	if dim <= 0 || dim%8 != 0 {
+		return 0, merr.WrapErrImportFailed(
+			fmt.Sprintf("dim of binary vector field '%s' should be a positive multiple of 8, got %d", field.GetName(), dim))
+	}
+	return int(dim / 8), nil
+}
+
+// CheckBinaryVectorDim checks if the dim of binary vector fields is a multiple of 8,
+// since each binary vector is stored in dim/8 bytes.
+func CheckBinaryVectorDim(schema *schemapb.CollectionSchema) error {
+	for _, field := range schema.GetFields() {
+		if field.GetDataType() != schemapb.DataType_BinaryVector {
+			continue
+		}
+		if _, err := binaryVectorRowBytes(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckBinaryVectorAlignment checks if every binary vector has exactly dim/8 bytes.
+// The vectors are flattened, so the mis-sized row is reported at the first incomplete one,
+// offset is the row offset of data in file.
+func CheckBinaryVectorAlignment(schema *schemapb.CollectionSchema, data *storage.InsertData, offset int) error {
+	for _, field := range schema.GetFields() {
+		fd, ok := data.Data[field.GetFieldID()].(*storage.BinaryVectorFieldData)
+		if !ok {
+			continue
+		}
+		rowBytes, err := binaryVectorRowBytes(field)
+		if err != nil {
+			return err
+		}
+		if fd.Dim != rowBytes*8 {
+			return merr.WrapErrImportFailed(
+				fmt.Sprintf("binary vector field '%s' at row %d is read with dim %d, expected %d",
+					field.GetName(), offset, fd.Dim, rowBytes*8))
+		}
+		if remain := len(fd.Data) % rowBytes; remain != 0 {
+			return merr.WrapErrImportFailed(
+				fmt.Sprintf("binary vector field '%s' at row %d has %d bytes, expected %d bytes",
+					field.GetName(), offset+len(fd.Data)/rowBytes, remain, rowBytes))
+		}
+	}
+	return nil
+}
+
+// jsonSchemaError is the error of a json document violating the user-supplied schema,
+// row is the row offset in batch.
+type jsonSchemaError struct {
+	row int
+	err error
+}
+
+func (e *jsonSchemaError) Error() string {
+	return e.err.Error()
+}
+
+func (e *jsonSchemaError) Unwrap() error {
+	return e.err
+}
+
+// CheckJSONSchemas validates the documents of json fields against the schemas keyed by field id,
+// and reports the first invalid document. Offset is the row offset of data in file.
+func CheckJSONSchemas(schema *schemapb.CollectionSchema, jsonSchemas map[int64]*importcommon.JSONSchema,
+	data *storage.InsertData, offset int,
+) error {
+	for _, field := range schema.GetFields() {
+		jsonSchema, ok := jsonSchemas[field.GetFieldID()]
+		if !ok {
+			continue
+		}
+		fd, ok := data.Data[field.GetFieldID()].(*storage.JSONFieldData)
+		if !ok {
+			continue
+		}
+		for i, doc := range fd.Data {
+			if err := jsonSchema.Validate(doc); err != nil {
+				return &jsonSchemaError{
+					row: i,
+					err: merr.WrapErrImportFailed(fmt.Sprintf("json field '%s' at row %d violates the schema, reason=%s",
+						field.GetName(), offset+i, err)),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CheckFilePartition checks if the target partition of import file is one of the partitions of task.
+func CheckFilePartition(task Task, file *internalpb.ImportFile) error {
+	if file.GetPartitionID() != 0 && !lo.Contains(task.GetPartitionIDs(), file.GetPartitionID()) {
+		return merr.WrapErrImportFailed(
+			fmt.Sprintf("the target partition %d of file %v is not in the import request, partitions=%v",
+				file.GetPartitionID(), file.GetPaths(), task.GetPartitionIDs()))
+	}
+	return nil
+}
+
+// DedupImportFiles collapses the files with the same paths and storage backend, the first one is kept.
+// It returns the deduplicated files and the paths of the collapsed duplicates.
+func DedupImportFiles(files []*internalpb.ImportFile) ([]*internalpb.ImportFile, []string) {
+	seen := typeutil.NewSet[string]()
+	dedup := make([]*internalpb.ImportFile, 0, len(files))
+	duplicates := make([]string, 0)
+	for _, file := range files {
+		key := file.GetStorageBackend() + "://" + strings.Join(file.GetPaths(), ",")
+		if seen.Contain(key) {
+			duplicates = append(duplicates, strings.Join(file.GetPaths(), ","))
+			continue
+		}
+		seen.Insert(key)
+		dedup = append(dedup, file)
+	}
+	return dedup, duplicates
+}
+
+// CheckHashedStats checks if all the vchannels and partitions referenced by the hashed data
+// are known by the import request.
+func CheckHashedStats(task Task, hashedStats map[string]*datapb.PartitionImportStats) error {
+	vchannels := typeutil.NewSet(task.GetVchannels()...)
+	partitions := typeutil.NewSet(task.GetPartitionIDs()...)
+	for channel, stats := range hashedStats {
+		if !vchannels.Contain(channel) {
+			return merr.WrapErrImportFailed(
+				fmt.Sprintf("data of vchannel '%s' is not in the import request, vchannels=%v", channel, task.GetVchannels()))
+		}
+		for partitionID := range stats.GetPartitionRows() {
+			if !partitions.Contain(partitionID) {
+				return merr.WrapErrImportFailed(
+					fmt.Sprintf("data of partition %d is not in the import request, partitions=%v", partitionID, task.GetPartitionIDs()))
+			}
+		}
+	}
+	return nil
+}
+
 func AppendSystemFieldsData(task *ImportTask, data *storage.InsertData) error {
 	idRange := task.req.GetAutoIDRange()
 	pkField, err := typeutil.GetPrimaryFieldSchema(task.GetSchema())
@@ -247,3 +398,30 @@ func NewMetaCache(req *datapb.ImportRequest) map[string]metacache.MetaCache {
 	}
 	return metaCaches
 }
+
+// IsTransientReadError tells if the error of reading import file is caused by flaky storage and worth retrying,
+// the errors of format or schema are permanent.
+func IsTransientReadError(err error) bool {
+	return errors.IsAny(err, merr.ErrIoFailed, merr.ErrIoUnexpectEOF)
+}
+
+// RetryRead runs fn with bounded retry and exponential backoff on transient read errors,
+// see dataNode.import.readRetryAttempts and dataNode.import.readRetryBaseDelay.
+func RetryRead(ctx context.Context, fn func() error) error {
+	attempts := paramtable.Get().DataNodeCfg.ReadRetryAttempts.GetAsInt()
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := paramtable.Get().DataNodeCfg.ReadRetryBaseDelay.GetAsDuration(time.Millisecond)
+	return retry.Do(ctx, fn, retry.Attempts(uint(attempts)), retry.Sleep(delay), retry.RetryErr(IsTransientReadError))
+}
+
+// ReadWithRetry reads the next batch of rows from reader, retrying on transient read errors.
+func ReadWithRetry(ctx context.Context, reader importutilv2.Reader) (*storage.InsertData, error) {
+	var data *storage.InsertData
+	err := RetryRead(ctx, func() (err error) {
+		data, err = reader.Read()
+		return err
+	})
+	return data, err
+}
@@ -17,15 +17,24 @@
 package importv2
 
 import (
+	"context"
+	"io"
 	"testing"
 
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/proto/datapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2"
 	"github.com/milvus-io/milvus/internal/util/testutil"
 	"github.com/milvus-io/milvus/pkg/common"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
 )
 
 func Test_AppendSystemFieldsData(t *testing.T) {
@@ -170,3 +179,210 @@ func Test_PickSegment(t *testing.T) {
 	fn(importedSize[int64(102)])
 	fn(importedSize[int64(103)])
 }
+
+func Test_CheckHashedStats(t *testing.T) {
+	task := &PreImportTask{
+		partitionIDs: []int64{1, 2},
+		vchannels:    []string{"ch-0"},
+	}
+	hashedStats := map[string]*datapb.PartitionImportStats{
+		"ch-0": {PartitionRows: map[int64]int64{1: 10}},
+	}
+	assert.NoError(t, CheckHashedStats(task, hashedStats))
+
+	hashedStats["ch-0"].PartitionRows[3] = 10
+	assert.Error(t, CheckHashedStats(task, hashedStats))
+
+	hashedStats = map[string]*datapb.PartitionImportStats{
+		"ch-1": {PartitionRows: map[int64]int64{1: 10}},
+	}
+	assert.Error(t, CheckHashedStats(task, hashedStats))
+}
+
+func Test_ReadWithRetry(t *testing.T) {
+	paramtable.Init()
+	paramtable.Get().Save(paramtable.Get().DataNodeCfg.ReadRetryBaseDelay.Key, "1")
+	defer paramtable.Get().Reset(paramtable.Get().DataNodeCfg.ReadRetryBaseDelay.Key)
+
+	assert.True(t, IsTransientReadError(merr.WrapErrIoFailed("a.json", errors.New("connection reset"))))
+	assert.False(t, IsTransientReadError(merr.WrapErrImportFailed("invalid json")))
+	assert.False(t, IsTransientReadError(io.EOF))
+
+	// transient error is retried.
+	data := &storage.InsertData{}
+	reader := importutilv2.NewMockReader(t)
+	reader.EXPECT().Read().Return(nil, merr.WrapErrIoFailed("a.json", errors.New("connection reset"))).Once()
+	reader.EXPECT().Read().Return(data, nil).Once()
+	res, err := ReadWithRetry(context.Background(), reader)
+	assert.NoError(t, err)
+	assert.Equal(t, data, res)
+
+	// permanent error is not retried.
+	reader = importutilv2.NewMockReader(t)
+	reader.EXPECT().Read().Return(nil, merr.WrapErrImportFailed("invalid json")).Once()
+	_, err = ReadWithRetry(context.Background(), reader)
+	assert.ErrorIs(t, err, merr.ErrImportFailed)
+
+	// give up after max attempts.
+	reader = importutilv2.NewMockReader(t)
+	reader.EXPECT().Read().Return(nil, merr.WrapErrIoFailed("a.json", errors.New("connection reset"))).Times(3)
+	_, err = ReadWithRetry(context.Background(), reader)
+	assert.ErrorIs(t, err, merr.ErrIoFailed)
+}
+
+func Test_CheckBinaryVector(t *testing.T) {
+	newSchema := func(dim string) *schemapb.CollectionSchema {
+		return &schemapb.CollectionSchema{
+			Fields: []*schemapb.FieldSchema{
+				{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+				{
+					FieldID:    101,
+					Name:       "vec",
+					DataType:   schemapb.DataType_BinaryVector,
+					TypeParams: []*commonpb.KeyValuePair{{Key: common.DimKey, Value: dim}},
+				},
+			},
+		}
+	}
+	assert.NoError(t, CheckBinaryVectorDim(newSchema("16")))
+	assert.ErrorIs(t, CheckBinaryVectorDim(newSchema("12")), merr.ErrImportFailed)
+	assert.ErrorIs(t, CheckBinaryVectorDim(newSchema("0")), merr.ErrImportFailed)
+
+	task := NewPreImportTask(&datapb.PreImportRequest{TaskID: 1, Schema: newSchema("12")}, NewTaskManager(), nil, nil)
+	assert.Equal(t, datapb.ImportTaskStateV2_Failed, task.GetState())
+	assert.Contains(t, task.GetReason(), "multiple of 8")
+
+	schema := newSchema("16")
+	data := &storage.InsertData{
+		Data: map[int64]storage.FieldData{
+			100: &storage.Int64FieldData{Data: []int64{1, 2}},
+			101: &storage.BinaryVectorFieldData{Data: []byte{1, 2, 3, 4}, Dim: 16},
+		},
+	}
+	assert.NoError(t, CheckBinaryVectorAlignment(schema, data, 0))
+
+	data.Data[101] = &storage.BinaryVectorFieldData{Data: []byte{1, 2, 3}, Dim: 16}
+	err := CheckBinaryVectorAlignment(schema, data, 10)
+	assert.ErrorIs(t, err, merr.ErrImportFailed)
+	assert.ErrorContains(t, err, "at row 11 has 1 bytes, expected 2 bytes")
+
+	data.Data[101] = &storage.BinaryVectorFieldData{Data: []byte{1, 2, 3, 4}, Dim: 32}
+	assert.ErrorIs(t, CheckBinaryVectorAlignment(schema, data, 0), merr.ErrImportFailed)
+}
+
+func Test_CheckJSONSchemas(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "meta", DataType: schemapb.DataType_JSON},
+		},
+	}
+	jsonSchemas, err := importutilv2.GetJSONSchemas(importutilv2.Options{{
+		Key:   importutilv2.JSONSchema,
+		Value: `{"meta": {"type": "object", "properties": {"age": {"type": "integer", "minimum": 0}}}}`,
+	}}, schema)
+	assert.NoError(t, err)
+	data := &storage.InsertData{
+		Data: map[int64]storage.FieldData{
+			100: &storage.Int64FieldData{Data: []int64{1, 2, 3}},
+			101: &storage.JSONFieldData{Data: [][]byte{[]byte(`{"age": 1}`), []byte(`{}`), []byte(`{"age": 2}`)}},
+		},
+	}
+	assert.NoError(t, CheckJSONSchemas(schema, jsonSchemas, data, 0))
+	assert.NoError(t, CheckJSONSchemas(schema, nil, data, 0))
+
+	data.Data[101] = &storage.JSONFieldData{Data: [][]byte{[]byte(`{"age": 1}`), []byte(`{"age": -1}`), []byte(`[]`)}}
+	err = CheckJSONSchemas(schema, jsonSchemas, data, 10)
+	assert.ErrorIs(t, err, merr.ErrImportFailed)
+	assert.ErrorContains(t, err, "json field 'meta' at row 11 violates the schema, reason=$.age is less than minimum 0")
+	var schemaErr *jsonSchemaError
+	assert.True(t, errors.As(err, &schemaErr))
+	assert.Equal(t, 1, schemaErr.row)
+}
+
+func Test_DedupImportFiles(t *testing.T) {
+	files := []*internalpb.ImportFile{
+		{Id: 1, Paths: []string{"a.json"}},
+		{Id: 2, Paths: []string{"b.json"}},
+		{Id: 3, Paths: []string{"a.json"}},
+		{Id: 4, Paths: []string{"a.json"}, StorageBackend: "remote"},
+	}
+	dedup, duplicates := DedupImportFiles(files)
+	assert.Equal(t, []int64{1, 2, 4}, lo.Map(dedup, func(file *internalpb.ImportFile, _ int) int64 {
+		return file.GetId()
+	}))
+	assert.Equal(t, []string{"a.json"}, duplicates)
+
+	// duplicates are collapsed by default, and fail the task in strict mode.
+	req := &datapb.PreImportRequest{TaskID: 1, ImportFiles: files}
+	task := NewPreImportTask(req, nil, nil, nil)
+	assert.Equal(t, datapb.ImportTaskStateV2_Pending, task.GetState())
+	assert.Len(t, task.(*PreImportTask).GetFileStats(), 3)
+	req.Options = []*commonpb.KeyValuePair{{Key: importutilv2.Strict, Value: "true"}}
+	task = NewPreImportTask(req, nil, nil, nil)
+	assert.Equal(t, datapb.ImportTaskStateV2_Failed, task.GetState())
+	assert.Contains(t, task.GetReason(), "duplicate import files")
+
+	// different files with identical rows are reported by summary.
+	stats := func(checksum uint64) *datapb.ImportFileStats {
+		return &datapb.ImportFileStats{
+			TotalRows: 2,
+			HashedStats: map[string]*datapb.PartitionImportStats{
+				"ch0": {PartitionRows: map[int64]int64{10: 2}, PartitionChecksum: map[int64]uint64{10: checksum}},
+			},
+		}
+	}
+	task = NewPreImportTask(&datapb.PreImportRequest{TaskID: 2, ImportFiles: []*internalpb.ImportFile{
+		{Paths: []string{"a.json"}}, {Paths: []string{"b.json"}}, {Paths: []string{"c.json"}}, {Paths: []string{"d.json"}},
+	}}, nil, nil, nil)
+	// d.json is empty and never reported.
+	for i, checksum := range []uint64{1, 2, 1} {
+		UpdateFileStat(i, stats(checksum))(task)
+	}
+	summary := SummarizeTask(task, 0)
+	assert.Equal(t, [][]string{{"a.json", "c.json"}}, summary.DuplicateFiles)
+}
+
+func Test_ReassignAutoID(t *testing.T) {
+	newSchema := func() *schemapb.CollectionSchema {
+		return &schemapb.CollectionSchema{
+			Fields: []*schemapb.FieldSchema{
+				{FieldID: 100, Name: "pk", IsPrimaryKey: true, AutoID: true, DataType: schemapb.DataType_Int64},
+			},
+		}
+	}
+	backup := []*commonpb.KeyValuePair{{Key: importutilv2.BackupFlag, Value: "true"}}
+	files := []*internalpb.ImportFile{{Paths: []string{"a"}}}
+
+	// the original primary keys are kept for backup import by default.
+	task := NewPreImportTask(&datapb.PreImportRequest{Schema: newSchema(), ImportFiles: files, Options: backup}, nil, nil, nil)
+	UpdateFileStat(0, &datapb.ImportFileStats{TotalRows: 10})(task)
+	assert.False(t, task.GetSchema().GetFields()[0].GetAutoID())
+	assert.Equal(t, int64(0), SummarizeTask(task, 0).ReservedAutoIDs)
+
+	options := append(backup, &commonpb.KeyValuePair{Key: importutilv2.ReassignAutoID, Value: "true"})
+	task = NewPreImportTask(&datapb.PreImportRequest{Schema: newSchema(), ImportFiles: files, Options: options}, nil, nil, nil)
+	UpdateFileStat(0, &datapb.ImportFileStats{TotalRows: 10})(task)
+	assert.True(t, task.GetSchema().GetFields()[0].GetAutoID())
+	assert.Equal(t, int64(10), SummarizeTask(task, 0).ReservedAutoIDs)
+}
+
+func Test_PreviewSegments(t *testing.T) {
+	fileStats := []*datapb.ImportFileStats{
+		{HashedStats: map[string]*datapb.PartitionImportStats{
+			"ch0": {PartitionRows: map[int64]int64{10: 5, 11: 1}, PartitionDataSize: map[int64]int64{10: 150, 11: 10}},
+			"ch1": {PartitionRows: map[int64]int64{10: 0}, PartitionDataSize: map[int64]int64{10: 0}},
+		}},
+		{HashedStats: map[string]*datapb.PartitionImportStats{
+			"ch0": {PartitionRows: map[int64]int64{10: 2}, PartitionDataSize: map[int64]int64{10: 60}},
+		}},
+	}
+	// 210 bytes of partition 10 in ch0 take 3 segments, and the empty bucket takes none.
+	assert.Equal(t, []SegmentPreview{
+		{Vchannel: "ch0", PartitionID: 10, Rows: 3, Size: 70},
+		{Vchannel: "ch0", PartitionID: 10, Rows: 2, Size: 70},
+		{Vchannel: "ch0", PartitionID: 10, Rows: 2, Size: 70},
+		{Vchannel: "ch0", PartitionID: 11, Rows: 1, Size: 10},
+	}, previewSegments(fileStats, 100))
+	assert.Empty(t, previewSegments(nil, 100))
+}
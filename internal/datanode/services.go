@@ -440,7 +440,7 @@ func (node *DataNode) PreImport(ctx context.Context, req *datapb.PreImportReques
 	if importutilv2.IsL0Import(req.GetOptions()) {
 		task = importv2.NewL0PreImportTask(req, node.importTaskMgr, node.chunkManager)
 	} else {
-		task = importv2.NewPreImportTask(req, node.importTaskMgr, node.chunkManager)
+		task = importv2.NewPreImportTask(req, node.importTaskMgr, node.chunkManager, node.importStorageBackends)
 	}
 	node.importTaskMgr.Add(task)
 
@@ -464,7 +464,7 @@ func (node *DataNode) ImportV2(ctx context.Context, req *datapb.ImportRequest) (
 	if importutilv2.IsL0Import(req.GetOptions()) {
 		task = importv2.NewL0ImportTask(req, node.importTaskMgr, node.syncMgr, node.chunkManager)
 	} else {
-		task = importv2.NewImportTask(req, node.importTaskMgr, node.syncMgr, node.chunkManager)
+		task = importv2.NewImportTask(req, node.importTaskMgr, node.syncMgr, node.chunkManager, node.importStorageBackends)
 	}
 	node.importTaskMgr.Add(task)
 
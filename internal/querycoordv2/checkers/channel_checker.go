@@ -213,7 +213,7 @@ func (c *ChannelChecker) createChannelLoadTask(ctx context.Context, channels []*
 	for _, ch := range channels {
 		rwNodes := replica.GetChannelRWNodes(ch.GetChannelName())
 		if len(rwNodes) == 0 {
-			rwNodes = replica.GetRWNodes()
+			rwNodes = replica.NodesByRole(meta.NodeRoleStreaming)
 		}
 		plan := c.getBalancerFunc().AssignChannel([]*meta.DmChannel{ch}, rwNodes, false)
 		plans = append(plans, plan...)
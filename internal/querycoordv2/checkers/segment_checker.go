@@ -389,6 +389,9 @@ func (c *SegmentChecker) createSegmentLoadTasks(ctx context.Context, segments []
 		}
 
 		rwNodes := replica.GetChannelRWNodes(shard)
+		if len(rwNodes) == 0 {
+			rwNodes = replica.NodesByRole(meta.NodeRoleRead)
+		}
 		if len(rwNodes) == 0 {
 			rwNodes = replica.GetRWNodes()
 		}
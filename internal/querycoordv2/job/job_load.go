@@ -150,16 +150,22 @@ func (job *LoadCollectionJob) Execute() error {
 	}
 
 	// 2. create replica if not exist
+	replicaConfig := utils.GetReplicaConfig(job.meta, req.GetCollectionID())
 	replicas := job.meta.ReplicaManager.GetByCollection(req.GetCollectionID())
 	if len(replicas) == 0 {
 		collectionInfo, err := job.broker.DescribeCollection(job.ctx, req.GetCollectionID())
 		if err != nil {
 			return err
 		}
+		replicaConfig, err = utils.ReplicaConfigFromProperties(collectionInfo.GetProperties())
+		if err != nil {
+			log.Warn("invalid replica config of collection", zap.Error(err))
+			return err
+		}
 
 		// API of LoadCollection is wired, we should use map[resourceGroupNames]replicaNumber as input, to keep consistency with `TransferReplica` API.
 		// Then we can implement dynamic replica changed in different resource group independently.
-		_, err = utils.SpawnReplicasWithRG(job.meta, req.GetCollectionID(), req.GetResourceGroups(), req.GetReplicaNumber(), collectionInfo.GetVirtualChannelNames())
+		_, err = utils.SpawnReplicasWithRG(job.meta, req.GetCollectionID(), req.GetResourceGroups(), req.GetReplicaNumber(), collectionInfo.GetVirtualChannelNames(), replicaConfig)
 		if err != nil {
 			msg := "failed to spawn replica for collection"
 			log.Warn(msg, zap.Error(err))
@@ -191,11 +197,13 @@ func (job *LoadCollectionJob) Execute() error {
 	ctx, sp := otel.Tracer(typeutil.QueryCoordRole).Start(job.ctx, "LoadCollection", trace.WithNewRoot())
 	collection := &meta.Collection{
 		CollectionLoadInfo: &querypb.CollectionLoadInfo{
-			CollectionID:  req.GetCollectionID(),
-			ReplicaNumber: req.GetReplicaNumber(),
-			Status:        querypb.LoadStatus_Loading,
-			FieldIndexID:  req.GetFieldIndexID(),
-			LoadType:      querypb.LoadType_LoadCollection,
+			CollectionID:          req.GetCollectionID(),
+			ReplicaNumber:         req.GetReplicaNumber(),
+			Status:                querypb.LoadStatus_Loading,
+			FieldIndexID:          req.GetFieldIndexID(),
+			LoadType:              querypb.LoadType_LoadCollection,
+			ResourceGroupAffinity: replicaConfig.ResourceGroupAffinity,
+			StandbyReplicas:       int32(replicaConfig.StandbyReplicas),
 		},
 		CreatedAt: time.Now(),
 		LoadSpan:  sp,
@@ -336,13 +344,19 @@ func (job *LoadPartitionJob) Execute() error {
 	}
 
 	// 2. create replica if not exist
+	replicaConfig := utils.GetReplicaConfig(job.meta, req.GetCollectionID())
 	replicas := job.meta.ReplicaManager.GetByCollection(req.GetCollectionID())
 	if len(replicas) == 0 {
 		collectionInfo, err := job.broker.DescribeCollection(job.ctx, req.GetCollectionID())
 		if err != nil {
 			return err
 		}
-		_, err = utils.SpawnReplicasWithRG(job.meta, req.GetCollectionID(), req.GetResourceGroups(), req.GetReplicaNumber(), collectionInfo.GetVirtualChannelNames())
+		replicaConfig, err = utils.ReplicaConfigFromProperties(collectionInfo.GetProperties())
+		if err != nil {
+			log.Warn("invalid replica config of collection", zap.Error(err))
+			return err
+		}
+		_, err = utils.SpawnReplicasWithRG(job.meta, req.GetCollectionID(), req.GetResourceGroups(), req.GetReplicaNumber(), collectionInfo.GetVirtualChannelNames(), replicaConfig)
 		if err != nil {
 			msg := "failed to spawn replica for collection"
 			log.Warn(msg, zap.Error(err))
@@ -376,11 +390,13 @@ func (job *LoadPartitionJob) Execute() error {
 
 		collection := &meta.Collection{
 			CollectionLoadInfo: &querypb.CollectionLoadInfo{
-				CollectionID:  req.GetCollectionID(),
-				ReplicaNumber: req.GetReplicaNumber(),
-				Status:        querypb.LoadStatus_Loading,
-				FieldIndexID:  req.GetFieldIndexID(),
-				LoadType:      querypb.LoadType_LoadPartition,
+				CollectionID:          req.GetCollectionID(),
+				ReplicaNumber:         req.GetReplicaNumber(),
+				Status:                querypb.LoadStatus_Loading,
+				FieldIndexID:          req.GetFieldIndexID(),
+				LoadType:              querypb.LoadType_LoadPartition,
+				ResourceGroupAffinity: replicaConfig.ResourceGroupAffinity,
+				StandbyReplicas:       int32(replicaConfig.StandbyReplicas),
 			},
 			CreatedAt: time.Now(),
 			LoadSpan:  sp,
@@ -26,6 +26,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/milvuspb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
 	"github.com/milvus-io/milvus/internal/kv"
 	etcdkv "github.com/milvus-io/milvus/internal/kv/etcd"
@@ -39,6 +41,7 @@ import (
 	"github.com/milvus-io/milvus/internal/querycoordv2/observers"
 	. "github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/util/etcd"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
@@ -418,6 +421,56 @@ func (suite *JobSuite) TestLoadCollectionWithReplicas() {
 	}
 }
 
+func (suite *JobSuite) TestLoadCollectionWithResourceGroupAffinity() {
+	ctx := context.Background()
+	suite.broker.ExpectedCalls = lo.Filter(suite.broker.ExpectedCalls, func(call *mock.Call, _ int) bool {
+		return call.Method != "DescribeCollection"
+	})
+	affinity := ""
+	suite.broker.EXPECT().DescribeCollection(mock.Anything, mock.Anything).RunAndReturn(
+		func(ctx context.Context, collection int64) (*milvuspb.DescribeCollectionResponse, error) {
+			return &milvuspb.DescribeCollectionResponse{
+				Properties: []*commonpb.KeyValuePair{{Key: common.CollectionResourceGroupAffinity, Value: affinity}},
+			}, nil
+		})
+
+	for _, collection := range suite.collections {
+		if suite.loadTypes[collection] != querypb.LoadType_LoadCollection {
+			continue
+		}
+		load := func() error {
+			job := NewLoadCollectionJob(
+				ctx,
+				&querypb.LoadCollectionRequest{CollectionID: collection},
+				suite.dist,
+				suite.meta,
+				suite.broker,
+				suite.cluster,
+				suite.targetMgr,
+				suite.targetObserver,
+				suite.collectionObserver,
+				suite.nodeMgr,
+			)
+			suite.scheduler.Add(job)
+			return job.Wait()
+		}
+		// the affinity in properties of collection is invalid.
+		affinity = ""
+		suite.ErrorIs(load(), merr.ErrParameterInvalid)
+		// the affined resource group doesn't exist.
+		affinity = "rg_not_exist"
+		suite.Error(load())
+		suite.Nil(suite.meta.GetCollection(collection))
+
+		affinity = meta.DefaultResourceGroupName
+		suite.NoError(load())
+		suite.Equal([]string{meta.DefaultResourceGroupName}, suite.meta.CollectionManager.GetResourceGroupAffinity(collection))
+		for _, replica := range suite.meta.ReplicaManager.GetByCollection(collection) {
+			suite.Equal(meta.DefaultResourceGroupName, replica.GetResourceGroup())
+		}
+	}
+}
+
 func (suite *JobSuite) TestLoadCollectionWithDiffIndex() {
 	ctx := context.Background()
 
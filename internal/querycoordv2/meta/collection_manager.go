@@ -345,6 +345,49 @@ func (m *CollectionManager) CalculateLoadStatus(collectionID typeutil.UniqueID)
 	return querypb.LoadStatus_Invalid
 }
 
+// GetResourceGroupAffinity returns the resource groups that the collection is bound to.
+// Returns nil if the collection is not loaded or has no affinity.
+func (m *CollectionManager) GetResourceGroupAffinity(collectionID typeutil.UniqueID) []string {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+
+	collection, ok := m.collections[collectionID]
+	if ok {
+		return collection.GetResourceGroupAffinity()
+	}
+	return nil
+}
+
+// GetStandbyReplicas returns the number of hot standby replicas of collection.
+// Returns 0 if the collection is not loaded or has no standby.
+func (m *CollectionManager) GetStandbyReplicas(collectionID typeutil.UniqueID) int32 {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+
+	collection, ok := m.collections[collectionID]
+	if ok {
+		return collection.GetStandbyReplicas()
+	}
+	return 0
+}
+
+// SetResourceGroupAffinity binds the loaded collection to the given resource groups durably,
+// replicas of the collection will never be placed outside them.
+// Empty resource groups clears the affinity. The resource groups are not validated here,
+// use utils.SetResourceGroupAffinity to check them against the replicas of collection.
+func (m *CollectionManager) SetResourceGroupAffinity(collectionID typeutil.UniqueID, rgs []string) error {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+
+	collection, ok := m.collections[collectionID]
+	if !ok {
+		return merr.WrapErrCollectionNotLoaded(collectionID)
+	}
+	newCollection := collection.Clone()
+	newCollection.ResourceGroupAffinity = rgs
+	return m.putCollection(true, newCollection)
+}
+
 func (m *CollectionManager) GetFieldIndex(collectionID typeutil.UniqueID) map[int64]int64 {
 	m.rwmutex.RLock()
 	defer m.rwmutex.RUnlock()
@@ -25,6 +25,12 @@ type Meta struct {
 	*CollectionManager
 	*ReplicaManager
 	*ResourceManager
+
+	// NodeHealthChecker excludes the unhealthy nodes from replica assignment during recovery.
+	NodeHealthChecker session.NodeHealthChecker
+
+	// Catalog is the store the meta is persisted to, which is read by the audit of meta.
+	Catalog metastore.QueryCoordCatalog
 }
 
 func NewMeta(
@@ -32,9 +38,32 @@ func NewMeta(
 	catalog metastore.QueryCoordCatalog,
 	nodeMgr *session.NodeManager,
 ) *Meta {
-	return &Meta{
-		NewCollectionManager(catalog),
-		NewReplicaManager(idAllocator, catalog),
-		NewResourceManager(catalog, nodeMgr),
+	replicaManager := NewReplicaManager(idAllocator, catalog)
+	if nodeMgr != nil {
+		replicaManager.SetStreamingCapability(func(nodeID int64) bool {
+			// the node which is gone keeps its role until it's removed from replica.
+			node := nodeMgr.Get(nodeID)
+			return node == nil || node.IsStreamingCapable()
+		})
+		replicaManager.SetNodeSelectorMatcher(func(nodeID int64, selector map[string]string) bool {
+			node := nodeMgr.Get(nodeID)
+			return node != nil && node.MatchLabels(selector)
+		})
+	}
+	m := &Meta{
+		CollectionManager: NewCollectionManager(catalog),
+		ReplicaManager:    replicaManager,
+		ResourceManager:   NewResourceManager(catalog, nodeMgr),
+		NodeHealthChecker: session.NewRegisteredNodeHealthChecker(),
+		Catalog:           catalog,
+	}
+	if nodeMgr != nil {
+		// the node moved into replica is serviceable once it's up, not stopping and healthy.
+		replicaManager.SetNodeServiceability(func(nodeID int64) bool {
+			node := nodeMgr.Get(nodeID)
+			return node != nil && !node.IsStoppingState() &&
+				(m.NodeHealthChecker == nil || m.NodeHealthChecker.IsHealthy(nodeID))
+		})
 	}
+	return m
 }
@@ -0,0 +1,41 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+// PlacementCostModel estimates how well the nodes fit the replicas, it's used to pick
+// the incoming nodes of replicas when recovering nodes in collection.
+//
+//	Given heterogeneous nodes, the replica with the least capacity picks the biggest incoming node first,
+//	so that no replica is left with only small nodes which can't fully load the collection.
+type PlacementCostModel interface {
+	// NodeCapacity returns the capacity of node, e.g. the memory size.
+	NodeCapacity(nodeID int64) int64
+	// CollectionSize returns the data size of collection, measured in the same unit as NodeCapacity,
+	// 0 if unknown.
+	CollectionSize(collectionID int64) int64
+}
+
+// countBalancingCostModel treats all nodes as the same, which balances replicas by node count only.
+type countBalancingCostModel struct{}
+
+func (countBalancingCostModel) NodeCapacity(nodeID int64) int64 {
+	return 1
+}
+
+func (countBalancingCostModel) CollectionSize(collectionID int64) int64 {
+	return 0
+}
@@ -1,13 +1,44 @@
 package meta
 
 import (
+	"math"
+	"sort"
+
 	"github.com/golang/protobuf/proto"
+	"github.com/samber/lo"
 
 	"github.com/milvus-io/milvus/internal/proto/querypb"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
+// NodeRole is the role of rw node in replica.
+type NodeRole int32
+
+const (
+	// NodeRoleAny is the single undifferentiated role, which serves both reads and streaming data.
+	NodeRoleAny NodeRole = iota
+	// NodeRoleRead serves reads of sealed data only.
+	NodeRoleRead
+	// NodeRoleStreaming handles streaming and growing data.
+	NodeRoleStreaming
+)
+
+// RequiredNodeRoles returns the roles which each replica should have at least one node of.
+func RequiredNodeRoles(roleSplit bool) []NodeRole {
+	if roleSplit {
+		return []NodeRole{NodeRoleStreaming, NodeRoleRead}
+	}
+	return []NodeRole{NodeRoleAny}
+}
+
+const (
+	// DefaultReadWeight is the read weight of replica not set by operator.
+	DefaultReadWeight = 1.0
+	// MaxReadWeight bounds the read weight of replica, so that no replica starves the others of reads.
+	MaxReadWeight = 100.0
+)
+
 // NilReplica is used to represent a nil replica.
 var NilReplica = newReplica(&querypb.Replica{
 	ID: -1,
@@ -132,6 +163,58 @@ func (replica *Replica) AddRWNode(nodes ...int64) {
 	replica.replicaPB.Nodes = replica.rwNodes.Collect()
 }
 
+// IsDegraded returns whether the rw nodes of replica dropped below the quorum in last recovery.
+func (replica *Replica) IsDegraded() bool {
+	return replica.replicaPB.GetDegraded()
+}
+
+// IsStandby returns whether the replica is a hot standby, which is loaded but not routed to
+// until it's promoted on the loss of an active replica.
+func (replica *Replica) IsStandby() bool {
+	return replica.replicaPB.GetStandby()
+}
+
+// GetNodeSelector returns the labels required on the nodes of replica, empty means any node.
+func (replica *Replica) GetNodeSelector() map[string]string {
+	return replica.replicaPB.GetNodeSelector()
+}
+
+// IsRoleSplit returns whether the rw nodes of replica are split into read and streaming roles.
+func (replica *Replica) IsRoleSplit() bool {
+	return replica.replicaPB.GetRoleSplit()
+}
+
+// GetPinnedNodes returns the nodes the replica is manually pinned to, empty means automatic placement.
+// readonly, don't modify the returned slice.
+func (replica *Replica) GetPinnedNodes() []int64 {
+	return replica.replicaPB.GetPinnedNodes()
+}
+
+// IsPinned returns whether the replica is manually pinned to nodes.
+func (replica *Replica) IsPinned() bool {
+	return len(replica.replicaPB.GetPinnedNodes()) > 0
+}
+
+// GetReadWeight returns the operator-set weight of routing reads to the replica, `DefaultReadWeight` if not set.
+func (replica *Replica) GetReadWeight() float64 {
+	if weight := replica.replicaPB.GetReadWeight(); weight > 0 {
+		return weight
+	}
+	return DefaultReadWeight
+}
+
+// NodesByRole returns the rw nodes of given role,
+// all rw nodes take every role if the replica is not role split.
+func (replica *Replica) NodesByRole(role NodeRole) []int64 {
+	if !replica.IsRoleSplit() || role == NodeRoleAny {
+		return replica.GetRWNodes()
+	}
+	streamingNodes := typeutil.NewUniqueSet(replica.replicaPB.GetStreamingNodes()...)
+	return lo.Filter(replica.GetRWNodes(), func(node int64, _ int) bool {
+		return streamingNodes.Contain(node) == (role == NodeRoleStreaming)
+	})
+}
+
 func (replica *Replica) GetChannelRWNodes(channelName string) []int64 {
 	channelNodeInfos := replica.replicaPB.GetChannelNodeInfos()
 	if channelNodeInfos[channelName] == nil || len(channelNodeInfos[channelName].GetRwNodes()) == 0 {
@@ -156,6 +239,7 @@ func (replica *Replica) CopyForWrite() *mutableReplica {
 			roNodes:   typeutil.NewUniqueSet(replica.replicaPB.RoNodes...),
 		},
 		exclusiveRWNodeToChannel: exclusiveRWNodeToChannel,
+		incomingNodes:            typeutil.NewUniqueSet(),
 	}
 }
 
@@ -164,6 +248,10 @@ type mutableReplica struct {
 	*Replica
 
 	exclusiveRWNodeToChannel map[int64]string
+	// incomingNodes are the rw nodes added by this write, which are preferred to fill the vacant roles.
+	incomingNodes typeutil.UniqueSet
+	// streamingCapable tells whether the node is able to take the streaming role, nil means all nodes are.
+	streamingCapable func(nodeID int64) bool
 }
 
 // SetResourceGroup sets the resource group name of the replica.
@@ -171,8 +259,33 @@ func (replica *mutableReplica) SetResourceGroup(resourceGroup string) {
 	replica.replicaPB.ResourceGroup = resourceGroup
 }
 
+// SetDegraded marks whether the replica is degraded.
+func (replica *mutableReplica) SetDegraded(degraded bool) {
+	replica.replicaPB.Degraded = degraded
+}
+
+// SetStandby marks whether the replica is a hot standby.
+func (replica *mutableReplica) SetStandby(standby bool) {
+	replica.replicaPB.Standby = standby
+}
+
+// SetPinnedNodes pins the replica to the nodes, empty nodes unpin the replica.
+func (replica *mutableReplica) SetPinnedNodes(nodes []int64) {
+	replica.replicaPB.PinnedNodes = nodes
+}
+
+// SetReadWeight sets the weight of routing reads to the replica.
+func (replica *mutableReplica) SetReadWeight(weight float64) {
+	replica.replicaPB.ReadWeight = weight
+}
+
 // AddRWNode adds the node to rw nodes of the replica.
 func (replica *mutableReplica) AddRWNode(nodes ...int64) {
+	for _, node := range nodes {
+		if !replica.rwNodes.Contain(node) {
+			replica.incomingNodes.Insert(node)
+		}
+	}
 	replica.Replica.AddRWNode(nodes...)
 
 	// try to update node's assignment between channels
@@ -283,8 +396,60 @@ func (replica *mutableReplica) tryBalanceNodeForChannel() {
 	}
 }
 
+// tryBalanceNodeRoles keeps the ratio of rw nodes in streaming role by `queryCoord.replicaStreamingNodeRatio`,
+// and the others in read role. Only the streaming capable nodes take the streaming role, so a failed streaming node
+// is replaced by another streaming capable node rather than any node. The incoming nodes are preferred to fill
+// the vacant streaming role, so the roles of existing nodes are kept when a failed node is replaced.
+func (replica *mutableReplica) tryBalanceNodeRoles() {
+	if !replica.IsRoleSplit() {
+		return
+	}
+	capable := func(node int64) bool {
+		return replica.streamingCapable == nil || replica.streamingCapable(node)
+	}
+	streamingNodes := lo.Filter(replica.replicaPB.GetStreamingNodes(), func(node int64, _ int) bool {
+		return replica.rwNodes.Contain(node) && capable(node)
+	})
+	sort.Slice(streamingNodes, func(i, j int) bool { return streamingNodes[i] < streamingNodes[j] })
+	expected := expectedStreamingNodeNum(replica.rwNodes.Len())
+	if len(streamingNodes) > expected {
+		streamingNodes = streamingNodes[:expected]
+	}
+	if len(streamingNodes) < expected {
+		used := typeutil.NewUniqueSet(streamingNodes...)
+		candidates := lo.Filter(replica.rwNodes.Collect(), func(node int64, _ int) bool {
+			return !used.Contain(node) && capable(node)
+		})
+		// incoming nodes first, then by node id.
+		sort.Slice(candidates, func(i, j int) bool {
+			iIncoming, jIncoming := replica.incomingNodes.Contain(candidates[i]), replica.incomingNodes.Contain(candidates[j])
+			if iIncoming != jIncoming {
+				return iIncoming
+			}
+			return candidates[i] < candidates[j]
+		})
+		streamingNodes = append(streamingNodes, candidates[:min(len(candidates), expected-len(streamingNodes))]...)
+	}
+	if len(streamingNodes) == 0 && replica.rwNodes.Len() > 0 {
+		// no rw node is streaming capable, fall back to any node rather than leaving the channels unserved.
+		nodes := replica.rwNodes.Collect()
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+		streamingNodes = nodes[:1]
+	}
+	replica.replicaPB.StreamingNodes = streamingNodes
+}
+
+// expectedStreamingNodeNum returns the number of rw nodes in streaming role, at least one if there is any rw node.
+func expectedStreamingNodeNum(rwNodeNum int) int {
+	ratio := paramtable.Get().QueryCoordCfg.ReplicaStreamingNodeRatio.GetAsFloat()
+	// tolerate the error of float multiplication, e.g. 10 * 0.3 should be 3 rather than 4.
+	expected := int(math.Ceil(float64(rwNodeNum)*ratio - 1e-9))
+	return lo.Clamp(expected, min(1, rwNodeNum), rwNodeNum)
+}
+
 // IntoReplica returns the immutable replica, After calling this method, the mutable replica should not be used again.
 func (replica *mutableReplica) IntoReplica() *Replica {
+	replica.tryBalanceNodeRoles()
 	r := replica.Replica
 	replica.Replica = nil
 	return r
@@ -0,0 +1,108 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import (
+	"time"
+
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// ReplicaAvailability is the availability of replicas of a collection since it's tracked,
+// the collection is healthy if all of its replicas have their full node complement.
+type ReplicaAvailability struct {
+	Healthy bool
+	// HealthyTime is the cumulative time the collection is healthy.
+	HealthyTime time.Duration
+	// TrackedTime is the time since the collection is tracked, e.g. loaded or recovered.
+	TrackedTime time.Duration
+}
+
+// Ratio returns the fraction of tracked time the collection is healthy, 1 if it's not tracked for any time yet.
+func (a ReplicaAvailability) Ratio() float64 {
+	if a.TrackedTime <= 0 {
+		return 1
+	}
+	return float64(a.HealthyTime) / float64(a.TrackedTime)
+}
+
+// IsFullNodeComplement returns whether the replica has its full node complement, that is no ro node left to drain,
+// and enough rw nodes to serve, at least the quorum if set.
+func IsFullNodeComplement(replica *Replica, quorum int) bool {
+	return !replica.IsDegraded() && replica.RONodesCount() == 0 && replica.RWNodesCount() >= max(quorum, 1)
+}
+
+type collectionAvailability struct {
+	healthy      bool
+	trackedSince time.Time
+	// healthySince is the start of the current healthy period, and healthyTime accumulates the past ones.
+	healthySince time.Time
+	healthyTime  time.Duration
+}
+
+// availabilityTracker records the transitions of collections between healthy and degraded.
+type availabilityTracker struct {
+	now         func() time.Time
+	collections map[typeutil.UniqueID]*collectionAvailability
+}
+
+func newAvailabilityTracker() *availabilityTracker {
+	return &availabilityTracker{
+		now:         time.Now,
+		collections: make(map[typeutil.UniqueID]*collectionAvailability),
+	}
+}
+
+// Update records the current health of collection, a collection seen for the first time starts being tracked.
+func (t *availabilityTracker) Update(collectionID typeutil.UniqueID, healthy bool) {
+	now := t.now()
+	a, ok := t.collections[collectionID]
+	if !ok {
+		a = &collectionAvailability{trackedSince: now}
+		t.collections[collectionID] = a
+	}
+	if ok && healthy == a.healthy {
+		return
+	}
+	if a.healthy {
+		a.healthyTime += now.Sub(a.healthySince)
+	} else {
+		a.healthySince = now
+	}
+	a.healthy = healthy
+}
+
+func (t *availabilityTracker) Remove(collectionID typeutil.UniqueID) {
+	delete(t.collections, collectionID)
+}
+
+func (t *availabilityTracker) Get(collectionID typeutil.UniqueID) (ReplicaAvailability, bool) {
+	a, ok := t.collections[collectionID]
+	if !ok {
+		return ReplicaAvailability{}, false
+	}
+	now := t.now()
+	availability := ReplicaAvailability{
+		Healthy:     a.healthy,
+		HealthyTime: a.healthyTime,
+		TrackedTime: now.Sub(a.trackedSince),
+	}
+	if a.healthy {
+		availability.HealthyTime += now.Sub(a.healthySince)
+	}
+	return availability, true
+}
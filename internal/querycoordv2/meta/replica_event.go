@@ -0,0 +1,100 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import (
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// replicaChangeEventBufferSize bounds the events buffered for each watcher.
+const replicaChangeEventBufferSize = 1024
+
+// ReplicaChangeEvent describes a change of the node set of replica.
+type ReplicaChangeEvent struct {
+	CollectionID int64
+	ReplicaID    int64
+	// Before and After are the nodes of replica before and after the change, both rw and ro nodes are included.
+	Before []int64
+	After  []int64
+	// AddedNodes and RemovedNodes are the difference between Before and After.
+	AddedNodes   []int64
+	RemovedNodes []int64
+}
+
+// newReplicaChangeEvent returns the change event of replica, nil if the node set is not changed.
+// old is nil if replica is newly added.
+func newReplicaChangeEvent(old, replica *Replica) *ReplicaChangeEvent {
+	before := typeutil.NewUniqueSet()
+	if old != nil {
+		before.Insert(old.GetNodes()...)
+	}
+	after := typeutil.NewUniqueSet(replica.GetNodes()...)
+	added := after.Complement(before)
+	removed := before.Complement(after)
+	if added.Len() == 0 && removed.Len() == 0 {
+		return nil
+	}
+	return &ReplicaChangeEvent{
+		CollectionID: replica.GetCollectionID(),
+		ReplicaID:    replica.GetID(),
+		Before:       before.Collect(),
+		After:        after.Collect(),
+		AddedNodes:   added.Collect(),
+		RemovedNodes: removed.Collect(),
+	}
+}
+
+// replicaWatchers broadcasts the replica change events to watchers.
+// The events are buffered for each watcher, the oldest event is dropped if the buffer is full,
+// so that slow watchers never block the coordinator.
+type replicaWatchers struct {
+	mu       sync.Mutex
+	watchers []chan ReplicaChangeEvent
+	dropped  atomic.Uint64
+}
+
+func (w *replicaWatchers) Watch() <-chan ReplicaChangeEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch := make(chan ReplicaChangeEvent, replicaChangeEventBufferSize)
+	w.watchers = append(w.watchers, ch)
+	return ch
+}
+
+func (w *replicaWatchers) Notify(event *ReplicaChangeEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.watchers {
+		select {
+		case ch <- *event:
+			continue
+		default:
+		}
+		// the buffer is full, drop the oldest event to make room.
+		select {
+		case <-ch:
+			w.dropped.Inc()
+		default:
+		}
+		// never blocks since the events are only sent with lock held.
+		ch <- *event
+	}
+}
@@ -18,6 +18,8 @@ package meta
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"sync"
 
 	"github.com/cockroachdb/errors"
@@ -39,6 +41,21 @@ type ReplicaManager struct {
 	replicas           map[typeutil.UniqueID]*Replica
 	collIDToReplicaIDs map[typeutil.UniqueID]typeutil.UniqueSet
 	catalog            metastore.QueryCoordCatalog
+	watchers           *replicaWatchers
+	costModel          PlacementCostModel
+	// streamingCapable tells whether the node is able to take the streaming role of role split replicas.
+	streamingCapable func(nodeID int64) bool
+	// matchNodeSelector tells whether the node has the labels required by node selector.
+	matchNodeSelector func(nodeID int64, selector map[string]string) bool
+	// serviceable tells whether the node moved into replica is able to serve, which gates the next wave of moves.
+	serviceable func(nodeID int64) bool
+	// movingReplicas is the nodes moved into each replica admitted to move, the replica keeps moving until
+	// it drains its ro nodes and the nodes moved in are serviceable.
+	movingReplicas map[typeutil.UniqueID]typeutil.UniqueSet
+	// deferredMoves is the number of replica moves of each collection deferred by the last recovery.
+	deferredMoves map[typeutil.UniqueID]int
+	// availability tracks how long all the replicas of each collection have their full node complement.
+	availability *availabilityTracker
 }
 
 func NewReplicaManager(idAllocator func() (int64, error), catalog metastore.QueryCoordCatalog) *ReplicaManager {
@@ -47,9 +64,92 @@ func NewReplicaManager(idAllocator func() (int64, error), catalog metastore.Quer
 		replicas:           make(map[int64]*Replica),
 		collIDToReplicaIDs: make(map[int64]typeutil.UniqueSet),
 		catalog:            catalog,
+		watchers:           &replicaWatchers{},
+		costModel:          countBalancingCostModel{},
+		movingReplicas:     make(map[typeutil.UniqueID]typeutil.UniqueSet),
+		deferredMoves:      make(map[typeutil.UniqueID]int),
+		availability:       newAvailabilityTracker(),
 	}
 }
 
+// SetPlacementCostModel sets the cost model used to assign nodes to replicas, nil falls back to count-balancing.
+func (m *ReplicaManager) SetPlacementCostModel(model PlacementCostModel) {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+	if model == nil {
+		model = countBalancingCostModel{}
+	}
+	m.costModel = model
+}
+
+// SetStreamingCapability sets how to tell whether the node is able to take the streaming role of role split replicas,
+// nil means all nodes are.
+func (m *ReplicaManager) SetStreamingCapability(capable func(nodeID int64) bool) {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+	m.streamingCapable = capable
+}
+
+// SetNodeServiceability sets how to tell whether the node moved into replica is able to serve,
+// nil means all nodes are.
+func (m *ReplicaManager) SetNodeServiceability(serviceable func(nodeID int64) bool) {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+	m.serviceable = serviceable
+}
+
+// IsStreamingCapable returns whether the node is able to take the streaming role of role split replicas.
+func (m *ReplicaManager) IsStreamingCapable(nodeID int64) bool {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+	return m.streamingCapable == nil || m.streamingCapable(nodeID)
+}
+
+// SetNodeSelectorMatcher sets how to tell whether the node has the labels required by node selector,
+// nil means all nodes match.
+func (m *ReplicaManager) SetNodeSelectorMatcher(match func(nodeID int64, selector map[string]string) bool) {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+	m.matchNodeSelector = match
+}
+
+// MatchNodeSelector returns whether the node has the labels required by node selector, all nodes match the empty selector.
+func (m *ReplicaManager) MatchNodeSelector(nodeID int64, selector map[string]string) bool {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+	return len(selector) == 0 || m.matchNodeSelector == nil || m.matchNodeSelector(nodeID, selector)
+}
+
+// GetNodeSelector returns the node selector of the replicas of collection, which is shared by all of them.
+func (m *ReplicaManager) GetNodeSelector(collectionID typeutil.UniqueID) map[string]string {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+	for replicaID := range m.collIDToReplicaIDs[collectionID] {
+		return m.replicas[replicaID].GetNodeSelector()
+	}
+	return nil
+}
+
+// copyForWrite returns the mutable replica which balances the node roles by the streaming capability of nodes,
+// should be called with lock held.
+func (m *ReplicaManager) copyForWrite(replica *Replica) *mutableReplica {
+	mutableReplica := replica.CopyForWrite()
+	mutableReplica.streamingCapable = m.streamingCapable
+	return mutableReplica
+}
+
+// WatchChanges subscribes the node set changes of replicas saved by ReplicaManager.
+// The events are buffered, and the oldest ones are dropped if the watcher falls behind,
+// see DroppedChangeEvents.
+func (m *ReplicaManager) WatchChanges() <-chan ReplicaChangeEvent {
+	return m.watchers.Watch()
+}
+
+// DroppedChangeEvents returns the number of change events dropped for slow watchers.
+func (m *ReplicaManager) DroppedChangeEvents() uint64 {
+	return m.watchers.dropped.Load()
+}
+
 // Recover recovers the replicas for given collections from meta store
 func (m *ReplicaManager) Recover(collections []int64) error {
 	replicas, err := m.catalog.GetReplicas()
@@ -82,6 +182,7 @@ func (m *ReplicaManager) Recover(collections []int64) error {
 			)
 		}
 	}
+	m.updateAvailability(lo.Keys(m.collIDToReplicaIDs)...)
 	return nil
 }
 
@@ -96,6 +197,13 @@ func (m *ReplicaManager) Get(id typeutil.UniqueID) *Replica {
 
 // Spawn spawns N replicas at resource group for given collection in ReplicaManager.
 func (m *ReplicaManager) Spawn(collection int64, replicaNumInRG map[string]int, channels []string) ([]*Replica, error) {
+	return m.SpawnWithNodeSelector(collection, replicaNumInRG, channels, nil)
+}
+
+// SpawnWithNodeSelector spawns the replicas which are only placed on the nodes matching the node selector.
+func (m *ReplicaManager) SpawnWithNodeSelector(collection int64, replicaNumInRG map[string]int, channels []string,
+	nodeSelector map[string]string,
+) ([]*Replica, error) {
 	m.rwmutex.Lock()
 	defer m.rwmutex.Unlock()
 	if m.collIDToReplicaIDs[collection] != nil {
@@ -104,6 +212,7 @@ func (m *ReplicaManager) Spawn(collection int64, replicaNumInRG map[string]int,
 
 	balancePolicy := paramtable.Get().QueryCoordCfg.Balancer.GetValue()
 	enableChannelExclusiveMode := balancePolicy == ChannelLevelScoreBalancerName
+	roleSplit := paramtable.Get().QueryCoordCfg.EnableReplicaRoleSplit.GetAsBool()
 
 	replicas := make([]*Replica, 0)
 	for rgName, replicaNum := range replicaNumInRG {
@@ -124,6 +233,8 @@ func (m *ReplicaManager) Spawn(collection int64, replicaNumInRG map[string]int,
 				CollectionID:     collection,
 				ResourceGroup:    rgName,
 				ChannelNodeInfos: channelExclusiveNodeInfo,
+				RoleSplit:        roleSplit,
+				NodeSelector:     nodeSelector,
 			}))
 		}
 	}
@@ -155,7 +266,15 @@ func (m *ReplicaManager) put(replicas ...*Replica) error {
 		return err
 	}
 
+	for _, replica := range replicas {
+		if event := newReplicaChangeEvent(m.replicas[replica.GetID()], replica); event != nil {
+			m.watchers.Notify(event)
+		}
+	}
 	m.putReplicaInMemory(replicas...)
+	m.updateAvailability(lo.Uniq(lo.Map(replicas, func(replica *Replica, _ int) typeutil.UniqueID {
+		return replica.GetCollectionID()
+	}))...)
 	return nil
 }
 
@@ -195,8 +314,34 @@ func (m *ReplicaManager) TransferReplica(collectionID typeutil.UniqueID, srcRGNa
 	// Node Change will be executed by replica_observer in background.
 	replicas := make([]*Replica, 0, replicaNum)
 	for i := 0; i < replicaNum; i++ {
-		mutableReplica := srcReplicas[i].CopyForWrite()
+		mutableReplica := m.copyForWrite(srcReplicas[i])
+		mutableReplica.SetResourceGroup(dstRGName)
+		// the pinned nodes are in the source resource group.
+		mutableReplica.SetPinnedNodes(nil)
+		replicas = append(replicas, mutableReplica.IntoReplica())
+	}
+	return m.put(replicas...)
+}
+
+// RelocateReplicas moves the given replicas of collection to the paired resource groups,
+// only the moved replicas are persisted. The nodes change is executed by recovery.
+func (m *ReplicaManager) RelocateReplicas(collectionID typeutil.UniqueID, moves map[typeutil.UniqueID]string) error {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+
+	replicas := make([]*Replica, 0, len(moves))
+	for replicaID, dstRGName := range moves {
+		replica, ok := m.replicas[replicaID]
+		if !ok || replica.GetCollectionID() != collectionID {
+			return merr.WrapErrReplicaNotFound(replicaID)
+		}
+		if replica.GetResourceGroup() == dstRGName {
+			continue
+		}
+		mutableReplica := m.copyForWrite(replica)
 		mutableReplica.SetResourceGroup(dstRGName)
+		// the pinned nodes are in the source resource group.
+		mutableReplica.SetPinnedNodes(nil)
 		replicas = append(replicas, mutableReplica.IntoReplica())
 	}
 	return m.put(replicas...)
@@ -241,6 +386,8 @@ func (m *ReplicaManager) RemoveCollection(collectionID typeutil.UniqueID) error
 		delete(m.replicas, replicaID)
 	}
 	delete(m.collIDToReplicaIDs, collectionID)
+	delete(m.deferredMoves, collectionID)
+	m.availability.Remove(collectionID)
 	return nil
 }
 
@@ -273,6 +420,14 @@ func (m *ReplicaManager) GetByCollectionAndNode(collectionID, nodeID typeutil.Un
 	return nil
 }
 
+// GetAllReplicas returns all replicas of all collections.
+func (m *ReplicaManager) GetAllReplicas() []*Replica {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+
+	return lo.Values(m.replicas)
+}
+
 func (m *ReplicaManager) GetByNode(nodeID typeutil.UniqueID) []*Replica {
 	m.rwmutex.RLock()
 	defer m.rwmutex.RUnlock()
@@ -323,37 +478,133 @@ func (m *ReplicaManager) GetByResourceGroup(rgName string) []*Replica {
 // 2. Add new incoming nodes into the replica if they are not in-used by other replicas of same collection.
 // 3. replicas in same resource group will shared the nodes in resource group fairly.
 func (m *ReplicaManager) RecoverNodesInCollection(collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet) error {
-	if err := m.validateResourceGroups(rgs); err != nil {
-		return err
-	}
+	return m.RecoverNodesInCollections(map[typeutil.UniqueID]map[string]typeutil.UniqueSet{collectionID: rgs})
+}
 
+// RecoverNodesInCollections recovers nodes of multiple collections under one move throttle,
+// the collections are recovered in the order of collection id, so the moves are admitted deterministically.
+// The modified replicas of all collections are saved in one transaction, either all of them are applied or none,
+// and the in-memory replicas and move state are kept untouched if the save fails.
+// A collection which can't be recovered is skipped, and its error is returned along with the others.
+func (m *ReplicaManager) RecoverNodesInCollections(rgsOfCollections map[typeutil.UniqueID]map[string]typeutil.UniqueSet) error {
+	var errs error
 	m.rwmutex.Lock()
 	defer m.rwmutex.Unlock()
 
+	m.pruneMovingReplicas()
+	throttle := newMoveThrottle(paramtable.Get().QueryCoordCfg.MaxConcurrentReplicaMoves.GetAsInt(), len(m.movingReplicas))
+	collectionIDs := lo.Keys(rgsOfCollections)
+	sort.Slice(collectionIDs, func(i, j int) bool { return collectionIDs[i] < collectionIDs[j] })
+	modifiedReplicas := make([]*Replica, 0)
+	deferredMoves := make(map[typeutil.UniqueID]int, len(collectionIDs))
+	for _, collectionID := range collectionIDs {
+		mark := len(throttle.admitted)
+		replicas, deferred, err := m.recoverNodesInCollection(collectionID, rgsOfCollections[collectionID], throttle)
+		if err != nil {
+			log.Warn("fail to recover nodes in collection", zap.Int64("collectionID", collectionID), zap.Error(err))
+			// the moves of collection are not applied, give them back to the others.
+			throttle.revert(mark)
+			errs = merr.Combine(errs, err)
+			continue
+		}
+		modifiedReplicas = append(modifiedReplicas, replicas...)
+		deferredMoves[collectionID] = deferred
+	}
+
+	if err := m.put(modifiedReplicas...); err != nil {
+		log.Warn("fail to save recovered replicas", zap.Int64s("collectionIDs", collectionIDs), zap.Error(err))
+		return merr.Combine(errs, err)
+	}
+	for collectionID, deferred := range deferredMoves {
+		m.deferredMoves[collectionID] = deferred
+	}
+	for _, move := range throttle.admitted {
+		if m.movingReplicas[move.replicaID] == nil {
+			m.movingReplicas[move.replicaID] = typeutil.NewUniqueSet()
+		}
+		m.movingReplicas[move.replicaID].Insert(move.nodes...)
+	}
+	return errs
+}
+
+// recoverNodesInCollection computes the replicas of collection to be modified and the number of moves deferred
+// without applying them, should be called with lock held.
+func (m *ReplicaManager) recoverNodesInCollection(collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet, throttle *moveThrottle) ([]*Replica, int, error) {
+	if err := m.validateResourceGroups(rgs); err != nil {
+		return nil, 0, err
+	}
+
+	// the pinned replicas are placed on their pinned nodes only, which are kept from the other replicas.
+	pinned, rgs := m.splitPinnedReplicas(collectionID, rgs)
+
 	// create a helper to do the recover.
-	helper, err := m.getCollectionAssignmentHelper(collectionID, rgs)
+	helper, err := m.getCollectionAssignmentHelper(collectionID, rgs, pinned)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	modifiedReplicas := make([]*Replica, 0)
+	deferred := 0
+	quorum := paramtable.Get().QueryCoordCfg.ReplicaNodeQuorum.GetAsInt()
+	for replicaID, available := range pinned {
+		if recovered := m.recoverPinnedReplica(replicaID, available, quorum); recovered != nil {
+			modifiedReplicas = append(modifiedReplicas, recovered)
+		}
+	}
 	// recover node by resource group.
+	capacity := m.costModel.NodeCapacity
+	collectionSize := m.costModel.CollectionSize(collectionID)
 	helper.RangeOverResourceGroup(func(replicaHelper *replicasInSameRGAssignmentHelper) {
-		replicaHelper.RangeOverReplicas(func(assignment *replicaAssignmentInfo) {
+		// the replica with the least capacity picks the biggest incoming nodes first.
+		replicaHelper.RangeOverReplicasByCapacity(capacity, func(assignment *replicaAssignmentInfo) {
 			roNodes := assignment.GetNewRONodes()
 			recoverableNodes, incomingNodeCount := assignment.GetRecoverNodesAndIncomingNodeCount()
 			// There may be not enough incoming nodes for current replica,
 			// Even we filtering the nodes that are used by other replica of same collection in other resource group,
 			// current replica's expected node may be still used by other replica of same collection in same resource group.
-			incomingNode := replicaHelper.AllocateIncomingNodes(incomingNodeCount)
-			if len(roNodes) == 0 && len(recoverableNodes) == 0 && len(incomingNode) == 0 {
+			incomingNode := replicaHelper.AllocateIncomingNodesByCapacity(incomingNodeCount, capacity)
+			replica := m.replicas[assignment.GetReplicaID()]
+			// loading onto incoming nodes is throttled except for the newly spawned replica and the replica already moving,
+			// the rw -> ro and ro -> rw changes are always applied to keep the replica off the lost nodes.
+			if len(incomingNode) > 0 && replica.NodesCount() > 0 {
+				if _, moving := m.movingReplicas[replica.GetID()]; moving {
+					throttle.follow(replica.GetID(), incomingNode)
+				} else if !throttle.admit(replica.GetID(), incomingNode) {
+					log.RatedInfo(10, "incoming nodes of replica deferred to next wave",
+						zap.Int64("replicaID", assignment.GetReplicaID()),
+						zap.Int64s("incomingNodes", incomingNode))
+					incomingNode = nil
+					deferred++
+				}
+			}
+			if len(roNodes) == 0 && len(recoverableNodes) == 0 && len(incomingNode) == 0 &&
+				replica.IsDegraded() == (replica.RWNodesCount() < quorum) {
 				// nothing to do.
 				return
 			}
-			mutableReplica := m.replicas[assignment.GetReplicaID()].CopyForWrite()
+			mutableReplica := m.copyForWrite(replica)
 			mutableReplica.AddRONode(roNodes...)          // rw -> ro
 			mutableReplica.AddRWNode(recoverableNodes...) // ro -> rw
 			mutableReplica.AddRWNode(incomingNode...)     // unused -> rw
+			// replica can't be recovered to the quorum is marked as degraded explicitly rather than quietly serving.
+			mutableReplica.SetDegraded(mutableReplica.RWNodesCount() < quorum)
+			if mutableReplica.IsDegraded() {
+				log.Warn("replica is degraded, rw nodes drop below the quorum",
+					zap.Int64("replicaID", assignment.GetReplicaID()),
+					zap.Int("rwNodes", mutableReplica.RWNodesCount()),
+					zap.Int("quorum", quorum))
+			}
+			replicaCapacity := int64(0)
+			mutableReplica.RangeOverRWNodes(func(node int64) bool {
+				replicaCapacity += capacity(node)
+				return true
+			})
+			if collectionSize > 0 && replicaCapacity < collectionSize {
+				log.Warn("replica capacity is less than the collection size, it may not be fully loaded",
+					zap.Int64("replicaID", assignment.GetReplicaID()),
+					zap.Int64("capacity", replicaCapacity),
+					zap.Int64("collectionSize", collectionSize))
+			}
 			log.Info(
 				"new replica recovery found",
 				zap.Int64("replicaID", assignment.GetReplicaID()),
@@ -363,9 +614,357 @@ func (m *ReplicaManager) RecoverNodesInCollection(collectionID typeutil.UniqueID
 			modifiedReplicas = append(modifiedReplicas, mutableReplica.IntoReplica())
 		})
 	})
+	return modifiedReplicas, deferred, nil
+}
+
+// splitPinnedReplicas returns the pinned replicas of collection with any pinned node available, and the nodes of
+// resource groups left for the other replicas, which exclude the nodes used or pinned by the pinned replicas.
+// The pinned replica without any available pinned node falls back to automatic placement until they are back.
+func (m *ReplicaManager) splitPinnedReplicas(collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet) (map[typeutil.UniqueID]typeutil.UniqueSet, map[string]typeutil.UniqueSet) {
+	pinned := make(map[typeutil.UniqueID]typeutil.UniqueSet)
+	for replicaID := range m.collIDToReplicaIDs[collectionID] {
+		replica := m.replicas[replicaID]
+		nodes, ok := rgs[replica.GetResourceGroup()]
+		if !replica.IsPinned() || !ok {
+			continue
+		}
+		available := typeutil.NewUniqueSet(lo.Filter(replica.GetPinnedNodes(), func(node int64, _ int) bool {
+			return nodes.Contain(node)
+		})...)
+		if available.Len() > 0 {
+			pinned[replicaID] = available
+		}
+	}
+	if len(pinned) == 0 {
+		return pinned, rgs
+	}
+	left := make(map[string]typeutil.UniqueSet, len(rgs))
+	for rgName, nodes := range rgs {
+		left[rgName] = nodes.Clone()
+	}
+	for replicaID := range pinned {
+		replica := m.replicas[replicaID]
+		for _, nodes := range left {
+			nodes.Remove(replica.GetNodes()...)
+			nodes.Remove(replica.GetPinnedNodes()...)
+		}
+	}
+	return pinned, left
+}
+
+// recoverPinnedReplica moves the replica onto its available pinned nodes, returns nil if nothing changes.
+// The pinned nodes still used by other replicas of the same collection are taken once they are released.
+func (m *ReplicaManager) recoverPinnedReplica(replicaID typeutil.UniqueID, available typeutil.UniqueSet, quorum int) *Replica {
+	replica := m.replicas[replicaID]
+	usedByOthers := typeutil.NewUniqueSet()
+	for id := range m.collIDToReplicaIDs[replica.GetCollectionID()] {
+		if id != replicaID {
+			usedByOthers.Insert(m.replicas[id].GetNodes()...)
+		}
+	}
+	roNodes := lo.Filter(replica.GetRWNodes(), func(node int64, _ int) bool {
+		return !available.Contain(node)
+	})
+	rwNodes := lo.Filter(available.Collect(), func(node int64, _ int) bool {
+		return !replica.ContainRWNode(node) && !usedByOthers.Contain(node)
+	})
+	if len(roNodes) == 0 && len(rwNodes) == 0 && replica.IsDegraded() == (replica.RWNodesCount() < quorum) {
+		return nil
+	}
+	mutableReplica := m.copyForWrite(replica)
+	mutableReplica.AddRONode(roNodes...) // rw -> ro
+	mutableReplica.AddRWNode(rwNodes...) // ro or unused -> rw
+	mutableReplica.SetDegraded(mutableReplica.RWNodesCount() < quorum)
+	log.Info("pinned replica recovery found",
+		zap.Int64("replicaID", replicaID),
+		zap.Int64s("pinnedNodes", replica.GetPinnedNodes()),
+		zap.Int64s("newRONodes", roNodes),
+		zap.Int64s("newRWNodes", rwNodes))
+	return mutableReplica.IntoReplica()
+}
+
+// PinNodes pins the replica to the nodes, which overrides the automatic placement in the next recovery.
+// Empty nodes unpin the replica.
+func (m *ReplicaManager) PinNodes(replicaID typeutil.UniqueID, nodes []int64) error {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+
+	replica, ok := m.replicas[replicaID]
+	if !ok {
+		return merr.WrapErrReplicaNotFound(replicaID)
+	}
+	mutableReplica := m.copyForWrite(replica)
+	mutableReplica.SetPinnedNodes(nodes)
+	return m.put(mutableReplica.IntoReplica())
+}
+
+// SetReadWeight sets the weight of routing reads to the replica, e.g. to bias reads toward the replicas on
+// more powerful nodes. The weight is persisted, so it survives the restart of coordinator, unlike the load of nodes
+// measured by proxy. The weight must be in (0, MaxReadWeight].
+func (m *ReplicaManager) SetReadWeight(replicaID typeutil.UniqueID, weight float64) error {
+	if math.IsNaN(weight) || weight <= 0 || weight > MaxReadWeight {
+		return merr.WrapErrParameterInvalid(fmt.Sprintf("read weight in (0, %v]", MaxReadWeight), fmt.Sprintf("%v", weight))
+	}
+
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+
+	replica, ok := m.replicas[replicaID]
+	if !ok {
+		return merr.WrapErrReplicaNotFound(replicaID)
+	}
+	mutableReplica := m.copyForWrite(replica)
+	mutableReplica.SetReadWeight(weight)
+	return m.put(mutableReplica.IntoReplica())
+}
+
+// GetReadWeight returns the weight of routing reads to the replica, `DefaultReadWeight` if it's never set.
+func (m *ReplicaManager) GetReadWeight(replicaID typeutil.UniqueID) (float64, error) {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+
+	replica, ok := m.replicas[replicaID]
+	if !ok {
+		return 0, merr.WrapErrReplicaNotFound(replicaID)
+	}
+	return replica.GetReadWeight(), nil
+}
+
+// RecoverStandbyReplicas promotes a healthy standby replica to active for each active replica which lost all its rw nodes,
+// the lost replica is demoted to standby in exchange, so a new standby is backfilled as nodes are recovered to it.
+// Then the standby replicas are adjusted to standbyNum, at least one replica of collection is kept active.
+func (m *ReplicaManager) RecoverStandbyReplicas(collectionID typeutil.UniqueID, standbyNum int) error {
+	m.rwmutex.Lock()
+	defer m.rwmutex.Unlock()
+
+	replicaIDs, ok := m.collIDToReplicaIDs[collectionID]
+	if !ok {
+		return nil
+	}
+	replicas := lo.Map(replicaIDs.Collect(), func(id typeutil.UniqueID, _ int) *Replica {
+		return m.replicas[id]
+	})
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].GetID() < replicas[j].GetID() })
+	standbyNum = lo.Clamp(standbyNum, 0, len(replicas)-1)
+
+	modified := make(map[typeutil.UniqueID]*mutableReplica)
+	setStandby := func(replica *Replica, standby bool) {
+		mutableReplica, ok := modified[replica.GetID()]
+		if !ok {
+			mutableReplica = m.copyForWrite(replica)
+			modified[replica.GetID()] = mutableReplica
+		}
+		mutableReplica.SetStandby(standby)
+	}
+	isStandby := func(replica *Replica) bool {
+		if mutableReplica, ok := modified[replica.GetID()]; ok {
+			return mutableReplica.IsStandby()
+		}
+		return replica.IsStandby()
+	}
+	// the standby with more rw nodes is promoted first, the degraded one is never promoted.
+	byRWNodesDesc := func(replicas []*Replica) {
+		sort.SliceStable(replicas, func(i, j int) bool { return replicas[i].RWNodesCount() > replicas[j].RWNodesCount() })
+	}
+
+	healthyStandbys := lo.Filter(replicas, func(replica *Replica, _ int) bool {
+		return replica.IsStandby() && replica.RWNodesCount() > 0 && !replica.IsDegraded()
+	})
+	byRWNodesDesc(healthyStandbys)
+	for _, replica := range replicas {
+		if replica.IsStandby() || replica.RWNodesCount() > 0 {
+			continue
+		}
+		if len(healthyStandbys) == 0 {
+			if !lo.ContainsBy(replicas, func(replica *Replica) bool { return replica.IsStandby() }) {
+				// no standby is configured, nothing to promote.
+				break
+			}
+			log.RatedWarn(10, "active replica lost all rw nodes, but no healthy standby replica to promote",
+				zap.Int64("collectionID", collectionID),
+				zap.Int64("replicaID", replica.GetID()))
+			break
+		}
+		promoted := healthyStandbys[0]
+		healthyStandbys = healthyStandbys[1:]
+		setStandby(promoted, false)
+		setStandby(replica, true)
+		log.Info("promote standby replica on the loss of active replica",
+			zap.Int64("collectionID", collectionID),
+			zap.Int64("promotedReplicaID", promoted.GetID()),
+			zap.Int64("lostReplicaID", replica.GetID()))
+	}
+
+	standbys := lo.Filter(replicas, func(replica *Replica, _ int) bool { return isStandby(replica) })
+	if len(standbys) > standbyNum {
+		byRWNodesDesc(standbys)
+		for _, replica := range standbys[:len(standbys)-standbyNum] {
+			setStandby(replica, false)
+		}
+	} else if len(standbys) < standbyNum {
+		// the active replica with the least rw nodes steps down first, the newer one breaks the tie.
+		actives := lo.Filter(replicas, func(replica *Replica, _ int) bool { return !isStandby(replica) })
+		sort.SliceStable(actives, func(i, j int) bool {
+			if actives[i].RWNodesCount() != actives[j].RWNodesCount() {
+				return actives[i].RWNodesCount() < actives[j].RWNodesCount()
+			}
+			return actives[i].GetID() > actives[j].GetID()
+		})
+		for _, replica := range actives[:standbyNum-len(standbys)] {
+			setStandby(replica, true)
+		}
+	}
+
+	modifiedReplicas := make([]*Replica, 0, len(modified))
+	for _, replica := range replicas {
+		if mutableReplica, ok := modified[replica.GetID()]; ok && mutableReplica.IsStandby() != replica.IsStandby() {
+			modifiedReplicas = append(modifiedReplicas, mutableReplica.IntoReplica())
+		}
+	}
 	return m.put(modifiedReplicas...)
 }
 
+// updateAvailability records whether all the replicas of collections have their full node complement,
+// should be called with lock held.
+func (m *ReplicaManager) updateAvailability(collectionIDs ...typeutil.UniqueID) {
+	quorum := paramtable.Get().QueryCoordCfg.ReplicaNodeQuorum.GetAsInt()
+	for _, collectionID := range collectionIDs {
+		replicaIDs := m.collIDToReplicaIDs[collectionID]
+		healthy := replicaIDs.Len() > 0
+		for replicaID := range replicaIDs {
+			if !IsFullNodeComplement(m.replicas[replicaID], quorum) {
+				healthy = false
+				break
+			}
+		}
+		m.availability.Update(collectionID, healthy)
+	}
+}
+
+// GetAvailability returns the availability of replicas of collection, false if the collection is not tracked.
+func (m *ReplicaManager) GetAvailability(collectionID typeutil.UniqueID) (ReplicaAvailability, bool) {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+	return m.availability.Get(collectionID)
+}
+
+// GetAllAvailability returns the availability of replicas of all the tracked collections, keyed by collection id.
+func (m *ReplicaManager) GetAllAvailability() map[typeutil.UniqueID]ReplicaAvailability {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+	availabilities := make(map[typeutil.UniqueID]ReplicaAvailability, len(m.availability.collections))
+	for collectionID := range m.availability.collections {
+		availabilities[collectionID], _ = m.availability.Get(collectionID)
+	}
+	return availabilities
+}
+
+// ReplicaMoveProgress is the progress of replica moves during recovery.
+type ReplicaMoveProgress struct {
+	// InFlight is the number of replicas admitted to move, which are still draining their ro nodes
+	// or waiting for the nodes moved in to be serviceable.
+	InFlight int
+	// Deferred is the number of replicas whose moves are deferred to the next wave by the last recovery.
+	Deferred int
+}
+
+// GetMoveProgress returns the progress of replica moves, for operators to watch a large recovery proceeds.
+func (m *ReplicaManager) GetMoveProgress() ReplicaMoveProgress {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+
+	progress := ReplicaMoveProgress{}
+	for replicaID, movedNodes := range m.movingReplicas {
+		if !m.isMoveDone(replicaID, movedNodes) {
+			progress.InFlight++
+		}
+	}
+	for _, deferred := range m.deferredMoves {
+		progress.Deferred += deferred
+	}
+	return progress
+}
+
+// pruneMovingReplicas removes the replicas which have finished moving from moving replicas,
+// should be called with lock held.
+func (m *ReplicaManager) pruneMovingReplicas() {
+	for replicaID, movedNodes := range m.movingReplicas {
+		if m.isMoveDone(replicaID, movedNodes) {
+			delete(m.movingReplicas, replicaID)
+		}
+	}
+}
+
+// isMoveDone returns true if the replica has drained its ro nodes, and the nodes moved into it which it still holds
+// are serviceable, should be called with lock held.
+func (m *ReplicaManager) isMoveDone(replicaID typeutil.UniqueID, movedNodes typeutil.UniqueSet) bool {
+	replica, ok := m.replicas[replicaID]
+	if !ok {
+		return true
+	}
+	if replica.RONodesCount() > 0 {
+		return false
+	}
+	if m.serviceable == nil {
+		return true
+	}
+	for node := range movedNodes {
+		if replica.ContainRWNode(node) && !m.serviceable(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// moveThrottle bounds the number of replicas moving nodes concurrently, so that a large recovery is applied in waves,
+// the next wave starts once the replicas of previous one drain their ro nodes and the nodes moved in are serviceable.
+type moveThrottle struct {
+	limited   bool
+	remaining int
+	admitted  []replicaMove
+}
+
+// replicaMove is the nodes moved into the replica, which is admitted by throttle or already moving.
+type replicaMove struct {
+	replicaID typeutil.UniqueID
+	nodes     []int64
+	// throttled is set if the move takes up the budget of throttle.
+	throttled bool
+}
+
+// newMoveThrottle creates a throttle admitting at most maxMoves moves including the in flight ones, 0 means no limit.
+func newMoveThrottle(maxMoves int, inFlight int) *moveThrottle {
+	return &moveThrottle{
+		limited:   maxMoves > 0,
+		remaining: maxMoves - inFlight,
+	}
+}
+
+// admit returns true if the replica is allowed to move the nodes in.
+func (t *moveThrottle) admit(replicaID typeutil.UniqueID, nodes []int64) bool {
+	if t.limited && t.remaining <= 0 {
+		return false
+	}
+	t.remaining--
+	t.admitted = append(t.admitted, replicaMove{replicaID: replicaID, nodes: nodes, throttled: true})
+	return true
+}
+
+// follow records the nodes moved into the replica already moving, which doesn't take up the budget.
+func (t *moveThrottle) follow(replicaID typeutil.UniqueID, nodes []int64) {
+	t.admitted = append(t.admitted, replicaMove{replicaID: replicaID, nodes: nodes})
+}
+
+// revert gives back the moves admitted since mark, which is the number of moves recorded by then.
+func (t *moveThrottle) revert(mark int) {
+	for _, move := range t.admitted[mark:] {
+		if move.throttled {
+			t.remaining++
+		}
+	}
+	t.admitted = t.admitted[:mark]
+}
+
 // validateResourceGroups checks if the resource groups are valid.
 func (m *ReplicaManager) validateResourceGroups(rgs map[string]typeutil.UniqueSet) error {
 	// make sure that node in resource group is mutual exclusive.
@@ -382,7 +981,10 @@ func (m *ReplicaManager) validateResourceGroups(rgs map[string]typeutil.UniqueSe
 }
 
 // getCollectionAssignmentHelper checks if the collection is recoverable and group replicas by resource group.
-func (m *ReplicaManager) getCollectionAssignmentHelper(collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet) (*collectionAssignmentHelper, error) {
+// The pinned replicas are excluded, which are recovered onto their pinned nodes instead.
+func (m *ReplicaManager) getCollectionAssignmentHelper(collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet,
+	pinned map[typeutil.UniqueID]typeutil.UniqueSet,
+) (*collectionAssignmentHelper, error) {
 	// check if the collection is exist.
 	replicaIDs, ok := m.collIDToReplicaIDs[collectionID]
 	if !ok {
@@ -391,6 +993,9 @@ func (m *ReplicaManager) getCollectionAssignmentHelper(collectionID typeutil.Uni
 
 	rgToReplicas := make(map[string][]*Replica)
 	for replicaID := range replicaIDs {
+		if _, ok := pinned[replicaID]; ok {
+			continue
+		}
 		replica := m.replicas[replicaID]
 		rgName := replica.GetResourceGroup()
 		if _, ok := rgs[rgName]; !ok {
@@ -414,7 +1019,7 @@ func (m *ReplicaManager) RemoveNode(replicaID typeutil.UniqueID, nodes ...typeut
 		return merr.WrapErrReplicaNotFound(replicaID)
 	}
 
-	mutableReplica := replica.CopyForWrite()
+	mutableReplica := m.copyForWrite(replica)
 	mutableReplica.RemoveNode(nodes...) // ro -> unused
 	return m.put(mutableReplica.IntoReplica())
 }
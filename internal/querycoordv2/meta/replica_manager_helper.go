@@ -117,6 +117,44 @@ func (h *replicasInSameRGAssignmentHelper) RangeOverReplicas(f func(*replicaAssi
 	}
 }
 
+// AllocateIncomingNodesByCapacity allocates n incoming nodes, the ones with the biggest capacity first.
+func (h *replicasInSameRGAssignmentHelper) AllocateIncomingNodesByCapacity(n int, capacity func(nodeID int64) int64) []int64 {
+	nodeIDs := h.incomingNodes.Collect()
+	sort.Slice(nodeIDs, func(i, j int) bool {
+		left, right := capacity(nodeIDs[i]), capacity(nodeIDs[j])
+		return left > right || (left == right && nodeIDs[i] < nodeIDs[j])
+	})
+	if n < len(nodeIDs) {
+		nodeIDs = nodeIDs[:n]
+	}
+	h.incomingNodes.Remove(nodeIDs...)
+	return nodeIDs
+}
+
+// RangeOverReplicasByCapacity iterate replicas in ascending order of the capacity of their available and recoverable nodes.
+func (h *replicasInSameRGAssignmentHelper) RangeOverReplicasByCapacity(capacity func(nodeID int64) int64, f func(*replicaAssignmentInfo)) {
+	capacities := make(map[int64]int64, len(h.replicas))
+	for _, info := range h.replicas {
+		info.rwNodes.Range(func(nodeID int64) bool {
+			capacities[info.replicaID] += capacity(nodeID)
+			return true
+		})
+		info.recoverableRONodes.Range(func(nodeID int64) bool {
+			capacities[info.replicaID] += capacity(nodeID)
+			return true
+		})
+	}
+	sorted := make([]*replicaAssignmentInfo, len(h.replicas))
+	copy(sorted, h.replicas)
+	sort.Slice(sorted, func(i, j int) bool {
+		left, right := capacities[sorted[i].replicaID], capacities[sorted[j].replicaID]
+		return left < right || (left == right && sorted[i].replicaID < sorted[j].replicaID)
+	})
+	for _, info := range sorted {
+		f(info)
+	}
+}
+
 // updateExpectedNodeCountForReplicas updates the expected node count for all replicas in same resource group.
 func (h *replicasInSameRGAssignmentHelper) updateExpectedNodeCountForReplicas(currentUsageNodesCount int) {
 	minimumNodeCount := currentUsageNodesCount / len(h.replicas)
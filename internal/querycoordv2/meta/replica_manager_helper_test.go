@@ -457,6 +457,31 @@ func (s *CollectionAssignmentHelperSuite) TestMixRecoverNode() {
 	})
 }
 
+func (s *CollectionAssignmentHelperSuite) TestAssignByCapacity() {
+	capacities := map[int64]int64{1: 1, 2: 1, 3: 4, 4: 4}
+	capacity := func(nodeID int64) int64 { return capacities[nodeID] }
+	cHelper := newCollectionAssignmentHelper(1, map[string][]*Replica{
+		"rg1": {
+			newReplica(&querypb.Replica{ID: 1, CollectionID: 1, Nodes: []int64{1}}),
+			newReplica(&querypb.Replica{ID: 2, CollectionID: 1, Nodes: []int64{3}}),
+		},
+	}, map[string]typeutil.UniqueSet{"rg1": typeutil.NewUniqueSet(1, 2, 3, 4)})
+
+	assigned := make(map[int64][]int64)
+	order := make([]int64, 0)
+	cHelper.RangeOverResourceGroup(func(rHelper *replicasInSameRGAssignmentHelper) {
+		rHelper.RangeOverReplicasByCapacity(capacity, func(assignment *replicaAssignmentInfo) {
+			order = append(order, assignment.GetReplicaID())
+			_, incomingNodeCount := assignment.GetRecoverNodesAndIncomingNodeCount()
+			assigned[assignment.GetReplicaID()] = rHelper.AllocateIncomingNodesByCapacity(incomingNodeCount, capacity)
+		})
+	})
+	// the replica with small node picks the big incoming node first.
+	s.Equal([]int64{1, 2}, order)
+	s.Equal([]int64{4}, assigned[1])
+	s.Equal([]int64{2}, assigned[2])
+}
+
 func (s *CollectionAssignmentHelperSuite) runCase(c testCase) {
 	cHelper := newCollectionAssignmentHelper(c.collectionID, c.rgToReplicas, c.rgs)
 	cHelper.RangeOverResourceGroup(func(rHelper *replicasInSameRGAssignmentHelper) {
@@ -17,10 +17,14 @@
 package meta
 
 import (
+	"math"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/milvuspb"
@@ -31,6 +35,7 @@ import (
 	"github.com/milvus-io/milvus/internal/proto/querypb"
 	. "github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/pkg/util/etcd"
+	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
@@ -273,6 +278,234 @@ func (suite *ReplicaManagerSuite) TestNodeManipulate() {
 	}
 }
 
+func (suite *ReplicaManagerSuite) TestDegradedReplica() {
+	mgr := suite.mgr
+	paramtable.Get().Save(paramtable.Get().QueryCoordCfg.ReplicaNodeQuorum.Key, "3")
+	defer paramtable.Get().Reset(paramtable.Get().QueryCoordCfg.ReplicaNodeQuorum.Key)
+
+	// collection 102 has 2 replicas in RG3, each replica can't reach the quorum.
+	rgs := map[string]typeutil.UniqueSet{"RG3": typeutil.NewUniqueSet(4, 5, 6, 9)}
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	for _, replica := range mgr.GetByCollection(102) {
+		suite.True(replica.IsDegraded())
+	}
+
+	// recovered to the quorum.
+	rgs = map[string]typeutil.UniqueSet{"RG3": typeutil.NewUniqueSet(4, 5, 6, 9, 10, 11)}
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	for _, replica := range mgr.GetByCollection(102) {
+		suite.False(replica.IsDegraded())
+	}
+
+	// no quorum.
+	paramtable.Get().Save(paramtable.Get().QueryCoordCfg.ReplicaNodeQuorum.Key, "0")
+	rgs = map[string]typeutil.UniqueSet{"RG3": typeutil.NewUniqueSet(4)}
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	for _, replica := range mgr.GetByCollection(102) {
+		suite.False(replica.IsDegraded())
+	}
+}
+
+func (suite *ReplicaManagerSuite) TestStandbyReplica() {
+	mgr := suite.mgr
+
+	// collection 102 has 2 replicas in RG3, the newer one steps down as standby.
+	rgs := map[string]typeutil.UniqueSet{"RG3": typeutil.NewUniqueSet(4, 5, 6, 9)}
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	suite.NoError(mgr.RecoverStandbyReplicas(102, 1))
+	replicas := mgr.GetByCollection(102)
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].GetID() < replicas[j].GetID() })
+	active, standby := replicas[0], replicas[1]
+	suite.False(active.IsStandby())
+	suite.True(standby.IsStandby())
+
+	// the active replica lost all its rw nodes, the standby is promoted and the lost one is demoted.
+	rgs = map[string]typeutil.UniqueSet{"RG3": typeutil.NewUniqueSet(standby.GetRWNodes()...)}
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	suite.Zero(mgr.Get(active.GetID()).RWNodesCount())
+	suite.NoError(mgr.RecoverStandbyReplicas(102, 1))
+	suite.True(mgr.Get(active.GetID()).IsStandby())
+	suite.False(mgr.Get(standby.GetID()).IsStandby())
+
+	// at least one replica is kept active.
+	suite.NoError(mgr.RecoverStandbyReplicas(102, 5))
+	suite.Equal(1, lo.CountBy(mgr.GetByCollection(102), func(replica *Replica) bool { return replica.IsStandby() }))
+
+	// no standby.
+	suite.NoError(mgr.RecoverStandbyReplicas(102, 0))
+	for _, replica := range mgr.GetByCollection(102) {
+		suite.False(replica.IsStandby())
+	}
+	suite.NoError(mgr.RecoverStandbyReplicas(1000, 1))
+}
+
+func (suite *ReplicaManagerSuite) TestReadWeight() {
+	mgr := suite.mgr
+	replica := mgr.GetByCollection(100)[0]
+
+	weight, err := mgr.GetReadWeight(replica.GetID())
+	suite.NoError(err)
+	suite.Equal(DefaultReadWeight, weight)
+	suite.NoError(mgr.SetReadWeight(replica.GetID(), 2.5))
+
+	// the weight survives the restart.
+	suite.clearMemory()
+	suite.NoError(mgr.Recover(lo.Keys(suite.collections)))
+	weight, err = mgr.GetReadWeight(replica.GetID())
+	suite.NoError(err)
+	suite.Equal(2.5, weight)
+
+	for _, invalid := range []float64{0, -1, MaxReadWeight + 1, math.NaN()} {
+		suite.ErrorIs(mgr.SetReadWeight(replica.GetID(), invalid), merr.ErrParameterInvalid)
+	}
+	suite.ErrorIs(mgr.SetReadWeight(10000, 1), merr.ErrReplicaNotFound)
+	_, err = mgr.GetReadWeight(10000)
+	suite.ErrorIs(err, merr.ErrReplicaNotFound)
+}
+
+func (suite *ReplicaManagerSuite) TestThrottleMoves() {
+	mgr := suite.mgr
+	paramtable.Get().Save(paramtable.Get().QueryCoordCfg.MaxConcurrentReplicaMoves.Key, "1")
+	defer paramtable.Get().Reset(paramtable.Get().QueryCoordCfg.MaxConcurrentReplicaMoves.Key)
+
+	// all nodes of collection 102 are moved out of RG3, only one replica moves in the first wave.
+	rgs := map[string]typeutil.UniqueSet{"RG3": typeutil.NewUniqueSet(7, 8, 9, 10)}
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	suite.Equal(ReplicaMoveProgress{InFlight: 1, Deferred: 1}, mgr.GetMoveProgress())
+	var moving, deferred *Replica
+	for _, replica := range mgr.GetByCollection(102) {
+		if replica.RWNodesCount() > 0 {
+			moving = replica
+		} else {
+			deferred = replica
+		}
+	}
+	suite.NotNil(moving)
+	suite.NotNil(deferred)
+	suite.Greater(deferred.RONodesCount(), 0)
+
+	// the next wave waits for the moving replica to drain its ro nodes.
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	suite.Equal(ReplicaMoveProgress{InFlight: 1, Deferred: 1}, mgr.GetMoveProgress())
+	suite.Equal(0, mgr.Get(deferred.GetID()).RWNodesCount())
+
+	// the next wave waits for the nodes moved in to be serviceable as well.
+	suite.NoError(mgr.RemoveNode(moving.GetID(), moving.GetRONodes()...))
+	movedNodes := typeutil.NewUniqueSet(mgr.Get(moving.GetID()).GetRWNodes()...)
+	serviceable := false
+	mgr.SetNodeServiceability(func(nodeID int64) bool {
+		return serviceable || !movedNodes.Contain(nodeID)
+	})
+	defer mgr.SetNodeServiceability(nil)
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	suite.Equal(ReplicaMoveProgress{InFlight: 1, Deferred: 1}, mgr.GetMoveProgress())
+	suite.Equal(0, mgr.Get(deferred.GetID()).RWNodesCount())
+
+	serviceable = true
+	suite.NoError(mgr.RecoverNodesInCollection(102, rgs))
+	suite.Equal(ReplicaMoveProgress{InFlight: 1, Deferred: 0}, mgr.GetMoveProgress())
+	suite.Greater(mgr.Get(deferred.GetID()).RWNodesCount(), 0)
+}
+
+func (suite *ReplicaManagerSuite) TestThrottleMovesInCollectionOrder() {
+	mgr := suite.mgr
+	paramtable.Get().Save(paramtable.Get().QueryCoordCfg.MaxConcurrentReplicaMoves.Key, "1")
+	defer paramtable.Get().Reset(paramtable.Get().QueryCoordCfg.MaxConcurrentReplicaMoves.Key)
+
+	// the only move is admitted to the collection with the smallest id.
+	suite.NoError(mgr.RecoverNodesInCollections(map[typeutil.UniqueID]map[string]typeutil.UniqueSet{
+		102: {"RG3": typeutil.NewUniqueSet(7, 8, 9, 10)},
+		101: {"RG2": typeutil.NewUniqueSet(11, 12, 13, 14)},
+	}))
+	suite.Equal(1, lo.CountBy(mgr.GetByCollection(101), func(replica *Replica) bool {
+		return replica.RWNodesCount() > 0
+	}))
+	for _, replica := range mgr.GetByCollection(102) {
+		suite.Equal(0, replica.RWNodesCount())
+	}
+	suite.Equal(ReplicaMoveProgress{InFlight: 1, Deferred: 3}, mgr.GetMoveProgress())
+}
+
+func TestMoveThrottleRevert(t *testing.T) {
+	throttle := newMoveThrottle(2, 0)
+	assert.True(t, throttle.admit(1, []int64{10}))
+	mark := len(throttle.admitted)
+	throttle.follow(5, []int64{50})
+	assert.True(t, throttle.admit(2, []int64{20}))
+	assert.False(t, throttle.admit(3, []int64{30}))
+
+	// the moves of a collection failing to recover are given back to the others, the followed ones take no budget.
+	throttle.revert(mark)
+	assert.Equal(t, []replicaMove{{replicaID: 1, nodes: []int64{10}, throttled: true}}, throttle.admitted)
+	assert.True(t, throttle.admit(3, []int64{30}))
+	assert.False(t, throttle.admit(4, []int64{40}))
+}
+
+func (suite *ReplicaManagerSuite) TestWatchChanges() {
+	mgr := suite.mgr
+	ch := mgr.WatchChanges()
+
+	// node 7 joins the replica of collection 100.
+	rgs := map[string]typeutil.UniqueSet{"RG1": typeutil.NewUniqueSet(1, 7)}
+	suite.NoError(mgr.RecoverNodesInCollection(100, rgs))
+	replica := mgr.GetByCollection(100)[0]
+	event := <-ch
+	suite.Equal(int64(100), event.CollectionID)
+	suite.Equal(replica.GetID(), event.ReplicaID)
+	suite.ElementsMatch([]int64{1}, event.Before)
+	suite.ElementsMatch([]int64{1, 7}, event.After)
+	suite.ElementsMatch([]int64{7}, event.AddedNodes)
+	suite.Empty(event.RemovedNodes)
+
+	// no event if node set is not changed.
+	suite.NoError(mgr.Put(replica))
+	suite.Len(ch, 0)
+
+	// the oldest events are dropped for slow watchers.
+	for i := 0; i <= replicaChangeEventBufferSize; i++ {
+		mgr.watchers.Notify(&ReplicaChangeEvent{ReplicaID: int64(i)})
+	}
+	suite.Equal(uint64(1), mgr.DroppedChangeEvents())
+	suite.Len(ch, replicaChangeEventBufferSize)
+	suite.Equal(int64(1), (<-ch).ReplicaID)
+}
+
+func (suite *ReplicaManagerSuite) TestAvailability() {
+	mgr := NewReplicaManager(suite.idAllocator, suite.catalog)
+	now := time.Unix(0, 0)
+	mgr.availability.now = func() time.Time { return now }
+
+	// the replica has no node after spawned.
+	_, err := mgr.Spawn(1000, map[string]int{"RG1": 1}, nil)
+	suite.NoError(err)
+	availability, ok := mgr.GetAvailability(1000)
+	suite.True(ok)
+	suite.False(availability.Healthy)
+	suite.Equal(float64(1), availability.Ratio())
+
+	now = now.Add(10 * time.Second)
+	suite.NoError(mgr.RecoverNodesInCollection(1000, map[string]typeutil.UniqueSet{"RG1": typeutil.NewUniqueSet(1)}))
+	now = now.Add(30 * time.Second)
+	availability, _ = mgr.GetAvailability(1000)
+	suite.True(availability.Healthy)
+	suite.Equal(30*time.Second, availability.HealthyTime)
+	suite.Equal(40*time.Second, availability.TrackedTime)
+	suite.Equal(0.75, availability.Ratio())
+
+	// the node is moved out of resource group, and left as ro node to drain.
+	suite.NoError(mgr.RecoverNodesInCollection(1000, map[string]typeutil.UniqueSet{"RG1": typeutil.NewUniqueSet()}))
+	now = now.Add(40 * time.Second)
+	availabilities := mgr.GetAllAvailability()
+	suite.Len(availabilities, 1)
+	suite.False(availabilities[1000].Healthy)
+	suite.Equal(30*time.Second, availabilities[1000].HealthyTime)
+	suite.Equal(80*time.Second, availabilities[1000].TrackedTime)
+
+	suite.NoError(mgr.RemoveCollection(1000))
+	_, ok = mgr.GetAvailability(1000)
+	suite.False(ok)
+}
+
 func (suite *ReplicaManagerSuite) spawnAll() {
 	mgr := suite.mgr
 
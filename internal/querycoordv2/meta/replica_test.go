@@ -236,6 +236,80 @@ func (suite *ReplicaSuite) TestChannelExclusiveMode() {
 	}
 }
 
+func (suite *ReplicaSuite) TestNodeRoles() {
+	// no role split by default.
+	r := newReplica(suite.replicaPB)
+	suite.False(r.IsRoleSplit())
+	suite.ElementsMatch(r.GetRWNodes(), r.NodesByRole(NodeRoleStreaming))
+	suite.ElementsMatch(r.GetRWNodes(), r.NodesByRole(NodeRoleRead))
+
+	r = newReplica(&querypb.Replica{
+		ID:            1,
+		CollectionID:  2,
+		ResourceGroup: DefaultResourceGroupName,
+		RoleSplit:     true,
+	})
+	mutableReplica := r.CopyForWrite()
+	mutableReplica.AddRWNode(1, 2, 3, 4)
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{1, 2}, r.NodesByRole(NodeRoleStreaming))
+	suite.ElementsMatch([]int64{3, 4}, r.NodesByRole(NodeRoleRead))
+	suite.Len(r.NodesByRole(NodeRoleAny), 4)
+
+	// the failed streaming node is replaced by the incoming node, roles of others are kept.
+	mutableReplica = r.CopyForWrite()
+	mutableReplica.AddRONode(1)
+	mutableReplica.AddRWNode(5)
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{2, 5}, r.NodesByRole(NodeRoleStreaming))
+	suite.ElementsMatch([]int64{3, 4}, r.NodesByRole(NodeRoleRead))
+
+	// the failed read node is replaced by the incoming node.
+	mutableReplica = r.CopyForWrite()
+	mutableReplica.RemoveNode(1, 3)
+	mutableReplica.AddRWNode(6)
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{2, 5}, r.NodesByRole(NodeRoleStreaming))
+	suite.ElementsMatch([]int64{4, 6}, r.NodesByRole(NodeRoleRead))
+
+	// single node takes streaming role.
+	mutableReplica = r.CopyForWrite()
+	mutableReplica.RemoveNode(2, 4, 6)
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{5}, r.NodesByRole(NodeRoleStreaming))
+	suite.Empty(r.NodesByRole(NodeRoleRead))
+
+	// the failed streaming node is replaced by a streaming capable node rather than the incoming one.
+	capable := func(nodeID int64) bool { return nodeID != 7 }
+	mutableReplica = r.CopyForWrite()
+	mutableReplica.streamingCapable = capable
+	mutableReplica.AddRWNode(6, 8)
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{5, 6}, r.NodesByRole(NodeRoleStreaming))
+	mutableReplica = r.CopyForWrite()
+	mutableReplica.streamingCapable = capable
+	mutableReplica.AddRONode(5)
+	mutableReplica.AddRWNode(7)
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{6, 8}, r.NodesByRole(NodeRoleStreaming))
+	suite.ElementsMatch([]int64{7}, r.NodesByRole(NodeRoleRead))
+
+	// the ratio of streaming nodes is configurable.
+	paramtable.Get().Save(paramtable.Get().QueryCoordCfg.ReplicaStreamingNodeRatio.Key, "0.3")
+	defer paramtable.Get().Reset(paramtable.Get().QueryCoordCfg.ReplicaStreamingNodeRatio.Key)
+	mutableReplica = r.CopyForWrite()
+	mutableReplica.streamingCapable = capable
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{6}, r.NodesByRole(NodeRoleStreaming))
+	suite.ElementsMatch([]int64{7, 8}, r.NodesByRole(NodeRoleRead))
+
+	// fall back to any node if no node is streaming capable.
+	mutableReplica = r.CopyForWrite()
+	mutableReplica.streamingCapable = func(int64) bool { return false }
+	r = mutableReplica.IntoReplica()
+	suite.ElementsMatch([]int64{6}, r.NodesByRole(NodeRoleStreaming))
+}
+
 func TestReplica(t *testing.T) {
 	suite.Run(t, new(ReplicaSuite))
 }
@@ -3,6 +3,7 @@ package meta
 import (
 	"github.com/cockroachdb/errors"
 	"github.com/golang/protobuf/proto"
+	"golang.org/x/exp/maps"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
 	"github.com/milvus-io/milvus/internal/proto/querypb"
@@ -15,6 +16,9 @@ var (
 	resourceGroupTransferBoost         = 10000
 )
 
+// SystemResourceGroupName is the resource group reserved for the replicas of system collections.
+var SystemResourceGroupName = "__system_resource_group"
+
 // newResourceGroupConfig create a new resource group config.
 func newResourceGroupConfig(request int32, limit int32) *rgpb.ResourceGroupConfig {
 	return &rgpb.ResourceGroupConfig{
@@ -33,6 +37,11 @@ type ResourceGroup struct {
 	name  string
 	nodes typeutil.UniqueSet
 	cfg   *rgpb.ResourceGroupConfig
+	// maxReplicas caps the replicas hosted by resource group, 0 means no limit.
+	maxReplicas int32
+	// sharedSlots is the number of replicas of each other resource group a node of resource group can host,
+	// which lends the nodes to the other resource groups, keyed by resource group name.
+	sharedSlots map[string]int32
 }
 
 // NewResourceGroup create resource group.
@@ -58,6 +67,8 @@ func NewResourceGroupFromMeta(meta *querypb.ResourceGroup) *ResourceGroup {
 		}
 	}
 	rg := NewResourceGroup(meta.Name, meta.Config)
+	rg.maxReplicas = meta.GetMaxReplicas()
+	rg.sharedSlots = meta.GetSharedSlots()
 	for _, node := range meta.GetNodes() {
 		rg.nodes.Insert(node)
 	}
@@ -91,6 +102,20 @@ func (rg *ResourceGroup) GetConfigCloned() *rgpb.ResourceGroupConfig {
 	return proto.Clone(rg.cfg).(*rgpb.ResourceGroupConfig)
 }
 
+// GetMaxReplicas return the replica cap of resource group, 0 means no limit.
+func (rg *ResourceGroup) GetMaxReplicas() int {
+	return int(rg.maxReplicas)
+}
+
+// GetSharedSlots return the replica slots a node of resource group lends to the other resource groups.
+func (rg *ResourceGroup) GetSharedSlots() map[string]int {
+	slots := make(map[string]int, len(rg.sharedSlots))
+	for rgName, num := range rg.sharedSlots {
+		slots[rgName] = int(num)
+	}
+	return slots
+}
+
 // GetNodes return nodes of resource group.
 func (rg *ResourceGroup) GetNodes() []int64 {
 	return rg.nodes.Collect()
@@ -170,6 +195,9 @@ func (rg *ResourceGroup) GetMeta() *querypb.ResourceGroup {
 		Capacity: int32(capacity),
 		Nodes:    rg.nodes.Collect(),
 		Config:   rg.GetConfigCloned(),
+
+		MaxReplicas: rg.maxReplicas,
+		SharedSlots: maps.Clone(rg.sharedSlots),
 	}
 }
 
@@ -179,6 +207,9 @@ func (rg *ResourceGroup) Snapshot() *ResourceGroup {
 		name:  rg.name,
 		nodes: rg.nodes.Clone(),
 		cfg:   rg.GetConfigCloned(),
+
+		maxReplicas: rg.maxReplicas,
+		sharedSlots: maps.Clone(rg.sharedSlots),
 	}
 }
 
@@ -219,6 +250,16 @@ func (r *mutableResourceGroup) UpdateConfig(cfg *rgpb.ResourceGroupConfig) {
 	r.cfg = cfg
 }
 
+// UpdateMaxReplicas update the replica cap of resource group.
+func (r *mutableResourceGroup) UpdateMaxReplicas(maxReplicas int32) {
+	r.maxReplicas = maxReplicas
+}
+
+// UpdateSharedSlots update the replica slots a node of resource group lends to the other resource groups.
+func (r *mutableResourceGroup) UpdateSharedSlots(slots map[string]int32) {
+	r.sharedSlots = slots
+}
+
 // Assign node to resource group.
 func (r *mutableResourceGroup) AssignNode(id int64) {
 	r.nodes.Insert(id)
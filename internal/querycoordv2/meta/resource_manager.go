@@ -18,6 +18,8 @@ package meta
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/cockroachdb/errors"
@@ -54,6 +56,9 @@ type ResourceManager struct {
 	// resource_observer will listen this notifier to do a resource group recovery.
 	nodeChangedNotifier *syncutil.VersionedNotifier // used to notify that node distribution in resource group has been changed.
 	// replica_observer will listen this notifier to do a replica recovery.
+
+	// maintainedNodes is the absent nodes kept in resource groups during their maintenance.
+	maintainedNodes typeutil.UniqueSet
 }
 
 // NewResourceManager is used to create a ResourceManager instance.
@@ -71,6 +76,7 @@ func NewResourceManager(catalog metastore.QueryCoordCatalog, nodeMgr *session.No
 		rwmutex:             sync.RWMutex{},
 		rgChangedNotifier:   syncutil.NewVersionedNotifier(),
 		nodeChangedNotifier: syncutil.NewVersionedNotifier(),
+		maintainedNodes:     typeutil.NewUniqueSet(),
 	}
 }
 
@@ -166,32 +172,118 @@ func (rm *ResourceManager) AddResourceGroup(rgName string, cfg *rgpb.ResourceGro
 	return nil
 }
 
+// AddResourceGroupsFromTemplate creates the resource groups with the same config as one transaction,
+// none of them is created if any of them is invalid or the save fails.
+// The resource group which already exists with the same config is skipped.
+func (rm *ResourceManager) AddResourceGroupsFromTemplate(rgNames []string, cfg *rgpb.ResourceGroupConfig) error {
+	if len(rgNames) == 0 {
+		return nil
+	}
+	if cfg == nil {
+		// Use default config if not set, compatible with old client.
+		cfg = newResourceGroupConfig(0, 0)
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	newRGNames := make([]string, 0, len(rgNames))
+	for _, rgName := range lo.Uniq(rgNames) {
+		if len(rgName) == 0 {
+			return merr.WrapErrParameterMissing("resource group name couldn't be empty")
+		}
+		if rm.groups[rgName] != nil {
+			// Idempotent promise, same as AddResourceGroup.
+			if proto.Equal(rm.groups[rgName].GetConfig(), cfg) {
+				continue
+			}
+			return merr.WrapErrResourceGroupAlreadyExist(rgName)
+		}
+		newRGNames = append(newRGNames, rgName)
+	}
+	if len(newRGNames) == 0 {
+		return nil
+	}
+
+	maxResourceGroup := paramtable.Get().QuotaConfig.MaxResourceGroupNumOfQueryNode.GetAsInt()
+	if len(rm.groups)+len(newRGNames) > maxResourceGroup {
+		return merr.WrapErrResourceGroupReachLimit(strings.Join(newRGNames, ","), maxResourceGroup)
+	}
+
+	newRGs := make([]*ResourceGroup, 0, len(newRGNames))
+	metas := make([]*querypb.ResourceGroup, 0, len(newRGNames))
+	for _, rgName := range newRGNames {
+		if err := rm.validateResourceGroupConfig(rgName, cfg); err != nil {
+			return err
+		}
+		rg := NewResourceGroup(rgName, proto.Clone(cfg).(*rgpb.ResourceGroupConfig))
+		newRGs = append(newRGs, rg)
+		metas = append(metas, rg.GetMeta())
+	}
+
+	if err := rm.catalog.SaveResourceGroup(metas...); err != nil {
+		log.Warn("failed to add resource groups from template",
+			zap.Strings("rgNames", newRGNames),
+			zap.Any("config", cfg),
+			zap.Error(err),
+		)
+		return merr.WrapErrResourceGroupServiceAvailable()
+	}
+
+	for _, rg := range newRGs {
+		rm.groups[rg.GetName()] = rg
+	}
+	log.Info("add resource groups from template",
+		zap.Strings("rgNames", newRGNames),
+		zap.Any("config", cfg),
+	)
+
+	// notify that resource group config has been changed.
+	rm.rgChangedNotifier.NotifyAll()
+	return nil
+}
+
 // UpdateResourceGroups update resource group configuration.
 // Only change the configuration, no change with node. all node will be reassign by auto recover.
 func (rm *ResourceManager) UpdateResourceGroups(rgs map[string]*rgpb.ResourceGroupConfig) error {
-	if len(rgs) == 0 {
+	return rm.UpdateResourceGroupsWithMaxReplicas(rgs, nil)
+}
+
+// UpdateResourceGroupsWithMaxReplicas update resource group configuration and replica caps together.
+// The replica cap of resource group is kept if it's not given in maxReplicas, 0 means no limit.
+func (rm *ResourceManager) UpdateResourceGroupsWithMaxReplicas(rgs map[string]*rgpb.ResourceGroupConfig, maxReplicas map[string]int32) error {
+	if len(rgs) == 0 && len(maxReplicas) == 0 {
 		return nil
 	}
 
 	rm.rwmutex.Lock()
 	defer rm.rwmutex.Unlock()
-	return rm.updateResourceGroups(rgs)
+	return rm.updateResourceGroups(rgs, maxReplicas)
 }
 
-// updateResourceGroups update resource group configuration.
-func (rm *ResourceManager) updateResourceGroups(rgs map[string]*rgpb.ResourceGroupConfig) error {
-	modifiedRG := make([]*ResourceGroup, 0, len(rgs))
-	updates := make([]*querypb.ResourceGroup, 0, len(rgs))
-	for rgName, cfg := range rgs {
+// updateResourceGroups update resource group configuration and replica caps.
+func (rm *ResourceManager) updateResourceGroups(rgs map[string]*rgpb.ResourceGroupConfig, maxReplicas map[string]int32) error {
+	rgNames := typeutil.NewSet(lo.Keys(rgs)...)
+	rgNames.Insert(lo.Keys(maxReplicas)...)
+	modifiedRG := make([]*ResourceGroup, 0, len(rgNames))
+	updates := make([]*querypb.ResourceGroup, 0, len(rgNames))
+	for rgName := range rgNames {
 		if _, ok := rm.groups[rgName]; !ok {
 			return merr.WrapErrResourceGroupNotFound(rgName)
 		}
-		if err := rm.validateResourceGroupConfig(rgName, cfg); err != nil {
-			return err
-		}
 		// Update with copy on write.
 		mrg := rm.groups[rgName].CopyForWrite()
-		mrg.UpdateConfig(cfg)
+		if cfg, ok := rgs[rgName]; ok {
+			if err := rm.validateResourceGroupConfig(rgName, cfg); err != nil {
+				return err
+			}
+			mrg.UpdateConfig(cfg)
+		}
+		if num, ok := maxReplicas[rgName]; ok {
+			if num < 0 {
+				return merr.WrapErrResourceGroupIllegalConfig(rgName, num, "max replicas should not less than 0")
+			}
+			mrg.UpdateMaxReplicas(num)
+		}
 		rg := mrg.ToResourceGroup()
 
 		updates = append(updates, rg.GetMeta())
@@ -199,10 +291,11 @@ func (rm *ResourceManager) updateResourceGroups(rgs map[string]*rgpb.ResourceGro
 	}
 
 	if err := rm.catalog.SaveResourceGroup(updates...); err != nil {
-		for rgName, cfg := range rgs {
+		for _, rg := range modifiedRG {
 			log.Warn("failed to update resource group",
-				zap.String("rgName", rgName),
-				zap.Any("config", cfg),
+				zap.String("rgName", rg.GetName()),
+				zap.Any("config", rg.GetConfig()),
+				zap.Int("maxReplicas", rg.GetMaxReplicas()),
 				zap.Error(err),
 			)
 		}
@@ -214,6 +307,57 @@ func (rm *ResourceManager) updateResourceGroups(rgs map[string]*rgpb.ResourceGro
 		log.Info("update resource group",
 			zap.String("rgName", rg.GetName()),
 			zap.Any("config", rg.GetConfig()),
+			zap.Int("maxReplicas", rg.GetMaxReplicas()),
+		)
+		rm.groups[rg.GetName()] = rg
+	}
+
+	// notify that resource group config has been changed.
+	rm.rgChangedNotifier.NotifyAll()
+	return nil
+}
+
+// UpdateSharedSlots update the replica slots the nodes of resource groups lend to the other resource groups,
+// keyed by the lending resource group, empty slots stop the sharing of resource group.
+func (rm *ResourceManager) UpdateSharedSlots(shared map[string]map[string]int32) error {
+	if len(shared) == 0 {
+		return nil
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+
+	modifiedRG := make([]*ResourceGroup, 0, len(shared))
+	updates := make([]*querypb.ResourceGroup, 0, len(shared))
+	for rgName, slots := range shared {
+		if _, ok := rm.groups[rgName]; !ok {
+			return merr.WrapErrResourceGroupNotFound(rgName)
+		}
+		if err := rm.validateSharedSlots(rgName, slots); err != nil {
+			return err
+		}
+		// Update with copy on write.
+		mrg := rm.groups[rgName].CopyForWrite()
+		mrg.UpdateSharedSlots(slots)
+		rg := mrg.ToResourceGroup()
+
+		updates = append(updates, rg.GetMeta())
+		modifiedRG = append(modifiedRG, rg)
+	}
+
+	if err := rm.catalog.SaveResourceGroup(updates...); err != nil {
+		log.Warn("failed to update shared slots of resource group",
+			zap.Any("sharedSlots", shared),
+			zap.Error(err),
+		)
+		return merr.WrapErrResourceGroupServiceAvailable()
+	}
+
+	// Commit updates to memory.
+	for _, rg := range modifiedRG {
+		log.Info("update shared slots of resource group",
+			zap.String("rgName", rg.GetName()),
+			zap.Any("sharedSlots", rg.GetSharedSlots()),
 		)
 		rm.groups[rg.GetName()] = rg
 	}
@@ -273,7 +417,7 @@ func (rm *ResourceManager) TransferNode(sourceRGName string, targetRGName string
 	return rm.updateResourceGroups(map[string]*rgpb.ResourceGroupConfig{
 		sourceRGName: sourceCfg,
 		targetRGName: targetCfg,
-	})
+	}, nil)
 }
 
 // RemoveResourceGroup remove resource group.
@@ -406,6 +550,17 @@ func (rm *ResourceManager) GetResourceGroup(rgName string) *ResourceGroup {
 	return rm.groups[rgName].Snapshot()
 }
 
+// GetMaxReplicas return the replica cap of given resource group, 0 means no limit or the resource group is not found.
+func (rm *ResourceManager) GetMaxReplicas(rgName string) int {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[rgName] == nil {
+		return 0
+	}
+	return rm.groups[rgName].GetMaxReplicas()
+}
+
 // ListResourceGroups return all resource groups names.
 func (rm *ResourceManager) ListResourceGroups() []string {
 	rm.rwmutex.RLock()
@@ -438,6 +593,8 @@ func (rm *ResourceManager) HandleNodeUp(node int64) {
 	defer rm.rwmutex.Unlock()
 
 	rm.incomingNode.Insert(node)
+	// the node back from maintenance is still in its resource group.
+	rm.maintainedNodes.Remove(node)
 	// Trigger assign incoming node right away.
 	// error can be ignored here, because `AssignPendingIncomingNode`` will retry assign node.
 	rgName, err := rm.assignIncomingNodeWithNodeCheck(node)
@@ -448,12 +605,89 @@ func (rm *ResourceManager) HandleNodeUp(node int64) {
 	)
 }
 
+// HandleNodesUp handles the nodes joining together, e.g. all the nodes registered at startup. The nodes are assigned
+// to resource groups the same as calling `HandleNodeUp` one by one, but the assignment is saved by a single catalog
+// update and notified once, so that the replicas are recovered once over the final assignment rather than per node.
+// The nodes are left in the incoming node set if the assignment fails to save, `AssignPendingIncomingNode` retries them.
+func (rm *ResourceManager) HandleNodesUp(nodes []int64) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+
+	for _, node := range nodes {
+		rm.incomingNode.Insert(node)
+		// the node back from maintenance is still in its resource group.
+		rm.maintainedNodes.Remove(node)
+	}
+
+	originalGroups := make(map[string]*ResourceGroup, len(rm.groups))
+	for rgName, rg := range rm.groups {
+		originalGroups[rgName] = rg
+	}
+	assigned := make(map[int64]string, len(nodes))
+	for _, node := range nodes {
+		if rm.nodeMgr.Get(node) == nil {
+			rm.incomingNode.Remove(node)
+			log.Info("HandleNodesUp: skip node not online", zap.Int64("node", node))
+			continue
+		}
+		if ok, _ := rm.nodeMgr.IsStoppingNode(node); ok {
+			rm.incomingNode.Remove(node)
+			log.Info("HandleNodesUp: skip node stopped", zap.Int64("node", node))
+			continue
+		}
+		if rg := rm.getResourceGroupByNodeID(node); rg != nil {
+			rm.incomingNode.Remove(node)
+			continue
+		}
+		// assign in memory one by one, so that each selection sees the nodes assigned before it.
+		mrg := rm.mustSelectAssignIncomingNodeTargetRG().CopyForWrite()
+		mrg.AssignNode(node)
+		rg := mrg.ToResourceGroup()
+		rm.groups[rg.GetName()] = rg
+		rm.nodeIDMap[node] = rg.GetName()
+		assigned[node] = rg.GetName()
+	}
+	if len(assigned) == 0 {
+		return
+	}
+
+	modifiedRGs := lo.Uniq(lo.Values(assigned))
+	updates := lo.Map(modifiedRGs, func(rgName string, _ int) *querypb.ResourceGroup {
+		return rm.groups[rgName].GetMeta()
+	})
+	if err := rm.catalog.SaveResourceGroup(updates...); err != nil {
+		// roll back the assignment in memory.
+		rm.groups = originalGroups
+		for node := range assigned {
+			delete(rm.nodeIDMap, node)
+		}
+		log.Warn("HandleNodesUp: failed to save the nodes assigned to resource groups, retry later",
+			zap.Int64s("nodes", lo.Keys(assigned)),
+			zap.Error(err),
+		)
+		return
+	}
+	for node := range assigned {
+		rm.incomingNode.Remove(node)
+	}
+
+	// notify that node distribution has been changed.
+	rm.nodeChangedNotifier.NotifyAll()
+	log.Info("HandleNodesUp: add nodes to resource groups",
+		zap.Any("assignment", assigned),
+		zap.Strings("modifiedRGs", modifiedRGs),
+	)
+}
+
 // HandleNodeDown handle the node when node is leave.
 func (rm *ResourceManager) HandleNodeDown(node int64) {
 	rm.rwmutex.Lock()
 	defer rm.rwmutex.Unlock()
 
 	rm.incomingNode.Remove(node)
+	if rm.keepMaintainedNode(node) {
+		return
+	}
 
 	// for stopping query node becomes offline, node change won't be triggered,
 	// cause when it becomes stopping, it already remove from resource manager
@@ -474,6 +708,9 @@ func (rm *ResourceManager) HandleNodeStopping(node int64) {
 	defer rm.rwmutex.Unlock()
 
 	rm.incomingNode.Remove(node)
+	if rm.keepMaintainedNode(node) {
+		return
+	}
 	rgName, err := rm.unassignNode(node)
 	log.Info("HandleNodeStopping: remove node from resource group",
 		zap.String("rgName", rgName),
@@ -482,6 +719,44 @@ func (rm *ResourceManager) HandleNodeStopping(node int64) {
 	)
 }
 
+// keepMaintainedNode keeps the stopping or down node in its resource group if it's in maintenance, so that it's kept
+// by the replicas until it's back, or the maintenance is over, see `ReleaseExpiredMaintenance`.
+func (rm *ResourceManager) keepMaintainedNode(node int64) bool {
+	rg := rm.getResourceGroupByNodeID(node)
+	if rg == nil || !rm.nodeMgr.IsInMaintenance(node) {
+		return false
+	}
+	rm.maintainedNodes.Insert(node)
+	log.Info("keep node in maintenance in resource group",
+		zap.String("rgName", rg.GetName()),
+		zap.Int64("node", node),
+	)
+	return true
+}
+
+// ReleaseExpiredMaintenance removes the nodes kept during maintenance from their resource groups if the maintenance
+// is over and they are not back, then the replicas are recovered as normal.
+func (rm *ResourceManager) ReleaseExpiredMaintenance() {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+
+	for _, node := range rm.maintainedNodes.Collect() {
+		if rm.nodeMgr.IsInMaintenance(node) {
+			continue
+		}
+		rm.maintainedNodes.Remove(node)
+		if info := rm.nodeMgr.Get(node); info != nil && !info.IsStoppingState() {
+			continue
+		}
+		rgName, err := rm.unassignNode(node)
+		log.Info("remove node out of maintenance from resource group",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+			zap.Error(err),
+		)
+	}
+}
+
 // ListenResourceGroupChanged return a listener for resource group changed.
 func (rm *ResourceManager) ListenResourceGroupChanged() *syncutil.VersionedListener {
 	return rm.rgChangedNotifier.Listen(syncutil.VersionedListenAtEarliest)
@@ -734,6 +1009,81 @@ func (rm *ResourceManager) mustSelectAssignIncomingNodeTargetRG() *ResourceGroup
 	return rm.groups[DefaultResourceGroupName]
 }
 
+// RGPlacementDecision is the decision of a resource group on an incoming node.
+type RGPlacementDecision struct {
+	ResourceGroup string
+	Accepted      bool
+	Reason        string
+}
+
+// PlacementExplanation explains which resource group a node is placed into and why.
+type PlacementExplanation struct {
+	NodeID int64
+	// ResourceGroup is the resource group the node is placed into, empty if the node is unassignable.
+	ResourceGroup string
+	Reason        string
+	// Candidates are the decisions of all resource groups, sorted by resource group name.
+	Candidates []RGPlacementDecision
+}
+
+// ExplainNodePlacement explains the placement of node made by `HandleNodeUp`,
+// it reports why each resource group accepts or rejects the node without changing anything.
+func (rm *ResourceManager) ExplainNodePlacement(nodeID int64) PlacementExplanation {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	explanation := PlacementExplanation{NodeID: nodeID}
+	if rm.nodeMgr.Get(nodeID) == nil {
+		explanation.Reason = "node is not online"
+		return explanation
+	}
+	if ok, _ := rm.nodeMgr.IsStoppingNode(nodeID); ok {
+		explanation.Reason = "node has been stopped"
+		return explanation
+	}
+
+	// keep the same selection as `assignIncomingNode`.
+	target := rm.getResourceGroupByNodeID(nodeID)
+	if target != nil {
+		explanation.Reason = "node is already assigned to resource group"
+	} else {
+		target = rm.mustSelectAssignIncomingNodeTargetRG()
+		switch {
+		case target.MissingNumOfNodes() > 0:
+			explanation.Reason = "resource group misses the most nodes to satisfy its requests"
+		case target.ReachLimitNumOfNodes() > 0:
+			explanation.Reason = "requests of all resource groups are satisfied, resource group is the farthest from its limits"
+		default:
+			explanation.Reason = "all resource groups reach their limits, fallback to default resource group"
+		}
+	}
+	explanation.ResourceGroup = target.GetName()
+
+	rgNames := lo.Keys(rm.groups)
+	sort.Strings(rgNames)
+	explanation.Candidates = make([]RGPlacementDecision, 0, len(rgNames))
+	for _, rgName := range rgNames {
+		rg := rm.groups[rgName]
+		decision := RGPlacementDecision{
+			ResourceGroup: rgName,
+			Accepted:      rgName == target.GetName(),
+		}
+		switch {
+		case rg.MissingNumOfNodes() > 0:
+			decision.Reason = fmt.Sprintf("requests not satisfied, missing %d nodes", rg.MissingNumOfNodes())
+		case rg.ReachLimitNumOfNodes() > 0:
+			decision.Reason = fmt.Sprintf("requests satisfied, %d nodes below limits", rg.ReachLimitNumOfNodes())
+		default:
+			decision.Reason = fmt.Sprintf("limits reached, %d nodes with limits of %d nodes", rg.NodeNum(), rg.GetConfig().GetLimits().GetNodeNum())
+		}
+		if !decision.Accepted && rg.ReachLimitNumOfNodes() > 0 {
+			decision.Reason += fmt.Sprintf(", but node is placed into resource group %s", target.GetName())
+		}
+		explanation.Candidates = append(explanation.Candidates, decision)
+	}
+	return explanation
+}
+
 // findMaxRGWithGivenFilter find resource group with given filter and return the max one.
 // not efficient, but it's ok for low nodes and low resource group.
 func (rm *ResourceManager) findMaxRGWithGivenFilter(filter func(rg *ResourceGroup) bool, attr func(rg *ResourceGroup) int) *ResourceGroup {
@@ -876,6 +1226,23 @@ func (rm *ResourceManager) validateResourceGroupConfig(rgName string, cfg *rgpb.
 	return nil
 }
 
+// validateSharedSlots validate the replica slots lent by the nodes of resource group.
+// validateSharedSlots must be called after lock, because it will check with other resource group.
+func (rm *ResourceManager) validateSharedSlots(rgName string, slots map[string]int32) error {
+	for target, num := range slots {
+		if target == rgName {
+			return merr.WrapErrResourceGroupIllegalConfig(rgName, slots, fmt.Sprintf("resource group in shared slots %s should not be itself", rgName))
+		}
+		if rm.groups[target] == nil {
+			return merr.WrapErrResourceGroupIllegalConfig(rgName, slots, fmt.Sprintf("resource group in shared slots %s not exist", target))
+		}
+		if num < 0 {
+			return merr.WrapErrResourceGroupIllegalConfig(rgName, slots, "shared slots should not less than 0")
+		}
+	}
+	return nil
+}
+
 // validateResourceGroupIsDeletable validate a resource group is deletable.
 func (rm *ResourceManager) validateResourceGroupIsDeletable(rgName string) error {
 	// default rg is not deletable.
@@ -900,6 +1267,9 @@ func (rm *ResourceManager) validateResourceGroupIsDeletable(rgName string) error
 				return merr.WrapErrParameterInvalid("not `TransferTo` of resource group", rgName, fmt.Sprintf("resource group %s is used by %s's `TransferTo`, remove that configuration first", rgName, rg.name))
 			}
 		}
+		if _, ok := rg.sharedSlots[rgName]; ok {
+			return merr.WrapErrParameterInvalid("not in shared slots of resource group", rgName, fmt.Sprintf("resource group %s is used by %s's shared slots, remove that configuration first", rgName, rg.name))
+		}
 	}
 	return nil
 }
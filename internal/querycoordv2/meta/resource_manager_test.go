@@ -17,13 +17,17 @@ package meta
 
 import (
 	"testing"
+	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
 	"github.com/milvus-io/milvus/internal/kv"
 	etcdkv "github.com/milvus-io/milvus/internal/kv/etcd"
 	"github.com/milvus-io/milvus/internal/metastore/kv/querycoord"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
 	"github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/internal/querycoordv2/session"
 	"github.com/milvus-io/milvus/pkg/util/etcd"
@@ -208,6 +212,117 @@ func (suite *ResourceManagerSuite) TestManipulateResourceGroup() {
 	suite.NoError(err)
 }
 
+func (suite *ResourceManagerSuite) TestUpdateMaxReplicas() {
+	err := suite.manager.AddResourceGroup("rg1", newResourceGroupConfig(0, 0))
+	suite.NoError(err)
+	defer suite.manager.RemoveResourceGroup("rg1")
+	suite.Equal(0, suite.manager.GetMaxReplicas("rg1"))
+
+	// update the replica cap only, the config is kept.
+	err = suite.manager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg1": 2})
+	suite.NoError(err)
+	suite.Equal(2, suite.manager.GetMaxReplicas("rg1"))
+	suite.Equal(int32(0), suite.manager.GetResourceGroup("rg1").GetConfig().GetLimits().GetNodeNum())
+
+	// update the config only, the replica cap is kept.
+	err = suite.manager.UpdateResourceGroups(map[string]*rgpb.ResourceGroupConfig{
+		"rg1": newResourceGroupConfig(1, 1),
+	})
+	suite.NoError(err)
+	suite.Equal(2, suite.manager.GetMaxReplicas("rg1"))
+
+	// invalid replica cap.
+	err = suite.manager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg1": -1})
+	suite.ErrorIs(err, merr.ErrResourceGroupIllegalConfig)
+	err = suite.manager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg2": 1})
+	suite.ErrorIs(err, merr.ErrResourceGroupNotFound)
+	suite.Equal(2, suite.manager.GetMaxReplicas("rg1"))
+
+	// the replica cap is persisted in resource group meta.
+	manager := NewResourceManager(querycoord.NewCatalog(suite.kv), session.NewNodeManager())
+	suite.NoError(manager.Recover())
+	suite.Equal(2, manager.GetMaxReplicas("rg1"))
+
+	err = suite.manager.UpdateResourceGroupsWithMaxReplicas(map[string]*rgpb.ResourceGroupConfig{
+		"rg1": newResourceGroupConfig(0, 0),
+	}, map[string]int32{"rg1": 0})
+	suite.NoError(err)
+	suite.Equal(0, suite.manager.GetMaxReplicas("rg1"))
+}
+
+func (suite *ResourceManagerSuite) TestAddResourceGroupsFromTemplate() {
+	cfg := newResourceGroupConfig(1, 2)
+	err := suite.manager.AddResourceGroupsFromTemplate([]string{"rg1", "rg2", "rg2"}, cfg)
+	suite.NoError(err)
+	suite.Len(suite.manager.ListResourceGroups(), 3)
+	for _, rgName := range []string{"rg1", "rg2"} {
+		suite.Equal(int32(1), suite.manager.GetResourceGroup(rgName).GetConfig().GetRequests().GetNodeNum())
+		suite.Equal(int32(2), suite.manager.GetResourceGroup(rgName).GetConfig().GetLimits().GetNodeNum())
+	}
+
+	// existing groups with same configuration are skipped.
+	err = suite.manager.AddResourceGroupsFromTemplate([]string{"rg1", "rg3"}, newResourceGroupConfig(1, 2))
+	suite.NoError(err)
+	suite.Len(suite.manager.ListResourceGroups(), 4)
+
+	// none is created if any of them is invalid.
+	err = suite.manager.AddResourceGroupsFromTemplate([]string{"rg4", "rg1"}, newResourceGroupConfig(0, 0))
+	suite.ErrorIs(err, merr.ErrResourceGroupAlreadyExist)
+	err = suite.manager.AddResourceGroupsFromTemplate([]string{"rg4", ""}, cfg)
+	suite.ErrorIs(err, merr.ErrParameterMissing)
+	err = suite.manager.AddResourceGroupsFromTemplate([]string{"rg4", "rg5"}, newResourceGroupConfig(2, 1))
+	suite.ErrorIs(err, merr.ErrResourceGroupIllegalConfig)
+	suite.Len(suite.manager.ListResourceGroups(), 4)
+
+	// none is created if the save fails.
+	catalog := mocks.NewQueryCoordCatalog(suite.T())
+	catalog.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(errors.New("mock error"))
+	manager := NewResourceManager(catalog, session.NewNodeManager())
+	err = manager.AddResourceGroupsFromTemplate([]string{"rg1", "rg2"}, cfg)
+	suite.ErrorIs(err, merr.ErrResourceGroupServiceAvailable)
+	suite.Len(manager.ListResourceGroups(), 1)
+}
+
+func (suite *ResourceManagerSuite) TestNodeMaintenance() {
+	for _, node := range []int64{1, 2} {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   node,
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		suite.manager.HandleNodeUp(node)
+	}
+	suite.Equal(2, suite.manager.GetResourceGroup(DefaultResourceGroupName).NodeNum())
+	suite.NoError(suite.manager.nodeMgr.EnterMaintenance(1, time.Hour))
+	suite.NoError(suite.manager.nodeMgr.EnterMaintenance(2, time.Hour))
+
+	// the node restarted within maintenance keeps its resource group.
+	suite.manager.nodeMgr.Stopping(1)
+	suite.manager.HandleNodeStopping(1)
+	suite.manager.nodeMgr.Remove(1)
+	suite.manager.HandleNodeDown(1)
+	suite.True(suite.manager.ContainsNode(DefaultResourceGroupName, 1))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+		NodeID:   1,
+		Address:  "localhost",
+		Hostname: "localhost",
+	}))
+	suite.manager.HandleNodeUp(1)
+	suite.manager.nodeMgr.ExitMaintenance(1)
+	suite.manager.ReleaseExpiredMaintenance()
+	suite.True(suite.manager.ContainsNode(DefaultResourceGroupName, 1))
+
+	// the node not back after maintenance is removed.
+	suite.manager.nodeMgr.Remove(2)
+	suite.manager.HandleNodeDown(2)
+	suite.manager.ReleaseExpiredMaintenance()
+	suite.True(suite.manager.ContainsNode(DefaultResourceGroupName, 2))
+	suite.manager.nodeMgr.ExitMaintenance(2)
+	suite.manager.ReleaseExpiredMaintenance()
+	suite.False(suite.manager.ContainsNode(DefaultResourceGroupName, 2))
+	suite.Equal(1, suite.manager.GetResourceGroup(DefaultResourceGroupName).NodeNum())
+}
+
 func (suite *ResourceManagerSuite) TestNodeUpAndDown() {
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
 		NodeID:   1,
@@ -492,7 +607,7 @@ func (suite *ResourceManagerSuite) TestAutoRecover() {
 		"rg3":                    newResourceGroupConfig(10, 10),
 		"rg2":                    newResourceGroupConfig(80, 80),
 		"rg1":                    newResourceGroupConfig(10, 10),
-	})
+	}, nil)
 	suite.manager.AutoRecoverResourceGroup(DefaultResourceGroupName)
 	suite.manager.AutoRecoverResourceGroup("rg1")
 	suite.manager.AutoRecoverResourceGroup("rg2")
@@ -591,3 +706,131 @@ func (suite *ResourceManagerSuite) TestIncomingNode() {
 	suite.NoError(err)
 	suite.Len(nodes, 1)
 }
+
+func (suite *ResourceManagerSuite) TestHandleNodesUp() {
+	setup := func(manager *ResourceManager) {
+		for i := 1; i <= 6; i++ {
+			manager.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+				NodeID:   int64(i),
+				Address:  "localhost",
+				Hostname: "localhost",
+			}))
+		}
+		// node 7 is stopping and node 8 is offline.
+		manager.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   7,
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		manager.nodeMgr.Stopping(7)
+		err := manager.UpdateResourceGroups(map[string]*rgpb.ResourceGroupConfig{
+			DefaultResourceGroupName: newResourceGroupConfig(0, 0),
+		})
+		suite.NoError(err)
+		err = manager.AddResourceGroup("rg1", newResourceGroupConfig(2, 2))
+		suite.NoError(err)
+		err = manager.AddResourceGroup("rg2", newResourceGroupConfig(1, 2))
+		suite.NoError(err)
+	}
+	nodes := []int64{1, 2, 3, 4, 5, 6, 7, 8}
+	nodeNums := func(manager *ResourceManager) map[string]int {
+		nums := make(map[string]int)
+		for _, rgName := range manager.ListResourceGroups() {
+			nums[rgName] = manager.GetResourceGroup(rgName).NodeNum()
+		}
+		return nums
+	}
+
+	setup(suite.manager)
+	for _, node := range nodes {
+		suite.manager.HandleNodeUp(node)
+	}
+
+	// the batched assignment is saved once.
+	catalog := mocks.NewQueryCoordCatalog(suite.T())
+	catalog.EXPECT().SaveResourceGroup(mock.Anything).Return(nil)
+	catalog.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	manager := NewResourceManager(catalog, session.NewNodeManager())
+	setup(manager)
+	manager.HandleNodesUp(nodes)
+
+	// the nodes of same requests and limits are placed arbitrarily, so compare the number of nodes.
+	suite.Equal(map[string]int{DefaultResourceGroupName: 2, "rg1": 2, "rg2": 2}, nodeNums(suite.manager))
+	suite.Equal(nodeNums(suite.manager), nodeNums(manager))
+	for _, node := range nodes[:6] {
+		suite.NotNil(manager.getResourceGroupByNodeID(node))
+	}
+	suite.Nil(manager.getResourceGroupByNodeID(7))
+	suite.Nil(manager.getResourceGroupByNodeID(8))
+	suite.Equal(0, manager.CheckIncomingNodeNum())
+
+	// the nodes are left incoming if the assignment fails to save.
+	catalog = mocks.NewQueryCoordCatalog(suite.T())
+	catalog.EXPECT().SaveResourceGroup(mock.Anything).Return(nil)
+	catalog.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(errors.New("mock error")).Once()
+	manager = NewResourceManager(catalog, session.NewNodeManager())
+	setup(manager)
+	manager.HandleNodesUp(nodes)
+	suite.Equal(map[string]int{DefaultResourceGroupName: 0, "rg1": 0, "rg2": 0}, nodeNums(manager))
+	suite.Equal(6, manager.CheckIncomingNodeNum())
+}
+
+func (suite *ResourceManagerSuite) TestExplainNodePlacement() {
+	for i := 1; i <= 2; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+	}
+	err := suite.manager.UpdateResourceGroups(map[string]*rgpb.ResourceGroupConfig{
+		DefaultResourceGroupName: newResourceGroupConfig(0, 0),
+	})
+	suite.NoError(err)
+	err = suite.manager.AddResourceGroup("rg1", newResourceGroupConfig(1, 1))
+	suite.NoError(err)
+	err = suite.manager.AddResourceGroup("rg2", newResourceGroupConfig(0, 1))
+	suite.NoError(err)
+
+	// node 1 is placed into rg1 which misses nodes.
+	explanation := suite.manager.ExplainNodePlacement(1)
+	suite.Equal("rg1", explanation.ResourceGroup)
+	suite.Len(explanation.Candidates, 3)
+	suite.Equal(DefaultResourceGroupName, explanation.Candidates[0].ResourceGroup)
+	suite.False(explanation.Candidates[0].Accepted)
+	suite.Contains(explanation.Candidates[0].Reason, "limits reached")
+	suite.True(explanation.Candidates[1].Accepted)
+	suite.Contains(explanation.Candidates[1].Reason, "missing 1 nodes")
+	suite.False(explanation.Candidates[2].Accepted)
+	suite.Contains(explanation.Candidates[2].Reason, "but node is placed into resource group rg1")
+	suite.manager.HandleNodeUp(1)
+	suite.Equal("rg1", suite.manager.ExplainNodePlacement(1).ResourceGroup)
+	suite.Contains(suite.manager.ExplainNodePlacement(1).Reason, "already assigned")
+
+	// node 2 is placed into rg2 which doesn't reach limits.
+	explanation = suite.manager.ExplainNodePlacement(2)
+	suite.Equal("rg2", explanation.ResourceGroup)
+	suite.Contains(explanation.Candidates[1].Reason, "limits reached")
+	suite.Contains(explanation.Candidates[2].Reason, "1 nodes below limits")
+	suite.manager.HandleNodeUp(2)
+
+	// all resource groups reach limits, node 3 falls back to default resource group.
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+		NodeID:   3,
+		Address:  "localhost",
+		Hostname: "localhost",
+	}))
+	explanation = suite.manager.ExplainNodePlacement(3)
+	suite.Equal(DefaultResourceGroupName, explanation.ResourceGroup)
+	suite.Contains(explanation.Reason, "fallback to default resource group")
+	for _, decision := range explanation.Candidates {
+		suite.Equal(decision.ResourceGroup == DefaultResourceGroupName, decision.Accepted)
+		suite.Contains(decision.Reason, "limits reached")
+	}
+
+	// offline node is unassignable.
+	explanation = suite.manager.ExplainNodePlacement(4)
+	suite.Empty(explanation.ResourceGroup)
+	suite.Empty(explanation.Candidates)
+	suite.Equal("node is not online", explanation.Reason)
+}
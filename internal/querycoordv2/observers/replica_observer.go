@@ -85,7 +85,28 @@ func (ob *ReplicaObserver) schedule(ctx context.Context) {
 func (ob *ReplicaObserver) waitNodeChangedOrTimeout(ctx context.Context, listener *syncutil.VersionedListener) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, params.Params.QueryCoordCfg.CheckNodeInReplicaInterval.GetAsDuration(time.Second))
 	defer cancel()
-	listener.Wait(ctxWithTimeout)
+	if err := listener.Wait(ctxWithTimeout); err != nil {
+		return
+	}
+	// node flapping may trigger a burst of node changed events,
+	// coalesce them to do only one recovery with the final membership.
+	coalesceNodeChanged(ctxWithTimeout, listener, params.Params.QueryCoordCfg.NodeChangedCoalesceWindow.GetAsDuration(time.Millisecond))
+}
+
+// coalesceNodeChanged waits until there's no more node changed event in the window,
+// or the context is done.
+func coalesceNodeChanged(ctx context.Context, listener *syncutil.VersionedListener, window time.Duration) {
+	if window <= 0 {
+		return
+	}
+	for {
+		ctxWithWindow, cancel := context.WithTimeout(ctx, window)
+		err := listener.Wait(ctxWithWindow)
+		cancel()
+		if err != nil {
+			return
+		}
+	}
 }
 
 func (ob *ReplicaObserver) checkNodesInReplica() {
@@ -94,6 +115,11 @@ func (ob *ReplicaObserver) checkNodesInReplica() {
 	for _, collectionID := range collections {
 		utils.RecoverReplicaOfCollection(ob.meta, collectionID)
 	}
+	utils.UpdateSpareNodeMetrics(ob.meta)
+	utils.UpdateResourceGroupReplicaMetrics(ob.meta)
+	utils.UpdateReplicaMoveMetrics(ob.meta)
+	utils.UpdateReplicaAvailabilityMetrics(ob.meta)
+	utils.UpdateSharedNodeMetrics(ob.meta)
 
 	// check all ro nodes, remove it from replica if all segment/channel has been moved
 	for _, collectionID := range collections {
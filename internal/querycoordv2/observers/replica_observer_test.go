@@ -16,9 +16,11 @@
 package observers
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
@@ -31,6 +33,7 @@ import (
 	"github.com/milvus-io/milvus/internal/querycoordv2/utils"
 	"github.com/milvus-io/milvus/pkg/util/etcd"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/syncutil"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
@@ -200,3 +203,41 @@ func (suite *ReplicaObserverSuite) TearDownSuite() {
 func TestReplicaObserver(t *testing.T) {
 	suite.Run(t, new(ReplicaObserverSuite))
 }
+
+func TestCoalesceNodeChanged(t *testing.T) {
+	notifier := syncutil.NewVersionedNotifier()
+	listener := notifier.Listen(syncutil.VersionedListenAtLatest)
+
+	// keep coalescing while the events keep coming in the window.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		coalesceNodeChanged(context.Background(), listener, time.Second)
+	}()
+	// the events are much closer than the window, so the check holds regardless of scheduling delays.
+	for i := 0; i < 5; i++ {
+		notifier.NotifyAll()
+		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-done:
+			t.Fatal("coalescing ends in the burst of events")
+		default:
+		}
+	}
+	assert.Eventually(t, func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// no coalescing if window is disabled.
+	coalesceNodeChanged(context.Background(), listener, 0)
+
+	// bounded by context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	coalesceNodeChanged(ctx, listener, time.Hour)
+}
@@ -92,6 +92,9 @@ func (ob *ResourceObserver) checkAndRecoverResourceGroup() {
 	enableRGAutoRecover := params.Params.QueryCoordCfg.EnableRGAutoRecover.GetAsBool()
 	log.Debug("start to check resource group", zap.Bool("enableRGAutoRecover", enableRGAutoRecover), zap.Int("resourceGroupNum", len(rgNames)))
 
+	// the nodes kept during maintenance are released if they don't come back in time.
+	manager.ReleaseExpiredMaintenance()
+
 	// Check if there is any incoming node.
 	if manager.CheckIncomingNodeNum() > 0 {
 		log.Info("new incoming node is ready to be assigned...", zap.Int("incomingNodeNum", manager.CheckIncomingNodeNum()))
@@ -50,6 +50,7 @@ import (
 	"github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/internal/querycoordv2/session"
 	"github.com/milvus-io/milvus/internal/querycoordv2/task"
+	"github.com/milvus-io/milvus/internal/querycoordv2/utils"
 	"github.com/milvus-io/milvus/internal/types"
 	"github.com/milvus-io/milvus/internal/util/proxyutil"
 	"github.com/milvus-io/milvus/internal/util/sessionutil"
@@ -379,6 +380,11 @@ func (s *Server) initMeta() error {
 		log.Warn("failed to recover resource groups", zap.Error(err))
 		return err
 	}
+	err = utils.EnsureSystemResourceGroup(s.meta)
+	if err != nil {
+		log.Warn("failed to ensure system resource group", zap.Error(err))
+		return err
+	}
 
 	s.dist = &meta.DistributionManager{
 		SegmentDistManager: meta.NewSegmentDistManager(),
@@ -445,10 +451,12 @@ func (s *Server) startQueryCoord() error {
 	}
 	for _, node := range sessions {
 		s.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
-			NodeID:   node.ServerID,
-			Address:  node.Address,
-			Hostname: node.HostName,
-			Version:  node.Version,
+			NodeID:           node.ServerID,
+			Address:          node.Address,
+			Hostname:         node.HostName,
+			Version:          node.Version,
+			DisableStreaming: node.DisableStreaming,
+			Labels:           node.Labels,
 		}))
 		s.taskScheduler.AddExecutor(node.ServerID)
 
@@ -457,9 +465,9 @@ func (s *Server) startQueryCoord() error {
 		}
 	}
 	s.checkNodeStateInRG()
-	for _, node := range sessions {
-		s.handleNodeUp(node.ServerID)
-	}
+	s.handleNodesUp(lo.MapToSlice(sessions, func(_ string, node *sessionutil.Session) int64 {
+		return node.ServerID
+	}))
 
 	s.wg.Add(2)
 	go s.handleNodeUpLoop()
@@ -680,10 +688,12 @@ func (s *Server) watchNodes(revision int64) {
 					zap.String("nodeAddr", addr),
 				)
 				s.nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
-					NodeID:   nodeID,
-					Address:  addr,
-					Hostname: event.Session.HostName,
-					Version:  event.Session.Version,
+					NodeID:           nodeID,
+					Address:          addr,
+					Hostname:         event.Session.HostName,
+					Version:          event.Session.Version,
+					DisableStreaming: event.Session.DisableStreaming,
+					Labels:           event.Session.Labels,
 				}))
 				s.nodeUpEventChan <- nodeID
 				select {
@@ -742,18 +752,22 @@ func (s *Server) tryHandleNodeUp() {
 			zap.Strings("unhealthyReason", reasons))
 		return
 	}
+	nodes := make([]int64, 0, len(s.nodeUpEventChan))
 	for len(s.nodeUpEventChan) > 0 {
 		nodeID := <-s.nodeUpEventChan
 		if s.nodeMgr.Get(nodeID) != nil {
 			// only if all nodes are healthy, node up event will be handled
-			s.handleNodeUp(nodeID)
-			s.metricsCacheManager.InvalidateSystemInfoMetrics()
-			s.checkerController.Check()
+			nodes = append(nodes, nodeID)
 		} else {
 			log.Warn("node already down",
 				zap.Int64("nodeID", nodeID))
 		}
 	}
+	if len(nodes) > 0 {
+		s.handleNodesUp(nodes)
+		s.metricsCacheManager.InvalidateSystemInfoMetrics()
+		s.checkerController.Check()
+	}
 }
 
 func (s *Server) handleNodeUp(node int64) {
@@ -763,6 +777,16 @@ func (s *Server) handleNodeUp(node int64) {
 	s.meta.ResourceManager.HandleNodeUp(node)
 }
 
+// handleNodesUp is handleNodeUp of many nodes, the nodes are assigned to resource groups in batch,
+// so that the replicas are recovered once rather than per node.
+func (s *Server) handleNodesUp(nodes []int64) {
+	for _, node := range nodes {
+		s.taskScheduler.AddExecutor(node)
+		s.distController.StartDistInstance(s.ctx, node)
+	}
+	s.meta.ResourceManager.HandleNodesUp(nodes)
+}
+
 func (s *Server) handleNodeDown(node int64) {
 	s.taskScheduler.RemoveExecutor(node)
 	s.distController.Remove(node)
@@ -971,6 +971,8 @@ func (s *Server) CreateResourceGroup(ctx context.Context, req *milvuspb.CreateRe
 func (s *Server) UpdateResourceGroups(ctx context.Context, req *querypb.UpdateResourceGroupsRequest) (*commonpb.Status, error) {
 	log := log.Ctx(ctx).With(
 		zap.Any("rgName", req.GetResourceGroups()),
+		zap.Any("maxReplicas", req.GetMaxReplicas()),
+		zap.Any("sharedSlots", req.GetSharedSlots()),
 	)
 
 	log.Info("update resource group request received")
@@ -979,11 +981,32 @@ func (s *Server) UpdateResourceGroups(ctx context.Context, req *querypb.UpdateRe
 		return merr.Status(err), nil
 	}
 
-	err := s.meta.ResourceManager.UpdateResourceGroups(req.GetResourceGroups())
+	// the replica cap should not be less than the replicas already hosted by resource group.
+	for rgName, maxReplicas := range req.GetMaxReplicas() {
+		replicas := len(s.meta.ReplicaManager.GetByResourceGroup(rgName))
+		if maxReplicas > 0 && int(maxReplicas) < replicas {
+			err := merr.WrapErrResourceGroupIllegalConfig(rgName, maxReplicas,
+				fmt.Sprintf("max replicas should not less than %d replicas hosted by resource group", replicas))
+			log.Warn("failed to update resource group", zap.Error(err))
+			return merr.Status(err), nil
+		}
+	}
+
+	err := s.meta.ResourceManager.UpdateResourceGroupsWithMaxReplicas(req.GetResourceGroups(), req.GetMaxReplicas())
 	if err != nil {
 		log.Warn("failed to update resource group", zap.Error(err))
 		return merr.Status(err), nil
 	}
+
+	sharedSlots := make(map[string]map[string]int32, len(req.GetSharedSlots()))
+	for rgName, slots := range req.GetSharedSlots() {
+		sharedSlots[rgName] = slots.GetSlots()
+	}
+	err = s.meta.ResourceManager.UpdateSharedSlots(sharedSlots)
+	if err != nil {
+		log.Warn("failed to update shared slots of resource group", zap.Error(err))
+		return merr.Status(err), nil
+	}
 	return merr.Success(), nil
 }
 
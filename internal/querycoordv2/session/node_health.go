@@ -0,0 +1,34 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+// NodeHealthChecker decides whether a registered node is healthy enough to be assigned to replicas,
+// e.g. a node may be up but unable to load any data since its disk is full.
+type NodeHealthChecker interface {
+	IsHealthy(nodeID int64) bool
+}
+
+// registeredNodeHealthChecker treats all registered nodes as healthy.
+type registeredNodeHealthChecker struct{}
+
+func NewRegisteredNodeHealthChecker() NodeHealthChecker {
+	return registeredNodeHealthChecker{}
+}
+
+func (registeredNodeHealthChecker) IsHealthy(nodeID int64) bool {
+	return true
+}
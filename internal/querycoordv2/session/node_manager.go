@@ -18,6 +18,7 @@ package session
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -39,11 +40,19 @@ type Manager interface {
 
 	Suspend(nodeID int64) error
 	Resume(nodeID int64) error
+
+	EnterMaintenance(nodeID int64, window time.Duration) error
+	ExitMaintenance(nodeID int64)
+	IsInMaintenance(nodeID int64) bool
+	GetMaintenanceNodes() []int64
 }
 
 type NodeManager struct {
 	mu    sync.RWMutex
 	nodes map[int64]*NodeInfo
+	// maintenance is the deadline of the maintenance window of each node, which survives the removal of node
+	// so that the node restarted within the window keeps its replica assignments.
+	maintenance map[int64]time.Time
 }
 
 func (m *NodeManager) Add(node *NodeInfo) {
@@ -104,6 +113,58 @@ func (m *NodeManager) Resume(nodeID int64) error {
 	}
 }
 
+// EnterMaintenance puts the registered node into maintenance for window, e.g. before restarting it during rolling
+// upgrade. The node stopped or gone within the window is kept in its resource group and replicas, instead of
+// triggering the recovery of replicas. Entering again extends the window.
+func (m *NodeManager) EnterMaintenance(nodeID int64, window time.Duration) error {
+	if window <= 0 {
+		return merr.WrapErrParameterInvalidMsg("maintenance window of node %d should be positive, got %s", nodeID, window)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.nodes[nodeID]; !ok {
+		return merr.WrapErrNodeNotFound(nodeID)
+	}
+	m.maintenance[nodeID] = time.Now().Add(window)
+	log.Info("node enters maintenance", zap.Int64("nodeID", nodeID), zap.Duration("window", window))
+	return nil
+}
+
+// ExitMaintenance ends the maintenance of node, the normal recovery resumes if the node is absent.
+func (m *NodeManager) ExitMaintenance(nodeID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.maintenance[nodeID]; ok {
+		delete(m.maintenance, nodeID)
+		log.Info("node exits maintenance", zap.Int64("nodeID", nodeID))
+	}
+}
+
+// IsInMaintenance returns true if the node is in maintenance and the window is not expired.
+func (m *NodeManager) IsInMaintenance(nodeID int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	deadline, ok := m.maintenance[nodeID]
+	return ok && time.Now().Before(deadline)
+}
+
+// GetMaintenanceNodes returns the nodes in maintenance in ascending order, the expired ones are cleared.
+func (m *NodeManager) GetMaintenanceNodes() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	ret := make([]int64, 0, len(m.maintenance))
+	for nodeID, deadline := range m.maintenance {
+		if now.Before(deadline) {
+			ret = append(ret, nodeID)
+		} else {
+			delete(m.maintenance, nodeID)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
 func (m *NodeManager) IsStoppingNode(nodeID int64) (bool, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -133,7 +194,8 @@ func (m *NodeManager) GetAll() []*NodeInfo {
 
 func NewNodeManager() *NodeManager {
 	return &NodeManager{
-		nodes: make(map[int64]*NodeInfo),
+		nodes:       make(map[int64]*NodeInfo),
+		maintenance: make(map[int64]time.Time),
 	}
 }
 
@@ -150,6 +212,10 @@ type ImmutableNodeInfo struct {
 	Address  string
 	Hostname string
 	Version  semver.Version
+	// DisableStreaming is set if the node only serves reads of sealed data.
+	DisableStreaming bool
+	// Labels are the arbitrary labels of node, which are matched by the node selectors of collections.
+	Labels map[string]string
 }
 
 const (
@@ -188,6 +254,26 @@ func (n *NodeInfo) Hostname() string {
 	return n.immutableInfo.Hostname
 }
 
+// IsStreamingCapable returns whether the node is able to take the streaming role of replica.
+func (n *NodeInfo) IsStreamingCapable() bool {
+	return !n.immutableInfo.DisableStreaming
+}
+
+func (n *NodeInfo) Labels() map[string]string {
+	return n.immutableInfo.Labels
+}
+
+// MatchLabels returns whether the node has all the labels of selector with the same values,
+// the node matches the empty selector.
+func (n *NodeInfo) MatchLabels(selector map[string]string) bool {
+	for key, value := range selector {
+		if label, ok := n.immutableInfo.Labels[key]; !ok || label != value {
+			return false
+		}
+	}
+	return true
+}
+
 func (n *NodeInfo) SegmentCnt() int {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
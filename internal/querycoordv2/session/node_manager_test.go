@@ -83,6 +83,38 @@ func (s *NodeManagerSuite) TestNodeOperation() {
 	s.Equal(NodeStateNormal, node.GetState())
 }
 
+func (s *NodeManagerSuite) TestMaintenance() {
+	s.nodeManager.Add(NewNodeInfo(ImmutableNodeInfo{
+		NodeID:   1,
+		Address:  "localhost",
+		Hostname: "localhost",
+	}))
+	s.ErrorIs(s.nodeManager.EnterMaintenance(2, time.Minute), merr.ErrNodeNotFound)
+	s.ErrorIs(s.nodeManager.EnterMaintenance(1, 0), merr.ErrParameterInvalid)
+
+	s.NoError(s.nodeManager.EnterMaintenance(1, time.Minute))
+	s.True(s.nodeManager.IsInMaintenance(1))
+	// the maintenance survives the removal of node.
+	s.nodeManager.Remove(1)
+	s.True(s.nodeManager.IsInMaintenance(1))
+	s.Equal([]int64{1}, s.nodeManager.GetMaintenanceNodes())
+	s.nodeManager.ExitMaintenance(1)
+	s.False(s.nodeManager.IsInMaintenance(1))
+	s.Empty(s.nodeManager.GetMaintenanceNodes())
+
+	// the expired maintenance is over.
+	s.nodeManager.Add(NewNodeInfo(ImmutableNodeInfo{
+		NodeID:   1,
+		Address:  "localhost",
+		Hostname: "localhost",
+	}))
+	s.NoError(s.nodeManager.EnterMaintenance(1, time.Millisecond))
+	s.Eventually(func() bool {
+		return !s.nodeManager.IsInMaintenance(1)
+	}, time.Second, 10*time.Millisecond)
+	s.Empty(s.nodeManager.GetMaintenanceNodes())
+}
+
 func (s *NodeManagerSuite) TestNodeInfo() {
 	node := NewNodeInfo(ImmutableNodeInfo{
 		NodeID:   1,
@@ -105,6 +137,22 @@ func (s *NodeManagerSuite) TestNodeInfo() {
 	s.NotNil(node.LastHeartbeat())
 }
 
+func (s *NodeManagerSuite) TestMatchLabels() {
+	node := NewNodeInfo(ImmutableNodeInfo{
+		NodeID: 1,
+		Labels: map[string]string{"gpu": "true", "ssd": "true"},
+	})
+	s.True(node.MatchLabels(nil))
+	s.True(node.MatchLabels(map[string]string{"gpu": "true"}))
+	s.True(node.MatchLabels(map[string]string{"gpu": "true", "ssd": "true"}))
+	s.False(node.MatchLabels(map[string]string{"gpu": "false"}))
+	s.False(node.MatchLabels(map[string]string{"gpu": "true", "zone": "a"}))
+
+	node = NewNodeInfo(ImmutableNodeInfo{NodeID: 2})
+	s.True(node.MatchLabels(nil))
+	s.False(node.MatchLabels(map[string]string{"gpu": "true"}))
+}
+
 func TestNodeManagerSuite(t *testing.T) {
 	suite.Run(t, new(NodeManagerSuite))
 }
@@ -0,0 +1,171 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// Discrepancy is a replica or resource group whose in-memory state differs from the one in store.
+type Discrepancy struct {
+	// ReplicaID is the replica differed, 0 if the discrepancy is about a resource group.
+	ReplicaID     int64
+	ResourceGroup string
+	Reason        string
+}
+
+// AuditMeta reloads the replicas and resource groups from catalog, and reports where they differ from
+// the in-memory ones. Nothing is changed, neither in memory nor in store.
+// A write in flight may be reported as a discrepancy, so rerun the audit before repairing it.
+func AuditMeta(m *meta.Meta) ([]Discrepancy, error) {
+	storedReplicas, err := m.Catalog.GetReplicas()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load replicas from store")
+	}
+	storedRGs, err := m.Catalog.GetResourceGroups()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load resource groups from store")
+	}
+
+	discrepancies := make([]Discrepancy, 0)
+	add := func(replicaID int64, rgName string, format string, args ...any) {
+		discrepancies = append(discrepancies, Discrepancy{
+			ReplicaID:     replicaID,
+			ResourceGroup: rgName,
+			Reason:        fmt.Sprintf(format, args...),
+		})
+	}
+
+	stored := make(map[int64]*querypb.Replica, len(storedReplicas))
+	for _, replica := range storedReplicas {
+		stored[replica.GetID()] = replica
+	}
+	for _, replica := range m.ReplicaManager.GetAllReplicas() {
+		id := replica.GetID()
+		pb, ok := stored[id]
+		if !ok {
+			add(id, replica.GetResourceGroup(), "replica %d of collection %d is missing in store", id, replica.GetCollectionID())
+			continue
+		}
+		delete(stored, id)
+		for _, reason := range diffReplica(replica, pb) {
+			add(id, replica.GetResourceGroup(), "replica %d %s", id, reason)
+		}
+	}
+	for id, pb := range stored {
+		add(id, pb.GetResourceGroup(), "replica %d of collection %d is missing in memory", id, pb.GetCollectionID())
+	}
+
+	rgs := make(map[string]*querypb.ResourceGroup, len(storedRGs))
+	for _, rg := range storedRGs {
+		rgs[rg.GetName()] = rg
+	}
+	for _, rgName := range m.ResourceManager.ListResourceGroups() {
+		rg := m.ResourceManager.GetResourceGroup(rgName)
+		if rg == nil {
+			continue
+		}
+		pb, ok := rgs[rgName]
+		if !ok {
+			// the default resource group is only saved once it holds nodes.
+			if rgName != meta.DefaultResourceGroupName || rg.NodeNum() > 0 {
+				add(0, rgName, "resource group %s is missing in store", rgName)
+			}
+			continue
+		}
+		delete(rgs, rgName)
+		if diff := diffNodes(rg.GetNodes(), pb.GetNodes()); diff != "" {
+			add(0, rgName, "resource group %s has nodes %s", rgName, diff)
+		}
+		if !proto.Equal(rg.GetConfig(), pb.GetConfig()) {
+			add(0, rgName, "resource group %s has config %v in memory but %v in store", rgName, rg.GetConfig(), pb.GetConfig())
+		}
+	}
+	for rgName := range rgs {
+		add(0, rgName, "resource group %s is missing in memory", rgName)
+	}
+
+	sort.SliceStable(discrepancies, func(i, j int) bool {
+		if discrepancies[i].ReplicaID != discrepancies[j].ReplicaID {
+			return discrepancies[i].ReplicaID < discrepancies[j].ReplicaID
+		}
+		if discrepancies[i].ResourceGroup != discrepancies[j].ResourceGroup {
+			return discrepancies[i].ResourceGroup < discrepancies[j].ResourceGroup
+		}
+		return discrepancies[i].Reason < discrepancies[j].Reason
+	})
+	return discrepancies, nil
+}
+
+// diffReplica returns the differences of in-memory replica from the one in store.
+func diffReplica(replica *meta.Replica, pb *querypb.Replica) []string {
+	reasons := make([]string, 0)
+	if replica.GetCollectionID() != pb.GetCollectionID() {
+		reasons = append(reasons, fmt.Sprintf("belongs to collection %d in memory but %d in store",
+			replica.GetCollectionID(), pb.GetCollectionID()))
+	}
+	// the replica without resource group is recovered into the default one.
+	storedRG := pb.GetResourceGroup()
+	if storedRG == "" {
+		storedRG = meta.DefaultResourceGroupName
+	}
+	if replica.GetResourceGroup() != storedRG {
+		reasons = append(reasons, fmt.Sprintf("is in resource group %s in memory but %s in store",
+			replica.GetResourceGroup(), storedRG))
+	}
+	if diff := diffNodes(replica.GetRWNodes(), pb.GetNodes()); diff != "" {
+		reasons = append(reasons, "has rw nodes "+diff)
+	}
+	if diff := diffNodes(replica.GetRONodes(), pb.GetRoNodes()); diff != "" {
+		reasons = append(reasons, "has ro nodes "+diff)
+	}
+	if diff := diffNodes(replica.GetPinnedNodes(), pb.GetPinnedNodes()); diff != "" {
+		reasons = append(reasons, "has pinned nodes "+diff)
+	}
+	if replica.IsStandby() != pb.GetStandby() {
+		reasons = append(reasons, fmt.Sprintf("is standby %t in memory but %t in store", replica.IsStandby(), pb.GetStandby()))
+	}
+	if replica.GetReadWeight() != pb.GetReadWeight() && pb.GetReadWeight() > 0 {
+		reasons = append(reasons, fmt.Sprintf("has read weight %v in memory but %v in store", replica.GetReadWeight(), pb.GetReadWeight()))
+	}
+	if replica.IsDegraded() != pb.GetDegraded() {
+		reasons = append(reasons, fmt.Sprintf("is degraded %t in memory but %t in store", replica.IsDegraded(), pb.GetDegraded()))
+	}
+	return reasons
+}
+
+// diffNodes describes the nodes only in memory and the ones only in store, empty if they are the same.
+func diffNodes(inMemory, inStore []int64) string {
+	memory := typeutil.NewUniqueSet(inMemory...)
+	store := typeutil.NewUniqueSet(inStore...)
+	onlyInMemory := memory.Complement(store).Collect()
+	onlyInStore := store.Complement(memory).Collect()
+	if len(onlyInMemory) == 0 && len(onlyInStore) == 0 {
+		return ""
+	}
+	sort.Slice(onlyInMemory, func(i, j int) bool { return onlyInMemory[i] < onlyInMemory[j] })
+	sort.Slice(onlyInStore, func(i, j int) bool { return onlyInStore[i] < onlyInStore[j] })
+	return fmt.Sprintf("%v only in memory and %v only in store", onlyInMemory, onlyInStore)
+}
@@ -0,0 +1,113 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
+	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestAuditMeta(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	rgConfig := &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+	}
+	m.ResourceManager.AddResourceGroup("rg1", rgConfig)
+	for i := 1; i <= 2; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	m.ReplicaManager.Put(
+		meta.NewReplica(&querypb.Replica{ID: 1, CollectionID: 1, Nodes: []int64{1}, ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 2, CollectionID: 1, Nodes: []int64{2}, RoNodes: []int64{100}, ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 3, CollectionID: 2, ResourceGroup: meta.DefaultResourceGroupName}),
+	)
+
+	t.Run("consistent", func(t *testing.T) {
+		store.EXPECT().GetReplicas().Return([]*querypb.Replica{
+			{ID: 1, CollectionID: 1, Nodes: []int64{1}, ResourceGroup: "rg1"},
+			{ID: 2, CollectionID: 1, Nodes: []int64{2}, RoNodes: []int64{100}, ResourceGroup: "rg1"},
+			// the replica without resource group is in the default one.
+			{ID: 3, CollectionID: 2},
+		}, nil).Once()
+		store.EXPECT().GetResourceGroups().Return([]*querypb.ResourceGroup{
+			{Name: "rg1", Nodes: []int64{2, 1}, Config: rgConfig},
+		}, nil).Once()
+
+		discrepancies, err := AuditMeta(m)
+		assert.NoError(t, err)
+		assert.Empty(t, discrepancies)
+	})
+
+	t.Run("inconsistent", func(t *testing.T) {
+		store.EXPECT().GetReplicas().Return([]*querypb.Replica{
+			{ID: 1, CollectionID: 1, Nodes: []int64{1}, ResourceGroup: "rg1", Degraded: true},
+			{ID: 2, CollectionID: 1, Nodes: []int64{2}, ResourceGroup: "rg2"},
+			{ID: 4, CollectionID: 3, ResourceGroup: "rg1"},
+		}, nil).Once()
+		store.EXPECT().GetResourceGroups().Return([]*querypb.ResourceGroup{
+			{Name: "rg1", Nodes: []int64{1, 3}, Config: &rgpb.ResourceGroupConfig{}},
+			{Name: "rg2"},
+		}, nil).Once()
+
+		discrepancies, err := AuditMeta(m)
+		assert.NoError(t, err)
+		assert.Contains(t, discrepancies[0].Reason, "resource group rg1 has config")
+		assert.Equal(t, []string{
+			"resource group rg1 has nodes [2] only in memory and [3] only in store",
+			"resource group rg2 is missing in memory",
+			"replica 1 is degraded false in memory but true in store",
+			"replica 2 has ro nodes [100] only in memory and [] only in store",
+			"replica 2 is in resource group rg1 in memory but rg2 in store",
+			"replica 3 of collection 2 is missing in store",
+			"replica 4 of collection 3 is missing in memory",
+		}, lo.Map(discrepancies[1:], func(d Discrepancy, _ int) string {
+			return d.Reason
+		}))
+		assert.Equal(t, "rg1", discrepancies[5].ResourceGroup)
+		assert.Equal(t, int64(2), discrepancies[5].ReplicaID)
+	})
+
+	t.Run("store failure", func(t *testing.T) {
+		store.EXPECT().GetReplicas().Return(nil, errors.New("mock error")).Once()
+		_, err := AuditMeta(m)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,248 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/metrics"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// ResourceGroupCapacity is the node accounting of a resource group.
+type ResourceGroupCapacity struct {
+	ResourceGroup string
+	// AvailableNodes is the number of nodes in resource group.
+	AvailableNodes int
+	// SharedNodes is the number of shared nodes lent to resource group by the other resource groups.
+	SharedNodes int
+	// StreamingNodes is the number of nodes in resource group which are able to take the streaming role.
+	StreamingNodes int
+	// CommittedNodes is the number of nodes in resource group which are used by replicas.
+	CommittedNodes int
+	// Headroom is the number of nodes in resource group which are not used by any replica.
+	Headroom int
+	// Replicas is the number of replicas of all collections hosted by resource group.
+	Replicas int
+	// MaxReplicas is the maximum number of replicas the resource group can host, 0 means no limit.
+	MaxReplicas int
+}
+
+// CapacityReport summarizes the capacity of resource groups.
+type CapacityReport struct {
+	Groups map[string]*ResourceGroupCapacity
+	// NotFound is the resource groups which are not found.
+	NotFound []string
+}
+
+// ClusterCapacityReport summarizes the available nodes, nodes committed to replicas and headroom
+// of the given resource groups, all resource groups are reported if no resource group is given.
+func ClusterCapacityReport(m *meta.Meta, resourceGroups []string) CapacityReport {
+	if len(resourceGroups) == 0 {
+		resourceGroups = m.ResourceManager.ListResourceGroups()
+	}
+
+	report := CapacityReport{
+		Groups:   make(map[string]*ResourceGroupCapacity),
+		NotFound: make([]string, 0),
+	}
+	view := newSharedNodeView(m)
+	for _, rgName := range typeutil.NewSet(resourceGroups...).Collect() {
+		if !m.ContainResourceGroup(rgName) {
+			report.NotFound = append(report.NotFound, rgName)
+			continue
+		}
+		nodes, err := m.ResourceManager.GetNodes(rgName)
+		if err != nil {
+			report.NotFound = append(report.NotFound, rgName)
+			continue
+		}
+		rgNodes := typeutil.NewUniqueSet(nodes...)
+		committed := typeutil.NewUniqueSet()
+		replicas := m.ReplicaManager.GetByResourceGroup(rgName)
+		for _, replica := range replicas {
+			for _, node := range replica.GetNodes() {
+				if rgNodes.Contain(node) {
+					committed.Insert(node)
+				}
+			}
+		}
+		streamingNodes := lo.CountBy(nodes, m.ReplicaManager.IsStreamingCapable)
+		report.Groups[rgName] = &ResourceGroupCapacity{
+			ResourceGroup:  rgName,
+			AvailableNodes: rgNodes.Len(),
+			SharedNodes:    view.lentNodeNum(rgName),
+			StreamingNodes: streamingNodes,
+			CommittedNodes: committed.Len(),
+			Headroom:       rgNodes.Len() - committed.Len(),
+			Replicas:       len(replicas),
+			MaxReplicas:    m.ResourceManager.GetMaxReplicas(rgName),
+		}
+	}
+	return report
+}
+
+// CheckReplicas checks if the resource groups can hold the replicas with their own and shared nodes,
+// replicas of same collection should be placed on different nodes.
+func (r CapacityReport) CheckReplicas(replicaNumInRG map[string]int) error {
+	for rgName, num := range replicaNumInRG {
+		capacity, ok := r.Groups[rgName]
+		if !ok {
+			return errors.Wrapf(ErrGetNodesFromRG, "resource group %s not found", rgName)
+		}
+		if available := capacity.AvailableNodes + capacity.SharedNodes; num > available {
+			return errors.Wrapf(meta.ErrNodeNotEnough, "need %d more nodes in %s", num-available, rgName)
+		}
+	}
+	return nil
+}
+
+// CheckStreamingNodes checks if the resource groups have a streaming capable node for each role split replica,
+// replicas of same collection should be placed on different nodes.
+func (r CapacityReport) CheckStreamingNodes(replicaNumInRG map[string]int) error {
+	for rgName, num := range replicaNumInRG {
+		capacity, ok := r.Groups[rgName]
+		if !ok {
+			return errors.Wrapf(ErrGetNodesFromRG, "resource group %s not found", rgName)
+		}
+		if num > capacity.StreamingNodes {
+			return errors.Wrapf(meta.ErrNodeNotEnough, "need %d more streaming capable nodes in %s", num-capacity.StreamingNodes, rgName)
+		}
+	}
+	return nil
+}
+
+// CheckReplicaCaps checks if the resource groups can host more replicas without exceeding their replica caps.
+func (r CapacityReport) CheckReplicaCaps(replicaNumInRG map[string]int) error {
+	for rgName, num := range replicaNumInRG {
+		capacity, ok := r.Groups[rgName]
+		if !ok {
+			return errors.Wrapf(ErrGetNodesFromRG, "resource group %s not found", rgName)
+		}
+		if capacity.MaxReplicas > 0 && capacity.Replicas+num > capacity.MaxReplicas {
+			return errors.Wrapf(ErrRGReplicaCapExceeded, "resource group %s hosts %d replicas, can't spawn %d more, max replicas is %d",
+				rgName, capacity.Replicas, num, capacity.MaxReplicas)
+		}
+	}
+	return nil
+}
+
+// RecommendReplicaCount recommends the replica number of collection to serve targetQPS, given the QPS a node can serve.
+// A query is served by all the nodes of one replica, so a replica serves about perNodeQPS, and at least one replica
+// is recommended. The recommendation is bounded by the nodes of the resource groups the collection is placed in,
+// which are its current resource groups if loaded, its affined ones, or the default one, since the replicas of
+// a collection can't share nodes. The replica caps of the resource groups are respected too.
+// Returns 0 if the resource groups can't host any replica.
+func RecommendReplicaCount(m *meta.Meta, collection int64, targetQPS, perNodeQPS float64) int {
+	wanted := 1
+	if targetQPS > 0 && perNodeQPS > 0 {
+		wanted = max(int(math.Ceil(targetQPS/perNodeQPS)), 1)
+	}
+
+	rgNames := m.ReplicaManager.GetResourceGroupByCollection(collection).Collect()
+	if len(rgNames) == 0 {
+		rgNames = m.CollectionManager.GetResourceGroupAffinity(collection)
+	}
+	if len(rgNames) == 0 {
+		rgNames = []string{meta.DefaultResourceGroupName}
+	}
+	// each replica needs a node of each required role.
+	roleNum := len(meta.RequiredNodeRoles(paramtable.Get().QueryCoordCfg.EnableReplicaRoleSplit.GetAsBool()))
+	replicas := m.ReplicaManager.GetByCollection(collection)
+	limit := 0
+	for _, capacity := range ClusterCapacityReport(m, rgNames).Groups {
+		num := (capacity.AvailableNodes + capacity.SharedNodes) / roleNum
+		if capacity.MaxReplicas > 0 {
+			// the slots taken by the replicas of the collection itself are counted as free.
+			own := lo.CountBy(replicas, func(replica *meta.Replica) bool {
+				return replica.GetResourceGroup() == capacity.ResourceGroup
+			})
+			num = min(num, max(capacity.MaxReplicas-capacity.Replicas+own, 0))
+		}
+		limit += num
+	}
+	return min(wanted, limit)
+}
+
+// PlacementFairness measures how evenly the replicas are spread across the nodes in resource group,
+// by the coefficient of variation of the number of replicas served by each node as rw node.
+// 0 means perfectly even, and 0 is also returned if the resource group has no node or no replica.
+func PlacementFairness(m *meta.Meta, rgName string) float64 {
+	nodes, err := m.ResourceManager.GetNodes(rgName)
+	if err != nil || len(nodes) == 0 {
+		return 0
+	}
+	replicaNum := make(map[int64]int, len(nodes))
+	for _, node := range nodes {
+		replicaNum[node] = 0
+	}
+	for _, replica := range m.ReplicaManager.GetByResourceGroup(rgName) {
+		replica.RangeOverRWNodes(func(node int64) bool {
+			if _, ok := replicaNum[node]; ok {
+				replicaNum[node]++
+			}
+			return true
+		})
+	}
+
+	sum := 0
+	for _, num := range replicaNum {
+		sum += num
+	}
+	if sum == 0 {
+		return 0
+	}
+	mean := float64(sum) / float64(len(replicaNum))
+	variance := 0.0
+	for _, num := range replicaNum {
+		variance += (float64(num) - mean) * (float64(num) - mean)
+	}
+	variance /= float64(len(replicaNum))
+	return math.Sqrt(variance) / mean
+}
+
+// UpdateResourceGroupReplicaMetrics updates the number of replicas hosted by each resource group,
+// and the placement fairness of them.
+func UpdateResourceGroupReplicaMetrics(m *meta.Meta) {
+	for _, rgName := range m.ResourceManager.ListResourceGroups() {
+		metrics.QueryCoordResourceGroupReplicaNum.WithLabelValues(rgName).Set(float64(len(m.ReplicaManager.GetByResourceGroup(rgName))))
+		metrics.QueryCoordResourceGroupPlacementFairness.WithLabelValues(rgName).Set(PlacementFairness(m, rgName))
+	}
+}
+
+// UpdateReplicaMoveMetrics updates the progress of replica moves, which are applied in waves if the moves are throttled.
+func UpdateReplicaMoveMetrics(m *meta.Meta) {
+	progress := m.ReplicaManager.GetMoveProgress()
+	metrics.QueryCoordReplicaMoveNum.WithLabelValues("in_flight").Set(float64(progress.InFlight))
+	metrics.QueryCoordReplicaMoveNum.WithLabelValues("deferred").Set(float64(progress.Deferred))
+}
+
+// UpdateReplicaAvailabilityMetrics updates the cumulative healthy and tracked time of collections,
+// the availability SLO of a collection is the ratio of them.
+func UpdateReplicaAvailabilityMetrics(m *meta.Meta) {
+	for collectionID, availability := range m.ReplicaManager.GetAllAvailability() {
+		label := strconv.FormatInt(collectionID, 10)
+		metrics.QueryCoordReplicaAvailabilitySeconds.WithLabelValues(label, "healthy").Set(availability.HealthyTime.Seconds())
+		metrics.QueryCoordReplicaAvailabilitySeconds.WithLabelValues(label, "tracked").Set(availability.TrackedTime.Seconds())
+	}
+}
@@ -0,0 +1,125 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
+	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestClusterCapacityReport(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	m.ResourceManager.AddResourceGroup("rg1", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 3},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 3},
+	})
+	m.ResourceManager.AddResourceGroup("rg2", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 1},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 1},
+	})
+	for i := 1; i <= 4; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+			// node 4 only serves reads.
+			DisableStreaming: i == 4,
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	rg1Nodes, err := m.ResourceManager.GetNodes("rg1")
+	assert.NoError(t, err)
+	m.ReplicaManager.Put(meta.NewReplica(&querypb.Replica{
+		ID:            1,
+		CollectionID:  1,
+		Nodes:         rg1Nodes[:2],
+		ResourceGroup: "rg1",
+	}))
+
+	report := ClusterCapacityReport(m, []string{"rg1", "rg2", "rg3"})
+	assert.Equal(t, []string{"rg3"}, report.NotFound)
+	assert.Equal(t, 3, report.Groups["rg1"].AvailableNodes)
+	assert.Equal(t, 2, report.Groups["rg1"].CommittedNodes)
+	assert.Equal(t, 1, report.Groups["rg1"].Headroom)
+	assert.Equal(t, 1, report.Groups["rg2"].AvailableNodes)
+	assert.Equal(t, 0, report.Groups["rg2"].CommittedNodes)
+	assert.Equal(t, 1, report.Groups["rg2"].Headroom)
+
+	assert.NoError(t, report.CheckReplicas(map[string]int{"rg1": 3, "rg2": 1}))
+	err = report.CheckReplicas(map[string]int{"rg2": 3})
+	assert.ErrorIs(t, err, meta.ErrNodeNotEnough)
+	assert.ErrorContains(t, err, "need 2 more nodes in rg2")
+	assert.ErrorIs(t, report.CheckReplicas(map[string]int{"rg3": 1}), ErrGetNodesFromRG)
+
+	// streaming capable nodes.
+	rgName := lo.Ternary(lo.Contains(rg1Nodes, 4), "rg1", "rg2")
+	available := report.Groups[rgName].AvailableNodes
+	assert.Equal(t, available-1, report.Groups[rgName].StreamingNodes)
+	assert.NoError(t, report.CheckStreamingNodes(map[string]int{rgName: available - 1}))
+	err = report.CheckStreamingNodes(map[string]int{rgName: available})
+	assert.ErrorIs(t, err, meta.ErrNodeNotEnough)
+	assert.ErrorContains(t, err, "need 1 more streaming capable nodes")
+	assert.ErrorIs(t, report.CheckStreamingNodes(map[string]int{"rg3": 1}), ErrGetNodesFromRG)
+
+	// all resource groups are reported if not specified.
+	report = ClusterCapacityReport(m, nil)
+	assert.Len(t, report.Groups, 3)
+
+	// rg1 has nodes serving 1, 1 and 0 replicas, rg2 has no replica.
+	assert.InDelta(t, 0.7071, PlacementFairness(m, "rg1"), 0.0001)
+	assert.Equal(t, 0.0, PlacementFairness(m, "rg2"))
+	assert.Equal(t, 0.0, PlacementFairness(m, "rg3"))
+
+	// replica caps
+	assert.Equal(t, 1, report.Groups["rg1"].Replicas)
+	assert.NoError(t, report.CheckReplicaCaps(map[string]int{"rg1": 2}))
+	assert.NoError(t, m.ResourceManager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg1": 2}))
+	report = ClusterCapacityReport(m, []string{"rg1", "rg2"})
+	assert.Equal(t, 2, report.Groups["rg1"].MaxReplicas)
+	assert.NoError(t, report.CheckReplicaCaps(map[string]int{"rg1": 1, "rg2": 3}))
+	err = report.CheckReplicaCaps(map[string]int{"rg1": 2})
+	assert.ErrorIs(t, err, ErrRGReplicaCapExceeded)
+	assert.ErrorContains(t, err, "max replicas is 2")
+
+	// recommendation, bounded by the 3 nodes of rg1 where collection 1 is placed.
+	assert.NoError(t, m.ResourceManager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg1": 0}))
+	assert.Equal(t, 2, RecommendReplicaCount(m, 1, 50, 30))
+	assert.Equal(t, 3, RecommendReplicaCount(m, 1, 1000, 30))
+	assert.Equal(t, 1, RecommendReplicaCount(m, 1, 0, 30))
+	// the replica of collection 1 itself is not counted against the replica cap.
+	assert.NoError(t, m.ResourceManager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg1": 2}))
+	assert.Equal(t, 2, RecommendReplicaCount(m, 1, 1000, 30))
+	// collection 2 goes to the default resource group, which has no node.
+	assert.Equal(t, 0, RecommendReplicaCount(m, 2, 50, 30))
+}
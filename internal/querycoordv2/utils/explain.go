@@ -0,0 +1,156 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// LoadBlocker is a reason which keeps the collection from being fully loaded.
+type LoadBlocker struct {
+	// ReplicaID is the replica blocked, 0 if the blocker is about the whole collection.
+	ReplicaID     int64
+	ResourceGroup string
+	Reason        string
+}
+
+// LoadExplanation tells why a collection is not fully loaded.
+type LoadExplanation struct {
+	CollectionID int64
+	// LoadPercentage is the load progress of collection, -1 if the collection is not loaded.
+	LoadPercentage int32
+	Blockers       []LoadBlocker
+}
+
+// String returns the blockers one per line, for operators to read.
+func (e LoadExplanation) String() string {
+	if len(e.Blockers) == 0 {
+		return fmt.Sprintf("collection %d is %d%% loaded, no blocker found", e.CollectionID, e.LoadPercentage)
+	}
+	reasons := make([]string, 0, len(e.Blockers))
+	for _, blocker := range e.Blockers {
+		reasons = append(reasons, blocker.Reason)
+	}
+	return fmt.Sprintf("collection %d is %d%% loaded, blocked by:\n%s", e.CollectionID, e.LoadPercentage, strings.Join(reasons, "\n"))
+}
+
+// ExplainLoadState inspects the replicas of collection against the nodes of their resource groups, and explains
+// what keeps the collection from being fully loaded, e.g. a replica lacking nodes in a resource group at its node limit,
+// or a replica left in a removed resource group. Nothing is changed.
+func ExplainLoadState(m *meta.Meta, collection int64) LoadExplanation {
+	explanation := LoadExplanation{
+		CollectionID:   collection,
+		LoadPercentage: m.CollectionManager.CalculateLoadPercentage(collection),
+		Blockers:       make([]LoadBlocker, 0),
+	}
+	addBlocker := func(replicaID int64, rgName string, format string, args ...any) {
+		explanation.Blockers = append(explanation.Blockers, LoadBlocker{
+			ReplicaID:     replicaID,
+			ResourceGroup: rgName,
+			Reason:        fmt.Sprintf(format, args...),
+		})
+	}
+	if m.CollectionManager.GetCollection(collection) == nil {
+		addBlocker(0, "", "collection %d is not loaded", collection)
+		return explanation
+	}
+
+	replicas := m.ReplicaManager.GetByCollection(collection)
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].GetID() < replicas[j].GetID()
+	})
+	active := 0
+	for _, replica := range replicas {
+		if !replica.IsStandby() {
+			active++
+		}
+	}
+	if expected := int(m.CollectionManager.GetReplicaNumber(collection)); active < expected {
+		addBlocker(0, "", "collection has %d of %d replicas", active, expected)
+	}
+	if affinity := m.CollectionManager.GetResourceGroupAffinity(collection); len(affinity) > 0 {
+		rgNames := m.ReplicaManager.GetResourceGroupByCollection(collection)
+		if outside := rgNames.Complement(typeutil.NewSet(affinity...)).Collect(); len(outside) > 0 {
+			sort.Strings(outside)
+			addBlocker(0, "", "replicas are placed in resource groups %v outside of affinity %v, no new node is assigned to them",
+				outside, affinity)
+		}
+	}
+
+	// the nodes are only assigned to replicas if they are healthy and match the node selector of collection.
+	selector := m.ReplicaManager.GetNodeSelector(collection)
+	usableNodes := func(rgName string) (typeutil.UniqueSet, bool) {
+		rgs, err := m.ResourceManager.GetNodesOfMultiRG([]string{rgName})
+		if err != nil {
+			return nil, false
+		}
+		excludeUnhealthyNodes(m, rgs)
+		excludeUnmatchedNodes(m, collection, rgs)
+		return rgs[rgName], true
+	}
+	quorum := max(paramtable.Get().QueryCoordCfg.ReplicaNodeQuorum.GetAsInt(), 1)
+	for _, replica := range replicas {
+		rgName := replica.GetResourceGroup()
+		nodes, ok := usableNodes(rgName)
+		if !ok {
+			addBlocker(replica.GetID(), rgName, "replica %d is left in resource group %s which doesn't exist", replica.GetID(), rgName)
+			continue
+		}
+		if replica.IsStandby() {
+			continue
+		}
+		if lack := quorum - replica.RWNodesCount(); lack > 0 {
+			// the nodes used by other replicas of the same collection can't be shared.
+			free := nodes.Clone()
+			for _, other := range replicas {
+				if other.GetID() != replica.GetID() {
+					free.Remove(other.GetNodes()...)
+				}
+			}
+			addBlocker(replica.GetID(), rgName, "replica %d needs %d more nodes in %s %s",
+				replica.GetID(), lack, rgName, explainNoNode(m, rgName, nodes, free, selector))
+		} else if replica.IsDegraded() {
+			addBlocker(replica.GetID(), rgName, "replica %d is degraded", replica.GetID())
+		}
+		if replica.RONodesCount() > 0 {
+			addBlocker(replica.GetID(), rgName, "replica %d is moving off ro nodes %v", replica.GetID(), replica.GetRONodes())
+		}
+	}
+	return explanation
+}
+
+// explainNoNode tells why there is no more node for replica in resource group.
+func explainNoNode(m *meta.Meta, rgName string, usable, free typeutil.UniqueSet, selector map[string]string) string {
+	if free.Len() > 0 {
+		return fmt.Sprintf("which has %d free nodes to be assigned by the next recovery", free.Len())
+	}
+	if len(selector) > 0 && usable.Len() == 0 {
+		return fmt.Sprintf("which has no healthy node satisfying node selector %s", FormatNodeSelector(selector))
+	}
+	if rg := m.ResourceManager.GetResourceGroup(rgName); rg != nil {
+		if limit := int(rg.GetConfig().GetLimits().GetNodeNum()); rg.NodeNum() >= limit {
+			return fmt.Sprintf("which is at its node limit %d", limit)
+		}
+	}
+	return "which has no free node"
+}
@@ -0,0 +1,93 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
+	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestExplainLoadState(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveCollection(mock.Anything).Return(nil)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	m.ResourceManager.AddResourceGroup("rg1", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+	})
+	for i := 1; i <= 3; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	rg1Nodes, err := m.ResourceManager.GetNodes("rg1")
+	assert.NoError(t, err)
+	assert.Len(t, rg1Nodes, 2)
+
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 5))
+	m.CollectionManager.PutCollection(CreateTestCollection(2, 1))
+	m.ReplicaManager.Put(
+		meta.NewReplica(&querypb.Replica{ID: 1, CollectionID: 1, Nodes: rg1Nodes[:1], ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 2, CollectionID: 1, Nodes: rg1Nodes[1:], RoNodes: []int64{100}, ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 3, CollectionID: 1, ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 4, CollectionID: 1, ResourceGroup: "rg2"}),
+		meta.NewReplica(&querypb.Replica{ID: 5, CollectionID: 2, ResourceGroup: "rg1"}),
+	)
+
+	explanation := ExplainLoadState(m, 1)
+	assert.Equal(t, int64(1), explanation.CollectionID)
+	assert.Equal(t, []string{
+		"collection has 4 of 5 replicas",
+		"replica 2 is moving off ro nodes [100]",
+		"replica 3 needs 1 more nodes in rg1 which is at its node limit 2",
+		"replica 4 is left in resource group rg2 which doesn't exist",
+	}, lo.Map(explanation.Blockers, func(blocker LoadBlocker, _ int) string {
+		return blocker.Reason
+	}))
+	assert.Equal(t, int64(3), explanation.Blockers[2].ReplicaID)
+	assert.Equal(t, "rg1", explanation.Blockers[2].ResourceGroup)
+	assert.Contains(t, explanation.String(), "blocked by:\ncollection has 4 of 5 replicas\n")
+
+	// the nodes used by replicas of other collections can be shared.
+	explanation = ExplainLoadState(m, 2)
+	assert.Len(t, explanation.Blockers, 1)
+	assert.Equal(t, "replica 5 needs 1 more nodes in rg1 which has 2 free nodes to be assigned by the next recovery",
+		explanation.Blockers[0].Reason)
+
+	explanation = ExplainLoadState(m, 3)
+	assert.Equal(t, int32(-1), explanation.LoadPercentage)
+	assert.Equal(t, "collection 3 is not loaded", explanation.Blockers[0].Reason)
+}
@@ -17,11 +17,18 @@
 package utils
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/cockroachdb/errors"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
 
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/log"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
@@ -32,6 +39,16 @@ var (
 	ErrNoReplicaFound       = errors.New("no replica found during assign nodes")
 	ErrReplicasInconsistent = errors.New("all replicas should belong to same collection during assign nodes")
 	ErrUseWrongNumRG        = errors.New("resource group num can only be 0, 1 or same as replica number")
+	ErrRGAffinityViolated   = errors.New("resource group is out of the affinity of collection")
+	ErrRGAffinityNotEnough  = errors.New("affined resource groups can't satisfy the replica number")
+	ErrRGReplicaCapExceeded = errors.New("resource group can't host more replicas")
+	ErrRGNotRemovable       = errors.New("resource group can't be removed")
+	// ErrSystemRGReserved is returned if the replicas of user collection are placed in the system resource group.
+	ErrSystemRGReserved = errors.New("system resource group is reserved for system collections")
+	// ErrNodeSelectorUnsatisfiable is returned if the nodes matching the node selector of collection can't hold its replicas.
+	ErrNodeSelectorUnsatisfiable = errors.New("node selector can't be satisfied")
+	// ErrInvalidPinnedNodes is returned if the replica can't be pinned to the nodes.
+	ErrInvalidPinnedNodes = errors.New("invalid pinned nodes")
 )
 
 func GetPartitions(collectionMgr *meta.CollectionManager, collectionID int64) ([]int64, error) {
@@ -89,33 +106,311 @@ func GroupSegmentsByReplica(replicaMgr *meta.ReplicaManager, collectionID int64,
 	return ret
 }
 
+// ReplicaConfig is the collection level config of replicas, which is set by the properties of collection,
+// and kept in the load meta of collection once the collection is loaded.
+type ReplicaConfig struct {
+	// ResourceGroupAffinity is the resource groups that the replicas are bound to, empty means no binding.
+	ResourceGroupAffinity []string
+	// NodeSelector is the labels required on the nodes of replicas, which is kept in the replica meta, nil means any node.
+	NodeSelector map[string]string
+	// StandbyReplicas is the number of replicas kept as hot standby, 0 means no standby.
+	StandbyReplicas int
+}
+
+// ReplicaConfigFromProperties parses the replica config from the properties of collection.
+func ReplicaConfigFromProperties(props []*commonpb.KeyValuePair) (ReplicaConfig, error) {
+	affinity, err := common.CollectionLevelResourceGroupAffinity(props)
+	if err != nil {
+		return ReplicaConfig{}, merr.WrapErrParameterInvalidMsg(err.Error())
+	}
+	nodeSelector, err := common.CollectionLevelNodeSelector(props)
+	if err != nil {
+		return ReplicaConfig{}, merr.WrapErrParameterInvalidMsg(err.Error())
+	}
+	standbyNum, err := common.CollectionLevelStandbyReplicaNumber(props)
+	if err != nil {
+		return ReplicaConfig{}, merr.WrapErrParameterInvalidMsg(err.Error())
+	}
+	return ReplicaConfig{ResourceGroupAffinity: affinity, NodeSelector: nodeSelector, StandbyReplicas: int(standbyNum)}, nil
+}
+
+// GetReplicaConfig returns the replica config kept in the load meta of collection, empty if it's not loaded.
+func GetReplicaConfig(m *meta.Meta, collectionID typeutil.UniqueID) ReplicaConfig {
+	return ReplicaConfig{
+		ResourceGroupAffinity: m.CollectionManager.GetResourceGroupAffinity(collectionID),
+		NodeSelector:          m.ReplicaManager.GetNodeSelector(collectionID),
+		StandbyReplicas:       int(m.CollectionManager.GetStandbyReplicas(collectionID)),
+	}
+}
+
 // RecoverReplicaOfCollection recovers all replica of collection with latest resource group.
 func RecoverReplicaOfCollection(m *meta.Meta, collectionID typeutil.UniqueID) {
+	rgs, ok := prepareRecoverReplicaOfCollection(m, newSharedNodeView(m), collectionID)
+	if !ok {
+		return
+	}
+	if err := m.ReplicaManager.RecoverNodesInCollection(collectionID, rgs); err != nil {
+		log.Warn("fail to set available nodes in replica", zap.Int64("collectionID", collectionID), zap.Error(err))
+	}
+}
+
+// PinReplicaToNodes pins the replica to the exact nodes for benchmarking and debugging, which overrides the automatic
+// placement, so recovery won't move the replica unless the pinned nodes fail. The nodes must be in the resource group
+// of replica, and not pinned by other replicas of the same collection. Empty nodes unpin the replica.
+func PinReplicaToNodes(m *meta.Meta, replicaID int64, nodeIDs []int64) error {
+	replica := m.ReplicaManager.Get(replicaID)
+	if replica == nil {
+		return merr.WrapErrReplicaNotFound(replicaID)
+	}
+	nodeIDs = lo.Uniq(nodeIDs)
+	if len(nodeIDs) > 0 {
+		rgName := replica.GetResourceGroup()
+		rgNodes, err := m.ResourceManager.GetNodes(rgName)
+		if err != nil {
+			return err
+		}
+		if outside := typeutil.NewUniqueSet(nodeIDs...).Complement(typeutil.NewUniqueSet(rgNodes...)); outside.Len() > 0 {
+			return errors.Wrapf(ErrInvalidPinnedNodes, "nodes %v are not in resource group %s of replica %d",
+				outside.Collect(), rgName, replicaID)
+		}
+		for _, other := range m.ReplicaManager.GetByCollection(replica.GetCollectionID()) {
+			if other.GetID() == replicaID {
+				continue
+			}
+			if shared := lo.Intersect(other.GetPinnedNodes(), nodeIDs); len(shared) > 0 {
+				return errors.Wrapf(ErrInvalidPinnedNodes, "nodes %v are pinned by replica %d of the same collection",
+					shared, other.GetID())
+			}
+		}
+	}
+	if err := m.ReplicaManager.PinNodes(replicaID, nodeIDs); err != nil {
+		return err
+	}
+	log.Info("pin replica to nodes", zap.Int64("replicaID", replicaID), zap.Int64s("nodes", nodeIDs))
+	RecoverReplicaOfCollection(m, replica.GetCollectionID())
+	return nil
+}
+
+// prepareRecoverReplicaOfCollection returns the nodes of resource groups available for recovering the replicas of collection,
+// returns false if the collection should not be recovered.
+func prepareRecoverReplicaOfCollection(m *meta.Meta, view *sharedNodeView, collectionID typeutil.UniqueID) (map[string]typeutil.UniqueSet, bool) {
 	logger := log.With(zap.Int64("collectionID", collectionID))
 	rgNames := m.ReplicaManager.GetResourceGroupByCollection(collectionID)
 	if rgNames.Len() == 0 {
 		logger.Error("no resource group found for collection", zap.Int64("collectionID", collectionID))
-		return
+		return nil, false
 	}
 	rgs, err := m.ResourceManager.GetNodesOfMultiRG(rgNames.Collect())
 	if err != nil {
 		logger.Error("unreachable code as expected, fail to get resource group for replica", zap.Error(err))
+		return nil, false
+	}
+	includeSharedNodes(m, view, collectionID, rgs)
+	excludeUnhealthyNodes(m, rgs)
+	excludeUnmatchedNodes(m, collectionID, rgs)
+	freezeReplicasOutsideAffinity(m, collectionID, rgs)
+	reserveSpareNodes(m, collectionID, rgs)
+	return rgs, true
+}
+
+// freezeReplicasOutsideAffinity keeps the replicas placed outside the affinity of collection on the nodes they hold,
+// so that they keep serving without taking new nodes, while the replicas inside the affinity are recovered as usual.
+func freezeReplicasOutsideAffinity(m *meta.Meta, collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet) {
+	affinity := m.CollectionManager.GetResourceGroupAffinity(collectionID)
+	if len(affinity) == 0 {
 		return
 	}
+	affinitySet := typeutil.NewSet(affinity...)
+	held := make(map[string]typeutil.UniqueSet)
+	for _, replica := range m.ReplicaManager.GetByCollection(collectionID) {
+		rgName := replica.GetResourceGroup()
+		if affinitySet.Contain(rgName) {
+			continue
+		}
+		if _, ok := held[rgName]; !ok {
+			held[rgName] = typeutil.NewUniqueSet()
+		}
+		held[rgName].Insert(replica.GetNodes()...)
+	}
+	for rgName, nodes := range held {
+		log.RatedWarn(10, "replicas placed outside of affined resource groups, keep them on the held nodes",
+			zap.Int64("collectionID", collectionID),
+			zap.Strings("affinity", affinity),
+			zap.String("resourceGroup", rgName))
+		rgs[rgName] = rgs[rgName].Intersection(nodes)
+	}
+}
 
-	if err := m.ReplicaManager.RecoverNodesInCollection(collectionID, rgs); err != nil {
-		logger.Warn("fail to set available nodes in replica", zap.Error(err))
+// SetResourceGroupAffinity binds the loaded collection to the resource groups durably, empty resource groups clear
+// the affinity. The resource groups must exist and cover the resource groups of the current replicas of collection,
+// so the replicas have to be remapped into the new resource groups before narrowing the affinity.
+func SetResourceGroupAffinity(m *meta.Meta, collectionID typeutil.UniqueID, rgs []string) error {
+	rgs = lo.Uniq(rgs)
+	for _, rgName := range rgs {
+		if !m.ResourceManager.ContainResourceGroup(rgName) {
+			return merr.WrapErrResourceGroupNotFound(rgName)
+		}
+	}
+	if len(rgs) > 0 {
+		current := m.ReplicaManager.GetResourceGroupByCollection(collectionID)
+		if outside := current.Complement(typeutil.NewSet(rgs...)).Collect(); len(outside) > 0 {
+			sort.Strings(outside)
+			return errors.Wrapf(ErrRGAffinityViolated, "replicas of collection %d are placed in resource groups %v out of affinity %v",
+				collectionID, outside, rgs)
+		}
+	}
+	if err := m.CollectionManager.SetResourceGroupAffinity(collectionID, rgs); err != nil {
+		return err
+	}
+	log.Info("set resource group affinity of collection", zap.Int64("collectionID", collectionID), zap.Strings("affinity", rgs))
+	return nil
+}
+
+// excludeUnhealthyNodes removes the nodes failing health check from resource groups,
+// so that they are not assigned to replicas even if they are still registered.
+func excludeUnhealthyNodes(m *meta.Meta, rgs map[string]typeutil.UniqueSet) {
+	if m.NodeHealthChecker == nil {
+		return
+	}
+	for rgName, nodes := range rgs {
+		for _, node := range nodes.Collect() {
+			if !m.NodeHealthChecker.IsHealthy(node) {
+				log.RatedInfo(10, "exclude unhealthy node from replica assignment",
+					zap.String("resourceGroup", rgName),
+					zap.Int64("nodeID", node))
+				nodes.Remove(node)
+			}
+		}
+	}
+}
+
+// excludeUnmatchedNodes removes the nodes not matching the node selector of collection from resource groups,
+// so that the replicas of collection are only placed on the matching nodes.
+func excludeUnmatchedNodes(m *meta.Meta, collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet) {
+	selector := m.ReplicaManager.GetNodeSelector(collectionID)
+	if len(selector) == 0 {
+		return
+	}
+	for _, nodes := range rgs {
+		for _, node := range nodes.Collect() {
+			if !m.ReplicaManager.MatchNodeSelector(node, selector) {
+				nodes.Remove(node)
+			}
+		}
 	}
 }
 
-// RecoverAllCollectionrecovers all replica of all collection in resource group.
+// RecoverAllCollection recovers all replica of all collection in resource group,
+// the replicas of all collections are saved in one transaction, so a failed save leaves all of them untouched.
 func RecoverAllCollection(m *meta.Meta) {
+	rgsOfCollections := make(map[typeutil.UniqueID]map[string]typeutil.UniqueSet)
+	// the shared nodes are resolved once for all collections, since the replicas are not changed until recovered.
+	view := newSharedNodeView(m)
 	for _, collection := range m.CollectionManager.GetAll() {
-		RecoverReplicaOfCollection(m, collection)
+		if rgs, ok := prepareRecoverReplicaOfCollection(m, view, collection); ok {
+			rgsOfCollections[collection] = rgs
+		}
+	}
+	if err := m.ReplicaManager.RecoverNodesInCollections(rgsOfCollections); err != nil {
+		log.Warn("fail to set available nodes in replica", zap.Error(err))
+	}
+	for collection := range rgsOfCollections {
+		if err := m.ReplicaManager.RecoverStandbyReplicas(collection, GetReplicaConfig(m, collection).StandbyReplicas); err != nil {
+			log.Warn("fail to recover standby replicas", zap.Int64("collectionID", collection), zap.Error(err))
+		}
+	}
+	UpdateSpareNodeMetrics(m)
+	UpdateResourceGroupReplicaMetrics(m)
+	UpdateReplicaMoveMetrics(m)
+	UpdateReplicaAvailabilityMetrics(m)
+	UpdateSharedNodeMetrics(m)
+}
+
+// FormatNodeSelector formats the node selector as sorted `key=value` pairs.
+func FormatNodeSelector(selector map[string]string) string {
+	pairs := lo.MapToSlice(selector, func(key string, value string) string {
+		return fmt.Sprintf("%s=%s", key, value)
+	})
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// checkNodeSelector checks if the nodes matching the node selector in each resource group can hold the replicas,
+// each replica needs at least nodeNumPerReplica nodes.
+func checkNodeSelector(m *meta.Meta, replicaNumInRG map[string]int, nodeNumPerReplica int, selector map[string]string) error {
+	if len(selector) == 0 {
+		return nil
+	}
+	for rgName, num := range replicaNumInRG {
+		nodes, err := m.ResourceManager.GetNodes(rgName)
+		if err != nil {
+			return err
+		}
+		matched := lo.CountBy(nodes, func(node int64) bool {
+			return m.ReplicaManager.MatchNodeSelector(node, selector)
+		})
+		if matched == 0 {
+			return errors.Wrapf(ErrNodeSelectorUnsatisfiable, "no available node in resource group %s satisfies node selector %s",
+				rgName, FormatNodeSelector(selector))
+		}
+		if required := num * nodeNumPerReplica; matched < required {
+			return errors.Wrapf(ErrNodeSelectorUnsatisfiable, "need %d more nodes satisfying node selector %s in resource group %s",
+				required-matched, FormatNodeSelector(selector), rgName)
+		}
 	}
+	return nil
 }
 
-func checkResourceGroup(m *meta.Meta, resourceGroups []string, replicaNumber int32) (map[string]int, error) {
+// applyResourceGroupAffinity restricts the resource groups used to spawn replicas of collection
+// to its affined resource groups.
+// If no resource group is given, replicas are spread over the affined resource groups by their free nodes.
+func applyResourceGroupAffinity(m *meta.Meta, collection int64, affinity []string, resourceGroups []string, replicaNumber int32) ([]string, error) {
+	if len(affinity) == 0 {
+		return resourceGroups, nil
+	}
+
+	affinitySet := typeutil.NewSet(affinity...)
+	if len(resourceGroups) > 0 {
+		for _, rgName := range resourceGroups {
+			if !affinitySet.Contain(rgName) {
+				return nil, errors.Wrapf(ErrRGAffinityViolated, "collection %d is affined to resource groups %v, but got %s", collection, affinity, rgName)
+			}
+		}
+		return resourceGroups, nil
+	}
+
+	freeNodes := make(map[string]int, len(affinity))
+	for _, rgName := range affinity {
+		if !m.ContainResourceGroup(rgName) {
+			return nil, errors.Wrapf(ErrGetNodesFromRG, "affined resource group %s of collection %d", rgName, collection)
+		}
+		nodes, err := m.ResourceManager.GetNodes(rgName)
+		if err != nil {
+			return nil, err
+		}
+		freeNodes[rgName] = len(nodes)
+	}
+	ret := make([]string, 0, replicaNumber)
+	for i := 0; i < int(replicaNumber); i++ {
+		// pick the resource group with most free nodes, keep the order of affinity if tie.
+		picked := ""
+		for _, rgName := range affinity {
+			if freeNodes[rgName] > 0 && (picked == "" || freeNodes[rgName] > freeNodes[picked]) {
+				picked = rgName
+			}
+		}
+		if picked == "" {
+			return nil, errors.Wrapf(ErrRGAffinityNotEnough, "collection %d is affined to resource groups %v, which can hold %d replicas at most, but %d required",
+				collection, affinity, i, replicaNumber)
+		}
+		freeNodes[picked]--
+		ret = append(ret, picked)
+	}
+	return ret, nil
+}
+
+// countReplicasInRG returns the number of replicas placed in each resource group by the given resource groups.
+func countReplicasInRG(resourceGroups []string, replicaNumber int32) (map[string]int, error) {
 	if len(resourceGroups) != 0 && len(resourceGroups) != 1 && len(resourceGroups) != int(replicaNumber) {
 		return nil, ErrUseWrongNumRG
 	}
@@ -133,36 +428,54 @@ func checkResourceGroup(m *meta.Meta, resourceGroups []string, replicaNumber int
 			replicaNumInRG[rgName] += 1
 		}
 	}
-
-	// TODO: !!!Warning, ResourceManager and ReplicaManager doesn't protected with each other in concurrent operation.
-	// 1. replica1 got rg1's node snapshot but doesn't spawn finished.
-	// 2. rg1 is removed.
-	// 3. replica1 spawn finished, but cannot find related resource group.
-	for rgName, num := range replicaNumInRG {
-		if !m.ContainResourceGroup(rgName) {
-			return nil, ErrGetNodesFromRG
-		}
-		nodes, err := m.ResourceManager.GetNodes(rgName)
-		if err != nil {
-			return nil, err
-		}
-		if num > len(nodes) {
-			log.Warn("node not enough", zap.Error(meta.ErrNodeNotEnough), zap.Int("replicaNum", num), zap.Int("nodeNum", len(nodes)), zap.String("rgName", rgName))
-			return nil, meta.ErrNodeNotEnough
-		}
-	}
 	return replicaNumInRG, nil
 }
 
-// SpawnReplicasWithRG spawns replicas in rgs one by one for given collection.
-func SpawnReplicasWithRG(m *meta.Meta, collection int64, resourceGroups []string, replicaNumber int32, channels []string) ([]*meta.Replica, error) {
-	replicaNumInRG, err := checkResourceGroup(m, resourceGroups, replicaNumber)
+// ReplicaPlan is the number of replicas planned to be spawned in a resource group.
+type ReplicaPlan struct {
+	ResourceGroup string
+	ReplicaNumber int
+	// NodeSelector is the labels required on the nodes of replicas, nil means any node.
+	NodeSelector map[string]string
+}
+
+// PlanReplicasWithRG plans the replicas to be spawned in rgs for given collection without any side effect.
+// The replicas of system collections are always placed in the system resource group if it's enabled,
+// which is out of reach of user collections. The placement is solved against the affinity and node selector
+// in replica config, and the node capacity and replica caps of resource groups,
+// an InfeasibilityReport naming the violated constraints is returned if no placement satisfies all of them.
+func PlanReplicasWithRG(m *meta.Meta, collection int64, resourceGroups []string, replicaNumber int32, cfg ReplicaConfig) ([]ReplicaPlan, error) {
+	resourceGroups, err := applySystemResourceGroup(m, collection, resourceGroups)
 	if err != nil {
 		return nil, err
 	}
+	replicaNumInRG, err := SolveReplicaPlacement(m, collection, resourceGroups, replicaNumber, cfg.ResourceGroupAffinity, cfg.NodeSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]ReplicaPlan, 0, len(replicaNumInRG))
+	for rgName, num := range replicaNumInRG {
+		plans = append(plans, ReplicaPlan{ResourceGroup: rgName, ReplicaNumber: num, NodeSelector: cfg.NodeSelector})
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].ResourceGroup < plans[j].ResourceGroup
+	})
+	return plans, nil
+}
+
+// ApplyReplicaPlan spawns the planned replicas for given collection, and recovers nodes of them.
+func ApplyReplicaPlan(m *meta.Meta, collection int64, plans []ReplicaPlan, channels []string) ([]*meta.Replica, error) {
+	replicaNumInRG := make(map[string]int, len(plans))
+	var nodeSelector map[string]string
+	for _, plan := range plans {
+		replicaNumInRG[plan.ResourceGroup] += plan.ReplicaNumber
+		// the replicas of collection share the same node selector.
+		nodeSelector = plan.NodeSelector
+	}
 
 	// Spawn it in replica manager.
-	replicas, err := m.ReplicaManager.Spawn(collection, replicaNumInRG, channels)
+	replicas, err := m.ReplicaManager.SpawnWithNodeSelector(collection, replicaNumInRG, channels, nodeSelector)
 	if err != nil {
 		return nil, err
 	}
@@ -170,3 +483,82 @@ func SpawnReplicasWithRG(m *meta.Meta, collection int64, resourceGroups []string
 	RecoverReplicaOfCollection(m, collection)
 	return replicas, nil
 }
+
+// SpawnReplicasWithRG spawns replicas in rgs one by one for given collection.
+// The resource groups are restricted by the affinity in replica config if it has one.
+func SpawnReplicasWithRG(m *meta.Meta, collection int64, resourceGroups []string, replicaNumber int32, channels []string, cfg ReplicaConfig) ([]*meta.Replica, error) {
+	plans, err := PlanReplicasWithRG(m, collection, resourceGroups, replicaNumber, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyReplicaPlan(m, collection, plans, channels)
+}
+
+// RemapReplicasMinimalMovement places the loaded replicas of collection in the new resource groups, which are
+// interpreted the same as loading. The replicas already in a resource group of the new ones stay as long as the group
+// needs them, only the surplus replicas are moved to the groups lacking replicas, and only the moved ones are persisted.
+// The replica with more rw nodes is kept in place first, since moving it churns more segments.
+func RemapReplicasMinimalMovement(m *meta.Meta, collection int64, newGroups []string) error {
+	replicas := m.ReplicaManager.GetByCollection(collection)
+	if len(replicas) == 0 {
+		return merr.WrapErrCollectionNotLoaded(collection)
+	}
+	newGroups, err := applySystemResourceGroup(m, collection, newGroups)
+	if err != nil {
+		return err
+	}
+	newGroups, err = applyResourceGroupAffinity(m, collection, m.CollectionManager.GetResourceGroupAffinity(collection), newGroups, int32(len(replicas)))
+	if err != nil {
+		return err
+	}
+	target, err := countReplicasInRG(newGroups, int32(len(replicas)))
+	if err != nil {
+		return err
+	}
+	for rgName := range target {
+		if !m.ResourceManager.ContainResourceGroup(rgName) {
+			return merr.WrapErrResourceGroupNotFound(rgName)
+		}
+	}
+
+	sort.Slice(replicas, func(i, j int) bool {
+		if replicas[i].RWNodesCount() != replicas[j].RWNodesCount() {
+			return replicas[i].RWNodesCount() > replicas[j].RWNodesCount()
+		}
+		return replicas[i].GetID() < replicas[j].GetID()
+	})
+	lack := maps.Clone(target)
+	surplus := make([]*meta.Replica, 0)
+	for _, replica := range replicas {
+		if rgName := replica.GetResourceGroup(); lack[rgName] > 0 {
+			lack[rgName]--
+			continue
+		}
+		surplus = append(surplus, replica)
+	}
+	if len(surplus) == 0 {
+		return nil
+	}
+
+	rgNames := lo.Keys(lack)
+	sort.Strings(rgNames)
+	moves := make(map[int64]string, len(surplus))
+	for _, replica := range surplus {
+		for _, rgName := range rgNames {
+			if lack[rgName] > 0 {
+				lack[rgName]--
+				moves[replica.GetID()] = rgName
+				break
+			}
+		}
+	}
+	if err := m.ReplicaManager.RelocateReplicas(collection, moves); err != nil {
+		return err
+	}
+	log.Info("remap replicas to new resource groups",
+		zap.Int64("collectionID", collection),
+		zap.Strings("resourceGroups", newGroups),
+		zap.Any("moves", moves))
+	RecoverReplicaOfCollection(m, collection)
+	return nil
+}
@@ -20,9 +20,11 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
 	etcdKV "github.com/milvus-io/milvus/internal/kv/etcd"
 	"github.com/milvus-io/milvus/internal/metastore/kv/querycoord"
@@ -31,7 +33,9 @@ import (
 	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
 	. "github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/util/etcd"
+	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
@@ -118,7 +122,7 @@ func TestSpawnReplicasWithRG(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := SpawnReplicasWithRG(tt.args.m, tt.args.collection, tt.args.resourceGroups, tt.args.replicaNumber, nil)
+			got, err := SpawnReplicasWithRG(tt.args.m, tt.args.collection, tt.args.resourceGroups, tt.args.replicaNumber, nil, ReplicaConfig{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SpawnReplicasWithRG() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -131,6 +135,245 @@ func TestSpawnReplicasWithRG(t *testing.T) {
 	}
 }
 
+func TestPlanReplicasWithRG(t *testing.T) {
+	paramtable.Init()
+
+	// planning never writes the store.
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	for _, rgName := range []string{"rg1", "rg2"} {
+		m.ResourceManager.AddResourceGroup(rgName, &rgpb.ResourceGroupConfig{
+			Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+			Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+		})
+	}
+	for i := 1; i <= 4; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+
+	plans, err := PlanReplicasWithRG(m, 1000, []string{"rg1"}, 2, ReplicaConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ReplicaPlan{{ResourceGroup: "rg1", ReplicaNumber: 2}}, plans)
+
+	plans, err = PlanReplicasWithRG(m, 1000, []string{"rg2", "rg1"}, 2, ReplicaConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ReplicaPlan{{ResourceGroup: "rg1", ReplicaNumber: 1}, {ResourceGroup: "rg2", ReplicaNumber: 1}}, plans)
+
+	_, err = PlanReplicasWithRG(m, 1000, []string{"rg1"}, 3, ReplicaConfig{})
+	assert.ErrorIs(t, err, meta.ErrNodeNotEnough)
+	assert.Len(t, m.ReplicaManager.GetByCollection(1000), 0)
+}
+
+func TestSpawnReplicasWithRGAffinity(t *testing.T) {
+	paramtable.Init()
+	config := GenerateEtcdConfig()
+	cli, _ := etcd.GetEtcdClient(
+		config.UseEmbedEtcd.GetAsBool(),
+		config.EtcdUseSSL.GetAsBool(),
+		config.Endpoints.GetAsStrings(),
+		config.EtcdTLSCert.GetValue(),
+		config.EtcdTLSKey.GetValue(),
+		config.EtcdTLSCACert.GetValue(),
+		config.EtcdTLSMinVersion.GetValue())
+	kv := etcdKV.NewEtcdKV(cli, config.MetaRootPath.GetValue())
+
+	store := querycoord.NewCatalog(kv)
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	for _, rgName := range []string{"rg1", "rg2", "rg3"} {
+		m.ResourceManager.AddResourceGroup(rgName, &rgpb.ResourceGroupConfig{
+			Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+			Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+		})
+	}
+	for i := 1; i <= 6; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+
+	// the affinity is given by the replica config before the collection is loaded.
+	cfg := ReplicaConfig{ResourceGroupAffinity: []string{"rg1", "rg2"}}
+	_, err := SpawnReplicasWithRG(m, 1000, []string{"rg3"}, 1, nil, cfg)
+	assert.ErrorIs(t, err, ErrRGAffinityViolated)
+
+	_, err = SpawnReplicasWithRG(m, 1000, nil, 5, nil, cfg)
+	assert.ErrorIs(t, err, ErrRGAffinityNotEnough)
+
+	replicas, err := SpawnReplicasWithRG(m, 1000, nil, 4, nil, cfg)
+	assert.NoError(t, err)
+	assert.Len(t, replicas, 4)
+	for _, replica := range replicas {
+		assert.Contains(t, []string{"rg1", "rg2"}, replica.GetResourceGroup())
+	}
+
+	// the affinity can't exclude the resource groups of current replicas.
+	err = SetResourceGroupAffinity(m, 1000, []string{"rg1", "rg2"})
+	assert.ErrorIs(t, err, merr.ErrCollectionNotLoaded)
+	collection := CreateTestCollection(1000, 4)
+	collection.ResourceGroupAffinity = cfg.ResourceGroupAffinity
+	m.CollectionManager.PutCollection(collection)
+	assert.Equal(t, cfg, GetReplicaConfig(m, 1000))
+	err = SetResourceGroupAffinity(m, 1000, []string{"rg1", "rg4"})
+	assert.ErrorIs(t, err, merr.ErrResourceGroupNotFound)
+	err = SetResourceGroupAffinity(m, 1000, []string{"rg1"})
+	assert.ErrorIs(t, err, ErrRGAffinityViolated)
+	err = SetResourceGroupAffinity(m, 1000, []string{"rg1", "rg2", "rg3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rg1", "rg2", "rg3"}, m.CollectionManager.GetResourceGroupAffinity(1000))
+
+	// the replicas inside the affinity are still recovered if some replicas are placed outside.
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(1000, []string{"rg1"}))
+	var inside, outside *meta.Replica
+	for _, replica := range m.ReplicaManager.GetByCollection(1000) {
+		if replica.GetResourceGroup() == "rg1" {
+			inside = replica
+		} else {
+			outside = replica
+		}
+	}
+	node := inside.GetNodes()[0]
+	assert.NoError(t, m.ReplicaManager.RemoveNode(inside.GetID(), node))
+	RecoverReplicaOfCollection(m, 1000)
+	assert.True(t, m.ReplicaManager.Get(inside.GetID()).Contains(node))
+	assert.ElementsMatch(t, outside.GetNodes(), m.ReplicaManager.Get(outside.GetID()).GetNodes())
+}
+
+func TestRecoverStandbyReplicasByLoadMeta(t *testing.T) {
+	paramtable.Init()
+	config := GenerateEtcdConfig()
+	cli, _ := etcd.GetEtcdClient(
+		config.UseEmbedEtcd.GetAsBool(),
+		config.EtcdUseSSL.GetAsBool(),
+		config.Endpoints.GetAsStrings(),
+		config.EtcdTLSCert.GetValue(),
+		config.EtcdTLSKey.GetValue(),
+		config.EtcdTLSCACert.GetValue(),
+		config.EtcdTLSMinVersion.GetValue())
+	kv := etcdKV.NewEtcdKV(cli, config.MetaRootPath.GetValue())
+
+	store := querycoord.NewCatalog(kv)
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	for i := 1; i <= 2; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+
+	// the standby number is kept in the load meta of collection.
+	_, err := SpawnReplicasWithRG(m, 1000, nil, 2, nil, ReplicaConfig{StandbyReplicas: 1})
+	assert.NoError(t, err)
+	collection := CreateTestCollection(1000, 2)
+	collection.StandbyReplicas = 1
+	m.CollectionManager.PutCollection(collection)
+	assert.Equal(t, 1, GetReplicaConfig(m, 1000).StandbyReplicas)
+
+	RecoverAllCollection(m)
+	standby := lo.CountBy(m.ReplicaManager.GetByCollection(1000), func(replica *meta.Replica) bool {
+		return replica.IsStandby()
+	})
+	assert.Equal(t, 1, standby)
+}
+
+func TestReplicaConfigFromProperties(t *testing.T) {
+	cfg, err := ReplicaConfigFromProperties(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ReplicaConfig{}, cfg)
+
+	cfg, err = ReplicaConfigFromProperties([]*commonpb.KeyValuePair{{Key: common.CollectionResourceGroupAffinity, Value: "rg1,rg2"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rg1", "rg2"}, cfg.ResourceGroupAffinity)
+
+	_, err = ReplicaConfigFromProperties([]*commonpb.KeyValuePair{{Key: common.CollectionResourceGroupAffinity, Value: ""}})
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+
+	cfg, err = ReplicaConfigFromProperties([]*commonpb.KeyValuePair{{Key: common.CollectionReplicaNodeSelector, Value: "gpu=true"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"gpu": "true"}, cfg.NodeSelector)
+
+	_, err = ReplicaConfigFromProperties([]*commonpb.KeyValuePair{{Key: common.CollectionReplicaNodeSelector, Value: "gpu"}})
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+
+	cfg, err = ReplicaConfigFromProperties([]*commonpb.KeyValuePair{{Key: common.CollectionStandbyReplicaNumber, Value: "1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cfg.StandbyReplicas)
+
+	_, err = ReplicaConfigFromProperties([]*commonpb.KeyValuePair{{Key: common.CollectionStandbyReplicaNumber, Value: "-1"}})
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+}
+
+func TestSpawnReplicasWithNodeSelector(t *testing.T) {
+	paramtable.Init()
+	config := GenerateEtcdConfig()
+	cli, _ := etcd.GetEtcdClient(
+		config.UseEmbedEtcd.GetAsBool(),
+		config.EtcdUseSSL.GetAsBool(),
+		config.Endpoints.GetAsStrings(),
+		config.EtcdTLSCert.GetValue(),
+		config.EtcdTLSKey.GetValue(),
+		config.EtcdTLSCACert.GetValue(),
+		config.EtcdTLSMinVersion.GetValue())
+	kv := etcdKV.NewEtcdKV(cli, config.MetaRootPath.GetValue())
+
+	store := querycoord.NewCatalog(kv)
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	m.ResourceManager.AddResourceGroup("rg1", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 4},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 4},
+	})
+	for i := 1; i <= 4; i++ {
+		labels := map[string]string{"gpu": "false"}
+		if i <= 2 {
+			labels = map[string]string{"gpu": "true"}
+		}
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+			Labels:   labels,
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+
+	cfg := ReplicaConfig{NodeSelector: map[string]string{"gpu": "true", "zone": "a"}}
+	_, err := SpawnReplicasWithRG(m, 1000, []string{"rg1"}, 1, nil, cfg)
+	assert.ErrorIs(t, err, ErrNodeSelectorUnsatisfiable)
+	assert.ErrorContains(t, err, "no available node in resource group rg1 satisfies node selector gpu=true,zone=a")
+
+	cfg, err = ReplicaConfigFromProperties([]*commonpb.KeyValuePair{{Key: common.CollectionReplicaNodeSelector, Value: "GPU=true"}})
+	assert.NoError(t, err)
+	_, err = SpawnReplicasWithRG(m, 1000, []string{"rg1"}, 3, nil, cfg)
+	assert.ErrorIs(t, err, ErrNodeSelectorUnsatisfiable)
+	assert.ErrorContains(t, err, "need 1 more nodes satisfying node selector gpu=true")
+
+	// the replicas are only placed on the matching nodes.
+	replicas, err := SpawnReplicasWithRG(m, 1000, []string{"rg1"}, 2, nil, cfg)
+	assert.NoError(t, err)
+	assert.Len(t, replicas, 2)
+	for _, replica := range m.ReplicaManager.GetByCollection(1000) {
+		assert.Equal(t, map[string]string{"gpu": "true"}, replica.GetNodeSelector())
+		assert.Equal(t, 1, replica.RWNodesCount())
+		assert.Subset(t, []int64{1, 2}, replica.GetRWNodes())
+	}
+	assert.Equal(t, cfg.NodeSelector, GetReplicaConfig(m, 1000).NodeSelector)
+}
+
 func TestAddNodesToCollectionsInRGFailed(t *testing.T) {
 	paramtable.Init()
 
@@ -197,13 +440,90 @@ func TestAddNodesToCollectionsInRGFailed(t *testing.T) {
 	assert.Len(t, m.ReplicaManager.Get(4).GetNodes(), 0)
 }
 
+func TestRecoverAllCollectionSaveFailed(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveCollection(mock.Anything).Return(nil)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil).Times(4)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil)
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	m.ResourceManager.AddResourceGroup("rg", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 4},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 4},
+	})
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 2))
+	m.CollectionManager.PutCollection(CreateTestCollection(2, 2))
+	m.ReplicaManager.Put(meta.NewReplica(
+		&querypb.Replica{
+			ID:            1,
+			CollectionID:  1,
+			Nodes:         []int64{},
+			ResourceGroup: "rg",
+		},
+		typeutil.NewUniqueSet(),
+	))
+
+	m.ReplicaManager.Put(meta.NewReplica(
+		&querypb.Replica{
+			ID:            2,
+			CollectionID:  1,
+			Nodes:         []int64{},
+			ResourceGroup: "rg",
+		},
+		typeutil.NewUniqueSet(),
+	))
+
+	m.ReplicaManager.Put(meta.NewReplica(
+		&querypb.Replica{
+			ID:            3,
+			CollectionID:  2,
+			Nodes:         []int64{},
+			ResourceGroup: "rg",
+		},
+		typeutil.NewUniqueSet(),
+	))
+
+	m.ReplicaManager.Put(meta.NewReplica(
+		&querypb.Replica{
+			ID:            4,
+			CollectionID:  2,
+			Nodes:         []int64{},
+			ResourceGroup: "rg",
+		},
+		typeutil.NewUniqueSet(),
+	))
+
+	for i := 1; i < 5; i++ {
+		nodeID := int64(i)
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   nodeID,
+			Address:  "127.0.0.1",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(nodeID)
+	}
+
+	// replicas of all collections are saved in one transaction, none of them is applied if the save fails.
+	storeErr := errors.New("store error")
+	store.EXPECT().SaveReplica(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(storeErr)
+	RecoverAllCollection(m)
+
+	assert.Len(t, m.ReplicaManager.Get(1).GetNodes(), 0)
+	assert.Len(t, m.ReplicaManager.Get(2).GetNodes(), 0)
+	assert.Len(t, m.ReplicaManager.Get(3).GetNodes(), 0)
+	assert.Len(t, m.ReplicaManager.Get(4).GetNodes(), 0)
+}
+
 func TestAddNodesToCollectionsInRG(t *testing.T) {
 	paramtable.Init()
 
 	store := mocks.NewQueryCoordCatalog(t)
 	store.EXPECT().SaveCollection(mock.Anything).Return(nil)
 	store.EXPECT().SaveReplica(mock.Anything).Return(nil)
-	store.EXPECT().SaveReplica(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().SaveReplica(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil)
 	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil)
 	nodeMgr := session.NewNodeManager()
@@ -269,3 +589,135 @@ func TestAddNodesToCollectionsInRG(t *testing.T) {
 	assert.Len(t, m.ReplicaManager.Get(3).GetNodes(), 2)
 	assert.Len(t, m.ReplicaManager.Get(4).GetNodes(), 2)
 }
+
+type unhealthyNodes typeutil.UniqueSet
+
+func (n unhealthyNodes) IsHealthy(nodeID int64) bool {
+	return !typeutil.UniqueSet(n).Contain(nodeID)
+}
+
+func TestExcludeUnhealthyNodes(t *testing.T) {
+	m := &meta.Meta{NodeHealthChecker: session.NewRegisteredNodeHealthChecker()}
+	rgs := map[string]typeutil.UniqueSet{
+		"rg1": typeutil.NewUniqueSet(1, 2),
+		"rg2": typeutil.NewUniqueSet(3, 4),
+	}
+	excludeUnhealthyNodes(m, rgs)
+	assert.Equal(t, 2, rgs["rg1"].Len())
+	assert.Equal(t, 2, rgs["rg2"].Len())
+
+	m.NodeHealthChecker = unhealthyNodes(typeutil.NewUniqueSet(2, 3))
+	excludeUnhealthyNodes(m, rgs)
+	assert.ElementsMatch(t, []int64{1}, rgs["rg1"].Collect())
+	assert.ElementsMatch(t, []int64{4}, rgs["rg2"].Collect())
+}
+
+func TestPinReplicaToNodes(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveCollection(mock.Anything).Return(nil)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	m.ResourceManager.AddResourceGroup("rg1", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 3},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 3},
+	})
+	for i := 1; i <= 4; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 2))
+	m.ReplicaManager.Put(
+		meta.NewReplica(&querypb.Replica{ID: 1, CollectionID: 1, Nodes: []int64{1, 2}, ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 2, CollectionID: 1, Nodes: []int64{3}, ResourceGroup: "rg1"}),
+	)
+
+	assert.True(t, merr.ErrReplicaNotFound.Is(PinReplicaToNodes(m, 3, []int64{1})))
+	// node 4 is in the default resource group.
+	assert.ErrorIs(t, PinReplicaToNodes(m, 1, []int64{2, 4}), ErrInvalidPinnedNodes)
+
+	// the node out of pin is moved off.
+	assert.NoError(t, PinReplicaToNodes(m, 1, []int64{2, 2}))
+	replica := m.ReplicaManager.Get(1)
+	assert.Equal(t, []int64{2}, replica.GetPinnedNodes())
+	assert.Equal(t, []int64{2}, replica.GetRWNodes())
+	assert.Equal(t, []int64{1}, replica.GetRONodes())
+	assert.ErrorIs(t, PinReplicaToNodes(m, 2, []int64{2}), ErrInvalidPinnedNodes)
+
+	// the pinned node is taken once it's released by the other replica.
+	assert.NoError(t, PinReplicaToNodes(m, 2, []int64{1}))
+	assert.Empty(t, m.ReplicaManager.Get(2).GetRWNodes())
+	assert.NoError(t, m.ReplicaManager.RemoveNode(1, 1))
+	RecoverReplicaOfCollection(m, 1)
+	assert.Equal(t, []int64{1}, m.ReplicaManager.Get(2).GetRWNodes())
+	assert.Equal(t, []int64{3}, m.ReplicaManager.Get(2).GetRONodes())
+
+	// the unpinned replica is placed automatically again.
+	assert.NoError(t, PinReplicaToNodes(m, 2, nil))
+	assert.False(t, m.ReplicaManager.Get(2).IsPinned())
+}
+
+func TestRemapReplicasMinimalMovement(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveCollection(mock.Anything).Return(nil)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveReplica(mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveReplica(mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	for _, rgName := range []string{"rg1", "rg2", "rg3"} {
+		m.ResourceManager.AddResourceGroup(rgName, &rgpb.ResourceGroupConfig{
+			Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+			Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+		})
+	}
+	for i := 1; i <= 6; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	rg1Nodes, _ := m.ResourceManager.GetNodes("rg1")
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 3))
+	m.ReplicaManager.Put(
+		meta.NewReplica(&querypb.Replica{ID: 1, CollectionID: 1, Nodes: rg1Nodes[:1], ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 2, CollectionID: 1, Nodes: rg1Nodes[1:], ResourceGroup: "rg1"}),
+		meta.NewReplica(&querypb.Replica{ID: 3, CollectionID: 1, ResourceGroup: "rg2"}),
+	)
+
+	assert.True(t, merr.ErrCollectionNotLoaded.Is(RemapReplicasMinimalMovement(m, 2, []string{"rg1"})))
+	assert.ErrorIs(t, RemapReplicasMinimalMovement(m, 1, []string{"rg1", "rg2"}), ErrUseWrongNumRG)
+	assert.True(t, merr.ErrResourceGroupNotFound.Is(RemapReplicasMinimalMovement(m, 1, []string{"rg1", "rg4", "rg4"})))
+
+	resourceGroups := func() []string {
+		return lo.Map([]int64{1, 2, 3}, func(id int64, _ int) string {
+			return m.ReplicaManager.Get(id).GetResourceGroup()
+		})
+	}
+
+	// nothing is changed if the replicas are already in place.
+	before := m.ReplicaManager.Get(3)
+	assert.NoError(t, RemapReplicasMinimalMovement(m, 1, []string{"rg2", "rg1", "rg1"}))
+	assert.Same(t, before, m.ReplicaManager.Get(3))
+
+	// only the replica of rg2 moves.
+	assert.NoError(t, RemapReplicasMinimalMovement(m, 1, []string{"rg1", "rg1", "rg3"}))
+	assert.Equal(t, []string{"rg1", "rg1", "rg3"}, resourceGroups())
+
+	// one of the replicas in rg1 moves, the older one stays if tie.
+	assert.NoError(t, RemapReplicasMinimalMovement(m, 1, []string{"rg1", "rg2", "rg3"}))
+	assert.Equal(t, []string{"rg1", "rg2", "rg3"}, resourceGroups())
+}
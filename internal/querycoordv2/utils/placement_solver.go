@@ -0,0 +1,254 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/log"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// ErrPlacementInfeasible is returned if the replicas can't be placed without violating any placement constraint.
+var ErrPlacementInfeasible = errors.New("replica placement is infeasible")
+
+// maxPlacementCandidates bounds the candidate placements tried by the solver.
+const maxPlacementCandidates = 1024
+
+// PlacementConstraint is a rule the placement of replicas must satisfy.
+type PlacementConstraint struct {
+	// Name names the constraint in the infeasibility report.
+	Name string
+	// Check returns the reason if the replicas placed in resource groups violate the constraint.
+	Check func(replicaNumInRG map[string]int) error
+}
+
+// PlacementViolation is a placement constraint violated by a candidate placement.
+type PlacementViolation struct {
+	Constraint string
+	Err        error
+}
+
+// InfeasibilityReport explains why the replicas of collection can't be placed by the constraints violated by
+// the candidate placement closest to feasible, the constraints violated together conflict with each other.
+type InfeasibilityReport struct {
+	CollectionID  int64
+	ReplicaNumber int32
+	// Candidate is the placement violating the fewest constraints.
+	Candidate  map[string]int
+	Violations []PlacementViolation
+}
+
+func (r *InfeasibilityReport) Error() string {
+	reasons := lo.Map(r.Violations, func(violation PlacementViolation, _ int) string {
+		return fmt.Sprintf("%s: %s", violation.Constraint, violation.Err.Error())
+	})
+	return fmt.Sprintf("%s, %d replicas of collection %d violate [%s]",
+		ErrPlacementInfeasible.Error(), r.ReplicaNumber, r.CollectionID, strings.Join(reasons, "; "))
+}
+
+// Is matches ErrPlacementInfeasible and the errors of violations.
+func (r *InfeasibilityReport) Is(target error) bool {
+	if target == ErrPlacementInfeasible {
+		return true
+	}
+	for _, violation := range r.Violations {
+		if errors.Is(violation.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ViolatedConstraints returns the names of the violated constraints.
+func (r *InfeasibilityReport) ViolatedConstraints() []string {
+	return lo.Map(r.Violations, func(violation PlacementViolation, _ int) string {
+		return violation.Constraint
+	})
+}
+
+// SolveReplicaPlacement decides the number of replicas of collection placed in each resource group, which satisfies
+// all the placement constraints, or returns an InfeasibilityReport naming the violated ones.
+// The given resource groups are interpreted the same as loading. If none is given and the collection is affined
+// to resource groups, the replicas are spread over the affined ones, where the placement by free nodes is tried first.
+func SolveReplicaPlacement(m *meta.Meta, collection int64, resourceGroups []string, replicaNumber int32,
+	affinity []string, nodeSelector map[string]string,
+) (map[string]int, error) {
+	candidates, spread, err := placementCandidates(m, collection, resourceGroups, replicaNumber, affinity)
+	if err != nil {
+		return nil, err
+	}
+	rgNames := typeutil.NewSet[string]()
+	for _, candidate := range candidates {
+		rgNames.Insert(lo.Keys(candidate)...)
+	}
+	constraints := placementConstraints(m, collection, rgNames.Collect(), affinity, nodeSelector)
+
+	var best *InfeasibilityReport
+	for _, candidate := range candidates {
+		violations := make([]PlacementViolation, 0)
+		for _, constraint := range constraints {
+			if err := constraint.Check(candidate); err != nil {
+				violations = append(violations, PlacementViolation{Constraint: constraint.Name, Err: err})
+			}
+		}
+		if len(violations) == 0 {
+			return candidate, nil
+		}
+		if best == nil || len(violations) < len(best.Violations) {
+			best = &InfeasibilityReport{
+				CollectionID:  collection,
+				ReplicaNumber: replicaNumber,
+				Candidate:     candidate,
+				Violations:    violations,
+			}
+		}
+	}
+	if spread {
+		best.Violations = append(best.Violations, PlacementViolation{
+			Constraint: "affinity",
+			Err: errors.Wrapf(ErrRGAffinityNotEnough, "no placement over affined resource groups %v satisfies the other constraints",
+				affinity),
+		})
+	}
+	log.Warn("replica placement is infeasible", zap.Int64("collectionID", collection),
+		zap.Any("candidate", best.Candidate), zap.Strings("violated", best.ViolatedConstraints()), zap.Error(best))
+	return nil, best
+}
+
+// placementCandidates returns the candidate placements in the order to be tried,
+// spread is true if the replicas are spread over the affined resource groups of collection.
+func placementCandidates(m *meta.Meta, collection int64, resourceGroups []string, replicaNumber int32, affinity []string) ([]map[string]int, bool, error) {
+	if len(resourceGroups) > 0 || len(affinity) == 0 {
+		replicaNumInRG, err := countReplicasInRG(resourceGroups, replicaNumber)
+		if err != nil {
+			return nil, false, err
+		}
+		return []map[string]int{replicaNumInRG}, false, nil
+	}
+
+	for _, rgName := range affinity {
+		if !m.ContainResourceGroup(rgName) {
+			return nil, false, errors.Wrapf(ErrGetNodesFromRG, "affined resource group %s of collection %d", rgName, collection)
+		}
+	}
+	candidates := make([]map[string]int, 0)
+	// the placement by free nodes is tried first, which is the placement before constraints are solved.
+	if greedy, err := applyResourceGroupAffinity(m, collection, affinity, nil, replicaNumber); err == nil {
+		if replicaNumInRG, err := countReplicasInRG(greedy, replicaNumber); err == nil {
+			candidates = append(candidates, replicaNumInRG)
+		}
+	}
+	parts := compositions(int(replicaNumber), len(affinity), maxPlacementCandidates)
+	// the even placements are preferred.
+	sort.SliceStable(parts, func(i, j int) bool {
+		return lo.Max(parts[i])-lo.Min(parts[i]) < lo.Max(parts[j])-lo.Min(parts[j])
+	})
+	for _, part := range parts {
+		candidate := make(map[string]int)
+		for i, num := range part {
+			if num > 0 {
+				candidate[affinity[i]] = num
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, true, nil
+}
+
+// compositions returns at most limit ways to place n replicas into k resource groups.
+func compositions(n, k, limit int) [][]int {
+	ret := make([][]int, 0)
+	part := make([]int, k)
+	var fill func(i, left int)
+	fill = func(i, left int) {
+		if len(ret) >= limit {
+			return
+		}
+		if i == k-1 {
+			part[i] = left
+			ret = append(ret, append([]int(nil), part...))
+			return
+		}
+		for num := left; num >= 0; num-- {
+			part[i] = num
+			fill(i+1, left-num)
+		}
+	}
+	if k > 0 {
+		fill(0, n)
+	}
+	return ret
+}
+
+// placementConstraints returns the constraints the replicas of collection placed in the resource groups must satisfy.
+func placementConstraints(m *meta.Meta, collection int64, rgNames []string, affinity []string, nodeSelector map[string]string) []PlacementConstraint {
+	// TODO: !!!Warning, ResourceManager and ReplicaManager doesn't protected with each other in concurrent operation.
+	// 1. replica1 got rg1's node snapshot but doesn't spawn finished.
+	// 2. rg1 is removed.
+	// 3. replica1 spawn finished, but cannot find related resource group.
+	// each replica needs at least one node of each required role.
+	roleSplit := paramtable.Get().QueryCoordCfg.EnableReplicaRoleSplit.GetAsBool()
+	roleNum := len(meta.RequiredNodeRoles(roleSplit))
+	report := ClusterCapacityReport(m, rgNames)
+
+	constraints := []PlacementConstraint{
+		{
+			Name: "affinity",
+			Check: func(replicaNumInRG map[string]int) error {
+				if len(affinity) == 0 {
+					return nil
+				}
+				outside := typeutil.NewSet(lo.Keys(replicaNumInRG)...).Complement(typeutil.NewSet(affinity...)).Collect()
+				if len(outside) > 0 {
+					sort.Strings(outside)
+					return errors.Wrapf(ErrRGAffinityViolated, "collection %d is affined to resource groups %v, but got %v",
+						collection, affinity, outside)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "node_capacity",
+			Check: func(replicaNumInRG map[string]int) error {
+				return report.CheckReplicas(lo.MapValues(replicaNumInRG, func(num int, _ string) int {
+					return num * roleNum
+				}))
+			},
+		},
+	}
+	if roleSplit {
+		constraints = append(constraints, PlacementConstraint{Name: "streaming_nodes", Check: report.CheckStreamingNodes})
+	}
+	return append(constraints,
+		PlacementConstraint{Name: "replica_cap", Check: report.CheckReplicaCaps},
+		PlacementConstraint{
+			Name: "node_selector",
+			Check: func(replicaNumInRG map[string]int) error {
+				return checkNodeSelector(m, replicaNumInRG, roleNum, nodeSelector)
+			},
+		},
+	)
+}
@@ -0,0 +1,88 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestSolveReplicaPlacement(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	for _, rgName := range []string{"rg1", "rg2"} {
+		m.ResourceManager.AddResourceGroup(rgName, &rgpb.ResourceGroupConfig{
+			Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+			Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+		})
+	}
+	for i := 1; i <= 4; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	affinity := []string{"rg1", "rg2"}
+
+	// the placement by free nodes violates the replica cap of rg1, so the solver moves a replica to rg2.
+	assert.NoError(t, m.ResourceManager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg1": 1}))
+	replicaNumInRG, err := SolveReplicaPlacement(m, 1000, nil, 3, affinity, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"rg1": 1, "rg2": 2}, replicaNumInRG)
+
+	// the conflicting constraints are reported.
+	_, err = SolveReplicaPlacement(m, 1000, nil, 4, affinity, nil)
+	assert.ErrorIs(t, err, ErrPlacementInfeasible)
+	assert.ErrorIs(t, err, ErrRGAffinityNotEnough)
+	report := &InfeasibilityReport{}
+	assert.True(t, errors.As(err, &report))
+	assert.Equal(t, []string{"replica_cap", "affinity"}, report.ViolatedConstraints())
+	assert.Equal(t, map[string]int{"rg1": 2, "rg2": 2}, report.Candidate)
+
+	_, err = SolveReplicaPlacement(m, 1000, []string{"rg1"}, 3, affinity, map[string]string{"gpu": "true"})
+	assert.ErrorIs(t, err, meta.ErrNodeNotEnough)
+	assert.ErrorIs(t, err, ErrRGReplicaCapExceeded)
+	assert.ErrorIs(t, err, ErrNodeSelectorUnsatisfiable)
+	assert.True(t, errors.As(err, &report))
+	assert.Equal(t, []string{"node_capacity", "replica_cap", "node_selector"}, report.ViolatedConstraints())
+
+	_, err = SolveReplicaPlacement(m, 1000, []string{"rg1", "rg2"}, 3, affinity, nil)
+	assert.ErrorIs(t, err, ErrUseWrongNumRG)
+}
+
+func TestCompositions(t *testing.T) {
+	assert.Equal(t, [][]int{{2, 0}, {1, 1}, {0, 2}}, compositions(2, 2, 10))
+	assert.Len(t, compositions(3, 3, 10), 10)
+	assert.Len(t, compositions(10, 5, 10), 10)
+	assert.Empty(t, compositions(1, 0, 10))
+}
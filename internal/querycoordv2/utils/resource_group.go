@@ -0,0 +1,343 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/golang/protobuf/proto"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/log"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// replicaRelocation is the move of a replica out of the resource group to be removed.
+type replicaRelocation struct {
+	collectionID  int64
+	replicaID     int64
+	resourceGroup string
+}
+
+// RemoveResourceGroup decommissions the resource group, the replicas in it are relocated to other resource groups
+// and its nodes are returned to the default resource group before it's removed.
+// Nothing is changed if any replica can't be relocated.
+func RemoveResourceGroup(m *meta.Meta, rgName string) error {
+	if rgName == meta.DefaultResourceGroupName {
+		return errors.Wrap(ErrRGNotRemovable, "default resource group is not removable")
+	}
+	if rgName == meta.SystemResourceGroupName && systemResourceGroupNodeNum() > 0 {
+		return errors.Wrap(ErrRGNotRemovable, "system resource group is not removable while it's enabled")
+	}
+	if !m.ContainResourceGroup(rgName) {
+		// removing a non-exist resource group is ok.
+		return nil
+	}
+	for _, other := range m.ResourceManager.ListResourceGroups() {
+		rg := m.ResourceManager.GetResourceGroup(other)
+		if other != rgName && rg != nil && (rg.HasFrom(rgName) || rg.HasTo(rgName)) {
+			return errors.Wrapf(ErrRGNotRemovable, "resource group %s is referenced by the transfer configuration of %s", rgName, other)
+		}
+	}
+
+	relocations, err := planReplicaRelocations(m, rgName)
+	if err != nil {
+		return err
+	}
+	moves := make(map[int64]map[string]int)
+	for _, r := range relocations {
+		if moves[r.collectionID] == nil {
+			moves[r.collectionID] = make(map[string]int)
+		}
+		moves[r.collectionID][r.resourceGroup]++
+		log.Info("relocate replica of removed resource group",
+			zap.String("rgName", rgName),
+			zap.Int64("collectionID", r.collectionID),
+			zap.Int64("replicaID", r.replicaID),
+			zap.String("targetRG", r.resourceGroup))
+	}
+	for collectionID, targets := range moves {
+		for target, num := range targets {
+			if err := m.ReplicaManager.TransferReplica(collectionID, rgName, target, num); err != nil {
+				return err
+			}
+		}
+	}
+
+	// return the nodes to default resource group, and clear the configuration to make it deletable.
+	nodes, err := m.ResourceManager.GetNodes(rgName)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		if err := m.ResourceManager.TransferNode(rgName, meta.DefaultResourceGroupName, len(nodes)); err != nil {
+			return err
+		}
+	}
+	if cfg := m.ResourceManager.GetResourceGroup(rgName).GetConfigCloned(); cfg.GetLimits().GetNodeNum() != 0 || cfg.GetRequests().GetNodeNum() != 0 {
+		cfg.Requests = &rgpb.ResourceGroupLimit{NodeNum: 0}
+		cfg.Limits = &rgpb.ResourceGroupLimit{NodeNum: 0}
+		if err := m.ResourceManager.UpdateResourceGroups(map[string]*rgpb.ResourceGroupConfig{rgName: cfg}); err != nil {
+			return err
+		}
+	}
+	return m.ResourceManager.RemoveResourceGroup(rgName)
+}
+
+// planReplicaRelocations picks the target resource group for each replica in the resource group to be removed.
+//
+//	The target respects the affinity of collection and the replica cap of resource group, and must have more nodes
+//	than the replicas of same collection in it, the one with the most free nodes is preferred.
+func planReplicaRelocations(m *meta.Meta, rgName string) ([]replicaRelocation, error) {
+	replicas := m.ReplicaManager.GetByResourceGroup(rgName)
+	if len(replicas) == 0 {
+		return nil, nil
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].GetID() < replicas[j].GetID()
+	})
+
+	report := ClusterCapacityReport(m, nil)
+	// the nodes of removed resource group will be returned to default resource group.
+	if removed, ok := report.Groups[rgName]; ok && report.Groups[meta.DefaultResourceGroupName] != nil {
+		report.Groups[meta.DefaultResourceGroupName].AvailableNodes += removed.AvailableNodes
+	}
+	candidates := make([]string, 0, len(report.Groups))
+	for name := range report.Groups {
+		if name != rgName && name != meta.SystemResourceGroupName {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+
+	// replicaNum[collection][rg] is the number of replicas of collection in resource group, including the planned ones.
+	replicaNum := make(map[int64]map[string]int)
+	plannedNum := make(map[string]int)
+	relocations := make([]replicaRelocation, 0, len(replicas))
+	for _, replica := range replicas {
+		collectionID := replica.GetCollectionID()
+		if replicaNum[collectionID] == nil {
+			replicaNum[collectionID] = make(map[string]int)
+			for _, r := range m.ReplicaManager.GetByCollection(collectionID) {
+				replicaNum[collectionID][r.GetResourceGroup()]++
+			}
+		}
+		eligible := candidates
+		if IsSystemCollection(collectionID) && m.ContainResourceGroup(meta.SystemResourceGroupName) {
+			eligible = []string{meta.SystemResourceGroupName}
+		}
+		if affinity := m.CollectionManager.GetResourceGroupAffinity(collectionID); len(affinity) > 0 {
+			affinitySet := typeutil.NewSet(affinity...)
+			eligible = make([]string, 0, len(affinity))
+			for _, name := range candidates {
+				if affinitySet.Contain(name) {
+					eligible = append(eligible, name)
+				}
+			}
+		}
+
+		picked, pickedFree := "", 0
+		for _, name := range eligible {
+			capacity := report.Groups[name]
+			if capacity.MaxReplicas > 0 && capacity.Replicas+plannedNum[name] >= capacity.MaxReplicas {
+				continue
+			}
+			if free := capacity.AvailableNodes - replicaNum[collectionID][name]; free > pickedFree {
+				picked, pickedFree = name, free
+			}
+		}
+		if picked == "" {
+			return nil, errors.Wrapf(ErrRGNotRemovable, "no resource group in %v can host replica %d of collection %d from resource group %s",
+				eligible, replica.GetID(), collectionID, rgName)
+		}
+		replicaNum[collectionID][picked]++
+		plannedNum[picked]++
+		relocations = append(relocations, replicaRelocation{
+			collectionID:  collectionID,
+			replicaID:     replica.GetID(),
+			resourceGroup: picked,
+		})
+	}
+	return relocations, nil
+}
+
+// IsSystemCollection tells whether the collection is configured as a system collection.
+func IsSystemCollection(collectionID int64) bool {
+	return lo.Contains(paramtable.Get().QueryCoordCfg.SystemCollections.GetAsStrings(), strconv.FormatInt(collectionID, 10))
+}
+
+func systemResourceGroupNodeNum() int32 {
+	return paramtable.Get().QueryCoordCfg.SystemResourceGroupNodeNum.GetAsInt32()
+}
+
+// systemResourceGroupConfig reserves nodeNum nodes for the system resource group, the requests guarantee the nodes
+// are recovered from the default resource group, and the limits keep it from taking more.
+func systemResourceGroupConfig(nodeNum int32) *rgpb.ResourceGroupConfig {
+	return &rgpb.ResourceGroupConfig{
+		Requests:     &rgpb.ResourceGroupLimit{NodeNum: nodeNum},
+		Limits:       &rgpb.ResourceGroupLimit{NodeNum: nodeNum},
+		TransferFrom: []*rgpb.ResourceGroupTransfer{{ResourceGroup: meta.DefaultResourceGroupName}},
+		TransferTo:   []*rgpb.ResourceGroupTransfer{{ResourceGroup: meta.DefaultResourceGroupName}},
+	}
+}
+
+// EnsureSystemResourceGroup creates the system resource group with the configured node number on startup, or
+// updates its config if it's changed by hand or the node number is reconfigured. Nothing is done if the system
+// resource group is disabled, the existing one is kept for the replicas in it.
+func EnsureSystemResourceGroup(m *meta.Meta) error {
+	nodeNum := systemResourceGroupNodeNum()
+	if nodeNum <= 0 {
+		return nil
+	}
+	cfg := systemResourceGroupConfig(nodeNum)
+	rg := m.ResourceManager.GetResourceGroup(meta.SystemResourceGroupName)
+	if rg == nil {
+		return m.ResourceManager.AddResourceGroup(meta.SystemResourceGroupName, cfg)
+	}
+	if proto.Equal(rg.GetConfig(), cfg) {
+		return nil
+	}
+	log.Info("reset config of system resource group",
+		zap.Any("oldConfig", rg.GetConfig()),
+		zap.Any("newConfig", cfg))
+	return m.ResourceManager.UpdateResourceGroups(map[string]*rgpb.ResourceGroupConfig{meta.SystemResourceGroupName: cfg})
+}
+
+// applySystemResourceGroup places the replicas of system collection in the system resource group if it exists,
+// and keeps the replicas of user collections out of it.
+func applySystemResourceGroup(m *meta.Meta, collection int64, resourceGroups []string) ([]string, error) {
+	if !m.ContainResourceGroup(meta.SystemResourceGroupName) {
+		return resourceGroups, nil
+	}
+	if IsSystemCollection(collection) {
+		return []string{meta.SystemResourceGroupName}, nil
+	}
+	if lo.Contains(resourceGroups, meta.SystemResourceGroupName) {
+		return nil, errors.Wrapf(ErrSystemRGReserved, "collection %d is not a system collection", collection)
+	}
+	return resourceGroups, nil
+}
+
+// Conflict is a misconfiguration of resource groups and collection affinities, which fails the loading of
+// the collections or lets them compete for the same resource groups.
+type Conflict struct {
+	ResourceGroups []string
+	Collections    []int64
+	Reason         string
+}
+
+// ValidateResourceGroupAssignments cross-checks the affinities and replicas of collections against
+// the node limits and replica caps of resource groups, and reports the conflicts before they fail loads.
+// It's a preflight check after changing configurations, nothing is changed.
+func ValidateResourceGroupAssignments(m *meta.Meta) []Conflict {
+	roleNum := len(meta.RequiredNodeRoles(paramtable.Get().QueryCoordCfg.EnableReplicaRoleSplit.GetAsBool()))
+	nodeLimit := func(rgName string) int {
+		if rg := m.ResourceManager.GetResourceGroup(rgName); rg != nil {
+			return int(rg.GetConfig().GetLimits().GetNodeNum())
+		}
+		return 0
+	}
+
+	conflicts := make([]Conflict, 0)
+	collections := m.CollectionManager.GetAllCollections()
+	sort.Slice(collections, func(i, j int) bool {
+		return collections[i].GetCollectionID() < collections[j].GetCollectionID()
+	})
+	// exclusive is the replica number of collections affined to only one resource group,
+	// all of their replicas are requested from it.
+	exclusive := make(map[string]map[int64]int)
+	for _, collection := range collections {
+		collectionID := collection.GetCollectionID()
+		affinity := collection.GetResourceGroupAffinity()
+		if len(affinity) == 0 {
+			continue
+		}
+		limits := 0
+		for _, rgName := range affinity {
+			if !m.ContainResourceGroup(rgName) {
+				conflicts = append(conflicts, Conflict{
+					ResourceGroups: []string{rgName},
+					Collections:    []int64{collectionID},
+					Reason:         "affined resource group not found",
+				})
+				continue
+			}
+			limits += nodeLimit(rgName)
+		}
+		if required := int(collection.GetReplicaNumber()) * roleNum; required > limits {
+			conflicts = append(conflicts, Conflict{
+				ResourceGroups: affinity,
+				Collections:    []int64{collectionID},
+				Reason: fmt.Sprintf("%d replicas require %d nodes, but the affined resource groups are limited to %d nodes",
+					collection.GetReplicaNumber(), required, limits),
+			})
+		}
+		if len(affinity) == 1 {
+			if exclusive[affinity[0]] == nil {
+				exclusive[affinity[0]] = make(map[int64]int)
+			}
+			exclusive[affinity[0]][collectionID] = int(collection.GetReplicaNumber())
+		}
+	}
+
+	rgNames := m.ResourceManager.ListResourceGroups()
+	sort.Strings(rgNames)
+	for _, rgName := range rgNames {
+		// requested is the replicas requested from the resource group by each collection.
+		requested := make(map[int64]int)
+		for _, replica := range m.ReplicaManager.GetByResourceGroup(rgName) {
+			requested[replica.GetCollectionID()]++
+		}
+		for collectionID, num := range exclusive[rgName] {
+			requested[collectionID] = max(requested[collectionID], num)
+		}
+		collectionIDs := lo.Keys(requested)
+		sort.Slice(collectionIDs, func(i, j int) bool {
+			return collectionIDs[i] < collectionIDs[j]
+		})
+
+		// replicas of the same collection are placed on different nodes.
+		limit := nodeLimit(rgName)
+		total := 0
+		for _, collectionID := range collectionIDs {
+			num := requested[collectionID]
+			total += num
+			if num*roleNum > limit {
+				conflicts = append(conflicts, Conflict{
+					ResourceGroups: []string{rgName},
+					Collections:    []int64{collectionID},
+					Reason:         fmt.Sprintf("%d replicas require %d nodes, but the resource group is limited to %d nodes", num, num*roleNum, limit),
+				})
+			}
+		}
+		if maxReplicas := m.ResourceManager.GetMaxReplicas(rgName); maxReplicas > 0 && total > maxReplicas {
+			conflicts = append(conflicts, Conflict{
+				ResourceGroups: []string{rgName},
+				Collections:    collectionIDs,
+				Reason:         fmt.Sprintf("%d replicas are requested, but the resource group can host %d replicas at most", total, maxReplicas),
+			})
+		}
+	}
+	return conflicts
+}
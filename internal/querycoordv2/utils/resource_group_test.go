@@ -0,0 +1,195 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
+	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestRemoveResourceGroup(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveCollection(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.EXPECT().RemoveResourceGroup(mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	for _, rgName := range []string{"rg1", "rg2"} {
+		m.ResourceManager.AddResourceGroup(rgName, &rgpb.ResourceGroupConfig{
+			Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+			Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+		})
+	}
+	for i := 1; i <= 5; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	for i, rgName := range []string{"rg1", "rg2"} {
+		nodes, err := m.ResourceManager.GetNodes(rgName)
+		assert.NoError(t, err)
+		m.ReplicaManager.Put(meta.NewReplica(&querypb.Replica{
+			ID:            int64(i + 1),
+			CollectionID:  1,
+			Nodes:         nodes,
+			ResourceGroup: rgName,
+		}))
+	}
+
+	assert.ErrorIs(t, RemoveResourceGroup(m, meta.DefaultResourceGroupName), ErrRGNotRemovable)
+	assert.NoError(t, RemoveResourceGroup(m, "rg3"))
+
+	// the replica can't be relocated out of the affinity, nothing is changed.
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 2))
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(1, []string{"rg1"}))
+	err := RemoveResourceGroup(m, "rg1")
+	assert.ErrorIs(t, err, ErrRGNotRemovable)
+	assert.ErrorContains(t, err, "replica 1 of collection 1")
+	assert.True(t, m.ContainResourceGroup("rg1"))
+	assert.Equal(t, "rg1", m.ReplicaManager.Get(1).GetResourceGroup())
+
+	// the replica is relocated to default resource group, which has most free nodes after taking the nodes of rg1.
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(1, nil))
+	assert.NoError(t, RemoveResourceGroup(m, "rg1"))
+	assert.False(t, m.ContainResourceGroup("rg1"))
+	assert.Equal(t, meta.DefaultResourceGroupName, m.ReplicaManager.Get(1).GetResourceGroup())
+	assert.Equal(t, "rg2", m.ReplicaManager.Get(2).GetResourceGroup())
+	nodes, err := m.ResourceManager.GetNodes(meta.DefaultResourceGroupName)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 3)
+}
+
+func TestValidateResourceGroupAssignments(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveCollection(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, session.NewNodeManager())
+	for _, rgName := range []string{"rg1", "rg2"} {
+		m.ResourceManager.AddResourceGroup(rgName, &rgpb.ResourceGroupConfig{
+			Requests: &rgpb.ResourceGroupLimit{NodeNum: 1},
+			Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+		})
+	}
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 2))
+	m.CollectionManager.PutCollection(CreateTestCollection(2, 2))
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(1, []string{"rg1"}))
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(2, []string{"rg1", "rg2"}))
+	m.ReplicaManager.Put(meta.NewReplica(&querypb.Replica{ID: 1, CollectionID: 2, ResourceGroup: "rg1"}))
+	assert.Empty(t, ValidateResourceGroupAssignments(m))
+
+	// collection 1 can't hold 3 replicas in rg1.
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 3))
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(1, []string{"rg1"}))
+	conflicts := ValidateResourceGroupAssignments(m)
+	assert.Len(t, conflicts, 2)
+	assert.Equal(t, []int64{1}, conflicts[0].Collections)
+	assert.Contains(t, conflicts[0].Reason, "affined resource groups are limited to 2 nodes")
+	assert.Equal(t, []string{"rg1"}, conflicts[1].ResourceGroups)
+	assert.Contains(t, conflicts[1].Reason, "resource group is limited to 2 nodes")
+
+	// collections 1 and 2 over-subscribe the replica cap of rg1.
+	m.CollectionManager.PutCollection(CreateTestCollection(1, 2))
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(1, []string{"rg1"}))
+	assert.NoError(t, m.ResourceManager.UpdateResourceGroupsWithMaxReplicas(nil, map[string]int32{"rg1": 2}))
+	conflicts = ValidateResourceGroupAssignments(m)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, []int64{1, 2}, conflicts[0].Collections)
+	assert.Contains(t, conflicts[0].Reason, "3 replicas are requested")
+
+	// affined to a missing resource group.
+	assert.NoError(t, m.CollectionManager.SetResourceGroupAffinity(2, []string{"rg3"}))
+	conflicts = ValidateResourceGroupAssignments(m)
+	assert.Equal(t, []string{"rg3"}, conflicts[0].ResourceGroups)
+	assert.Equal(t, "affined resource group not found", conflicts[0].Reason)
+}
+
+func TestSystemResourceGroup(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveReplica(mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	for i := 1; i <= 4; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+
+	// disabled by default.
+	assert.NoError(t, EnsureSystemResourceGroup(m))
+	assert.False(t, m.ContainResourceGroup(meta.SystemResourceGroupName))
+
+	paramtable.Get().Save("queryCoord.systemResourceGroupNodeNum", "1")
+	defer paramtable.Get().Reset("queryCoord.systemResourceGroupNodeNum")
+	paramtable.Get().Save("queryCoord.systemCollections", "1")
+	defer paramtable.Get().Reset("queryCoord.systemCollections")
+	assert.NoError(t, EnsureSystemResourceGroup(m))
+	assert.NoError(t, EnsureSystemResourceGroup(m))
+	rg := m.ResourceManager.GetResourceGroup(meta.SystemResourceGroupName)
+	assert.NotNil(t, rg)
+	assert.EqualValues(t, 1, rg.GetConfig().GetRequests().GetNodeNum())
+	assert.ErrorIs(t, RemoveResourceGroup(m, meta.SystemResourceGroupName), ErrRGNotRemovable)
+
+	// the reconfigured node number is applied.
+	paramtable.Get().Save("queryCoord.systemResourceGroupNodeNum", "2")
+	assert.NoError(t, EnsureSystemResourceGroup(m))
+	rg = m.ResourceManager.GetResourceGroup(meta.SystemResourceGroupName)
+	assert.EqualValues(t, 2, rg.GetConfig().GetRequests().GetNodeNum())
+	assert.EqualValues(t, 2, rg.GetConfig().GetLimits().GetNodeNum())
+	assert.NoError(t, m.ResourceManager.AutoRecoverResourceGroup(meta.SystemResourceGroupName))
+	nodes, err := m.ResourceManager.GetNodes(meta.SystemResourceGroupName)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+
+	// the system collection is placed in the system resource group whatever requested.
+	plans, err := PlanReplicasWithRG(m, 1, []string{meta.DefaultResourceGroupName}, 1, ReplicaConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ReplicaPlan{{ResourceGroup: meta.SystemResourceGroupName, ReplicaNumber: 1}}, plans)
+
+	// the user collection can't be loaded into the system resource group.
+	_, err = PlanReplicasWithRG(m, 2, []string{meta.SystemResourceGroupName}, 1, ReplicaConfig{})
+	assert.ErrorIs(t, err, ErrSystemRGReserved)
+	plans, err = PlanReplicasWithRG(m, 2, nil, 1, ReplicaConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ReplicaPlan{{ResourceGroup: meta.DefaultResourceGroupName, ReplicaNumber: 1}}, plans)
+}
@@ -0,0 +1,186 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/metrics"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// SharedNodeAllocation is the replica slots of a shared node allocated to a resource group.
+type SharedNodeAllocation struct {
+	NodeID        int64
+	ResourceGroup string
+	// Slots is the number of replicas of resource group the node can host.
+	Slots int
+	// Used is the number of replicas of resource group placed on the node.
+	Used int
+}
+
+// sharedNodeView is a snapshot of the shared nodes and the collections served on them, which is built once
+// and shared by all the collections recovered together.
+type sharedNodeView struct {
+	// slots is the replica slots of shared nodes keyed by node id.
+	// The nodes of a resource group are shared if the resource group lends slots to the other resource groups.
+	slots map[int64]map[string]int
+	// owners is the resource group each shared node belongs to.
+	owners map[int64]string
+	// collections is the collections whose replicas in each resource group serve on the shared node,
+	// sorted by collection id.
+	collections map[int64]map[string][]int64
+}
+
+func newSharedNodeView(m *meta.Meta) *sharedNodeView {
+	view := &sharedNodeView{
+		slots:       make(map[int64]map[string]int),
+		owners:      make(map[int64]string),
+		collections: make(map[int64]map[string][]int64),
+	}
+	rgNames := m.ResourceManager.ListResourceGroups()
+	for _, rgName := range rgNames {
+		rg := m.ResourceManager.GetResourceGroup(rgName)
+		if rg == nil {
+			continue
+		}
+		slots := rg.GetSharedSlots()
+		if len(slots) == 0 {
+			continue
+		}
+		for _, nodeID := range rg.GetNodes() {
+			view.slots[nodeID] = slots
+			view.owners[nodeID] = rgName
+			view.collections[nodeID] = make(map[string][]int64)
+		}
+	}
+	if len(view.slots) == 0 {
+		return view
+	}
+	for _, rgName := range rgNames {
+		for _, replica := range m.ReplicaManager.GetByResourceGroup(rgName) {
+			for _, nodeID := range replica.GetRWNodes() {
+				if collections, ok := view.collections[nodeID]; ok {
+					collections[rgName] = append(collections[rgName], replica.GetCollectionID())
+				}
+			}
+		}
+	}
+	for _, collections := range view.collections {
+		for _, ids := range collections {
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		}
+	}
+	return view
+}
+
+// includeSharedNodes offers each shared node to one of the resource groups of collection having free slots on it.
+//
+//	A node hosts at most one replica of a collection, so the node is kept in the resource group whose replica of
+//	collection already serves on it, otherwise it's kept in its own resource group, which isn't limited by the
+//	slots, or offered to the resource group with the most free slots.
+//	The slots are granted to the collections in the order of collection id, so the replicas exceeding the slots
+//	are moved away if the slots shrink.
+func includeSharedNodes(m *meta.Meta, view *sharedNodeView, collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet) {
+	if len(view.slots) == 0 {
+		return
+	}
+	rgNames := lo.Keys(rgs)
+	sort.Strings(rgNames)
+	replicas := m.ReplicaManager.GetByCollection(collectionID)
+	for nodeID, slots := range view.slots {
+		own := view.owners[nodeID]
+		for _, nodes := range rgs {
+			nodes.Remove(nodeID)
+		}
+
+		picked, pickedFree := "", 0
+		holder, ok := lo.Find(replicas, func(replica *meta.Replica) bool {
+			return replica.ContainRWNode(nodeID)
+		})
+		if ok {
+			rgName := holder.GetResourceGroup()
+			_, found := rgs[rgName]
+			if rank := lo.IndexOf(view.collections[nodeID][rgName], collectionID); found && (rgName == own || rank < slots[rgName]) {
+				picked = rgName
+			}
+		}
+		if _, found := rgs[own]; picked == "" && found {
+			picked = own
+		}
+		if picked == "" {
+			for _, rgName := range rgNames {
+				if free := slots[rgName] - len(view.collections[nodeID][rgName]); free > pickedFree {
+					picked, pickedFree = rgName, free
+				}
+			}
+		}
+		if picked != "" {
+			rgs[picked].Insert(nodeID)
+		}
+	}
+}
+
+// lentNodeNum returns the number of shared nodes lent to resource group by the other resource groups.
+func (view *sharedNodeView) lentNodeNum(rgName string) int {
+	num := 0
+	for nodeID, slots := range view.slots {
+		if view.owners[nodeID] != rgName && slots[rgName] > 0 {
+			num++
+		}
+	}
+	return num
+}
+
+// SharedNodeAllocations returns the replica slots of shared nodes allocated to each resource group,
+// sorted by node id and resource group.
+func SharedNodeAllocations(m *meta.Meta) []SharedNodeAllocation {
+	view := newSharedNodeView(m)
+	allocations := make([]SharedNodeAllocation, 0)
+	for nodeID, slots := range view.slots {
+		for rgName, num := range slots {
+			allocations = append(allocations, SharedNodeAllocation{
+				NodeID:        nodeID,
+				ResourceGroup: rgName,
+				Slots:         num,
+				Used:          len(view.collections[nodeID][rgName]),
+			})
+		}
+	}
+	sort.Slice(allocations, func(i, j int) bool {
+		if allocations[i].NodeID != allocations[j].NodeID {
+			return allocations[i].NodeID < allocations[j].NodeID
+		}
+		return allocations[i].ResourceGroup < allocations[j].ResourceGroup
+	})
+	return allocations
+}
+
+// UpdateSharedNodeMetrics updates the allocated and used replica slots of shared nodes.
+func UpdateSharedNodeMetrics(m *meta.Meta) {
+	// the shared slots and the nodes of resource groups change, drop the ones not shared any more.
+	metrics.QueryCoordSharedNodeReplicaSlots.Reset()
+	for _, allocation := range SharedNodeAllocations(m) {
+		nodeLabel := strconv.FormatInt(allocation.NodeID, 10)
+		metrics.QueryCoordSharedNodeReplicaSlots.WithLabelValues(nodeLabel, allocation.ResourceGroup, "allocated").Set(float64(allocation.Slots))
+		metrics.QueryCoordSharedNodeReplicaSlots.WithLabelValues(nodeLabel, allocation.ResourceGroup, "used").Set(float64(allocation.Used))
+	}
+}
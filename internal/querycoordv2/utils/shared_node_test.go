@@ -0,0 +1,139 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
+	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestSharedNodes(t *testing.T) {
+	paramtable.Init()
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	m.ResourceManager.AddResourceGroup("rg1", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 2},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 2},
+	})
+	m.ResourceManager.AddResourceGroup("rg2", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 1},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 1},
+	})
+	for i := 1; i <= 3; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	rg1Nodes, err := m.ResourceManager.GetNodes("rg1")
+	assert.NoError(t, err)
+	rg2Nodes, err := m.ResourceManager.GetNodes("rg2")
+	assert.NoError(t, err)
+	shared := rg1Nodes[0]
+	m.ReplicaManager.Put(meta.NewReplica(&querypb.Replica{
+		ID:            1,
+		CollectionID:  1,
+		Nodes:         []int64{shared},
+		ResourceGroup: "rg1",
+	}), meta.NewReplica(&querypb.Replica{
+		ID:            2,
+		CollectionID:  2,
+		Nodes:         rg2Nodes,
+		ResourceGroup: "rg2",
+	}), meta.NewReplica(&querypb.Replica{
+		ID:            3,
+		CollectionID:  3,
+		Nodes:         rg2Nodes,
+		ResourceGroup: "rg2",
+	}))
+
+	recoverableNodes := func(collectionID int64, rgName string) []int64 {
+		rgs, err := m.ResourceManager.GetNodesOfMultiRG([]string{rgName})
+		assert.NoError(t, err)
+		includeSharedNodes(m, newSharedNodeView(m), collectionID, rgs)
+		return rgs[rgName].Collect()
+	}
+
+	// nodes are exclusive by default.
+	assert.ElementsMatch(t, rg1Nodes, recoverableNodes(1, "rg1"))
+	assert.ElementsMatch(t, rg2Nodes, recoverableNodes(2, "rg2"))
+	assert.Empty(t, SharedNodeAllocations(m))
+	assert.Equal(t, 0, ClusterCapacityReport(m, []string{"rg2"}).Groups["rg2"].SharedNodes)
+
+	// the nodes of rg1 lend one slot to rg2.
+	assert.NoError(t, m.ResourceManager.UpdateSharedSlots(map[string]map[string]int32{"rg1": {"rg2": 1}}))
+	assert.Equal(t, map[string]int{"rg2": 1}, m.ResourceManager.GetResourceGroup("rg1").GetSharedSlots())
+
+	// the own resource group isn't limited by the slots, and the shared nodes are offered to rg2.
+	assert.ElementsMatch(t, rg1Nodes, recoverableNodes(1, "rg1"))
+	assert.ElementsMatch(t, append(append([]int64{}, rg1Nodes...), rg2Nodes...), recoverableNodes(2, "rg2"))
+	report := ClusterCapacityReport(m, []string{"rg2"})
+	assert.Equal(t, 2, report.Groups["rg2"].SharedNodes)
+	assert.NoError(t, report.CheckReplicas(map[string]int{"rg2": 2}))
+
+	// no slot is left for the other collections once taken.
+	m.ReplicaManager.Put(meta.NewReplica(&querypb.Replica{
+		ID:            2,
+		CollectionID:  2,
+		Nodes:         append([]int64{shared}, rg2Nodes...),
+		ResourceGroup: "rg2",
+	}))
+	assert.ElementsMatch(t, append([]int64{rg1Nodes[1]}, rg2Nodes...), recoverableNodes(3, "rg2"))
+	view := newSharedNodeView(m)
+	assert.Equal(t, map[string][]int64{"rg1": {1}, "rg2": {2}}, view.collections[shared])
+	assert.Empty(t, view.collections[rg1Nodes[1]])
+	assert.Equal(t, "rg1", view.owners[shared])
+	assert.Equal(t, 2, view.lentNodeNum("rg2"))
+	assert.Equal(t, 0, view.lentNodeNum("rg1"))
+	assert.ElementsMatch(t, []SharedNodeAllocation{
+		{NodeID: shared, ResourceGroup: "rg2", Slots: 1, Used: 1},
+		{NodeID: rg1Nodes[1], ResourceGroup: "rg2", Slots: 1, Used: 0},
+	}, SharedNodeAllocations(m))
+
+	// the replica exceeding the slots is moved away after the slots shrink.
+	assert.NoError(t, m.ResourceManager.UpdateSharedSlots(map[string]map[string]int32{"rg1": {"rg2": 0}}))
+	assert.ElementsMatch(t, rg2Nodes, recoverableNodes(2, "rg2"))
+
+	// invalid slots are rejected.
+	assert.ErrorIs(t, m.ResourceManager.UpdateSharedSlots(map[string]map[string]int32{"rg1": {"rg1": 1}}), merr.ErrResourceGroupIllegalConfig)
+	assert.ErrorIs(t, m.ResourceManager.UpdateSharedSlots(map[string]map[string]int32{"rg1": {"rg3": 1}}), merr.ErrResourceGroupIllegalConfig)
+	assert.ErrorIs(t, m.ResourceManager.UpdateSharedSlots(map[string]map[string]int32{"rg1": {"rg2": -1}}), merr.ErrResourceGroupIllegalConfig)
+	assert.ErrorIs(t, m.ResourceManager.UpdateSharedSlots(map[string]map[string]int32{"rg3": {"rg2": 1}}), merr.ErrResourceGroupNotFound)
+
+	// the nodes are exclusive again after the sharing stops.
+	assert.NoError(t, m.ResourceManager.UpdateSharedSlots(map[string]map[string]int32{"rg1": {}}))
+	assert.ElementsMatch(t, rg1Nodes, recoverableNodes(1, "rg1"))
+	assert.ElementsMatch(t, rg2Nodes, recoverableNodes(2, "rg2"))
+	assert.Empty(t, SharedNodeAllocations(m))
+}
@@ -0,0 +1,91 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"sort"
+
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/pkg/metrics"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// idleNodes returns the nodes of resource group which are not used by any replica, sorted by node id.
+func idleNodes(m *meta.Meta, rgName string, nodes typeutil.UniqueSet) []int64 {
+	idle := nodes.Clone()
+	for _, replica := range m.ReplicaManager.GetByResourceGroup(rgName) {
+		idle.Remove(replica.GetNodes()...)
+	}
+	ret := idle.Collect()
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// SpareNodeNum returns the number of standby nodes of resource group,
+// which are not assigned to any replica for fast failover.
+func SpareNodeNum(m *meta.Meta, rgName string) int {
+	nodes, err := m.ResourceManager.GetNodes(rgName)
+	if err != nil {
+		return 0
+	}
+	spareNum := paramtable.Get().QueryCoordCfg.SpareNodeNumPerResourceGroup.GetAsInt()
+	return min(spareNum, len(idleNodes(m, rgName, typeutil.NewUniqueSet(nodes...))))
+}
+
+// reserveSpareNodes removes the spare nodes of resource groups from the nodes available to replicas of collection.
+//
+//	Spares are picked from the idle nodes, and the nodes lost by replicas of collection are recovered by spares first,
+//	so the spare count shrinks on node failure until new nodes join the resource group.
+func reserveSpareNodes(m *meta.Meta, collectionID typeutil.UniqueID, rgs map[string]typeutil.UniqueSet) {
+	spareNum := paramtable.Get().QueryCoordCfg.SpareNodeNumPerResourceGroup.GetAsInt()
+	if spareNum <= 0 {
+		return
+	}
+	replicasOfCollection := m.ReplicaManager.GetByCollection(collectionID)
+	for rgName, nodes := range rgs {
+		replicas := lo.Filter(replicasOfCollection, func(replica *meta.Replica, _ int) bool {
+			return replica.GetResourceGroup() == rgName
+		})
+		// the rw nodes which are not in resource group any more should be recovered by spares.
+		lost := 0
+		for _, replica := range replicas {
+			replica.RangeOverRWNodes(func(node int64) bool {
+				if !nodes.Contain(node) {
+					lost++
+				}
+				return true
+			})
+		}
+		idle := idleNodes(m, rgName, nodes)
+		// keep at least one node for each replica.
+		reserved := min(spareNum, len(idle)-lost, nodes.Len()-len(replicas))
+		if reserved <= 0 {
+			continue
+		}
+		nodes.Remove(idle[:reserved]...)
+	}
+}
+
+// UpdateSpareNodeMetrics updates the spare node number of all resource groups.
+func UpdateSpareNodeMetrics(m *meta.Meta) {
+	for _, rgName := range m.ResourceManager.ListResourceGroups() {
+		metrics.QueryCoordResourceGroupSpareNodeNum.WithLabelValues(rgName).Set(float64(SpareNodeNum(m, rgName)))
+	}
+}
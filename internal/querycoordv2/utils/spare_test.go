@@ -0,0 +1,88 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/rgpb"
+	"github.com/milvus-io/milvus/internal/metastore/mocks"
+	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
+	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestReserveSpareNodes(t *testing.T) {
+	paramtable.Init()
+	paramtable.Get().Save(paramtable.Get().QueryCoordCfg.SpareNodeNumPerResourceGroup.Key, "2")
+	defer paramtable.Get().Reset(paramtable.Get().QueryCoordCfg.SpareNodeNumPerResourceGroup.Key)
+
+	store := mocks.NewQueryCoordCatalog(t)
+	store.EXPECT().SaveReplica(mock.Anything).Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything).Return(nil).Maybe()
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Maybe()
+	nodeMgr := session.NewNodeManager()
+	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
+	m.ResourceManager.AddResourceGroup("rg1", &rgpb.ResourceGroupConfig{
+		Requests: &rgpb.ResourceGroupLimit{NodeNum: 5},
+		Limits:   &rgpb.ResourceGroupLimit{NodeNum: 5},
+	})
+	for i := 1; i <= 5; i++ {
+		nodeMgr.Add(session.NewNodeInfo(session.ImmutableNodeInfo{
+			NodeID:   int64(i),
+			Address:  "localhost",
+			Hostname: "localhost",
+		}))
+		m.ResourceManager.HandleNodeUp(int64(i))
+	}
+	m.ReplicaManager.Put(meta.NewReplica(&querypb.Replica{
+		ID:            1,
+		CollectionID:  1,
+		Nodes:         []int64{1, 2},
+		ResourceGroup: "rg1",
+	}), meta.NewReplica(&querypb.Replica{
+		ID:            2,
+		CollectionID:  1,
+		Nodes:         []int64{3},
+		ResourceGroup: "rg1",
+	}))
+
+	rgs, err := m.ResourceManager.GetNodesOfMultiRG([]string{"rg1"})
+	assert.NoError(t, err)
+	reserveSpareNodes(m, 1, rgs)
+	assert.ElementsMatch(t, []int64{1, 2, 3}, rgs["rg1"].Collect())
+	assert.Equal(t, 2, SpareNodeNum(m, "rg1"))
+
+	// the lost node is recovered by spare first.
+	m.ResourceManager.HandleNodeDown(1)
+	rgs, err = m.ResourceManager.GetNodesOfMultiRG([]string{"rg1"})
+	assert.NoError(t, err)
+	reserveSpareNodes(m, 1, rgs)
+	assert.ElementsMatch(t, []int64{2, 3, 5}, rgs["rg1"].Collect())
+
+	// no spare is reserved if disabled.
+	paramtable.Get().Save(paramtable.Get().QueryCoordCfg.SpareNodeNumPerResourceGroup.Key, "0")
+	rgs, err = m.ResourceManager.GetNodesOfMultiRG([]string{"rg1"})
+	assert.NoError(t, err)
+	reserveSpareNodes(m, 1, rgs)
+	assert.Equal(t, 4, rgs["rg1"].Len())
+	assert.Equal(t, 0, SpareNodeNum(m, "rg1"))
+}
@@ -138,13 +138,21 @@ func GetShardLeaders(m *meta.Meta, targetMgr *meta.TargetManager, dist *meta.Dis
 		}
 
 		readableLeaders = filterDupLeaders(m.ReplicaManager, readableLeaders)
+		readableLeaders = filterDegradedLeaders(m.ReplicaManager, readableLeaders)
+		readableLeaders = filterStandbyLeaders(m.ReplicaManager, readableLeaders)
 		ids := make([]int64, 0, len(leaders))
 		addrs := make([]string, 0, len(leaders))
+		weights := make([]float64, 0, len(leaders))
 		for _, leader := range readableLeaders {
 			info := nodeMgr.Get(leader.ID)
 			if info != nil {
 				ids = append(ids, info.ID())
 				addrs = append(addrs, info.Addr())
+				weight := meta.DefaultReadWeight
+				if replica := m.ReplicaManager.GetByCollectionAndNode(collectionID, leader.ID); replica != nil {
+					weight = replica.GetReadWeight()
+				}
+				weights = append(weights, weight)
 			}
 		}
 
@@ -160,12 +168,47 @@ func GetShardLeaders(m *meta.Meta, targetMgr *meta.TargetManager, dist *meta.Dis
 			ChannelName: channel.GetChannelName(),
 			NodeIds:     ids,
 			NodeAddrs:   addrs,
+			ReadWeights: weights,
 		})
 	}
 
 	return ret, nil
 }
 
+// filterDegradedLeaders routes around the leaders of degraded replicas,
+// unless all the readable leaders are in degraded replicas.
+func filterDegradedLeaders(replicaManager *meta.ReplicaManager, leaders map[int64]*meta.LeaderView) map[int64]*meta.LeaderView {
+	result := make(map[int64]*meta.LeaderView)
+	for id, view := range leaders {
+		replica := replicaManager.GetByCollectionAndNode(view.CollectionID, view.ID)
+		if replica != nil && replica.IsDegraded() {
+			continue
+		}
+		result[id] = view
+	}
+	if len(result) == 0 {
+		return leaders
+	}
+	return result
+}
+
+// filterStandbyLeaders routes around the leaders of standby replicas,
+// unless all the readable leaders are in standby replicas.
+func filterStandbyLeaders(replicaManager *meta.ReplicaManager, leaders map[int64]*meta.LeaderView) map[int64]*meta.LeaderView {
+	result := make(map[int64]*meta.LeaderView)
+	for id, view := range leaders {
+		replica := replicaManager.GetByCollectionAndNode(view.CollectionID, view.ID)
+		if replica != nil && replica.IsStandby() {
+			continue
+		}
+		result[id] = view
+	}
+	if len(result) == 0 {
+		return leaders
+	}
+	return result
+}
+
 func filterDupLeaders(replicaManager *meta.ReplicaManager, leaders map[int64]*meta.LeaderView) map[int64]*meta.LeaderView {
 	type leaderID struct {
 		ReplicaID int64
@@ -155,7 +155,10 @@ func NewQueryNode(ctx context.Context, factory dependency.Factory) *QueryNode {
 
 func (node *QueryNode) initSession() error {
 	minimalIndexVersion, currentIndexVersion := getIndexEngineVersion()
-	node.session = sessionutil.NewSession(node.ctx, sessionutil.WithIndexEngineVersion(minimalIndexVersion, currentIndexVersion))
+	node.session = sessionutil.NewSession(node.ctx,
+		sessionutil.WithIndexEngineVersion(minimalIndexVersion, currentIndexVersion),
+		sessionutil.WithDisableStreaming(!paramtable.Get().QueryNodeCfg.EnableStreaming.GetAsBool()),
+		sessionutil.WithLabels(paramtable.Get().QueryNodeCfg.Labels.GetValue()))
 	if node.session == nil {
 		return fmt.Errorf("session is nil, the etcd client connection may have failed")
 	}
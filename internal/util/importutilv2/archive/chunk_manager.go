@@ -0,0 +1,298 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+const (
+	TarFileExt = ".tar"
+	ZipFileExt = ".zip"
+)
+
+// IsArchive returns whether the file is an archive of import files.
+func IsArchive(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == TarFileExt || ext == ZipFileExt
+}
+
+type entry struct {
+	size int64
+	// compressed entry is read as a stream, it doesn't support random access.
+	compressed bool
+	open       func() (storage.FileReader, error)
+}
+
+// ChunkManager serves the regular file entries of an archive as files, so that the readers of import files
+// read them as if they were in the storage. The other operations are delegated to the chunk manager of archive.
+//
+//	The entries stored without compression are read from the archive by range, the compressed ones
+//	are inflated as a stream once opened, so they can only be read sequentially.
+type ChunkManager struct {
+	storage.ChunkManager
+	path    string
+	reader  storage.FileReader
+	names   []string
+	entries map[string]*entry
+}
+
+// Open lists the entries of archive, fails if the archive format is unsupported,
+// or the uncompressed size of any entry exceeds maxSize.
+func Open(ctx context.Context, cm storage.ChunkManager, path string, maxSize int64) (*ChunkManager, error) {
+	size, err := cm.Size(ctx, path)
+	if err != nil {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("get size of archive failed, path=%s, err=%s", path, err.Error()))
+	}
+	reader, err := cm.Reader(ctx, path)
+	if err != nil {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("read archive failed, path=%s, err=%s", path, err.Error()))
+	}
+	m := &ChunkManager{
+		ChunkManager: cm,
+		path:         path,
+		reader:       reader,
+		entries:      make(map[string]*entry),
+	}
+	switch filepath.Ext(path) {
+	case TarFileExt:
+		err = m.listTar(maxSize)
+	case ZipFileExt:
+		err = m.listZip(size, maxSize)
+	default:
+		err = merr.WrapErrImportFailed(fmt.Sprintf("unsupported archive format, path=%s", path))
+	}
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	if len(m.names) == 0 {
+		reader.Close()
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("no file in archive, path=%s", path))
+	}
+	return m, nil
+}
+
+func (m *ChunkManager) addEntry(name string, size, maxSize int64, compressed bool, open func() (storage.FileReader, error)) error {
+	if size > maxSize {
+		return merr.WrapErrImportFailed(fmt.Sprintf(
+			"The uncompressed size of file in archive has reached the maximum limit allowed for importing, "+
+				"archive=%s, file=%s, size=%d, maxSize=%d", m.path, name, size, maxSize))
+	}
+	if _, ok := m.entries[name]; ok {
+		return merr.WrapErrImportFailed(fmt.Sprintf("duplicate file in archive, archive=%s, file=%s", m.path, name))
+	}
+	m.names = append(m.names, name)
+	m.entries[name] = &entry{size: size, compressed: compressed, open: open}
+	return nil
+}
+
+func (m *ChunkManager) listTar(maxSize int64) error {
+	counter := &countingReader{r: m.reader}
+	tr := tar.NewReader(counter)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return merr.WrapErrImportFailed(fmt.Sprintf("read tar archive failed, path=%s, err=%s", m.path, err.Error()))
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return merr.WrapErrImportFailed(fmt.Sprintf("unsupported file in tar archive, archive=%s, file=%s", m.path, header.Name))
+		}
+		// the data of regular file follows the header without compression.
+		offset, size := counter.pos, header.Size
+		err = m.addEntry(header.Name, size, maxSize, false, func() (storage.FileReader, error) {
+			return &sectionReader{io.NewSectionReader(m.reader, offset, size)}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (m *ChunkManager) listZip(size, maxSize int64) error {
+	zr, err := zip.NewReader(m.reader, size)
+	if err != nil {
+		return merr.WrapErrImportFailed(fmt.Sprintf("read zip archive failed, path=%s, err=%s", m.path, err.Error()))
+	}
+	for _, file := range zr.File {
+		file := file
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			return merr.WrapErrImportFailed(fmt.Sprintf("unsupported file in zip archive, archive=%s, file=%s", m.path, file.Name))
+		}
+		entrySize := int64(file.UncompressedSize64)
+		compressed := file.Method != zip.Store
+		err = m.addEntry(file.Name, entrySize, maxSize, compressed, func() (storage.FileReader, error) {
+			if !compressed {
+				offset, err := file.DataOffset()
+				if err != nil {
+					return nil, err
+				}
+				return &sectionReader{io.NewSectionReader(m.reader, offset, entrySize)}, nil
+			}
+			// the decompressor of zip fails the read once the data exceeds the size in header.
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			return &streamReader{ReadCloser: rc, name: file.Name}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsCompressed returns whether the file is compressed in archive, the compressed file can only be read sequentially.
+func (m *ChunkManager) IsCompressed(filePath string) bool {
+	e, ok := m.entries[filePath]
+	return ok && e.compressed
+}
+
+// Entries returns the names of files in archive, in the order of archive.
+func (m *ChunkManager) Entries() []string {
+	return m.names
+}
+
+func (m *ChunkManager) getEntry(filePath string) (*entry, error) {
+	e, ok := m.entries[filePath]
+	if !ok {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("no such file in archive, archive=%s, file=%s", m.path, filePath))
+	}
+	return e, nil
+}
+
+func (m *ChunkManager) Size(ctx context.Context, filePath string) (int64, error) {
+	e, err := m.getEntry(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return e.size, nil
+}
+
+func (m *ChunkManager) Exist(ctx context.Context, filePath string) (bool, error) {
+	_, ok := m.entries[filePath]
+	return ok, nil
+}
+
+func (m *ChunkManager) Reader(ctx context.Context, filePath string) (storage.FileReader, error) {
+	e, err := m.getEntry(filePath)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := e.open()
+	if err != nil {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("open file in archive failed, archive=%s, file=%s, err=%s", m.path, filePath, err.Error()))
+	}
+	return reader, nil
+}
+
+func (m *ChunkManager) Read(ctx context.Context, filePath string) ([]byte, error) {
+	reader, err := m.Reader(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func (m *ChunkManager) ReadAt(ctx context.Context, filePath string, off int64, length int64) ([]byte, error) {
+	reader, err := m.Reader(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	p := make([]byte, length)
+	if m.IsCompressed(filePath) {
+		// skip the leading data of stream instead of seeking.
+		if _, err := io.CopyN(io.Discard, reader, off); err != nil {
+			return nil, err
+		}
+		n, err := io.ReadFull(reader, p)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return p[:n], err
+	}
+	n, err := reader.ReadAt(p, off)
+	return p[:n], err
+}
+
+// Close releases the reader of archive.
+func (m *ChunkManager) Close() {
+	m.reader.Close()
+}
+
+// countingReader records the position of reader, it's seekable so that tar skips the file data by seeking.
+type countingReader struct {
+	r   io.ReadSeeker
+	pos int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.pos += int64(n)
+	return n, err
+}
+
+func (c *countingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := c.r.Seek(offset, whence)
+	if err == nil {
+		c.pos = pos
+	}
+	return pos, err
+}
+
+type sectionReader struct {
+	*io.SectionReader
+}
+
+func (r *sectionReader) Close() error {
+	return nil
+}
+
+// streamReader reads the compressed file in zip archive sequentially, the random access is refused.
+type streamReader struct {
+	io.ReadCloser
+	name string
+}
+
+func (r *streamReader) ReadAt(p []byte, off int64) (int, error) {
+	return 0, fmt.Errorf("random access to compressed file in archive is unsupported, file=%s", r.name)
+}
+
+func (r *streamReader) Seek(offset int64, whence int) (int64, error) {
+	return 0, fmt.Errorf("random access to compressed file in archive is unsupported, file=%s", r.name)
+}
@@ -0,0 +1,127 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/internal/storage"
+)
+
+func writeTar(t *testing.T, files map[string]string, names ...string) []byte {
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+	assert.NoError(t, w.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, name := range names {
+		assert.NoError(t, w.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(files[name]))}))
+		_, err := w.Write([]byte(files[name]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func writeZip(t *testing.T, files map[string]string, names ...string) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for i, name := range names {
+		// store and deflate the files alternately.
+		method := zip.Store
+		if i%2 == 1 {
+			method = zip.Deflate
+		}
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(files[name]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestArchiveChunkManager(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cm := storage.NewLocalChunkManager(storage.RootPath(dir))
+	files := map[string]string{
+		"dir/a.json": `[{"pk": 1}]`,
+		"dir/b.json": `[{"pk": 2}, {"pk": 3}]`,
+	}
+
+	for _, name := range []string{"files.tar", "files.zip"} {
+		filePath := path.Join(dir, name)
+		content := writeTar(t, files, "dir/a.json", "dir/b.json")
+		if path.Ext(name) == ZipFileExt {
+			content = writeZip(t, files, "dir/a.json", "dir/b.json")
+		}
+		assert.NoError(t, cm.Write(ctx, filePath, content))
+		assert.True(t, IsArchive(filePath))
+
+		acm, err := Open(ctx, cm, filePath, 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"dir/a.json", "dir/b.json"}, acm.Entries())
+		for entry, expected := range files {
+			size, err := acm.Size(ctx, entry)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(expected)), size)
+			reader, err := acm.Reader(ctx, entry)
+			assert.NoError(t, err)
+			data, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(data))
+			reader.Close()
+			data, err = acm.ReadAt(ctx, entry, 1, 4)
+			assert.NoError(t, err)
+			assert.Equal(t, expected[1:5], string(data))
+		}
+		_, err = acm.Reader(ctx, "dir/c.json")
+		assert.Error(t, err)
+		// the compressed entry is streamed, random access to it is refused.
+		assert.False(t, acm.IsCompressed("dir/a.json"))
+		assert.Equal(t, path.Ext(name) == ZipFileExt, acm.IsCompressed("dir/b.json"))
+		if acm.IsCompressed("dir/b.json") {
+			reader, err := acm.Reader(ctx, "dir/b.json")
+			assert.NoError(t, err)
+			_, err = reader.Seek(1, io.SeekStart)
+			assert.ErrorContains(t, err, "random access")
+			_, err = reader.ReadAt(make([]byte, 1), 1)
+			assert.ErrorContains(t, err, "random access")
+			reader.Close()
+		}
+		acm.Close()
+
+		// the uncompressed size of entry exceeds the limit.
+		_, err = Open(ctx, cm, filePath, 16)
+		assert.ErrorContains(t, err, "file=dir/b.json")
+	}
+
+	assert.False(t, IsArchive("a.json"))
+	assert.NoError(t, cm.Write(ctx, path.Join(dir, "empty.tar"), writeTar(t, nil)))
+	_, err := Open(ctx, cm, path.Join(dir, "empty.tar"), 1024)
+	assert.ErrorContains(t, err, "no file in archive")
+	assert.NoError(t, cm.Write(ctx, path.Join(dir, "broken.zip"), []byte("not a zip")))
+	_, err = Open(ctx, cm, path.Join(dir, "broken.zip"), 1024)
+	assert.ErrorContains(t, err, "read zip archive failed")
+}
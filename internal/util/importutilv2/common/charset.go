@@ -0,0 +1,227 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+// Charset is the character encoding of text import files, which are transcoded to UTF-8 while read.
+type Charset string
+
+const (
+	CharsetUTF8 Charset = "utf-8"
+	// CharsetUTF16 tells the byte order by BOM, little-endian if there is no BOM.
+	CharsetUTF16   Charset = "utf-16"
+	CharsetUTF16LE Charset = "utf-16le"
+	CharsetUTF16BE Charset = "utf-16be"
+	CharsetLatin1  Charset = "latin-1"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func WrapCharsetUnsupportedError(format string) error {
+	return merr.WrapErrImportFailed(fmt.Sprintf("charset is not supported by %s files", format))
+}
+
+// NewCharsetReader returns the reader which transcodes r from charset to UTF-8 and strips the leading BOM.
+// The read fails on the byte sequences undecodable by charset, rather than replacing them with U+FFFD.
+func NewCharsetReader(r io.Reader, charset Charset) io.Reader {
+	if charset == CharsetUTF8 {
+		return &utf8Reader{r: r}
+	}
+	return &transcodingReader{r: bufio.NewReader(r), charset: charset}
+}
+
+func wrapUndecodableError(charset Charset, offset int64) error {
+	return merr.WrapErrImportFailed(fmt.Sprintf("undecodable %s byte sequence at offset %d, please check the charset of file", charset, offset))
+}
+
+// utf8Reader validates the UTF-8 text in place, the incomplete rune at the end of each read
+// is carried to the next read.
+type utf8Reader struct {
+	r          io.Reader
+	carry      []byte
+	offset     int64
+	bomChecked bool
+	err        error
+}
+
+func (u *utf8Reader) Read(p []byte) (int, error) {
+	if len(p) < utf8.UTFMax {
+		return 0, io.ErrShortBuffer
+	}
+	for {
+		n := copy(p, u.carry)
+		u.carry = u.carry[:0]
+		for u.err == nil && (n < utf8.UTFMax || !u.bomChecked && n < len(utf8BOM)) {
+			var m int
+			m, u.err = u.r.Read(p[n:])
+			n += m
+			if m == 0 && u.err == nil {
+				break
+			}
+		}
+		if !u.bomChecked && (n >= len(utf8BOM) || u.err != nil) {
+			u.bomChecked = true
+			if bytes.HasPrefix(p[:n], utf8BOM) {
+				n = copy(p, p[len(utf8BOM):n])
+				u.offset += int64(len(utf8BOM))
+			}
+		}
+		if u.err == nil {
+			cut := incompleteRuneStart(p[:n])
+			u.carry = append(u.carry, p[cut:n]...)
+			n = cut
+		}
+		if i := invalidUTF8Index(p[:n]); i >= 0 {
+			return 0, wrapUndecodableError(CharsetUTF8, u.offset+int64(i))
+		}
+		u.offset += int64(n)
+		if n > 0 || u.err != nil {
+			if n > 0 && u.err == io.EOF {
+				return n, nil
+			}
+			return n, u.err
+		}
+	}
+}
+
+// incompleteRuneStart returns where the incomplete rune at the end of p starts, or len(p) if there is none.
+func incompleteRuneStart(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(p)
+}
+
+// invalidUTF8Index returns the index of the first invalid UTF-8 sequence in p, or -1 if p is valid.
+func invalidUTF8Index(p []byte) int {
+	if utf8.Valid(p) {
+		return -1
+	}
+	for i := 0; i < len(p); {
+		r, size := utf8.DecodeRune(p[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// transcodingReader decodes the runes of charset one by one and encodes them in UTF-8.
+type transcodingReader struct {
+	r       *bufio.Reader
+	charset Charset
+	// order is the byte order of UTF-16, resolved by BOM at the start.
+	order   Charset
+	started bool
+	offset  int64
+	buf     []byte
+	err     error
+}
+
+func (t *transcodingReader) Read(p []byte) (int, error) {
+	for len(t.buf) < len(p) && t.err == nil {
+		var r rune
+		r, t.err = t.readRune()
+		if t.err != nil {
+			break
+		}
+		// the BOM is only stripped at the start, it's a zero width no-break space elsewhere.
+		if !t.started {
+			t.started = true
+			if r == '\uFEFF' {
+				continue
+			}
+		}
+		t.buf = utf8.AppendRune(t.buf, r)
+	}
+	n := copy(p, t.buf)
+	t.buf = t.buf[:copy(t.buf, t.buf[n:])]
+	if n > 0 {
+		return n, nil
+	}
+	return 0, t.err
+}
+
+func (t *transcodingReader) readRune() (rune, error) {
+	if t.charset == CharsetLatin1 {
+		b, err := t.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		t.offset++
+		return rune(b), nil
+	}
+	if t.order == "" {
+		t.order = t.charset
+		if t.charset == CharsetUTF16 {
+			t.order = CharsetUTF16LE
+			if bom, _ := t.r.Peek(2); bytes.Equal(bom, []byte{0xFE, 0xFF}) {
+				t.order = CharsetUTF16BE
+			}
+		}
+	}
+	offset := t.offset
+	r1, err := t.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	if !utf16.IsSurrogate(r1) {
+		return r1, nil
+	}
+	r2, err := t.readUnit()
+	if err == io.EOF {
+		return 0, wrapUndecodableError(t.charset, offset)
+	} else if err != nil {
+		return 0, err
+	}
+	if r := utf16.DecodeRune(r1, r2); r != utf8.RuneError {
+		return r, nil
+	}
+	return 0, wrapUndecodableError(t.charset, offset)
+}
+
+// readUnit reads a 2-byte code unit of UTF-16.
+func (t *transcodingReader) readUnit() (rune, error) {
+	var unit [2]byte
+	n, err := io.ReadFull(t.r, unit[:])
+	if err == io.ErrUnexpectedEOF {
+		return 0, wrapUndecodableError(t.charset, t.offset)
+	} else if err != nil {
+		return 0, err
+	}
+	t.offset += int64(n)
+	if t.order == CharsetUTF16BE {
+		return rune(unit[0])<<8 | rune(unit[1]), nil
+	}
+	return rune(unit[1])<<8 | rune(unit[0]), nil
+}
@@ -0,0 +1,64 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharsetReader(t *testing.T) {
+	read := func(text string, charset Charset) (string, error) {
+		// read byte by byte to split the runes across reads.
+		data, err := io.ReadAll(NewCharsetReader(iotest.OneByteReader(strings.NewReader(text)), charset))
+		return string(data), err
+	}
+
+	// the leading BOM is stripped.
+	text, err := read("\xEF\xBB\xBF{\"名\": \"é\"}", CharsetUTF8)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"名": "é"}`, text)
+	_, err = read("{\"a\": \"\xE9\"}", CharsetUTF8)
+	assert.ErrorContains(t, err, "undecodable utf-8 byte sequence at offset 7")
+	// the incomplete rune at the end is undecodable.
+	_, err = read("{\"名\xE5\x90", CharsetUTF8)
+	assert.ErrorContains(t, err, "at offset 5")
+
+	text, err = read("{\"a\": \"\xE9\"}", CharsetLatin1)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": "é"}`, text)
+
+	// the byte order of UTF-16 is told by BOM.
+	text, err = read("\xFF\xFE{\x00a\x00=\xD8\x00\xDE", CharsetUTF16)
+	assert.NoError(t, err)
+	assert.Equal(t, "{a\U0001F600", text)
+	text, err = read("\xFE\xFF\x00{\x00a", CharsetUTF16)
+	assert.NoError(t, err)
+	assert.Equal(t, "{a", text)
+	text, err = read("\x00{\x00a", CharsetUTF16BE)
+	assert.NoError(t, err)
+	assert.Equal(t, "{a", text)
+	// the odd bytes and unpaired surrogates are undecodable.
+	_, err = read("{\x00a", CharsetUTF16LE)
+	assert.ErrorContains(t, err, "undecodable utf-16le byte sequence at offset 2")
+	_, err = read("{\x00=\xD8a\x00", CharsetUTF16LE)
+	assert.ErrorContains(t, err, "at offset 2")
+}
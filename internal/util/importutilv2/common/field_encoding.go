@@ -0,0 +1,54 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+// FieldEncoding is how the values of a vector field are encoded in the source file,
+// the vectors are arrays of numbers if not set.
+type FieldEncoding string
+
+const (
+	FieldEncodingDefault FieldEncoding = ""
+	// FieldEncodingBase64 is the base64 string of the raw bytes of vector,
+	// i.e. the little-endian float32 of float vector, the packed bits of binary vector,
+	// and the 2-byte halves of float16 and bfloat16 vector.
+	FieldEncodingBase64 FieldEncoding = "base64"
+	// FieldEncodingHex is the hex string of the raw bytes of vector.
+	FieldEncodingHex FieldEncoding = "hex"
+)
+
+// Decode decodes the encoded string into the raw bytes of vector.
+func (e FieldEncoding) Decode(value string) ([]byte, error) {
+	switch e {
+	case FieldEncodingBase64:
+		return base64.StdEncoding.DecodeString(value)
+	case FieldEncodingHex:
+		return hex.DecodeString(value)
+	}
+	return nil, merr.WrapErrImportFailed(fmt.Sprintf("unsupported field encoding '%s'", e))
+}
+
+func WrapFieldEncodingUnsupportedError(format string) error {
+	return merr.WrapErrImportFailed(fmt.Sprintf("field encoding is not supported by %s files", format))
+}
@@ -0,0 +1,331 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+)
+
+// MaxJSONSchemaDepth bounds the nesting of both schema and validated documents,
+// so that a hostile document can't exhaust the stack or the memory of validation.
+const MaxJSONSchemaDepth = 32
+
+// JSONSchema is a subset of JSON Schema used to validate the documents of json field at import,
+// the supported keywords are type, enum, minimum, maximum, minLength, maxLength,
+// properties, required, additionalProperties and items. Other keywords are rejected
+// instead of ignored, so that users don't take them as enforced.
+type JSONSchema struct {
+	Types                []string
+	Enum                 []any
+	Minimum              *float64
+	Maximum              *float64
+	MinLength            *int
+	MaxLength            *int
+	Properties           map[string]*JSONSchema
+	Required             []string
+	AdditionalProperties *bool
+	Items                *JSONSchema
+}
+
+var jsonSchemaTypes = map[string]struct{}{
+	"null": {}, "boolean": {}, "integer": {}, "number": {}, "string": {}, "array": {}, "object": {},
+}
+
+// ParseJSONSchema parses and checks the schema.
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var raw any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return parseJSONSchema(raw, 0)
+}
+
+func parseJSONSchema(raw any, depth int) (*JSONSchema, error) {
+	if depth >= MaxJSONSchemaDepth {
+		return nil, errors.Newf("schema is nested deeper than %d", MaxJSONSchemaDepth)
+	}
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("schema must be an object")
+	}
+	s := &JSONSchema{}
+	for key, value := range obj {
+		var err error
+		switch key {
+		case "type":
+			s.Types, err = parseSchemaTypes(value)
+		case "enum":
+			values, ok := value.([]any)
+			if !ok {
+				return nil, errors.New("enum must be an array")
+			}
+			s.Enum = make([]any, 0, len(values))
+			for _, v := range values {
+				s.Enum = append(s.Enum, normalizeJSONValue(v))
+			}
+		case "minimum":
+			s.Minimum, err = parseSchemaNumber(key, value)
+		case "maximum":
+			s.Maximum, err = parseSchemaNumber(key, value)
+		case "minLength":
+			s.MinLength, err = parseSchemaLength(key, value)
+		case "maxLength":
+			s.MaxLength, err = parseSchemaLength(key, value)
+		case "properties":
+			props, ok := value.(map[string]any)
+			if !ok {
+				return nil, errors.New("properties must be an object")
+			}
+			s.Properties = make(map[string]*JSONSchema, len(props))
+			for name, prop := range props {
+				if s.Properties[name], err = parseJSONSchema(prop, depth+1); err != nil {
+					return nil, errors.Wrapf(err, "property %s", name)
+				}
+			}
+		case "required":
+			names, ok := value.([]any)
+			if !ok {
+				return nil, errors.New("required must be an array of strings")
+			}
+			for _, name := range names {
+				str, ok := name.(string)
+				if !ok {
+					return nil, errors.New("required must be an array of strings")
+				}
+				s.Required = append(s.Required, str)
+			}
+		case "additionalProperties":
+			allowed, ok := value.(bool)
+			if !ok {
+				return nil, errors.New("additionalProperties must be a boolean")
+			}
+			s.AdditionalProperties = &allowed
+		case "items":
+			if s.Items, err = parseJSONSchema(value, depth+1); err != nil {
+				return nil, errors.Wrap(err, "items")
+			}
+		case "$schema", "title", "description":
+		default:
+			return nil, errors.Newf("unsupported keyword %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func parseSchemaTypes(value any) ([]string, error) {
+	var types []string
+	switch v := value.(type) {
+	case string:
+		types = []string{v}
+	case []any:
+		for _, t := range v {
+			str, ok := t.(string)
+			if !ok {
+				return nil, errors.New("type must be a string or an array of strings")
+			}
+			types = append(types, str)
+		}
+	default:
+		return nil, errors.New("type must be a string or an array of strings")
+	}
+	for _, t := range types {
+		if _, ok := jsonSchemaTypes[t]; !ok {
+			return nil, errors.Newf("unsupported type %s", t)
+		}
+	}
+	return types, nil
+}
+
+func parseSchemaNumber(key string, value any) (*float64, error) {
+	num, ok := value.(json.Number)
+	if !ok {
+		return nil, errors.Newf("%s must be a number", key)
+	}
+	f, err := num.Float64()
+	if err != nil {
+		return nil, errors.Newf("%s must be a number", key)
+	}
+	return &f, nil
+}
+
+func parseSchemaLength(key string, value any) (*int, error) {
+	num, ok := value.(json.Number)
+	if !ok {
+		return nil, errors.Newf("%s must be a non-negative integer", key)
+	}
+	n, err := num.Int64()
+	if err != nil || n < 0 {
+		return nil, errors.Newf("%s must be a non-negative integer", key)
+	}
+	length := int(n)
+	return &length, nil
+}
+
+// normalizeJSONValue returns a copy whose numbers are converted to float64, so that the values
+// decoded from schema and document are compared in the same representation.
+func normalizeJSONValue(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		f, _ := val.Float64()
+		return f
+	case []any:
+		values := make([]any, len(val))
+		for i := range val {
+			values[i] = normalizeJSONValue(val[i])
+		}
+		return values
+	case map[string]any:
+		values := make(map[string]any, len(val))
+		for k := range val {
+			values[k] = normalizeJSONValue(val[k])
+		}
+		return values
+	}
+	return v
+}
+
+// Validate checks the json document against schema, the returned error tells
+// the location and the reason of the first violation.
+func (s *JSONSchema) Validate(doc []byte) error {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return errors.Newf("invalid json, err=%s", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid json, unexpected data after the document")
+	}
+	return s.validate(value, "$", 0)
+}
+
+func (s *JSONSchema) validate(value any, path string, depth int) error {
+	if depth >= MaxJSONSchemaDepth {
+		return errors.Newf("%s is nested deeper than %d", path, MaxJSONSchemaDepth)
+	}
+	if len(s.Types) > 0 && !s.matchType(value) {
+		return errors.Newf("%s expects type %v, got %s", path, s.Types, jsonTypeOf(value))
+	}
+	if len(s.Enum) > 0 {
+		normalized := normalizeJSONValue(value)
+		matched := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(e, normalized) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errors.Newf("%s is not one of the enum values", path)
+		}
+	}
+
+	switch val := value.(type) {
+	case json.Number:
+		f, err := val.Float64()
+		if err != nil {
+			return errors.Newf("%s is not a valid number", path)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return errors.Newf("%s is less than minimum %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return errors.Newf("%s is greater than maximum %v", path, *s.Maximum)
+		}
+	case string:
+		length := utf8.RuneCountInString(val)
+		if s.MinLength != nil && length < *s.MinLength {
+			return errors.Newf("%s is shorter than minLength %d", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return errors.Newf("%s is longer than maxLength %d", path, *s.MaxLength)
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return errors.Newf("%s misses required property %s", path, name)
+			}
+		}
+		for name, prop := range val {
+			propSchema, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return errors.Newf("%s has additional property %s", path, name)
+				}
+				continue
+			}
+			if err := propSchema.validate(prop, path+"."+name, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *JSONSchema) matchType(value any) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range s.Types {
+		if t == actual {
+			return true
+		}
+		// an integer is also a number.
+		if t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeOf(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
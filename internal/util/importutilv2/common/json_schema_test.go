@@ -0,0 +1,71 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	s, err := ParseJSONSchema([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 4},
+			"score": {"type": ["number", "null"], "minimum": 0, "maximum": 1},
+			"level": {"enum": [1, 2, "top"]},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Validate([]byte(`{"name": "a", "score": 0.5, "level": 2.0, "tags": ["x"]}`)))
+	assert.NoError(t, s.Validate([]byte(`{"name": "ab", "score": null, "level": "top"}`)))
+
+	cases := map[string]string{
+		`{"name": `:                       "invalid json",
+		`{"name": "a"} {}`:                "unexpected data",
+		`[]`:                              "$ expects type [object], got array",
+		`{}`:                              "$ misses required property name",
+		`{"name": ""}`:                    "$.name is shorter than minLength 1",
+		`{"name": "abcde"}`:               "$.name is longer than maxLength 4",
+		`{"name": "a", "score": 2}`:       "$.score is greater than maximum 1",
+		`{"name": "a", "score": "1"}`:     "$.score expects type [number null], got string",
+		`{"name": "a", "level": 3}`:       "$.level is not one of the enum values",
+		`{"name": "a", "tags": ["x", 1]}`: "$.tags[1] expects type [string], got integer",
+		`{"name": "a", "other": 1}`:       "$ has additional property other",
+	}
+	for doc, reason := range cases {
+		assert.ErrorContains(t, s.Validate([]byte(doc)), reason, doc)
+	}
+
+	_, err = ParseJSONSchema([]byte(`{"type": "decimal"}`))
+	assert.ErrorContains(t, err, "unsupported type decimal")
+	_, err = ParseJSONSchema([]byte(`{"minLength": -1}`))
+	assert.ErrorContains(t, err, "non-negative integer")
+	_, err = ParseJSONSchema([]byte(`[]`))
+	assert.ErrorContains(t, err, "schema must be an object")
+
+	// the nesting of schema is bounded.
+	deep := strings.Repeat(`{"items": `, MaxJSONSchemaDepth) + `{}` + strings.Repeat(`}`, MaxJSONSchemaDepth)
+	_, err = ParseJSONSchema([]byte(deep))
+	assert.ErrorContains(t, err, "nested deeper than")
+}
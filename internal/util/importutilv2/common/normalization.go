@@ -0,0 +1,32 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+// VectorNormalization is how the float vectors of fields using cosine metric are checked for unit norm.
+type VectorNormalization string
+
+const (
+	// VectorNormalizationNone neither checks nor normalizes the vectors.
+	VectorNormalizationNone VectorNormalization = ""
+	// VectorNormalizationCheck counts the vectors out of the norm tolerance, which are imported as is.
+	VectorNormalizationCheck VectorNormalization = "check"
+	// VectorNormalizationNormalize counts the vectors out of the norm tolerance, and imports them L2-normalized.
+	VectorNormalizationNormalize VectorNormalization = "normalize"
+)
+
+// VectorNormTolerance is the maximum difference of the L2 norm from 1 of a vector regarded as unit-norm.
+const VectorNormTolerance = 1e-3
@@ -0,0 +1,53 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+// PrimaryKeyError is a primary key value mismatching the type of primary key field. It's the most common
+// failure of import, so it names the row and the offending value rather than failing generically.
+type PrimaryKeyError struct {
+	Field    string
+	DataType schemapb.DataType
+	// Row is the row offset in file, -1 if unknown yet.
+	Row    int64
+	Value  any
+	Reason string
+}
+
+func NewPrimaryKeyError(field *schemapb.FieldSchema, value any, reason string) *PrimaryKeyError {
+	return &PrimaryKeyError{
+		Field:    field.GetName(),
+		DataType: field.GetDataType(),
+		Row:      -1,
+		Value:    value,
+		Reason:   reason,
+	}
+}
+
+func (e *PrimaryKeyError) Error() string {
+	row := "unknown row"
+	if e.Row >= 0 {
+		row = fmt.Sprintf("row offset %d", e.Row)
+	}
+	return fmt.Sprintf("invalid value '%v' of primary key '%s' with type '%s' at %s, %s",
+		e.Value, e.Field, e.DataType.String(), row, e.Reason)
+}
@@ -0,0 +1,69 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// SparseDuplicatePolicy is how the elements of a sparse float vector with the same index are handled.
+type SparseDuplicatePolicy string
+
+const (
+	SparseDuplicateReject SparseDuplicatePolicy = "reject"
+	SparseDuplicateSum    SparseDuplicatePolicy = "sum"
+)
+
+// NormalizeSparseFloatRow sorts the elements of row by index, and merges the elements with the same index
+// by policy. The row is returned as is if it's already normalized, otherwise a new row is returned.
+// Returns error if the row is malformed, e.g. an index out of range or a negative value.
+func NormalizeSparseFloatRow(row []byte, policy SparseDuplicatePolicy) ([]byte, error) {
+	if typeutil.ValidateSparseFloatRows(row) == nil {
+		return row, nil
+	}
+	if len(row) == 0 || len(row)%8 != 0 {
+		return nil, typeutil.ValidateSparseFloatRows(row)
+	}
+	num := typeutil.SparseFloatRowElementCount(row)
+	indices := make([]uint32, num)
+	values := make([]float32, num)
+	for i := 0; i < num; i++ {
+		indices[i] = typeutil.SparseFloatRowIndexAt(row, i)
+		values[i] = typeutil.SparseFloatRowValueAt(row, i)
+	}
+	indices, values = typeutil.SortSparseFloatRow(indices, values)
+
+	n := 0
+	for i := range indices {
+		if n > 0 && indices[i] == indices[n-1] {
+			if policy != SparseDuplicateSum {
+				return nil, fmt.Errorf("duplicate index %d in sparse float vector", indices[i])
+			}
+			values[n-1] += values[i]
+			continue
+		}
+		indices[n], values[n] = indices[i], values[i]
+		n++
+	}
+	normalized := typeutil.CreateSparseFloatRow(indices[:n], values[:n])
+	if err := typeutil.ValidateSparseFloatRows(normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
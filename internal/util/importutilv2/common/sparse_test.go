@@ -0,0 +1,51 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+func TestNormalizeSparseFloatRow(t *testing.T) {
+	// the normalized row is kept as is.
+	row := typeutil.CreateSparseFloatRow([]uint32{1, 3}, []float32{0.1, 0.3})
+	normalized, err := NormalizeSparseFloatRow(row, SparseDuplicateReject)
+	assert.NoError(t, err)
+	assert.Equal(t, row, normalized)
+
+	row = typeutil.CreateSparseFloatRow([]uint32{3, 1, 3}, []float32{0.3, 0.1, 0.2})
+	_, err = NormalizeSparseFloatRow(row, SparseDuplicateReject)
+	assert.ErrorContains(t, err, "duplicate index 3")
+	normalized, err = NormalizeSparseFloatRow(row, SparseDuplicateSum)
+	assert.NoError(t, err)
+	assert.Equal(t, typeutil.CreateSparseFloatRow([]uint32{1, 3}, []float32{0.1, 0.5}), normalized)
+
+	// malformed rows.
+	_, err = NormalizeSparseFloatRow(typeutil.CreateSparseFloatRow([]uint32{2, math.MaxUint32}, []float32{0.1, 0.2}), SparseDuplicateSum)
+	assert.Error(t, err)
+	_, err = NormalizeSparseFloatRow(typeutil.CreateSparseFloatRow([]uint32{2, 1}, []float32{-0.1, 0.2}), SparseDuplicateSum)
+	assert.Error(t, err)
+	_, err = NormalizeSparseFloatRow(nil, SparseDuplicateSum)
+	assert.Error(t, err)
+	_, err = NormalizeSparseFloatRow([]byte{1, 2, 3}, SparseDuplicateSum)
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+// UnknownColumnPolicy is how the source columns not defined in schema are handled.
+type UnknownColumnPolicy string
+
+const (
+	// UnknownColumnDefault keeps the behavior of each file format, the unknown columns of json are stored
+	// as dynamic if the collection enables dynamic schema, the ones of parquet fail the import,
+	// and the ones of numpy are ignored.
+	UnknownColumnDefault        UnknownColumnPolicy = ""
+	UnknownColumnIgnore         UnknownColumnPolicy = "ignore"
+	UnknownColumnError          UnknownColumnPolicy = "error"
+	UnknownColumnStoreAsDynamic UnknownColumnPolicy = "store_as_dynamic"
+)
+
+// Or returns the policy, or the default policy of file format if it's not set.
+func (p UnknownColumnPolicy) Or(defaultPolicy UnknownColumnPolicy) UnknownColumnPolicy {
+	if p == UnknownColumnDefault {
+		return defaultPolicy
+	}
+	return p
+}
+
+func WrapUnknownColumnError(column string) error {
+	return merr.WrapErrImportFailed(fmt.Sprintf("the field '%s' is not defined in schema", column))
+}
+
+func WrapStoreAsDynamicUnsupportedError(format string, column string) error {
+	return merr.WrapErrImportFailed(fmt.Sprintf("storing unknown column as dynamic is not supported by %s files, column=%s", format, column))
+}
@@ -23,10 +23,12 @@ import (
 	"io"
 	"strings"
 
+	"github.com/cockroachdb/errors"
 	"go.uber.org/atomic"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
@@ -49,11 +51,16 @@ type reader struct {
 	bufferSize  int
 	count       int64
 	isOldFormat bool
+	// offset is the number of rows read so far.
+	offset int64
 
 	parser RowParser
 }
 
-func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, path string, bufferSize int) (*reader, error) {
+func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, path string, bufferSize int,
+	unknownColumnPolicy common.UnknownColumnPolicy, encodings map[int64]common.FieldEncoding, sparsePolicy common.SparseDuplicatePolicy,
+	charset common.Charset,
+) (*reader, error) {
 	r, err := cm.Reader(ctx, path)
 	if err != nil {
 		return nil, merr.WrapErrImportFailed(fmt.Sprintf("read json file failed, path=%s, err=%s", path, err.Error()))
@@ -62,17 +69,19 @@ func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.Co
 	if err != nil {
 		return nil, err
 	}
+	// the file is transcoded to UTF-8 without the leading BOM, which would be mistaken for part of
+	// the first field name otherwise, and the undecodable bytes fail the read rather than turning into U+FFFD.
 	reader := &reader{
 		ctx:        ctx,
 		cm:         cm,
 		schema:     schema,
 		fileSize:   atomic.NewInt64(0),
 		filePath:   path,
-		dec:        json.NewDecoder(r),
+		dec:        json.NewDecoder(common.NewCharsetReader(r, charset)),
 		bufferSize: bufferSize,
 		count:      count,
 	}
-	reader.parser, err = NewRowParser(schema)
+	reader.parser, err = NewRowParser(schema, unknownColumnPolicy, encodings, sparsePolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -140,8 +149,14 @@ func (j *reader) Read() (*storage.InsertData, error) {
 		}
 		row, err := j.parser.Parse(value)
 		if err != nil {
+			var pkErr *common.PrimaryKeyError
+			if errors.As(err, &pkErr) {
+				pkErr.Row = j.offset
+				return nil, merr.WrapErrImportFailed(pkErr.Error())
+			}
 			return nil, err
 		}
+		j.offset++
 		err = insertData.Append(row)
 		if err != nil {
 			return nil, merr.WrapErrImportFailed(fmt.Sprintf("failed to append row, err=%s", err.Error()))
@@ -180,6 +195,16 @@ func (j *reader) Size() (int64, error) {
 	return size, nil
 }
 
+// UnknownColumns returns the keys not defined in schema read so far.
+func (j *reader) UnknownColumns() []string {
+	return j.parser.UnknownColumns()
+}
+
+// DecodeFailures returns the encoded values failed to decode so far by field name.
+func (j *reader) DecodeFailures() map[string]int64 {
+	return j.parser.DecodeFailures()
+}
+
 func (j *reader) Close() {}
 
 func estimateReadCountPerBatch(bufferSize int, schema *schemapb.CollectionSchema) (int64, error) {
@@ -32,6 +32,7 @@ import (
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/mocks"
 	"github.com/milvus-io/milvus/internal/storage"
+	importcommon "github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/internal/util/testutil"
 	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
@@ -118,7 +119,7 @@ func (suite *ReaderSuite) run(dataType schemapb.DataType, elemType schemapb.Data
 		r := &mockReader{Reader: strings.NewReader(string(jsonBytes))}
 		return r, nil
 	})
-	reader, err := NewReader(context.Background(), cm, schema, "mockPath", math.MaxInt)
+	reader, err := NewReader(context.Background(), cm, schema, "mockPath", math.MaxInt, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateReject, importcommon.CharsetUTF8)
 	suite.NoError(err)
 
 	checkFn := func(actualInsertData *storage.InsertData, offsetBegin, expectRows int) {
@@ -183,6 +184,29 @@ func (suite *ReaderSuite) TestVector() {
 	suite.run(schemapb.DataType_Int32, schemapb.DataType_None)
 }
 
+func (suite *ReaderSuite) TestPrimaryKeyMismatch() {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "vec", DataType: schemapb.DataType_FloatVector, TypeParams: []*commonpb.KeyValuePair{{Key: common.DimKey, Value: "2"}}},
+		},
+	}
+	type mockReader struct {
+		io.Reader
+		io.Closer
+		io.ReaderAt
+		io.Seeker
+	}
+	cm := mocks.NewChunkManager(suite.T())
+	cm.EXPECT().Reader(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, s string) (storage.FileReader, error) {
+		return &mockReader{Reader: strings.NewReader(`[{"pk": 1, "vec": [1, 2]}, {"pk": 2, "vec": [1, 2]}, {"pk": "x3", "vec": [1, 2]}]`)}, nil
+	})
+	reader, err := NewReader(context.Background(), cm, schema, "mockPath", math.MaxInt, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateReject, importcommon.CharsetUTF8)
+	suite.NoError(err)
+	_, err = reader.Read()
+	suite.ErrorContains(err, "invalid value 'x3' of primary key 'pk' with type 'Int64' at row offset 2, expect an integer, got a string")
+}
+
 func TestUtil(t *testing.T) {
 	suite.Run(t, new(ReaderSuite))
 }
@@ -17,20 +17,27 @@
 package json
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/cockroachdb/errors"
 	"github.com/samber/lo"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 type RowParser interface {
 	Parse(raw any) (Row, error)
+	// UnknownColumns returns the keys not defined in schema found so far, in the order they are found.
+	UnknownColumns() []string
+	// DecodeFailures returns the number of encoded values failed to decode so far by field name.
+	DecodeFailures() map[string]int64
 }
 
 type rowParser struct {
@@ -39,9 +46,20 @@ type rowParser struct {
 	name2FieldID map[string]int64
 	pkField      *schemapb.FieldSchema
 	dynamicField *schemapb.FieldSchema
+
+	unknownColumnPolicy common.UnknownColumnPolicy
+	unknownColumns      []string
+	seenUnknownColumns  typeutil.Set[string]
+
+	encodings      map[int64]common.FieldEncoding
+	decodeFailures map[string]int64
+	// sparsePolicy is how the duplicate indices of sparse float vectors are handled.
+	sparsePolicy common.SparseDuplicatePolicy
 }
 
-func NewRowParser(schema *schemapb.CollectionSchema) (RowParser, error) {
+func NewRowParser(schema *schemapb.CollectionSchema, unknownColumnPolicy common.UnknownColumnPolicy,
+	encodings map[int64]common.FieldEncoding, sparsePolicy common.SparseDuplicatePolicy,
+) (RowParser, error) {
 	id2Field := lo.KeyBy(schema.GetFields(), func(field *schemapb.FieldSchema) int64 {
 		return field.GetFieldID()
 	})
@@ -75,15 +93,37 @@ func NewRowParser(schema *schemapb.CollectionSchema) (RowParser, error) {
 	if dynamicField != nil {
 		delete(name2FieldID, dynamicField.GetName())
 	}
+	// the unknown keys are stored as dynamic if the collection enables dynamic schema by default.
+	defaultPolicy := common.UnknownColumnError
+	if dynamicField != nil {
+		defaultPolicy = common.UnknownColumnStoreAsDynamic
+	}
+	unknownColumnPolicy = unknownColumnPolicy.Or(defaultPolicy)
+	if unknownColumnPolicy == common.UnknownColumnStoreAsDynamic && dynamicField == nil {
+		return nil, merr.WrapErrImportFailed("storing unknown column as dynamic requires the collection to enable dynamic schema")
+	}
 	return &rowParser{
-		id2Dim:       id2Dim,
-		id2Field:     id2Field,
-		name2FieldID: name2FieldID,
-		pkField:      pkField,
-		dynamicField: dynamicField,
+		id2Dim:              id2Dim,
+		id2Field:            id2Field,
+		name2FieldID:        name2FieldID,
+		pkField:             pkField,
+		dynamicField:        dynamicField,
+		unknownColumnPolicy: unknownColumnPolicy,
+		seenUnknownColumns:  typeutil.NewSet[string](),
+		encodings:           encodings,
+		decodeFailures:      make(map[string]int64),
+		sparsePolicy:        sparsePolicy,
 	}, nil
 }
 
+func (r *rowParser) UnknownColumns() []string {
+	return r.unknownColumns
+}
+
+func (r *rowParser) DecodeFailures() map[string]int64 {
+	return r.decodeFailures
+}
+
 func (r *rowParser) wrapTypeError(v any, fieldID int64) error {
 	field := r.id2Field[fieldID]
 	return merr.WrapErrImportFailed(fmt.Sprintf("expected type '%s' for field '%s', got type '%T' with value '%v'",
@@ -114,16 +154,29 @@ func (r *rowParser) Parse(raw any) (Row, error) {
 	row := make(Row)
 	for key, value := range stringMap {
 		if fieldID, ok := r.name2FieldID[key]; ok {
-			data, err := r.parseEntity(fieldID, value)
+			parse := r.parseEntity
+			if fieldID == r.pkField.GetFieldID() {
+				parse = r.parsePrimaryKey
+			}
+			data, err := parse(fieldID, value)
 			if err != nil {
 				return nil, err
 			}
 			row[fieldID] = data
-		} else if r.dynamicField != nil {
-			// has dynamic field, put redundant pair to dynamicValues
+		} else if r.dynamicField != nil && key == r.dynamicField.GetName() {
 			dynamicValues[key] = value
 		} else {
-			return nil, merr.WrapErrImportFailed(fmt.Sprintf("the field '%s' is not defined in schema", key))
+			if !r.seenUnknownColumns.Contain(key) {
+				r.seenUnknownColumns.Insert(key)
+				r.unknownColumns = append(r.unknownColumns, key)
+			}
+			switch r.unknownColumnPolicy {
+			case common.UnknownColumnStoreAsDynamic:
+				// has dynamic field, put redundant pair to dynamicValues
+				dynamicValues[key] = value
+			case common.UnknownColumnError:
+				return nil, common.WrapUnknownColumnError(key)
+			}
 		}
 	}
 	for fieldName, fieldID := range r.name2FieldID {
@@ -196,7 +249,43 @@ func (r *rowParser) combineDynamicRow(dynamicValues map[string]any, row Row) err
 	return nil
 }
 
+// parsePrimaryKey parses the value of primary key, the value mismatching the type of primary key
+// fails with `common.PrimaryKeyError`, whose row is set by reader.
+func (r *rowParser) parsePrimaryKey(fieldID int64, obj any) (any, error) {
+	switch r.pkField.GetDataType() {
+	case schemapb.DataType_Int64:
+		switch value := obj.(type) {
+		case json.Number:
+			num, err := strconv.ParseInt(value.String(), 0, 64)
+			if errors.Is(err, strconv.ErrRange) {
+				return nil, common.NewPrimaryKeyError(r.pkField, obj, "the integer is out of int64 range")
+			} else if err != nil {
+				return nil, common.NewPrimaryKeyError(r.pkField, obj, "expect an integer, got a non-integer number")
+			}
+			return num, nil
+		case string:
+			if _, err := strconv.ParseInt(value, 0, 64); err == nil {
+				return nil, common.NewPrimaryKeyError(r.pkField, obj, "expect an integer, got a quoted integer string")
+			}
+			return nil, common.NewPrimaryKeyError(r.pkField, obj, "expect an integer, got a string")
+		}
+		return nil, common.NewPrimaryKeyError(r.pkField, obj, fmt.Sprintf("expect an integer, got type '%T'", obj))
+	case schemapb.DataType_VarChar:
+		if _, ok := obj.(string); !ok {
+			return nil, common.NewPrimaryKeyError(r.pkField, obj, fmt.Sprintf("expect a string, got type '%T'", obj))
+		}
+	}
+	return r.parseEntity(fieldID, obj)
+}
+
 func (r *rowParser) parseEntity(fieldID int64, obj any) (any, error) {
+	if encoding, ok := r.encodings[fieldID]; ok {
+		vec, err := r.decodeVector(fieldID, encoding, obj)
+		if err != nil {
+			r.decodeFailures[r.id2Field[fieldID].GetName()]++
+		}
+		return vec, err
+	}
 	switch r.id2Field[fieldID].GetDataType() {
 	case schemapb.DataType_Bool:
 		b, ok := obj.(bool)
@@ -355,11 +444,12 @@ func (r *rowParser) parseEntity(fieldID int64, obj any) (any, error) {
 		if !ok {
 			return nil, r.wrapTypeError(obj, fieldID)
 		}
-		vec, err := typeutil.CreateSparseFloatRowFromMap(arr)
+		// the indices are sorted and deduplicated here, since unnormalized rows are rejected when appended.
+		indices, values, err := typeutil.ParseSparseFloatRowFromMap(arr)
 		if err != nil {
 			return nil, err
 		}
-		return vec, nil
+		return common.NormalizeSparseFloatRow(typeutil.CreateSparseFloatRow(indices, values), r.sparsePolicy)
 	case schemapb.DataType_String, schemapb.DataType_VarChar:
 		value, ok := obj.(string)
 		if !ok {
@@ -519,3 +609,43 @@ func (r *rowParser) arrayToFieldData(arr []interface{}, eleType schemapb.DataTyp
 		return nil, errors.New(fmt.Sprintf("unsupported array data type '%s'", eleType.String()))
 	}
 }
+
+// decodeVector decodes the encoded string of a dense vector field, the decoded bytes are the raw bytes of vector.
+func (r *rowParser) decodeVector(fieldID int64, encoding common.FieldEncoding, obj any) (any, error) {
+	field := r.id2Field[fieldID]
+	value, ok := obj.(string)
+	if !ok {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("expected %s string for field '%s', got type '%T'",
+			encoding, field.GetName(), obj))
+	}
+	bytes, err := encoding.Decode(value)
+	if err != nil {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("failed to decode field '%s' as %s, err=%s",
+			field.GetName(), encoding, err))
+	}
+	dim := r.id2Dim[fieldID]
+	expected := 0
+	switch field.GetDataType() {
+	case schemapb.DataType_BinaryVector:
+		expected = dim / 8
+	case schemapb.DataType_FloatVector:
+		expected = dim * 4
+	case schemapb.DataType_Float16Vector, schemapb.DataType_BFloat16Vector:
+		expected = dim * 2
+	default:
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("field '%s' with type '%s' can't be encoded",
+			field.GetName(), field.GetDataType().String()))
+	}
+	if len(bytes) != expected {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("expected %d bytes for field '%s' with dim '%d', got %d bytes",
+			expected, field.GetName(), dim, len(bytes)))
+	}
+	if field.GetDataType() != schemapb.DataType_FloatVector {
+		return bytes, nil
+	}
+	vec := make([]float32, dim)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(bytes[i*4:]))
+	}
+	return vec, nil
+}
@@ -25,7 +25,9 @@ import (
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	importcommon "github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/pkg/common"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 func TestRowParser_Parse_Valid(t *testing.T) {
@@ -51,7 +53,7 @@ func TestRowParser_Parse_Valid(t *testing.T) {
 			},
 		},
 	}
-	r, err := NewRowParser(schema)
+	r, err := NewRowParser(schema, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateReject)
 	assert.NoError(t, err)
 
 	type testCase struct {
@@ -122,7 +124,7 @@ func TestRowParser_Parse_Invalid(t *testing.T) {
 			},
 		},
 	}
-	r, err := NewRowParser(schema)
+	r, err := NewRowParser(schema, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateReject)
 	assert.NoError(t, err)
 
 	type testCase struct {
@@ -135,6 +137,11 @@ func TestRowParser_Parse_Invalid(t *testing.T) {
 		{name: `{"id": 1, "vector": [], "x": 6, "$meta": "{\"x\": 8}"}`, expectErr: "duplicated key is not allowed"},
 		{name: `{"id": 1, "vector": [], "x": 6, "$meta": "{*&%%&$*(&"}`, expectErr: "not a JSON format string"},
 		{name: `{"id": 1, "vector": [], "x": 6, "$meta": []}`, expectErr: "not a JSON object"},
+		{name: `{"id": "1", "vector": []}`, expectErr: "invalid value '1' of primary key 'id' with type 'Int64' at unknown row, expect an integer, got a quoted integer string"},
+		{name: `{"id": "a", "vector": []}`, expectErr: "expect an integer, got a string"},
+		{name: `{"id": 1.5, "vector": []}`, expectErr: "invalid value '1.5' of primary key 'id'"},
+		{name: `{"id": 9223372036854775808, "vector": []}`, expectErr: "out of int64 range"},
+		{name: `{"id": null, "vector": []}`, expectErr: "got type '<nil>'"},
 	}
 
 	for _, c := range cases {
@@ -152,3 +159,156 @@ func TestRowParser_Parse_Invalid(t *testing.T) {
 		})
 	}
 }
+
+func TestRowParser_UnknownColumn(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{
+				FieldID:      1,
+				Name:         "id",
+				IsPrimaryKey: true,
+				DataType:     schemapb.DataType_Int64,
+			},
+		},
+	}
+	parse := func(r RowParser, raw string) (Row, error) {
+		var mp map[string]interface{}
+		desc := json.NewDecoder(strings.NewReader(raw))
+		desc.UseNumber()
+		assert.NoError(t, desc.Decode(&mp))
+		return r.Parse(mp)
+	}
+
+	// unknown column fails the import by default without dynamic schema.
+	r, err := NewRowParser(schema, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateReject)
+	assert.NoError(t, err)
+	_, err = parse(r, `{"id": 1, "x": 6}`)
+	assert.ErrorContains(t, err, "the field 'x' is not defined in schema")
+	assert.Equal(t, []string{"x"}, r.UnknownColumns())
+
+	r, err = NewRowParser(schema, importcommon.UnknownColumnIgnore, nil, importcommon.SparseDuplicateReject)
+	assert.NoError(t, err)
+	row, err := parse(r, `{"id": 1, "x": 6}`)
+	assert.NoError(t, err)
+	assert.Len(t, row, 1)
+	_, err = parse(r, `{"id": 2, "x": 6, "y": 7}`)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"x", "y"}, r.UnknownColumns())
+
+	_, err = NewRowParser(schema, importcommon.UnknownColumnStoreAsDynamic, nil, importcommon.SparseDuplicateReject)
+	assert.Error(t, err)
+
+	// unknown column is stored as dynamic by default with dynamic schema.
+	schema.EnableDynamicField = true
+	schema.Fields = append(schema.Fields, &schemapb.FieldSchema{
+		FieldID:   2,
+		Name:      "$meta",
+		IsDynamic: true,
+		DataType:  schemapb.DataType_JSON,
+	})
+	r, err = NewRowParser(schema, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateReject)
+	assert.NoError(t, err)
+	row, err = parse(r, `{"id": 1, "x": 6}`)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"x": 6}`, string(row[2].([]byte)))
+	assert.Equal(t, []string{"x"}, r.UnknownColumns())
+
+	// the dynamic field itself is not unknown.
+	r, err = NewRowParser(schema, importcommon.UnknownColumnError, nil, importcommon.SparseDuplicateReject)
+	assert.NoError(t, err)
+	_, err = parse(r, `{"id": 1, "$meta": {"x": 6}}`)
+	assert.NoError(t, err)
+	_, err = parse(r, `{"id": 1, "x": 6}`)
+	assert.ErrorContains(t, err, "the field 'x' is not defined in schema")
+}
+
+func TestRowParser_FieldEncoding(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{
+				FieldID:      1,
+				Name:         "id",
+				IsPrimaryKey: true,
+				DataType:     schemapb.DataType_Int64,
+			},
+			{
+				FieldID:    2,
+				Name:       "vector",
+				DataType:   schemapb.DataType_FloatVector,
+				TypeParams: []*commonpb.KeyValuePair{{Key: common.DimKey, Value: "2"}},
+			},
+			{
+				FieldID:    3,
+				Name:       "bin",
+				DataType:   schemapb.DataType_BinaryVector,
+				TypeParams: []*commonpb.KeyValuePair{{Key: common.DimKey, Value: "16"}},
+			},
+		},
+	}
+	parse := func(r RowParser, raw string) (Row, error) {
+		var mp map[string]interface{}
+		desc := json.NewDecoder(strings.NewReader(raw))
+		desc.UseNumber()
+		assert.NoError(t, desc.Decode(&mp))
+		return r.Parse(mp)
+	}
+
+	r, err := NewRowParser(schema, importcommon.UnknownColumnDefault, map[int64]importcommon.FieldEncoding{
+		2: importcommon.FieldEncodingBase64,
+		3: importcommon.FieldEncodingHex,
+	}, importcommon.SparseDuplicateReject)
+	assert.NoError(t, err)
+	// [1.0, -2.0] in little-endian float32.
+	row, err := parse(r, `{"id": 1, "vector": "AACAPwAAAMA=", "bin": "0aff"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1, -2}, row[2])
+	assert.Equal(t, []byte{0x0a, 0xff}, row[3])
+	assert.Empty(t, r.DecodeFailures())
+
+	_, err = parse(r, `{"id": 1, "vector": "not base64", "bin": "0aff"}`)
+	assert.ErrorContains(t, err, "failed to decode field 'vector' as base64")
+	_, err = parse(r, `{"id": 1, "vector": "AACAPw==", "bin": "0aff"}`)
+	assert.ErrorContains(t, err, "expected 8 bytes for field 'vector' with dim '2', got 4 bytes")
+	_, err = parse(r, `{"id": 1, "vector": "AACAPwAAAMA=", "bin": [10, 255]}`)
+	assert.ErrorContains(t, err, "expected hex string for field 'bin'")
+	assert.Equal(t, map[string]int64{"vector": 2, "bin": 1}, r.DecodeFailures())
+}
+
+func TestRowParser_SparseDuplicateIndex(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{
+				FieldID:      1,
+				Name:         "id",
+				IsPrimaryKey: true,
+				DataType:     schemapb.DataType_Int64,
+			},
+			{
+				FieldID:  2,
+				Name:     "sparse",
+				DataType: schemapb.DataType_SparseFloatVector,
+			},
+		},
+	}
+	parse := func(r RowParser, raw string) (Row, error) {
+		var mp map[string]interface{}
+		desc := json.NewDecoder(strings.NewReader(raw))
+		desc.UseNumber()
+		assert.NoError(t, desc.Decode(&mp))
+		return r.Parse(mp)
+	}
+	raw := `{"id": 1, "sparse": {"indices": [5, 1, 5], "values": [0.5, 0.1, 0.25]}}`
+
+	r, err := NewRowParser(schema, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateReject)
+	assert.NoError(t, err)
+	_, err = parse(r, raw)
+	assert.ErrorContains(t, err, "duplicate index 5")
+
+	r, err = NewRowParser(schema, importcommon.UnknownColumnDefault, nil, importcommon.SparseDuplicateSum)
+	assert.NoError(t, err)
+	row, err := parse(r, raw)
+	assert.NoError(t, err)
+	assert.Equal(t, typeutil.CreateSparseFloatRow([]uint32{1, 5}, []float32{0.1, 0.75}), row[2])
+	_, err = parse(r, `{"id": 1, "sparse": {"indices": [2, 1], "values": [0.1, -0.2]}}`)
+	assert.ErrorContains(t, err, "negative value")
+}
@@ -0,0 +1,129 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importutilv2
+
+import (
+	"math"
+
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// normalizingReader checks the float vectors of cosine metric fields read by the underlying reader for unit norm,
+// and normalizes the ones out of tolerance if required.
+type normalizingReader struct {
+	Reader
+	normalization common.VectorNormalization
+	fields        typeutil.UniqueSet
+	// outOfNorm is the number of vectors out of the norm tolerance read so far.
+	outOfNorm int64
+}
+
+func newNormalizingReader(reader Reader, normalization common.VectorNormalization, fields typeutil.UniqueSet) Reader {
+	if normalization == common.VectorNormalizationNone || fields.Len() == 0 {
+		return reader
+	}
+	return &normalizingReader{Reader: reader, normalization: normalization, fields: fields}
+}
+
+func (r *normalizingReader) Read() (*storage.InsertData, error) {
+	data, err := r.Reader.Read()
+	if err != nil {
+		return data, err
+	}
+	normalize := r.normalization == common.VectorNormalizationNormalize
+	for fieldID, fieldData := range data.Data {
+		if !r.fields.Contain(fieldID) {
+			continue
+		}
+		switch fd := fieldData.(type) {
+		case *storage.FloatVectorFieldData:
+			r.outOfNorm += normalizeVectors(fd.Data, fd.Dim, normalize)
+		case *storage.Float16VectorFieldData:
+			r.outOfNorm += normalizeHalfVectors(fd.Data, fd.Dim, normalize,
+				typeutil.Float16BytesToFloat32Vector, typeutil.Float32ToFloat16Bytes)
+		case *storage.BFloat16VectorFieldData:
+			r.outOfNorm += normalizeHalfVectors(fd.Data, fd.Dim, normalize,
+				typeutil.BFloat16BytesToFloat32Vector, typeutil.Float32ToBFloat16Bytes)
+		}
+	}
+	return data, nil
+}
+
+// OutOfNormVectors returns the number of vectors out of the norm tolerance read so far.
+func (r *normalizingReader) OutOfNormVectors() int64 {
+	return r.outOfNorm
+}
+
+func (r *normalizingReader) UnknownColumns() []string {
+	return GetUnknownColumns(r.Reader)
+}
+
+func (r *normalizingReader) DecodeFailures() map[string]int64 {
+	return GetDecodeFailures(r.Reader)
+}
+
+// normalizeVectors counts the vectors out of the norm tolerance, and normalizes them in place if required.
+// The zero vectors are counted but kept as is, since they have no direction.
+func normalizeVectors(vectors []float32, dim int, normalize bool) int64 {
+	if dim <= 0 {
+		return 0
+	}
+	var outOfNorm int64
+	for start := 0; start+dim <= len(vectors); start += dim {
+		vector := vectors[start : start+dim]
+		var sum float64
+		for _, v := range vector {
+			sum += float64(v) * float64(v)
+		}
+		norm := math.Sqrt(sum)
+		if math.Abs(norm-1) <= common.VectorNormTolerance {
+			continue
+		}
+		outOfNorm++
+		if normalize && norm > 0 {
+			for i := range vector {
+				vector[i] = float32(float64(vector[i]) / norm)
+			}
+		}
+	}
+	return outOfNorm
+}
+
+// normalizeHalfVectors is normalizeVectors of the vectors encoded in 2 bytes per element.
+func normalizeHalfVectors(vectors []byte, dim int, normalize bool,
+	decode func([]byte) []float32, encode func(float32) []byte,
+) int64 {
+	if dim <= 0 {
+		return 0
+	}
+	var outOfNorm int64
+	for start := 0; start+dim*2 <= len(vectors); start += dim * 2 {
+		vector := decode(vectors[start : start+dim*2])
+		if normalizeVectors(vector, dim, normalize) == 0 {
+			continue
+		}
+		outOfNorm++
+		if normalize {
+			for i, v := range vector {
+				copy(vectors[start+i*2:], encode(v))
+			}
+		}
+	}
+	return outOfNorm
+}
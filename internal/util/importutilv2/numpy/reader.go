@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/samber/lo"
@@ -42,9 +43,13 @@ type reader struct {
 
 	count int64
 	frs   map[int64]*FieldReader // fieldID -> FieldReader
+
+	unknownColumns []string
 }
 
-func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, paths []string, bufferSize int) (*reader, error) {
+func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, paths []string, bufferSize int,
+	unknownColumnPolicy common.UnknownColumnPolicy,
+) (*reader, error) {
 	fields := lo.KeyBy(schema.GetFields(), func(field *schemapb.FieldSchema) int64 {
 		return field.GetFieldID()
 	})
@@ -53,7 +58,7 @@ func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.Co
 		return nil, err
 	}
 	crs := make(map[int64]*FieldReader)
-	readers, err := CreateReaders(ctx, cm, schema, paths)
+	readers, unknownColumns, err := CreateReaders(ctx, cm, schema, paths, unknownColumnPolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -72,9 +77,16 @@ func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.Co
 		paths:    paths,
 		count:    count,
 		frs:      crs,
+
+		unknownColumns: unknownColumns,
 	}, nil
 }
 
+// UnknownColumns returns the names of the files not matching any field in schema.
+func (r *reader) UnknownColumns() []string {
+	return r.unknownColumns
+}
+
 func (r *reader) Read() (*storage.InsertData, error) {
 	insertData, err := storage.NewInsertData(r.schema)
 	if err != nil {
@@ -119,17 +131,38 @@ func (r *reader) Close() {
 	}
 }
 
-func CreateReaders(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, paths []string) (map[int64]io.Reader, error) {
+// CreateReaders opens the file of each field by the file name, and returns the names of the files
+// not matching any field if they are ignored.
+func CreateReaders(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, paths []string,
+	unknownColumnPolicy common.UnknownColumnPolicy,
+) (map[int64]io.Reader, []string, error) {
 	readers := make(map[int64]io.Reader)
 	nameToPath := lo.SliceToMap(paths, func(path string) (string, string) {
 		nameWithExt := filepath.Base(path)
 		name := strings.TrimSuffix(nameWithExt, filepath.Ext(nameWithExt))
 		return name, path
 	})
+	fieldNames := lo.SliceToMap(schema.GetFields(), func(field *schemapb.FieldSchema) (string, struct{}) {
+		return field.GetName(), struct{}{}
+	})
+	unknownColumns := lo.Filter(lo.Keys(nameToPath), func(name string, _ int) bool {
+		_, ok := fieldNames[name]
+		return !ok
+	})
+	sort.Strings(unknownColumns)
+	if len(unknownColumns) > 0 {
+		// the files not matching any field are ignored by default.
+		switch unknownColumnPolicy.Or(common.UnknownColumnIgnore) {
+		case common.UnknownColumnError:
+			return nil, nil, common.WrapUnknownColumnError(unknownColumns[0])
+		case common.UnknownColumnStoreAsDynamic:
+			return nil, nil, common.WrapStoreAsDynamicUnsupportedError("numpy", unknownColumns[0])
+		}
+	}
 	for _, field := range schema.GetFields() {
 		if field.GetIsPrimaryKey() && field.GetAutoID() {
 			if _, ok := nameToPath[field.GetName()]; ok {
-				return nil, merr.WrapErrImportFailed(
+				return nil, nil, merr.WrapErrImportFailed(
 					fmt.Sprintf("the primary key '%s' is auto-generated, no need to provide", field.GetName()))
 			}
 			continue
@@ -138,15 +171,15 @@ func CreateReaders(ctx context.Context, cm storage.ChunkManager, schema *schemap
 			if field.GetIsDynamic() {
 				continue
 			}
-			return nil, merr.WrapErrImportFailed(
+			return nil, nil, merr.WrapErrImportFailed(
 				fmt.Sprintf("no file for field: %s, files: %v", field.GetName(), lo.Values(nameToPath)))
 		}
 		reader, err := cm.Reader(ctx, nameToPath[field.GetName()])
 		if err != nil {
-			return nil, merr.WrapErrImportFailed(
+			return nil, nil, merr.WrapErrImportFailed(
 				fmt.Sprintf("failed to read the file '%s', error: %s", nameToPath[field.GetName()], err.Error()))
 		}
 		readers[field.GetFieldID()] = reader
 	}
-	return readers, nil
+	return readers, unknownColumns, nil
 }
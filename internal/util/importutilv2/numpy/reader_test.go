@@ -36,6 +36,7 @@ import (
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/mocks"
 	"github.com/milvus-io/milvus/internal/storage"
+	importcommon "github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/internal/util/testutil"
 	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
@@ -178,7 +179,7 @@ func (suite *ReaderSuite) run(dt schemapb.DataType) {
 		}, nil)
 	}
 
-	reader, err := NewReader(context.Background(), cm, schema, lo.Values(files), math.MaxInt)
+	reader, err := NewReader(context.Background(), cm, schema, lo.Values(files), math.MaxInt, importcommon.UnknownColumnDefault)
 	suite.NoError(err)
 
 	checkFn := func(actualInsertData *storage.InsertData, offsetBegin, expectRows int) {
@@ -312,7 +313,7 @@ func (suite *ReaderSuite) failRun(dt schemapb.DataType, isDynamic bool) {
 		}, nil)
 	}
 
-	reader, err := NewReader(context.Background(), cm, schema, lo.Values(files), math.MaxInt)
+	reader, err := NewReader(context.Background(), cm, schema, lo.Values(files), math.MaxInt, importcommon.UnknownColumnDefault)
 	suite.NoError(err)
 
 	_, err = reader.Read()
@@ -354,6 +355,40 @@ func TestUtil(t *testing.T) {
 	suite.Run(t, new(ReaderSuite))
 }
 
+func TestFortranOrder(t *testing.T) {
+	// npyio only writes c-order arrays, flip the order flag of header and keep the header length.
+	createFortranReader := func(data interface{}) io.Reader {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, npyio.Write(buf, data))
+		raw := bytes.Replace(buf.Bytes(), []byte("'fortran_order': False"), []byte("'fortran_order': True"), 1)
+		end := bytes.Index(raw, []byte("}")) + 1
+		raw = append(raw[:end:end], append([]byte(" "), raw[end:]...)...)
+		return bytes.NewReader(raw)
+	}
+	vecField := &schemapb.FieldSchema{
+		FieldID:    100,
+		Name:       "vec",
+		DataType:   schemapb.DataType_FloatVector,
+		TypeParams: []*commonpb.KeyValuePair{{Key: common.DimKey, Value: "2"}},
+	}
+	_, err := NewFieldReader(createFortranReader([][2]float32{{1, 2}, {3, 4}}), vecField)
+	assert.ErrorContains(t, err, "fortran-order not supported for field 'vec'")
+
+	// the array with a single row is the same in both orders.
+	fr, err := NewFieldReader(createFortranReader([][2]float32{{1, 2}}), vecField)
+	assert.NoError(t, err)
+	data, err := fr.Next(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1, 2}, data)
+
+	pkField := &schemapb.FieldSchema{FieldID: 101, Name: "pk", DataType: schemapb.DataType_Int64}
+	fr, err = NewFieldReader(createFortranReader([]int64{1, 2, 3}), pkField)
+	assert.NoError(t, err)
+	data, err = fr.Next(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, data)
+}
+
 func TestCreateReaders(t *testing.T) {
 	ctx := context.Background()
 	cm := mocks.NewChunkManager(t)
@@ -367,7 +402,7 @@ func TestCreateReaders(t *testing.T) {
 			{Name: "json", DataType: schemapb.DataType_JSON},
 		},
 	}
-	_, err := CreateReaders(ctx, cm, schema, []string{"pk", "vec", "json"})
+	_, _, err := CreateReaders(ctx, cm, schema, []string{"pk", "vec", "json"}, importcommon.UnknownColumnDefault)
 	assert.NoError(t, err)
 
 	// auto id
@@ -378,7 +413,7 @@ func TestCreateReaders(t *testing.T) {
 			{Name: "json", DataType: schemapb.DataType_JSON},
 		},
 	}
-	_, err = CreateReaders(ctx, cm, schema, []string{"pk", "vec", "json"})
+	_, _, err = CreateReaders(ctx, cm, schema, []string{"pk", "vec", "json"}, importcommon.UnknownColumnDefault)
 	assert.Error(t, err)
 
 	// $meta
@@ -389,6 +424,19 @@ func TestCreateReaders(t *testing.T) {
 			{Name: "$meta", DataType: schemapb.DataType_JSON, IsDynamic: true},
 		},
 	}
-	_, err = CreateReaders(ctx, cm, schema, []string{"pk", "vec"})
+	_, _, err = CreateReaders(ctx, cm, schema, []string{"pk", "vec"}, importcommon.UnknownColumnDefault)
+	assert.NoError(t, err)
+
+	// unknown files
+	files := []string{"pk.npy", "vec.npy", "y.npy", "x.npy"}
+	_, unknownColumns, err := CreateReaders(ctx, cm, schema, files, importcommon.UnknownColumnDefault)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, unknownColumns)
+	_, unknownColumns, err = CreateReaders(ctx, cm, schema, files, importcommon.UnknownColumnIgnore)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, unknownColumns)
+	_, _, err = CreateReaders(ctx, cm, schema, files, importcommon.UnknownColumnError)
+	assert.ErrorContains(t, err, "the field 'x' is not defined in schema")
+	_, _, err = CreateReaders(ctx, cm, schema, files, importcommon.UnknownColumnStoreAsDynamic)
+	assert.ErrorContains(t, err, "not supported by numpy files")
 }
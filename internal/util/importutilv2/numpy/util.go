@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"unicode/utf8"
 
+	"github.com/samber/lo"
 	"github.com/sbinet/npyio"
 	"github.com/sbinet/npyio/npy"
 	"golang.org/x/text/encoding/unicode"
@@ -191,6 +192,12 @@ func validateHeader(npyReader *npy.Reader, field *schemapb.FieldSchema, dim int)
 		return err
 	}
 	shape := npyReader.Header.Descr.Shape
+	// the rows of fortran-order array are interleaved column by column, which can't be read as a stream,
+	// it's only the same as c-order if there is at most one axis longer than 1.
+	if npyReader.Header.Descr.Fortran && lo.CountBy(shape, func(n int) bool { return n > 1 }) > 1 {
+		return merr.WrapErrImportFailed(fmt.Sprintf("fortran-order not supported for field '%s', "+
+			"please save the array in c-order, e.g. by numpy.ascontiguousarray", field.GetName()))
+	}
 
 	switch field.GetDataType() {
 	case schemapb.DataType_FloatVector:
@@ -17,15 +17,24 @@
 package importutilv2
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/samber/lo"
+
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
+	pkgcommon "github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/util/funcutil"
 	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/metric"
 	"github.com/milvus-io/milvus/pkg/util/tsoutil"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 const (
@@ -35,8 +44,37 @@ const (
 	EndTs2     = "endTs"
 	BackupFlag = "backup"
 	L0Import   = "l0_import"
+
+	// ReassignAutoID makes the backup import reassign the primary keys of autoID collection,
+	// the original primary keys are kept by default.
+	ReassignAutoID = "reassign_autoid"
+	// ValidateOnly makes the import validate all the rows by preimport and write nothing.
+	ValidateOnly = "validate_only"
+
+	ErrorSampleLimit = "error_sample_limit"
+	ColumnMapping    = "column_mapping"
+	RejectEmptyFiles = "reject_empty_files"
+	RowTransform     = "row_transform"
+	SortByPK         = "sort_by_pk"
+	Strict           = "strict"
+	OnUnknownColumn  = "on_unknown_column"
+	JSONSchema       = "json_schema"
+	FieldEncoding    = "field_encoding"
+
+	SparseDuplicateIndex = "sparse_duplicate_index"
+	// VectorNormalization checks the float vectors of fields using cosine metric for unit norm,
+	// and normalizes them if required.
+	VectorNormalization = "vector_normalization"
+	// VectorMetricType is the metric types of float vector fields checked for unit norm,
+	// which override the metric types in the field params.
+	VectorMetricType = "vector_metric_type"
+	// Charset is the character encoding of text files, which are transcoded to UTF-8 while read.
+	Charset = "charset"
 )
 
+// MaxErrorSampleLimit bounds the number of bad rows sampled for each import file.
+const MaxErrorSampleLimit = 100
+
 type Options []*commonpb.KeyValuePair
 
 func ParseTimeRange(options Options) (uint64, uint64, error) {
@@ -85,3 +123,320 @@ func IsL0Import(options Options) bool {
 	}
 	return true
 }
+
+// IsReassignAutoID returns whether the backup import should reassign the primary keys of autoID collection
+// and emit the mapping from the original primary keys, it takes no effect if not backup import.
+func IsReassignAutoID(options Options) bool {
+	if !IsBackup(options) {
+		return false
+	}
+	reassign, err := funcutil.GetAttrByKeyFromRepeatedKV(ReassignAutoID, options)
+	if err != nil || strings.ToLower(reassign) != "true" {
+		return false
+	}
+	return true
+}
+
+// IsValidateOnly returns whether the import only validates the files without writing segments, the bad rows don't fail
+// the preimport but are counted and sampled, so that all of them are reported instead of the first one.
+func IsValidateOnly(options Options) bool {
+	validateOnly, err := funcutil.GetAttrByKeyFromRepeatedKV(ValidateOnly, options)
+	if err != nil || strings.ToLower(validateOnly) != "true" {
+		return false
+	}
+	return true
+}
+
+// IsRejectEmptyFiles returns whether an import file without any row should fail the import,
+// empty files are accepted by default.
+func IsRejectEmptyFiles(options Options) bool {
+	rejectEmptyFiles, err := funcutil.GetAttrByKeyFromRepeatedKV(RejectEmptyFiles, options)
+	if err != nil || strings.ToLower(rejectEmptyFiles) != "true" {
+		return false
+	}
+	return true
+}
+
+// IsSortByPK returns whether the rows should be sorted by primary key before flushing,
+// which narrows the bloom filters of segments and improves pruning.
+func IsSortByPK(options Options) bool {
+	sortByPK, err := funcutil.GetAttrByKeyFromRepeatedKV(SortByPK, options)
+	if err != nil || strings.ToLower(sortByPK) != "true" {
+		return false
+	}
+	return true
+}
+
+// IsStrict returns whether the import should fail on ambiguous requests instead of fixing them up,
+// e.g. the duplicate files are collapsed with a warning if not strict.
+func IsStrict(options Options) bool {
+	strict, err := funcutil.GetAttrByKeyFromRepeatedKV(Strict, options)
+	if err != nil || strings.ToLower(strict) != "true" {
+		return false
+	}
+	return true
+}
+
+// GetUnknownColumnPolicy returns how the source columns not defined in schema are handled,
+// the behavior of each file format is kept if not set. store_as_dynamic requires the collection
+// to enable dynamic schema.
+func GetUnknownColumnPolicy(options Options, schema *schemapb.CollectionSchema) (common.UnknownColumnPolicy, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(OnUnknownColumn, options)
+	if err != nil {
+		return common.UnknownColumnDefault, nil
+	}
+	policy := common.UnknownColumnPolicy(strings.ToLower(value))
+	switch policy {
+	case common.UnknownColumnIgnore, common.UnknownColumnError:
+		return policy, nil
+	case common.UnknownColumnStoreAsDynamic:
+		if typeutil.GetDynamicField(schema) == nil {
+			return "", merr.WrapErrImportFailed(fmt.Sprintf("%s=%s requires the collection to enable dynamic schema", OnUnknownColumn, value))
+		}
+		return policy, nil
+	}
+	return "", merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, expect one of %s, %s and %s", OnUnknownColumn, value,
+		common.UnknownColumnIgnore, common.UnknownColumnError, common.UnknownColumnStoreAsDynamic))
+}
+
+// GetSparseDuplicatePolicy returns how the duplicate indices of sparse float vectors are handled,
+// the rows with duplicate indices are rejected if not set.
+func GetSparseDuplicatePolicy(options Options) (common.SparseDuplicatePolicy, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(SparseDuplicateIndex, options)
+	if err != nil {
+		return common.SparseDuplicateReject, nil
+	}
+	policy := common.SparseDuplicatePolicy(strings.ToLower(value))
+	switch policy {
+	case common.SparseDuplicateReject, common.SparseDuplicateSum:
+		return policy, nil
+	}
+	return "", merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, expect %s or %s", SparseDuplicateIndex, value,
+		common.SparseDuplicateReject, common.SparseDuplicateSum))
+}
+
+// GetVectorNormalization returns how the float vectors are checked for unit norm, they are not checked if not set.
+func GetVectorNormalization(options Options) (common.VectorNormalization, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(VectorNormalization, options)
+	if err != nil {
+		return common.VectorNormalizationNone, nil
+	}
+	normalization := common.VectorNormalization(strings.ToLower(value))
+	switch normalization {
+	case common.VectorNormalizationCheck, common.VectorNormalizationNormalize:
+		return normalization, nil
+	}
+	return "", merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, expect %s or %s", VectorNormalization, value,
+		common.VectorNormalizationCheck, common.VectorNormalizationNormalize))
+}
+
+// GetNormalizedFields returns the ids of float vector fields checked for unit norm, which are the ones using cosine metric.
+// The metric types are given as a json object from field name to metric type, e.g. {"vector": "COSINE"},
+// or by the index and type params of fields. Normalizing is rejected for the fields given other metrics,
+// and for the collections without any float vector field using cosine metric.
+func GetNormalizedFields(options Options, schema *schemapb.CollectionSchema, normalization common.VectorNormalization) (typeutil.UniqueSet, error) {
+	if normalization == common.VectorNormalizationNone {
+		return nil, nil
+	}
+	metricTypes := make(map[string]string)
+	if value, err := funcutil.GetAttrByKeyFromRepeatedKV(VectorMetricType, options); err == nil {
+		if err = json.Unmarshal([]byte(value), &metricTypes); err != nil {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, err=%s", VectorMetricType, value, err))
+		}
+	}
+	fields := lo.KeyBy(schema.GetFields(), func(field *schemapb.FieldSchema) string {
+		return field.GetName()
+	})
+	for name := range metricTypes {
+		if field, ok := fields[name]; !ok || !typeutil.IsDenseFloatVectorType(field.GetDataType()) {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, field %s is not a float vector field", VectorMetricType, name))
+		}
+	}
+
+	normalized := typeutil.NewUniqueSet()
+	for _, field := range schema.GetFields() {
+		if !typeutil.IsDenseFloatVectorType(field.GetDataType()) {
+			continue
+		}
+		metricType, given := metricTypes[field.GetName()]
+		if !given {
+			metricType = getFieldMetricType(field)
+		}
+		if strings.EqualFold(metricType, metric.COSINE) {
+			normalized.Insert(field.GetFieldID())
+		} else if given && normalization == common.VectorNormalizationNormalize {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("%s=%s is only allowed for %s metric, field %s uses %s",
+				VectorNormalization, normalization, metric.COSINE, field.GetName(), metricType))
+		}
+	}
+	if normalized.Len() == 0 && normalization == common.VectorNormalizationNormalize {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("%s=%s is only allowed for %s metric, no float vector field uses it",
+			VectorNormalization, normalization, metric.COSINE))
+	}
+	return normalized, nil
+}
+
+// getFieldMetricType returns the metric type in the index params of field, or in its type params if not indexed.
+func getFieldMetricType(field *schemapb.FieldSchema) string {
+	if metricType, err := funcutil.GetAttrByKeyFromRepeatedKV(pkgcommon.MetricTypeKey, field.GetIndexParams()); err == nil {
+		return metricType
+	}
+	metricType, _ := funcutil.GetAttrByKeyFromRepeatedKV(pkgcommon.MetricTypeKey, field.GetTypeParams())
+	return metricType
+}
+
+// GetCharset returns the character encoding of text files, UTF-8 if not set.
+func GetCharset(options Options) (common.Charset, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(Charset, options)
+	if err != nil {
+		return common.CharsetUTF8, nil
+	}
+	switch strings.ToLower(value) {
+	case "utf-8", "utf8":
+		return common.CharsetUTF8, nil
+	case "utf-16", "utf16":
+		return common.CharsetUTF16, nil
+	case "utf-16le", "utf16le":
+		return common.CharsetUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return common.CharsetUTF16BE, nil
+	case "latin-1", "latin1", "iso-8859-1":
+		return common.CharsetLatin1, nil
+	}
+	return "", merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, expect one of %s, %s, %s, %s and %s", Charset, value,
+		common.CharsetUTF8, common.CharsetUTF16, common.CharsetUTF16LE, common.CharsetUTF16BE, common.CharsetLatin1))
+}
+
+// GetRowTransformName returns the name of registered transform applied to each decoded row,
+// empty if not set.
+func GetRowTransformName(options Options) string {
+	name, err := funcutil.GetAttrByKeyFromRepeatedKV(RowTransform, options)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// GetErrorSampleLimit returns the number of bad rows to be sampled for debugging,
+// 0 means sampling is disabled, which is the default.
+func GetErrorSampleLimit(options Options) (int, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(ErrorSampleLimit, options)
+	if err != nil {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s", ErrorSampleLimit, value))
+	}
+	return min(limit, MaxErrorSampleLimit), nil
+}
+
+// GetJSONSchemas returns the schemas to validate the documents of json fields by field id,
+// which are given as a json object from field name to schema, e.g. {"meta": {"type": "object", "required": ["id"]}}.
+func GetJSONSchemas(options Options, schema *schemapb.CollectionSchema) (map[int64]*common.JSONSchema, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(JSONSchema, options)
+	if err != nil {
+		return nil, nil
+	}
+	raws := make(map[string]json.RawMessage)
+	if err = json.Unmarshal([]byte(value), &raws); err != nil {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, err=%s", JSONSchema, value, err))
+	}
+	fields := lo.KeyBy(schema.GetFields(), func(field *schemapb.FieldSchema) string {
+		return field.GetName()
+	})
+	schemas := make(map[int64]*common.JSONSchema, len(raws))
+	for name, raw := range raws {
+		field, ok := fields[name]
+		if !ok || field.GetDataType() != schemapb.DataType_JSON {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, field %s is not a json field", JSONSchema, name))
+		}
+		jsonSchema, err := common.ParseJSONSchema(raw)
+		if err != nil {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s of field %s, err=%s", JSONSchema, name, err))
+		}
+		schemas[field.GetFieldID()] = jsonSchema
+	}
+	return schemas, nil
+}
+
+// GetFieldEncodings returns the encodings of vector fields by field id, which are given as a json object
+// from field name to encoding, e.g. {"vector": "base64"}. Only the dense vector fields can be encoded.
+func GetFieldEncodings(options Options, schema *schemapb.CollectionSchema) (map[int64]common.FieldEncoding, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(FieldEncoding, options)
+	if err != nil {
+		return nil, nil
+	}
+	raws := make(map[string]string)
+	if err = json.Unmarshal([]byte(value), &raws); err != nil {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, err=%s", FieldEncoding, value, err))
+	}
+	fields := lo.KeyBy(schema.GetFields(), func(field *schemapb.FieldSchema) string {
+		return field.GetName()
+	})
+	encodings := make(map[int64]common.FieldEncoding, len(raws))
+	for name, raw := range raws {
+		field, ok := fields[name]
+		if !ok || !typeutil.IsVectorType(field.GetDataType()) || typeutil.IsSparseFloatVectorType(field.GetDataType()) {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, field %s is not a dense vector field", FieldEncoding, name))
+		}
+		encoding := common.FieldEncoding(strings.ToLower(raw))
+		if encoding != common.FieldEncodingBase64 && encoding != common.FieldEncodingHex {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s of field %s, value=%s, expect %s or %s",
+				FieldEncoding, name, raw, common.FieldEncodingBase64, common.FieldEncodingHex))
+		}
+		encodings[field.GetFieldID()] = encoding
+	}
+	return encodings, nil
+}
+
+// GetColumnMapping returns the mapping from source column name to field name,
+// which is given as a json object, e.g. {"src_id": "id", "src_vec": "vector"}.
+func GetColumnMapping(options Options) (map[string]string, error) {
+	value, err := funcutil.GetAttrByKeyFromRepeatedKV(ColumnMapping, options)
+	if err != nil {
+		return nil, nil
+	}
+	mapping := make(map[string]string)
+	if err = json.Unmarshal([]byte(value), &mapping); err != nil {
+		return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid %s, value=%s, err=%s", ColumnMapping, value, err))
+	}
+	return mapping, nil
+}
+
+// ApplyColumnMapping returns a copy of schema whose fields are named by the mapped source columns,
+// so that readers read the source columns into the mapped fields. Fields not in mapping keep their names.
+func ApplyColumnMapping(schema *schemapb.CollectionSchema, mapping map[string]string) (*schemapb.CollectionSchema, error) {
+	if len(mapping) == 0 {
+		return schema, nil
+	}
+	nameToField := lo.KeyBy(schema.GetFields(), func(field *schemapb.FieldSchema) string {
+		return field.GetName()
+	})
+	fieldToColumn := make(map[string]string, len(mapping))
+	for column, fieldName := range mapping {
+		if _, ok := nameToField[fieldName]; !ok {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("column '%s' is mapped to a nonexistent field '%s'", column, fieldName))
+		}
+		if other, ok := fieldToColumn[fieldName]; ok {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("field '%s' is mapped by both column '%s' and '%s'", fieldName, other, column))
+		}
+		fieldToColumn[fieldName] = column
+	}
+	for column, fieldName := range mapping {
+		// the column named after a field is taken by another field, the field is left without source column.
+		if _, ok := nameToField[column]; ok && column != fieldName {
+			if _, mapped := fieldToColumn[column]; !mapped {
+				return nil, merr.WrapErrImportFailed(fmt.Sprintf("field '%s' is left unmapped, since column '%s' is mapped to field '%s'", column, column, fieldName))
+			}
+		}
+	}
+
+	mapped := proto.Clone(schema).(*schemapb.CollectionSchema)
+	for _, field := range mapped.GetFields() {
+		if column, ok := fieldToColumn[field.GetName()]; ok {
+			field.Name = column
+		}
+	}
+	return mapped, nil
+}
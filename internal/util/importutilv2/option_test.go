@@ -0,0 +1,244 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importutilv2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
+)
+
+func TestRejectEmptyFiles(t *testing.T) {
+	assert.False(t, IsRejectEmptyFiles(Options{}))
+	assert.False(t, IsRejectEmptyFiles(Options{{Key: RejectEmptyFiles, Value: "false"}}))
+	assert.True(t, IsRejectEmptyFiles(Options{{Key: RejectEmptyFiles, Value: "True"}}))
+}
+
+func TestReassignAutoID(t *testing.T) {
+	assert.False(t, IsReassignAutoID(Options{}))
+	assert.False(t, IsReassignAutoID(Options{{Key: ReassignAutoID, Value: "true"}}))
+	assert.False(t, IsReassignAutoID(Options{{Key: BackupFlag, Value: "true"}, {Key: ReassignAutoID, Value: "false"}}))
+	assert.True(t, IsReassignAutoID(Options{{Key: BackupFlag, Value: "true"}, {Key: ReassignAutoID, Value: "True"}}))
+}
+
+func TestValidateOnly(t *testing.T) {
+	assert.False(t, IsValidateOnly(Options{}))
+	assert.False(t, IsValidateOnly(Options{{Key: ValidateOnly, Value: "false"}}))
+	assert.True(t, IsValidateOnly(Options{{Key: ValidateOnly, Value: "TRUE"}}))
+}
+
+func TestSortByPK(t *testing.T) {
+	assert.False(t, IsSortByPK(Options{}))
+	assert.False(t, IsSortByPK(Options{{Key: SortByPK, Value: "false"}}))
+	assert.True(t, IsSortByPK(Options{{Key: SortByPK, Value: "true"}}))
+}
+
+func TestStrict(t *testing.T) {
+	assert.False(t, IsStrict(Options{}))
+	assert.False(t, IsStrict(Options{{Key: Strict, Value: "false"}}))
+	assert.True(t, IsStrict(Options{{Key: Strict, Value: "true"}}))
+}
+
+func TestUnknownColumnPolicy(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "id", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+		},
+	}
+	policy, err := GetUnknownColumnPolicy(Options{}, schema)
+	assert.NoError(t, err)
+	assert.Equal(t, common.UnknownColumnDefault, policy)
+	policy, err = GetUnknownColumnPolicy(Options{{Key: OnUnknownColumn, Value: "Ignore"}}, schema)
+	assert.NoError(t, err)
+	assert.Equal(t, common.UnknownColumnIgnore, policy)
+	_, err = GetUnknownColumnPolicy(Options{{Key: OnUnknownColumn, Value: "drop"}}, schema)
+	assert.ErrorContains(t, err, "invalid on_unknown_column")
+
+	// store_as_dynamic requires dynamic schema.
+	_, err = GetUnknownColumnPolicy(Options{{Key: OnUnknownColumn, Value: "store_as_dynamic"}}, schema)
+	assert.ErrorContains(t, err, "dynamic schema")
+	schema.EnableDynamicField = true
+	schema.Fields = append(schema.Fields, &schemapb.FieldSchema{FieldID: 101, Name: "$meta", IsDynamic: true, DataType: schemapb.DataType_JSON})
+	policy, err = GetUnknownColumnPolicy(Options{{Key: OnUnknownColumn, Value: "store_as_dynamic"}}, schema)
+	assert.NoError(t, err)
+	assert.Equal(t, common.UnknownColumnStoreAsDynamic, policy)
+}
+
+func TestSparseDuplicatePolicy(t *testing.T) {
+	policy, err := GetSparseDuplicatePolicy(Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, common.SparseDuplicateReject, policy)
+	policy, err = GetSparseDuplicatePolicy(Options{{Key: SparseDuplicateIndex, Value: "Sum"}})
+	assert.NoError(t, err)
+	assert.Equal(t, common.SparseDuplicateSum, policy)
+	_, err = GetSparseDuplicatePolicy(Options{{Key: SparseDuplicateIndex, Value: "max"}})
+	assert.ErrorContains(t, err, "invalid sparse_duplicate_index")
+}
+
+func TestVectorNormalization(t *testing.T) {
+	normalization, err := GetVectorNormalization(Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, common.VectorNormalizationNone, normalization)
+	normalization, err = GetVectorNormalization(Options{{Key: VectorNormalization, Value: "Normalize"}})
+	assert.NoError(t, err)
+	assert.Equal(t, common.VectorNormalizationNormalize, normalization)
+	_, err = GetVectorNormalization(Options{{Key: VectorNormalization, Value: "l2"}})
+	assert.ErrorContains(t, err, "invalid vector_normalization")
+}
+
+func TestNormalizedFields(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "id", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "cosine", DataType: schemapb.DataType_FloatVector, IndexParams: []*commonpb.KeyValuePair{{Key: "metric_type", Value: "COSINE"}}},
+			{FieldID: 102, Name: "l2", DataType: schemapb.DataType_Float16Vector, IndexParams: []*commonpb.KeyValuePair{{Key: "metric_type", Value: "L2"}}},
+			{FieldID: 103, Name: "unknown", DataType: schemapb.DataType_BFloat16Vector},
+		},
+	}
+
+	fields, err := GetNormalizedFields(Options{}, schema, common.VectorNormalizationNone)
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+	// only the fields using cosine metric are checked.
+	fields, err = GetNormalizedFields(Options{}, schema, common.VectorNormalizationNormalize)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{101}, fields.Collect())
+	fields, err = GetNormalizedFields(Options{{Key: VectorMetricType, Value: `{"unknown": "cosine"}`}}, schema, common.VectorNormalizationCheck)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{101, 103}, fields.Collect())
+	fields, err = GetNormalizedFields(Options{{Key: VectorMetricType, Value: `{"cosine": "IP"}`}}, schema, common.VectorNormalizationCheck)
+	assert.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = GetNormalizedFields(Options{{Key: VectorMetricType, Value: "{"}}, schema, common.VectorNormalizationCheck)
+	assert.Error(t, err)
+	_, err = GetNormalizedFields(Options{{Key: VectorMetricType, Value: `{"id": "COSINE"}`}}, schema, common.VectorNormalizationCheck)
+	assert.ErrorContains(t, err, "field id is not a float vector field")
+	// normalizing is rejected for the other metrics.
+	_, err = GetNormalizedFields(Options{{Key: VectorMetricType, Value: `{"unknown": "IP"}`}}, schema, common.VectorNormalizationNormalize)
+	assert.ErrorContains(t, err, "field unknown uses IP")
+	_, err = GetNormalizedFields(Options{{Key: VectorMetricType, Value: `{"cosine": "L2"}`}}, schema, common.VectorNormalizationNormalize)
+	assert.ErrorContains(t, err, "field cosine uses L2")
+	schema.Fields[1].IndexParams = nil
+	_, err = GetNormalizedFields(Options{}, schema, common.VectorNormalizationNormalize)
+	assert.ErrorContains(t, err, "no float vector field uses it")
+}
+
+func TestCharset(t *testing.T) {
+	charset, err := GetCharset(Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, common.CharsetUTF8, charset)
+	charset, err = GetCharset(Options{{Key: Charset, Value: "ISO-8859-1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, common.CharsetLatin1, charset)
+	_, err = GetCharset(Options{{Key: Charset, Value: "gbk"}})
+	assert.ErrorContains(t, err, "invalid charset")
+}
+
+func TestJSONSchemas(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "id", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "meta", DataType: schemapb.DataType_JSON},
+		},
+	}
+
+	schemas, err := GetJSONSchemas(Options{}, schema)
+	assert.NoError(t, err)
+	assert.Nil(t, schemas)
+	_, err = GetJSONSchemas(Options{{Key: JSONSchema, Value: "{"}}, schema)
+	assert.Error(t, err)
+	_, err = GetJSONSchemas(Options{{Key: JSONSchema, Value: `{"id": {"type": "object"}}`}}, schema)
+	assert.ErrorContains(t, err, "field id is not a json field")
+	_, err = GetJSONSchemas(Options{{Key: JSONSchema, Value: `{"meta": {"pattern": "^a"}}`}}, schema)
+	assert.ErrorContains(t, err, "unsupported keyword pattern")
+
+	schemas, err = GetJSONSchemas(Options{{Key: JSONSchema, Value: `{"meta": {"type": "object", "required": ["a"]}}`}}, schema)
+	assert.NoError(t, err)
+	assert.Len(t, schemas, 1)
+	assert.NoError(t, schemas[101].Validate([]byte(`{"a": 1}`)))
+	assert.Error(t, schemas[101].Validate([]byte(`{"b": 1}`)))
+}
+
+func TestFieldEncodings(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "id", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "vector", DataType: schemapb.DataType_FloatVector},
+			{FieldID: 102, Name: "sparse", DataType: schemapb.DataType_SparseFloatVector},
+		},
+	}
+
+	encodings, err := GetFieldEncodings(Options{}, schema)
+	assert.NoError(t, err)
+	assert.Nil(t, encodings)
+	_, err = GetFieldEncodings(Options{{Key: FieldEncoding, Value: "{"}}, schema)
+	assert.Error(t, err)
+	_, err = GetFieldEncodings(Options{{Key: FieldEncoding, Value: `{"id": "base64"}`}}, schema)
+	assert.ErrorContains(t, err, "field id is not a dense vector field")
+	_, err = GetFieldEncodings(Options{{Key: FieldEncoding, Value: `{"sparse": "base64"}`}}, schema)
+	assert.ErrorContains(t, err, "field sparse is not a dense vector field")
+	_, err = GetFieldEncodings(Options{{Key: FieldEncoding, Value: `{"vector": "gzip"}`}}, schema)
+	assert.ErrorContains(t, err, "expect base64 or hex")
+
+	encodings, err = GetFieldEncodings(Options{{Key: FieldEncoding, Value: `{"vector": "Base64"}`}}, schema)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]common.FieldEncoding{101: common.FieldEncodingBase64}, encodings)
+}
+
+func TestColumnMapping(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "id", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "vector", DataType: schemapb.DataType_FloatVector},
+			{FieldID: 102, Name: "text", DataType: schemapb.DataType_VarChar},
+		},
+	}
+
+	mapping, err := GetColumnMapping(Options{})
+	assert.NoError(t, err)
+	assert.Nil(t, mapping)
+	_, err = GetColumnMapping(Options{{Key: ColumnMapping, Value: "{"}})
+	assert.Error(t, err)
+
+	mapping, err = GetColumnMapping(Options{{Key: ColumnMapping, Value: `{"pk": "id", "emb": "vector"}`}})
+	assert.NoError(t, err)
+	mapped, err := ApplyColumnMapping(schema, mapping)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pk", "emb", "text"}, []string{
+		mapped.GetFields()[0].GetName(), mapped.GetFields()[1].GetName(), mapped.GetFields()[2].GetName(),
+	})
+	// the original schema is untouched.
+	assert.Equal(t, "id", schema.GetFields()[0].GetName())
+
+	// swap columns.
+	mapped, err = ApplyColumnMapping(schema, map[string]string{"vector": "text", "text": "vector"})
+	assert.NoError(t, err)
+	assert.Equal(t, "text", mapped.GetFields()[1].GetName())
+	assert.Equal(t, "vector", mapped.GetFields()[2].GetName())
+
+	_, err = ApplyColumnMapping(schema, map[string]string{"pk": "not_exist"})
+	assert.ErrorContains(t, err, "nonexistent field 'not_exist'")
+	_, err = ApplyColumnMapping(schema, map[string]string{"a": "id", "b": "id"})
+	assert.Error(t, err)
+	_, err = ApplyColumnMapping(schema, map[string]string{"text": "vector"})
+	assert.ErrorContains(t, err, "field 'text' is left unmapped")
+}
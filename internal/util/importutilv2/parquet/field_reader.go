@@ -29,6 +29,7 @@ import (
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
@@ -39,6 +40,8 @@ type FieldReader struct {
 
 	dim   int
 	field *schemapb.FieldSchema
+	// sparsePolicy is how the duplicate indices of sparse float vectors are handled.
+	sparsePolicy common.SparseDuplicatePolicy
 }
 
 func NewFieldReader(ctx context.Context, reader *pqarrow.FileReader, columnIndex int, field *schemapb.FieldSchema) (*FieldReader, error) {
@@ -289,10 +292,14 @@ func ReadSparseFloatVectorData(pcr *FieldReader, count int64) (any, error) {
 	byteArr := make([][]byte, 0, count)
 	maxDim := uint32(0)
 	for _, str := range data.([]string) {
-		rowVec, err := typeutil.CreateSparseFloatRowFromJSON([]byte(str))
+		indices, values, err := typeutil.ParseSparseFloatRowFromJSON([]byte(str))
 		if err != nil {
 			return nil, merr.WrapErrImportFailed(fmt.Sprintf("Invalid JSON string for SparseFloatVector: '%s', err = %v", str, err))
 		}
+		rowVec, err := common.NormalizeSparseFloatRow(typeutil.CreateSparseFloatRow(indices, values), pcr.sparsePolicy)
+		if err != nil {
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("Invalid SparseFloatVector: '%s', err = %v", str, err))
+		}
 		byteArr = append(byteArr, rowVec)
 		elemCount := len(rowVec) / 8
 		maxIdx := typeutil.SparseFloatRowIndexAt(rowVec, elemCount-1)
@@ -48,9 +48,13 @@ type reader struct {
 	count      int64
 
 	frs map[int64]*FieldReader // fieldID -> FieldReader
+
+	unknownColumns []string
 }
 
-func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, path string, bufferSize int) (*reader, error) {
+func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.CollectionSchema, path string, bufferSize int,
+	unknownColumnPolicy common.UnknownColumnPolicy, sparsePolicy common.SparseDuplicatePolicy,
+) (*reader, error) {
 	cmReader, err := cm.Reader(ctx, path)
 	if err != nil {
 		return nil, err
@@ -70,7 +74,7 @@ func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.Co
 		return nil, merr.WrapErrImportFailed(fmt.Sprintf("new parquet file reader failed, err=%v", err))
 	}
 
-	crs, err := CreateFieldReaders(ctx, fileReader, schema)
+	crs, unknownColumns, err := CreateFieldReaders(ctx, fileReader, schema, unknownColumnPolicy, sparsePolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -88,9 +92,16 @@ func NewReader(ctx context.Context, cm storage.ChunkManager, schema *schemapb.Co
 		bufferSize: bufferSize,
 		count:      count,
 		frs:        crs,
+
+		unknownColumns: unknownColumns,
 	}, nil
 }
 
+// UnknownColumns returns the names of the parquet columns not defined in schema.
+func (r *reader) UnknownColumns() []string {
+	return r.unknownColumns
+}
+
 func (r *reader) Read() (*storage.InsertData, error) {
 	insertData, err := storage.NewInsertData(r.schema)
 	if err != nil {
@@ -34,6 +34,7 @@ import (
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/storage"
+	importcommon "github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/internal/util/testutil"
 	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
@@ -150,7 +151,7 @@ func (s *ReaderSuite) run(dataType schemapb.DataType, elemType schemapb.DataType
 	f := storage.NewChunkManagerFactory("local", storage.RootPath("/tmp/milvus_test/test_parquet_reader/"))
 	cm, err := f.NewPersistentStorageChunkManager(ctx)
 	assert.NoError(s.T(), err)
-	reader, err := NewReader(ctx, cm, schema, filePath, 64*1024*1024)
+	reader, err := NewReader(ctx, cm, schema, filePath, 64*1024*1024, importcommon.UnknownColumnDefault, importcommon.SparseDuplicateReject)
 	s.NoError(err)
 
 	checkFn := func(actualInsertData *storage.InsertData, offsetBegin, expectRows int) {
@@ -228,7 +229,7 @@ func (s *ReaderSuite) failRun(dt schemapb.DataType, isDynamic bool) {
 	f := storage.NewChunkManagerFactory("local", storage.RootPath("/tmp/milvus_test/test_parquet_reader/"))
 	cm, err := f.NewPersistentStorageChunkManager(ctx)
 	assert.NoError(s.T(), err)
-	reader, err := NewReader(ctx, cm, schema, filePath, 64*1024*1024)
+	reader, err := NewReader(ctx, cm, schema, filePath, 64*1024*1024, importcommon.UnknownColumnDefault, importcommon.SparseDuplicateReject)
 	s.NoError(err)
 
 	_, err = reader.Read()
@@ -277,6 +278,41 @@ func (s *ReaderSuite) TestVector() {
 	s.run(schemapb.DataType_Int32, schemapb.DataType_None)
 }
 
+func (s *ReaderSuite) TestUnknownColumn() {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, Name: "x", DataType: schemapb.DataType_Int32},
+		},
+	}
+	filePath := fmt.Sprintf("/tmp/test_%d_reader.parquet", rand.Int())
+	defer os.Remove(filePath)
+	wf, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0o666)
+	s.NoError(err)
+	_, err = writeParquet(wf, schema, s.numRows)
+	s.NoError(err)
+
+	ctx := context.Background()
+	f := storage.NewChunkManagerFactory("local", storage.RootPath("/tmp/milvus_test/test_parquet_reader/"))
+	cm, err := f.NewPersistentStorageChunkManager(ctx)
+	s.NoError(err)
+
+	// the column x is not defined in the collection schema.
+	schema.Fields = schema.Fields[:1]
+	_, err = NewReader(ctx, cm, schema, filePath, 64*1024*1024, importcommon.UnknownColumnDefault, importcommon.SparseDuplicateReject)
+	s.ErrorContains(err, "the field: x is not in schema")
+	_, err = NewReader(ctx, cm, schema, filePath, 64*1024*1024, importcommon.UnknownColumnStoreAsDynamic, importcommon.SparseDuplicateReject)
+	s.ErrorContains(err, "not supported by parquet files")
+
+	reader, err := NewReader(ctx, cm, schema, filePath, 64*1024*1024, importcommon.UnknownColumnIgnore, importcommon.SparseDuplicateReject)
+	s.NoError(err)
+	s.Equal([]string{"x"}, reader.UnknownColumns())
+	res, err := reader.Read()
+	s.NoError(err)
+	s.Equal(s.numRows, res.GetRowNum())
+	s.Len(res.Data, 1)
+}
+
 func TestUtil(t *testing.T) {
 	suite.Run(t, new(ReaderSuite))
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
@@ -42,40 +43,54 @@ func calcBufferSize(blockSize int, schema *schemapb.CollectionSchema) int {
 	return blockSize / len(schema.GetFields())
 }
 
-func CreateFieldReaders(ctx context.Context, fileReader *pqarrow.FileReader, schema *schemapb.CollectionSchema) (map[int64]*FieldReader, error) {
+// CreateFieldReaders creates the readers of the parquet columns defined in schema,
+// and returns the names of the columns not defined in schema if they are ignored.
+func CreateFieldReaders(ctx context.Context, fileReader *pqarrow.FileReader, schema *schemapb.CollectionSchema,
+	unknownColumnPolicy common.UnknownColumnPolicy, sparsePolicy common.SparseDuplicatePolicy,
+) (map[int64]*FieldReader, []string, error) {
 	nameToField := lo.KeyBy(schema.GetFields(), func(field *schemapb.FieldSchema) string {
 		return field.GetName()
 	})
 
 	pqSchema, err := fileReader.Schema()
 	if err != nil {
-		return nil, merr.WrapErrImportFailed(fmt.Sprintf("get parquet schema failed, err=%v", err))
+		return nil, nil, merr.WrapErrImportFailed(fmt.Sprintf("get parquet schema failed, err=%v", err))
 	}
 
 	err = isSchemaEqual(schema, pqSchema)
 	if err != nil {
-		return nil, merr.WrapErrImportFailed(fmt.Sprintf("schema not equal, err=%v", err))
+		return nil, nil, merr.WrapErrImportFailed(fmt.Sprintf("schema not equal, err=%v", err))
 	}
 
 	crs := make(map[int64]*FieldReader)
+	unknownColumns := make([]string, 0)
 	for i, pqField := range pqSchema.Fields() {
 		field, ok := nameToField[pqField.Name]
 		if !ok {
-			// TODO @cai.zhang: handle dynamic field
-			return nil, merr.WrapErrImportFailed(fmt.Sprintf("the field: %s is not in schema, "+
-				"if it's a dynamic field, please reformat data by bulk_writer", pqField.Name))
+			switch unknownColumnPolicy.Or(common.UnknownColumnError) {
+			case common.UnknownColumnIgnore:
+				unknownColumns = append(unknownColumns, pqField.Name)
+				continue
+			case common.UnknownColumnStoreAsDynamic:
+				// TODO @cai.zhang: handle dynamic field
+				return nil, nil, common.WrapStoreAsDynamicUnsupportedError("parquet", pqField.Name)
+			default:
+				return nil, nil, merr.WrapErrImportFailed(fmt.Sprintf("the field: %s is not in schema, "+
+					"if it's a dynamic field, please reformat data by bulk_writer", pqField.Name))
+			}
 		}
 		if typeutil.IsAutoPKField(field) {
-			return nil, merr.WrapErrImportFailed(
+			return nil, nil, merr.WrapErrImportFailed(
 				fmt.Sprintf("the primary key '%s' is auto-generated, no need to provide", field.GetName()))
 		}
 
 		cr, err := NewFieldReader(ctx, fileReader, i, field)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		cr.sparsePolicy = sparsePolicy
 		if _, ok = crs[field.GetFieldID()]; ok {
-			return nil, merr.WrapErrImportFailed(
+			return nil, nil, merr.WrapErrImportFailed(
 				fmt.Sprintf("there is multi field with name: %s", field.GetName()))
 		}
 		crs[field.GetFieldID()] = cr
@@ -86,11 +101,11 @@ func CreateFieldReaders(ctx context.Context, fileReader *pqarrow.FileReader, sch
 			continue
 		}
 		if _, ok := crs[field.GetFieldID()]; !ok {
-			return nil, merr.WrapErrImportFailed(
+			return nil, nil, merr.WrapErrImportFailed(
 				fmt.Sprintf("no parquet field for milvus file '%s'", field.GetName()))
 		}
 	}
-	return crs, nil
+	return crs, unknownColumns, nil
 }
 
 func isArrowIntegerType(dataType arrow.Type) bool {
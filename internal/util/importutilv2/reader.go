@@ -18,15 +18,25 @@ package importutilv2
 
 import (
 	"context"
+	"fmt"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/proto/internalpb"
 	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/archive"
 	"github.com/milvus-io/milvus/internal/util/importutilv2/binlog"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
 	"github.com/milvus-io/milvus/internal/util/importutilv2/json"
 	"github.com/milvus-io/milvus/internal/util/importutilv2/numpy"
 	"github.com/milvus-io/milvus/internal/util/importutilv2/parquet"
 	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
 )
 
 //go:generate mockery --name=Reader --structname=MockReader --output=./  --filename=mock_reader.go --with-expecter --inpackage
@@ -43,6 +53,50 @@ type Reader interface {
 	Close()
 }
 
+// UnknownColumnReporter is implemented by the readers which are able to tell
+// the source columns not defined in schema.
+type UnknownColumnReporter interface {
+	// UnknownColumns returns the names of the unknown columns found so far.
+	UnknownColumns() []string
+}
+
+// GetUnknownColumns returns the unknown columns found by the reader, or nil if the reader can't tell.
+func GetUnknownColumns(reader Reader) []string {
+	if reporter, ok := reader.(UnknownColumnReporter); ok {
+		return reporter.UnknownColumns()
+	}
+	return nil
+}
+
+// DecodeFailureReporter is implemented by the readers which decode the encoded values of fields.
+type DecodeFailureReporter interface {
+	// DecodeFailures returns the number of values failed to decode by field name.
+	DecodeFailures() map[string]int64
+}
+
+// GetDecodeFailures returns the decode failures found by the reader, or nil if the reader decodes nothing.
+func GetDecodeFailures(reader Reader) map[string]int64 {
+	if reporter, ok := reader.(DecodeFailureReporter); ok {
+		return reporter.DecodeFailures()
+	}
+	return nil
+}
+
+// NormalizationReporter is implemented by the readers which check the float vectors for unit norm.
+type NormalizationReporter interface {
+	// OutOfNormVectors returns the number of vectors out of the norm tolerance found so far.
+	OutOfNormVectors() int64
+}
+
+// GetOutOfNormVectors returns the vectors out of the norm tolerance found by the reader,
+// or 0 if the reader doesn't check the vectors.
+func GetOutOfNormVectors(reader Reader) int64 {
+	if reporter, ok := reader.(NormalizationReporter); ok {
+		return reporter.OutOfNormVectors()
+	}
+	return 0
+}
+
 func NewReader(ctx context.Context,
 	cm storage.ChunkManager,
 	schema *schemapb.CollectionSchema,
@@ -63,13 +117,225 @@ func NewReader(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	// the encodings and metric types are given by the field names of collection, so they are resolved before column mapping.
+	encodings, err := GetFieldEncodings(options, schema)
+	if err != nil {
+		return nil, err
+	}
+	normalization, err := GetVectorNormalization(options)
+	if err != nil {
+		return nil, err
+	}
+	normalizedFields, err := GetNormalizedFields(options, schema, normalization)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := GetColumnMapping(options)
+	if err != nil {
+		return nil, err
+	}
+	schema, err = ApplyColumnMapping(schema, mapping)
+	if err != nil {
+		return nil, err
+	}
+	unknownColumnPolicy, err := GetUnknownColumnPolicy(options, schema)
+	if err != nil {
+		return nil, err
+	}
+	sparsePolicy, err := GetSparseDuplicatePolicy(options)
+	if err != nil {
+		return nil, err
+	}
+	charset, err := GetCharset(options)
+	if err != nil {
+		return nil, err
+	}
+	var reader Reader
+	if fileType == Archive {
+		reader, err = newArchiveReader(ctx, cm, schema, importFile, bufferSize, unknownColumnPolicy, encodings, sparsePolicy, charset)
+	} else {
+		reader, err = newFileReader(ctx, cm, schema, fileType, importFile.GetPaths(), bufferSize, unknownColumnPolicy, encodings, sparsePolicy, charset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the vectors are normalized as read, so both preimport and import see the same vectors.
+	return newNormalizingReader(reader, normalization, normalizedFields), nil
+}
+
+func newFileReader(ctx context.Context,
+	cm storage.ChunkManager,
+	schema *schemapb.CollectionSchema,
+	fileType FileType,
+	paths []string,
+	bufferSize int,
+	unknownColumnPolicy common.UnknownColumnPolicy,
+	encodings map[int64]common.FieldEncoding,
+	sparsePolicy common.SparseDuplicatePolicy,
+	charset common.Charset,
+) (Reader, error) {
+	if len(encodings) > 0 && fileType != JSON {
+		return nil, common.WrapFieldEncodingUnsupportedError(fileType.String())
+	}
+	if charset != common.CharsetUTF8 && fileType != JSON {
+		return nil, common.WrapCharsetUnsupportedError(fileType.String())
+	}
 	switch fileType {
 	case JSON:
-		return json.NewReader(ctx, cm, schema, importFile.GetPaths()[0], bufferSize)
+		return json.NewReader(ctx, cm, schema, paths[0], bufferSize, unknownColumnPolicy, encodings, sparsePolicy, charset)
 	case Numpy:
-		return numpy.NewReader(ctx, cm, schema, importFile.GetPaths(), bufferSize)
+		return numpy.NewReader(ctx, cm, schema, paths, bufferSize, unknownColumnPolicy)
 	case Parquet:
-		return parquet.NewReader(ctx, cm, schema, importFile.GetPaths()[0], bufferSize)
+		return parquet.NewReader(ctx, cm, schema, paths[0], bufferSize, unknownColumnPolicy, sparsePolicy)
 	}
 	return nil, merr.WrapErrImportFailed("unexpected import file")
 }
+
+// archiveReader reads the files in archive as logical import files one by one,
+// the numpy files in archive are read together as one logical import file.
+type archiveReader struct {
+	ctx        context.Context
+	archive    *archive.ChunkManager
+	schema     *schemapb.CollectionSchema
+	fileType   FileType
+	files      [][]string
+	bufferSize int
+
+	unknownColumnPolicy common.UnknownColumnPolicy
+	// unknownColumns are the unknown columns of the files already read.
+	unknownColumns []string
+	encodings      map[int64]common.FieldEncoding
+	// decodeFailures are the decode failures of the files already read.
+	decodeFailures map[string]int64
+	sparsePolicy   common.SparseDuplicatePolicy
+	charset        common.Charset
+
+	current Reader
+	next    int
+}
+
+func newArchiveReader(ctx context.Context,
+	cm storage.ChunkManager,
+	schema *schemapb.CollectionSchema,
+	importFile *internalpb.ImportFile,
+	bufferSize int,
+	unknownColumnPolicy common.UnknownColumnPolicy,
+	encodings map[int64]common.FieldEncoding,
+	sparsePolicy common.SparseDuplicatePolicy,
+	charset common.Charset,
+) (Reader, error) {
+	path := importFile.GetPaths()[0]
+	maxSize := paramtable.Get().DataNodeCfg.MaxImportFileSizeInGB.GetAsFloat() * 1024 * 1024 * 1024
+	acm, err := archive.Open(ctx, cm, path, int64(maxSize))
+	if err != nil {
+		return nil, err
+	}
+	fileType := Invalid
+	for _, entry := range acm.Entries() {
+		entryType, err := GetFileType(&internalpb.ImportFile{Paths: []string{entry}})
+		if err == nil && entryType == Archive {
+			err = merr.WrapErrImportFailed("nested archive is not supported")
+		}
+		if err == nil && fileType != Invalid && entryType != fileType {
+			err = merr.WrapErrImportFailed(fmt.Sprintf("inconsistency in file types, expect %s files", fileType))
+		}
+		// the readers of parquet and numpy seek in file, which the compressed file in archive doesn't support.
+		if err == nil && (entryType == Parquet || entryType == Numpy) && acm.IsCompressed(entry) {
+			err = merr.WrapErrImportFailed(fmt.Sprintf("compressed %s file in archive is not supported, store it without compression", entryType))
+		}
+		if err != nil {
+			acm.Close()
+			return nil, merr.WrapErrImportFailed(fmt.Sprintf("invalid file in archive, archive=%s, file=%s, err=%s", path, entry, err.Error()))
+		}
+		fileType = entryType
+	}
+
+	r := &archiveReader{
+		ctx:        ctx,
+		archive:    acm,
+		schema:     schema,
+		fileType:   fileType,
+		bufferSize: bufferSize,
+
+		unknownColumnPolicy: unknownColumnPolicy,
+		encodings:           encodings,
+		decodeFailures:      make(map[string]int64),
+		sparsePolicy:        sparsePolicy,
+		charset:             charset,
+	}
+	if fileType == Numpy {
+		r.files = [][]string{acm.Entries()}
+	} else {
+		r.files = lo.Map(acm.Entries(), func(entry string, _ int) []string {
+			return []string{entry}
+		})
+	}
+	return r, nil
+}
+
+// Size returns the uncompressed size of files in archive.
+func (r *archiveReader) Size() (int64, error) {
+	var size int64
+	for _, entry := range r.archive.Entries() {
+		entrySize, err := r.archive.Size(r.ctx, entry)
+		if err != nil {
+			return 0, err
+		}
+		size += entrySize
+	}
+	return size, nil
+}
+
+func (r *archiveReader) Read() (*storage.InsertData, error) {
+	for {
+		if r.current == nil {
+			if r.next >= len(r.files) {
+				return nil, io.EOF
+			}
+			reader, err := newFileReader(r.ctx, r.archive, r.schema, r.fileType, r.files[r.next], r.bufferSize, r.unknownColumnPolicy, r.encodings, r.sparsePolicy, r.charset)
+			if err != nil {
+				return nil, err
+			}
+			r.current = reader
+			r.next++
+		}
+		data, err := r.current.Read()
+		if errors.Is(err, io.EOF) {
+			r.unknownColumns = lo.Uniq(append(r.unknownColumns, GetUnknownColumns(r.current)...))
+			for field, failures := range GetDecodeFailures(r.current) {
+				r.decodeFailures[field] += failures
+			}
+			r.current.Close()
+			r.current = nil
+			continue
+		}
+		return data, err
+	}
+}
+
+// UnknownColumns returns the unknown columns of all files in archive read so far.
+func (r *archiveReader) UnknownColumns() []string {
+	if r.current == nil {
+		return r.unknownColumns
+	}
+	return lo.Uniq(append(slices.Clone(r.unknownColumns), GetUnknownColumns(r.current)...))
+}
+
+// DecodeFailures returns the decode failures of all files in archive read so far.
+func (r *archiveReader) DecodeFailures() map[string]int64 {
+	failures := maps.Clone(r.decodeFailures)
+	if r.current != nil {
+		for field, n := range GetDecodeFailures(r.current) {
+			failures[field] += n
+		}
+	}
+	return failures
+}
+
+func (r *archiveReader) Close() {
+	if r.current != nil {
+		r.current.Close()
+		r.current = nil
+	}
+	r.archive.Close()
+}
@@ -0,0 +1,181 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importutilv2
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/storage"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/common"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+func TestArchiveReader(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	dir := t.TempDir()
+	cm := storage.NewLocalChunkManager(storage.RootPath(dir))
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", IsPrimaryKey: true, DataType: schemapb.DataType_Int64},
+		},
+	}
+	writeTar := func(name string, files ...string) *internalpb.ImportFile {
+		buf := &bytes.Buffer{}
+		w := tar.NewWriter(buf)
+		for i := 0; i < len(files); i += 2 {
+			assert.NoError(t, w.WriteHeader(&tar.Header{Name: files[i], Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(files[i+1]))}))
+			_, err := w.Write([]byte(files[i+1]))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, w.Close())
+		filePath := path.Join(dir, name)
+		assert.NoError(t, cm.Write(ctx, filePath, buf.Bytes()))
+		return &internalpb.ImportFile{Paths: []string{filePath}}
+	}
+
+	// the json files in archive are read one by one.
+	file := writeTar("files.tar", "a.json", `[{"pk": 1}]`, "b.json", `[{"pk": 2}, {"pk": 3}]`)
+	fileType, err := GetFileType(file)
+	assert.NoError(t, err)
+	assert.Equal(t, Archive, fileType)
+	reader, err := NewReader(ctx, cm, schema, file, nil, 1024)
+	assert.NoError(t, err)
+	size, err := reader.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(`[{"pk": 1}]`)+len(`[{"pk": 2}, {"pk": 3}]`)), size)
+	rows := make([]int, 0)
+	for {
+		data, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		rows = append(rows, data.GetRowNum())
+	}
+	assert.Equal(t, []int{1, 2}, rows)
+	reader.Close()
+
+	// the unknown columns of all files in archive are reported.
+	file = writeTar("unknown_columns.tar", "a.json", `[{"pk": 1, "x": 1}]`, "b.json", `[{"pk": 2, "y": 2, "x": 2}]`)
+	_, err = NewReader(ctx, cm, schema, file, Options{{Key: OnUnknownColumn, Value: "store_as_dynamic"}}, 1024)
+	assert.Error(t, err)
+	reader, err = NewReader(ctx, cm, schema, file, Options{{Key: OnUnknownColumn, Value: "ignore"}}, 1024)
+	assert.NoError(t, err)
+	for {
+		_, err = reader.Read()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+	}
+	assert.ElementsMatch(t, []string{"x", "y"}, GetUnknownColumns(reader))
+	reader.Close()
+
+	// files of different formats or nested archives are rejected.
+	file = writeTar("mixed.tar", "a.json", `[{"pk": 1}]`, "pk.npy", "")
+	_, err = NewReader(ctx, cm, schema, file, nil, 1024)
+	assert.ErrorContains(t, err, "file=pk.npy")
+	file = writeTar("nested.tar", "a.zip", "")
+	_, err = NewReader(ctx, cm, schema, file, nil, 1024)
+	assert.ErrorContains(t, err, "nested archive is not supported")
+	file = writeTar("unknown.tar", "a.csv", "")
+	_, err = NewReader(ctx, cm, schema, file, nil, 1024)
+	assert.ErrorContains(t, err, "file=a.csv")
+
+	// the compressed json files are read as a stream, the compressed parquet files are rejected.
+	writeZip := func(name string, files ...string) *internalpb.ImportFile {
+		buf := &bytes.Buffer{}
+		w := zip.NewWriter(buf)
+		for i := 0; i < len(files); i += 2 {
+			fw, err := w.CreateHeader(&zip.FileHeader{Name: files[i], Method: zip.Deflate})
+			assert.NoError(t, err)
+			_, err = fw.Write([]byte(files[i+1]))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, w.Close())
+		filePath := path.Join(dir, name)
+		assert.NoError(t, cm.Write(ctx, filePath, buf.Bytes()))
+		return &internalpb.ImportFile{Paths: []string{filePath}}
+	}
+	file = writeZip("files.zip", "a.json", `[{"pk": 1}]`, "b.json", `[{"pk": 2}, {"pk": 3}]`)
+	reader, err = NewReader(ctx, cm, schema, file, nil, 1024)
+	assert.NoError(t, err)
+	rows = rows[:0]
+	for {
+		data, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		rows = append(rows, data.GetRowNum())
+	}
+	assert.Equal(t, []int{1, 2}, rows)
+	reader.Close()
+	file = writeZip("parquet.zip", "a.parquet", "")
+	_, err = NewReader(ctx, cm, schema, file, nil, 1024)
+	assert.ErrorContains(t, err, "compressed Parquet file in archive is not supported")
+}
+
+func TestNormalizeVectors(t *testing.T) {
+	vectors := []float32{1, 0, 0.6, 0.8, 3, 4, 0, 0}
+	assert.Equal(t, int64(2), normalizeVectors(vectors, 2, false))
+	assert.Equal(t, []float32{1, 0, 0.6, 0.8, 3, 4, 0, 0}, vectors)
+
+	assert.Equal(t, int64(2), normalizeVectors(vectors, 2, true))
+	assert.InDeltaSlice(t, []float32{1, 0, 0.6, 0.8, 0.6, 0.8, 0, 0}, vectors, 1e-6)
+	// the zero vector is kept as is, the others are in tolerance now
+	assert.Equal(t, int64(1), normalizeVectors(vectors, 2, false))
+
+	halfVectors := make([]byte, 0, 8)
+	for _, v := range []float32{1, 0, 2, 0} {
+		halfVectors = append(halfVectors, typeutil.Float32ToFloat16Bytes(v)...)
+	}
+	assert.Equal(t, int64(1), normalizeHalfVectors(halfVectors, 2, true,
+		typeutil.Float16BytesToFloat32Vector, typeutil.Float32ToFloat16Bytes))
+	assert.Equal(t, []float32{1, 0, 1, 0}, typeutil.Float16BytesToFloat32Vector(halfVectors))
+}
+
+func TestNormalizingReader(t *testing.T) {
+	mockReader := NewMockReader(t)
+	mockReader.EXPECT().Read().Return(&storage.InsertData{
+		Data: map[int64]storage.FieldData{
+			101: &storage.FloatVectorFieldData{Data: []float32{3, 4, 1, 0}, Dim: 2},
+			102: &storage.FloatVectorFieldData{Data: []float32{3, 4, 1, 0}, Dim: 2},
+		},
+	}, nil)
+	// only the vectors of field using cosine metric are normalized.
+	reader := newNormalizingReader(mockReader, common.VectorNormalizationNormalize, typeutil.NewUniqueSet(101))
+	data, err := reader.Read()
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float32{0.6, 0.8, 1, 0}, data.Data[101].(*storage.FloatVectorFieldData).Data, 1e-6)
+	assert.Equal(t, []float32{3, 4, 1, 0}, data.Data[102].(*storage.FloatVectorFieldData).Data)
+	assert.Equal(t, int64(1), GetOutOfNormVectors(reader))
+
+	assert.Equal(t, mockReader, newNormalizingReader(mockReader, common.VectorNormalizationCheck, typeutil.NewUniqueSet()))
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/milvus-io/milvus/internal/proto/internalpb"
+	"github.com/milvus-io/milvus/internal/util/importutilv2/archive"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 )
 
@@ -33,6 +34,7 @@ const (
 	JSON    FileType = 1
 	Numpy   FileType = 2
 	Parquet FileType = 3
+	Archive FileType = 4
 
 	JSONFileExt    = ".json"
 	NumpyFileExt   = ".npy"
@@ -44,6 +46,7 @@ var FileTypeName = map[int]string{
 	1: "JSON",
 	2: "Numpy",
 	3: "Parquet",
+	4: "Archive",
 }
 
 func (f FileType) String() string {
@@ -80,6 +83,11 @@ func GetFileType(file *internalpb.ImportFile) (FileType, error) {
 			return Invalid, merr.WrapErrImportFailed("for Parquet import, accepts only one file")
 		}
 		return Parquet, nil
+	case archive.TarFileExt, archive.ZipFileExt:
+		if len(file.GetPaths()) != 1 {
+			return Invalid, merr.WrapErrImportFailed("for archive import, accepts only one file")
+		}
+		return Archive, nil
 	}
 	return Invalid, merr.WrapErrImportFailed(fmt.Sprintf("unexpect file type, files=%v", file.GetPaths()))
 }
@@ -102,6 +102,11 @@ type SessionRaw struct {
 
 	HostName   string `json:"HostName,omitempty"`
 	EnableDisk bool   `json:"EnableDisk,omitempty"`
+	// DisableStreaming is set by the querynode which only serves reads of sealed data,
+	// it's negative so that the sessions of former versions are able to take the streaming role.
+	DisableStreaming bool `json:"DisableStreaming,omitempty"`
+	// Labels are the arbitrary labels of querynode, e.g. gpu=true.
+	Labels map[string]string `json:"Labels,omitempty"`
 }
 
 func (s *SessionRaw) GetAddress() string {
@@ -183,6 +188,20 @@ func WithEnableDisk(enableDisk bool) SessionOption {
 	}
 }
 
+// WithDisableStreaming should be only used by querynode.
+func WithDisableStreaming(disableStreaming bool) SessionOption {
+	return func(s *Session) {
+		s.DisableStreaming = disableStreaming
+	}
+}
+
+// WithLabels should be only used by querynode.
+func WithLabels(labels map[string]string) SessionOption {
+	return func(s *Session) {
+		s.Labels = labels
+	}
+}
+
 func (s *Session) apply(opts ...SessionOption) {
 	for _, opt := range opts {
 		opt(s)
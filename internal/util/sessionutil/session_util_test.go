@@ -768,10 +768,12 @@ func TestServerInfoOp(t *testing.T) {
 
 func TestSession_apply(t *testing.T) {
 	session := &Session{}
-	opts := []SessionOption{WithTTL(100), WithRetryTimes(200)}
+	opts := []SessionOption{WithTTL(100), WithRetryTimes(200), WithDisableStreaming(true), WithLabels(map[string]string{"gpu": "true"})}
 	session.apply(opts...)
 	assert.Equal(t, int64(100), session.sessionTTL)
 	assert.Equal(t, int64(200), session.sessionRetryTimes)
+	assert.True(t, session.DisableStreaming)
+	assert.Equal(t, map[string]string{"gpu": "true"}, session.Labels)
 }
 
 func TestIntegrationMode(t *testing.T) {
@@ -147,6 +147,11 @@ const (
 	// database level properties
 	DatabaseReplicaNumber  = "database.replica.number"
 	DatabaseResourceGroups = "database.resource_groups"
+
+	// collection level properties of replicas, which take effect once the collection is loaded
+	CollectionResourceGroupAffinity = "collection.resource_groups.affinity"
+	CollectionReplicaNodeSelector   = "collection.replica.node_selector"
+	CollectionStandbyReplicaNumber  = "collection.replica.standby_number"
 )
 
 // common properties
@@ -253,3 +258,57 @@ func DatabaseLevelResourceGroups(kvs []*commonpb.KeyValuePair) ([]string, error)
 
 	return nil, fmt.Errorf("database property not found: %s", DatabaseResourceGroups)
 }
+
+// CollectionLevelResourceGroupAffinity returns the resource groups that the replicas of collection are bound to,
+// returns nil if the property is not set.
+func CollectionLevelResourceGroupAffinity(kvs []*commonpb.KeyValuePair) ([]string, error) {
+	for _, kv := range kvs {
+		if kv.Key == CollectionResourceGroupAffinity {
+			rgs := strings.Split(kv.Value, ",")
+			for i := range rgs {
+				rgs[i] = strings.TrimSpace(rgs[i])
+				if len(rgs[i]) == 0 {
+					return nil, fmt.Errorf("invalid collection property: [key=%s] [value=%s]", kv.Key, kv.Value)
+				}
+			}
+			return rgs, nil
+		}
+	}
+	return nil, nil
+}
+
+// CollectionLevelStandbyReplicaNumber returns the number of hot standby replicas of collection,
+// returns 0 if the property is not set.
+func CollectionLevelStandbyReplicaNumber(kvs []*commonpb.KeyValuePair) (int64, error) {
+	for _, kv := range kvs {
+		if kv.Key == CollectionStandbyReplicaNumber {
+			standbyNum, err := strconv.ParseInt(kv.Value, 10, 64)
+			if err != nil || standbyNum < 0 {
+				return 0, fmt.Errorf("invalid collection property: [key=%s] [value=%s]", kv.Key, kv.Value)
+			}
+			return standbyNum, nil
+		}
+	}
+	return 0, nil
+}
+
+// CollectionLevelNodeSelector returns the labels required on the nodes of replicas of collection,
+// which are comma separated `key=value` pairs, e.g. `gpu=true,ssd=true`. Returns nil if the property is not set.
+func CollectionLevelNodeSelector(kvs []*commonpb.KeyValuePair) (map[string]string, error) {
+	for _, kv := range kvs {
+		if kv.Key == CollectionReplicaNodeSelector {
+			selector := make(map[string]string)
+			for _, pair := range strings.Split(kv.Value, ",") {
+				key, label, ok := strings.Cut(pair, "=")
+				// the labels of nodes are configured by param group, whose keys are case insensitive.
+				key = strings.ToLower(strings.TrimSpace(key))
+				if !ok || key == "" {
+					return nil, fmt.Errorf("invalid collection property: [key=%s] [value=%s]", kv.Key, kv.Value)
+				}
+				selector[key] = strings.TrimSpace(label)
+			}
+			return selector, nil
+		}
+	}
+	return nil, nil
+}
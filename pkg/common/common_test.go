@@ -88,3 +88,84 @@ func TestDatabaseProperties(t *testing.T) {
 	_, err = DatabaseLevelResourceGroups(props)
 	assert.Error(t, err)
 }
+
+func TestCollectionResourceGroupAffinity(t *testing.T) {
+	rgs, err := CollectionLevelResourceGroupAffinity([]*commonpb.KeyValuePair{
+		{
+			Key:   CollectionResourceGroupAffinity,
+			Value: "rg1, rg2",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rg1", "rg2"}, rgs)
+
+	// test prop not found
+	rgs, err = CollectionLevelResourceGroupAffinity(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, rgs)
+
+	// test invalid prop value
+	for _, value := range []string{"", "rg1,,rg2"} {
+		_, err = CollectionLevelResourceGroupAffinity([]*commonpb.KeyValuePair{
+			{
+				Key:   CollectionResourceGroupAffinity,
+				Value: value,
+			},
+		})
+		assert.Error(t, err)
+	}
+}
+
+func TestCollectionStandbyReplicaNumber(t *testing.T) {
+	standbyNum, err := CollectionLevelStandbyReplicaNumber([]*commonpb.KeyValuePair{
+		{
+			Key:   CollectionStandbyReplicaNumber,
+			Value: "1",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), standbyNum)
+
+	// test prop not found
+	standbyNum, err = CollectionLevelStandbyReplicaNumber(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), standbyNum)
+
+	// test invalid prop value
+	for _, value := range []string{"", "xxxx", "-1"} {
+		_, err = CollectionLevelStandbyReplicaNumber([]*commonpb.KeyValuePair{
+			{
+				Key:   CollectionStandbyReplicaNumber,
+				Value: value,
+			},
+		})
+		assert.Error(t, err)
+	}
+}
+
+func TestCollectionNodeSelector(t *testing.T) {
+	selector, err := CollectionLevelNodeSelector([]*commonpb.KeyValuePair{
+		{
+			Key:   CollectionReplicaNodeSelector,
+			Value: "GPU=true, zone=a",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"gpu": "true", "zone": "a"}, selector)
+
+	// test prop not found
+	selector, err = CollectionLevelNodeSelector(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, selector)
+
+	// test invalid prop value
+	for _, value := range []string{"", "gpu", "=true"} {
+		_, err = CollectionLevelNodeSelector([]*commonpb.KeyValuePair{
+			{
+				Key:   CollectionReplicaNodeSelector,
+				Value: value,
+			},
+		})
+		assert.Error(t, err)
+	}
+}
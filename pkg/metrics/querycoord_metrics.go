@@ -124,6 +124,54 @@ var (
 			channelNameLabelName,
 		})
 
+	QueryCoordResourceGroupSpareNodeNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_spare_node_num",
+			Help:      "number of standby nodes which are not assigned to any replica in resource group",
+		}, []string{resourceGroupLabelName})
+
+	QueryCoordResourceGroupReplicaNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_replica_num",
+			Help:      "number of replicas of all collections hosted by resource group",
+		}, []string{resourceGroupLabelName})
+
+	QueryCoordResourceGroupPlacementFairness = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_placement_fairness",
+			Help:      "coefficient of variation of the replica count per node in resource group, 0 means perfectly even",
+		}, []string{resourceGroupLabelName})
+
+	QueryCoordReplicaMoveNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "replica_move_num",
+			Help:      "number of replicas moving nodes during recovery, in_flight ones are draining their ro nodes, deferred ones are waiting for the next wave",
+		}, []string{statusLabelName})
+
+	QueryCoordReplicaAvailabilitySeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "replica_availability_seconds",
+			Help:      "cumulative seconds of collection since tracked, healthy ones are the time all replicas have their full node complement",
+		}, []string{collectionIDLabelName, statusLabelName})
+
+	QueryCoordSharedNodeReplicaSlots = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "shared_node_replica_slots",
+			Help:      "replica slots of shared node allocated to resource group, used ones are taken by the replicas of resource group",
+		}, []string{nodeIDLabelName, resourceGroupLabelName, statusLabelName})
+
 	QueryCoordTaskLatency = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: milvusNamespace,
@@ -146,10 +194,19 @@ func RegisterQueryCoord(registry *prometheus.Registry) {
 	registry.MustRegister(QueryCoordNumQueryNodes)
 	registry.MustRegister(QueryCoordCurrentTargetCheckpointUnixSeconds)
 	registry.MustRegister(QueryCoordTaskLatency)
+	registry.MustRegister(QueryCoordResourceGroupSpareNodeNum)
+	registry.MustRegister(QueryCoordResourceGroupReplicaNum)
+	registry.MustRegister(QueryCoordResourceGroupPlacementFairness)
+	registry.MustRegister(QueryCoordReplicaMoveNum)
+	registry.MustRegister(QueryCoordReplicaAvailabilitySeconds)
+	registry.MustRegister(QueryCoordSharedNodeReplicaSlots)
 }
 
 func CleanQueryCoordMetricsWithCollectionID(collectionID int64) {
 	QueryCoordTaskLatency.DeletePartialMatch(prometheus.Labels{
 		collectionIDLabelName: fmt.Sprint(collectionID),
 	})
+	QueryCoordReplicaAvailabilitySeconds.DeletePartialMatch(prometheus.Labels{
+		collectionIDLabelName: fmt.Sprint(collectionID),
+	})
 }
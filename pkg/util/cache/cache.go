@@ -19,9 +19,12 @@ package cache
 import (
 	"container/list"
 	"context"
+	"runtime/debug"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
@@ -35,6 +38,15 @@ import (
 var (
 	ErrNoSuchItem     = merr.WrapErrServiceInternal("no such item")
 	ErrNotEnoughSpace = merr.WrapErrServiceInternal("not enough space")
+	// errTooManyDoers is returned by getAndPin if the item is pinned by the max concurrent doers,
+	// `Do` waits for an unpin instead of returning it.
+	errTooManyDoers = merr.WrapErrServiceInternal("too many concurrent doers")
+	// errStalePinned is returned by getAndPin if the item is too stale to serve but can't be evicted for reload
+	// since it's pinned, `Do` waits for an unpin instead of returning it.
+	errStalePinned = merr.WrapErrServiceInternal("stale item pinned")
+	// ErrNoLoader is returned on miss of cache built without loader, whose items can't be loaded on demand.
+	// It's a sentinel of its own rather than a service internal error, so that `errors.Is` tells it from `ErrNoSuchItem`.
+	ErrNoLoader = errors.New("cache has no loader")
 )
 
 type cacheItem[K comparable, V any] struct {
@@ -42,6 +54,35 @@ type cacheItem[K comparable, V any] struct {
 	value      V
 	pinCount   atomic.Int32
 	needReload bool
+	// accessed is set by the hits under read lock, which are not moved to front yet.
+	accessed atomic.Bool
+	// passThrough is set if the item is loaded under memory pressure and not admitted into cache.
+	passThrough bool
+	// dirty is set by `Dirty` if the value is modified and not flushed yet.
+	dirty atomic.Bool
+	// protectedUntil is set if the key is reloaded soon after eviction, the item is evicted after
+	// the unprotected ones until then.
+	protectedUntil time.Time
+
+	// lastAccess is the access sequence when the item is moved to front last time, the smaller one is less
+	// recently used, which orders the entries sampled for eviction.
+	lastAccess uint64
+
+	// version is the version of source when the value started loading, only set with `WithVersionSource`.
+	version uint64
+}
+
+// tryPin pins the item if it's pinned less than limit times, no limit if limit is not positive.
+func (item *cacheItem[K, V]) tryPin(limit int32) bool {
+	for {
+		pinCount := item.pinCount.Load()
+		if limit > 0 && pinCount >= limit {
+			return false
+		}
+		if item.pinCount.CompareAndSwap(pinCount, pinCount+1) {
+			return true
+		}
+	}
 }
 
 type (
@@ -52,7 +93,8 @@ type (
 // Scavenger records occupation of cache and decide whether to evict if necessary.
 //
 //	The scavenger makes decision based on keys only, and it is called before value loading,
-//	because value loading could be very expensive.
+//	because value loading could be very expensive. The weight of key is a provisional estimate then,
+//	which is corrected by the weight of value once loaded if the scavenger supports reweighing.
 type Scavenger[K comparable] interface {
 	// Collect records entry additions, if there is room, return true, or else return false and a collector.
 	//	The collector is a function which can be invoked repetedly, each invocation will test if there is enough
@@ -99,8 +141,18 @@ func (s *LazyScavenger[K]) Collect(key K) (bool, func(K) bool) {
 }
 
 func (s *LazyScavenger[K]) Replace(key K) (bool, func(K) bool, func()) {
+	return s.replace(key, s.weight(key))
+}
+
+// Reweigh replaces the recorded weight of key with the actual weight, e.g. measured after the value is loaded.
+// If there is no room for the new weight, return false and a collector like `Collect`.
+func (s *LazyScavenger[K]) Reweigh(key K, weight int64) (bool, func(K) bool) {
+	ok, collector, _ := s.replace(key, weight)
+	return ok, collector
+}
+
+func (s *LazyScavenger[K]) replace(key K, w int64) (bool, func(K) bool, func()) {
 	pw := s.weights[key]
-	w := s.weight(key)
 	if s.size-pw+w > s.capacity {
 		needCollect := s.size - pw + w - s.capacity
 		return false, func(key K) bool {
@@ -123,8 +175,28 @@ func (s *LazyScavenger[K]) Throw(key K) {
 	}
 }
 
+// Weight returns the recorded weight of key, 0 if the key is not recorded.
+func (s *LazyScavenger[K]) Weight(key K) int64 {
+	return s.weights[key]
+}
+
+// Size returns the total weight of the recorded entries.
+func (s *LazyScavenger[K]) Size() int64 {
+	return s.size
+}
+
+// Capacity returns the maximum total weight of entries.
+func (s *LazyScavenger[K]) Capacity() int64 {
+	return s.capacity
+}
+
+// Spare frees the recorded weight of the thrown key, which may be reweighed by value, while the pending keys
+// are not loaded yet, so their weights are estimated by key.
 func (s *LazyScavenger[K]) Spare(key K) func(K) bool {
-	w := s.weight(key)
+	w, ok := s.weights[key]
+	if !ok {
+		w = s.weight(key)
+	}
 	available := s.capacity - s.size + w
 	return func(k K) bool {
 		available -= s.weight(k)
@@ -140,23 +212,175 @@ type Stats struct {
 	TotalLoadTimeMs     atomic.Uint64
 	TotalFinalizeTimeMs atomic.Uint64
 	EvictionCount       atomic.Uint64
+	// LoadDedups counts the misses which joined an in-flight load of the same key
+	// instead of invoking the loader by themselves.
+	LoadDedups atomic.Uint64
+	// PassThroughCount counts the loaded values not admitted into cache due to memory pressure.
+	PassThroughCount atomic.Uint64
+	// PinHoldDuration is the histogram of how long the items are pinned by `Do`,
+	// only recorded if the cache is built with `WithPinHoldTracking`.
+	PinHoldDuration DurationHistogram
+	// FlushCount and FlushFailCount count the flushes of dirty entries by write-back.
+	FlushCount     atomic.Uint64
+	FlushFailCount atomic.Uint64
+	// EvictedWeight is the total weight of evicted entries measured by scavenger, e.g. the bytes
+	// if the weight is the size of value, only recorded if the scavenger reports the weight of entries.
+	EvictedWeight atomic.Uint64
+	// GCHintCount counts the memory returned to OS by `WithGCHint`.
+	GCHintCount atomic.Uint64
+	// ProvideCount counts the values inserted by `Provide` instead of loaded.
+	ProvideCount atomic.Uint64
+	// ReadmissionCount counts the keys admitted again within the hysteresis window after being evicted
+	// for room, which tells how much the cache is thrashing, only recorded with `WithEvictionHysteresis`.
+	ReadmissionCount atomic.Uint64
+	// NegativeHitCount counts the lookups of keys failed by their tombstones without invoking the loader,
+	// only recorded with `WithNegativeCache`.
+	NegativeHitCount atomic.Uint64
+	// StaleCount counts the hits on entries too many versions stale, which are reloaded instead,
+	// only recorded with `WithVersionSource`.
+	StaleCount atomic.Uint64
 }
 
 type Cache[K comparable, V any] interface {
 	// Do the operation `doer` on the given key `key`. The key is kept in the cache until the operation
 	// completes.
-	// Throws `ErrNoSuchItem` if the key is not found or not able to be loaded from given loader.
+	// Throws `ErrNoSuchItem` if the key is not found or not able to be loaded from given loader,
+	// or `ErrNoLoader` if the key is not found and the cache has no loader.
 	Do(ctx context.Context, key K, doer func(context.Context, V) error) (missing bool, err error)
 
+	// DoWithOutcome is the same as `Do`, but tells how the value is obtained, so that the caller is able to
+	// tell whether it caused the load, or joined the in-flight load of a concurrent caller.
+	DoWithOutcome(ctx context.Context, key K, doer func(context.Context, V) error) (LoadOutcome, error)
+
+	// TryDo runs `doer` on the value of key only if it's resident, and returns immediately with hit=false otherwise,
+	// without invoking the loader or waiting, e.g. for the latency-critical paths which have their own fallback.
+	// The value which needs reload, is too stale or pinned by the max concurrent doers is regarded as not resident.
+	TryDo(key K, doer func(V) error) (hit bool, err error)
+
 	// Get stats
 	Stats() *Stats
 
 	MarkItemNeedReload(ctx context.Context, key K) bool
 
+	// Dirty marks the value of key as modified, so that it's flushed by the write-back of cache.
+	// Returns false if the cache is built without write-back, or the key is not in cache, e.g. the value
+	// is passed through under memory pressure, then the caller should write the value through by itself.
+	Dirty(key K) bool
+
+	// Provide inserts the value of key produced out of band, e.g. pushed by a notification, without invoking the loader,
+	// and wakes up the `Do` waiting for space, which then operate on the provided value instead of loading it again.
+	// The value is subject to the scavenger, it's finalized and `ErrNotEnoughSpace` is returned if there is no room.
+	// Returns false if the key is already in cache, then the value is not taken and left to the caller.
+	Provide(ctx context.Context, key K, value V) (bool, error)
+
 	// Remove removes the item from the cache.
 	// Return nil if the item is removed.
 	// Return error if the Remove operation is canceled.
 	Remove(ctx context.Context, key K) error
+
+	// Close stops the background routines of the cache, and waits for the pending async finalizers.
+	Close()
+
+	// WaitFinalizers blocks until all the pending async finalizers complete, or ctx is done.
+	// Returns nil immediately if the finalizers are synchronous.
+	WaitFinalizers(ctx context.Context) error
+
+	// NextVictims returns up to n keys which would be evicted next, in eviction order.
+	// It's for diagnostics only and evicts nothing.
+	NextVictims(n int) []K
+
+	// TopByWeight returns up to n resident keys with the most weight, in descending order of weight.
+	// It's for diagnostics only, and returns nothing if the scavenger doesn't report the weight of entries.
+	TopByWeight(n int) []KeyWeight[K]
+
+	// Materialize returns a snapshot of all the resident entries, e.g. to hand them off to another cache on reconfiguration.
+	// The values are shared references rather than deep copies, the caller must treat them as read-only.
+	Materialize() map[K]V
+}
+
+// LoadOutcome tells how the value operated by `DoWithOutcome` is obtained.
+type LoadOutcome int
+
+const (
+	// LoadOutcomeHit means the value is resident in cache.
+	LoadOutcomeHit LoadOutcome = iota
+	// LoadOutcomeLoaded means the value is loaded by the caller itself.
+	LoadOutcomeLoaded
+	// LoadOutcomeJoined means the value is loaded by a concurrent caller, which the caller waited for.
+	LoadOutcomeJoined
+	// LoadOutcomeMissed means the value is neither resident nor loaded, the error tells why.
+	LoadOutcomeMissed
+)
+
+// flushRetryInterval is how long the flusher waits before flushing the queued items again after a failure.
+const flushRetryInterval = 100 * time.Millisecond
+
+func (o LoadOutcome) String() string {
+	switch o {
+	case LoadOutcomeHit:
+		return "hit"
+	case LoadOutcomeLoaded:
+		return "loaded"
+	case LoadOutcomeJoined:
+		return "joined"
+	case LoadOutcomeMissed:
+		return "missed"
+	}
+	return "unknown"
+}
+
+// missing returns whether the caller missed the cache as reported by `Do`,
+// the value loaded by a concurrent caller is not counted as missing.
+func (o LoadOutcome) missing() bool {
+	return o == LoadOutcomeLoaded || o == LoadOutcomeMissed
+}
+
+// DoPhases is the time a `Do` spends in each phase, which tells a slow backing store from the contention of cache
+// and the slow doer.
+type DoPhases struct {
+	// WaitDuration is the time waiting for space, a doer slot, the in-flight load of the same key or a load slot.
+	WaitDuration time.Duration
+	// LoadDuration is the time invoking the loader and admitting the loaded value, 0 if the loader is not invoked.
+	LoadDuration time.Duration
+	// DoerDuration is the time running the doer.
+	DoerDuration time.Duration
+}
+
+// KeyWeight is the weight of a resident key measured by scavenger.
+type KeyWeight[K any] struct {
+	Key    K
+	Weight int64
+}
+
+// sizer is implemented by scavengers which are able to report the occupation of cache.
+type sizer interface {
+	Size() int64
+}
+
+// weigher is implemented by scavengers which are able to report the weight of recorded entries.
+type weigher[K comparable] interface {
+	Weight(key K) int64
+}
+
+// reweigher is implemented by scavengers which are able to update the weight of recorded entries.
+type reweigher[K comparable] interface {
+	Reweigh(key K, weight int64) (bool, func(K) bool)
+}
+
+// capacitor is implemented by scavengers which are able to report the capacity of cache.
+type capacitor interface {
+	Capacity() int64
+}
+
+// CapacityExceededHandler is notified when an item can't be loaded into cache for lack of space,
+// with the number of pinned items, the size and capacity of cache measured by the weight of scavenger.
+type CapacityExceededHandler[K comparable] func(key K, pinnedCount int, size, capacity int64)
+
+// MemoryPressureSource reports whether the process is under memory pressure.
+//
+//	It's polled on the hot path of cache, so it should be cheap, e.g. returning a value refreshed periodically.
+type MemoryPressureSource interface {
+	UnderPressure() bool
 }
 
 // lruCache extends the ccache library to provide pinning and unpinning of items.
@@ -173,6 +397,74 @@ type lruCache[K comparable, V any] struct {
 	finalizer Finalizer[K, V]
 	scavenger Scavenger[K]
 	reloader  Loader[K, V]
+	fairness  *groupFairness[K]
+	// loadSlots bounds the number of concurrent loader invocations, nil means unlimited.
+	loadSlots chan struct{}
+	// deferPromotion makes hits pin items under read lock, and defers moving them to front.
+	deferPromotion bool
+	// capacityExceededHandler is notified when getAndPin fails with ErrNotEnoughSpace.
+	capacityExceededHandler CapacityExceededHandler[K]
+	// pendingPromotions is the number of items accessed but not moved to front.
+	pendingPromotions atomic.Int64
+	// trackPinHold makes `Do` record how long the items are pinned.
+	trackPinHold bool
+	// maxDoers bounds the number of concurrent `Do` on each item, 0 means unlimited.
+	maxDoers int32
+	// valueWeight measures the actual weight of loaded value, which replaces the weight estimated by key.
+	valueWeight func(K, V) int64
+	reweigher   reweigher[K]
+
+	// softCapacity is the size which the background reclaimer evicts toward, 0 means no soft capacity.
+	softCapacity int64
+	sizer        sizer
+	// pressure makes misses pass through without admission and the reclaimer evict under memory pressure.
+	pressure MemoryPressureSource
+	// gcHintCh wakes up the gc hinter once the weight evicted since the last hint crosses gcHintThreshold,
+	// nil means no hint.
+	gcHintCh         chan struct{}
+	gcHintThreshold  int64
+	evictedSinceHint atomic.Int64
+	freeOSMemory     func()
+
+	// hysteresis is how long a key readmitted after eviction is protected from the next eviction,
+	// 0 means no hysteresis. evictedAt remembers when the keys are evicted for room.
+	hysteresis time.Duration
+	evictedAt  map[K]time.Time
+
+	// clock is the source of time of all the time-based logic.
+	clock Clock
+
+	// asyncFinalizer runs the finalizer asynchronously if it's set, then finalizer is its `Finalize`.
+	asyncFinalizer *asyncFinalizer[K, V]
+
+	// evictionSamples is the number of entries sampled to pick each victim, 0 means the victims are picked
+	// by walking the access list. accessSeq stamps the items moved to front.
+	evictionSamples int
+	accessSeq       uint64
+
+	// negative remembers the keys not found by loader, nil means the misses always invoke the loader.
+	negative *negativeCache[K]
+
+	// doObserver receives the phases of each `Do`, nil means the phases are not timed.
+	doObserver func(key K, phases DoPhases)
+
+	// versionSource is the current version which the items are checked against, the items loaded more than
+	// maxStaleness versions ago are reloaded. nil means the items never go stale by version.
+	versionSource func() uint64
+	maxStaleness  uint64
+
+	// validate checks the values returned by loader and reloader before admitting them, nil means no check.
+	validate func(V) error
+
+	// flush writes back the dirty values, nil means the values are never dirty.
+	flush func(K, V) error
+	// flushQueue holds the dirty keys blocking eviction, which are flushed by the flusher without lock held.
+	flushQueue map[K]struct{}
+	flushCh    chan struct{}
+	reclaimCh  chan struct{}
+	closeCh    chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
 }
 
 type CacheBuilder[K comparable, V any] struct {
@@ -180,18 +472,63 @@ type CacheBuilder[K comparable, V any] struct {
 	finalizer Finalizer[K, V]
 	scavenger Scavenger[K]
 	reloader  Loader[K, V]
+
+	weight     func(K) int64
+	groupOf    func(K) string
+	guarantees map[string]int64
+
+	maxConcurrentLoads int
+	maxConcurrentDoers int
+	softCapacity       int64
+	deferPromotion     bool
+	trackPinHold       bool
+	pressure           MemoryPressureSource
+
+	capacityExceededHandler CapacityExceededHandler[K]
+
+	valueWeight func(K, V) int64
+
+	revalidateInterval time.Duration
+	valid              func(K, V) bool
+
+	valueStore ValueStore
+	marshal    func(V) ([]byte, error)
+	unmarshal  func([]byte) (V, error)
+
+	flush         func(K, V) error
+	flushInterval time.Duration
+
+	gcHintThreshold   int64
+	gcHintMinInterval time.Duration
+
+	hysteresis time.Duration
+
+	clock Clock
+
+	evictionSamples int
+
+	asyncFinalizers int
+
+	negativeCapacity int
+	negativeTTL      time.Duration
+
+	doObserver func(key K, phases DoPhases)
+
+	versionSource func() uint64
+	maxStaleness  uint64
+
+	validate func(V) error
 }
 
 func NewCacheBuilder[K comparable, V any]() *CacheBuilder[K, V] {
+	weight := func(key K) int64 {
+		return 1
+	}
 	return &CacheBuilder[K, V]{
 		loader:    nil,
 		finalizer: nil,
-		scavenger: NewLazyScavenger(
-			func(key K) int64 {
-				return 1
-			},
-			64,
-		),
+		scavenger: NewLazyScavenger(weight, 64),
+		weight:    weight,
 	}
 }
 
@@ -207,16 +544,15 @@ func (b *CacheBuilder[K, V]) WithFinalizer(finalizer Finalizer[K, V]) *CacheBuil
 
 func (b *CacheBuilder[K, V]) WithLazyScavenger(weight func(K) int64, capacity int64) *CacheBuilder[K, V] {
 	b.scavenger = NewLazyScavenger(weight, capacity)
+	b.weight = weight
 	return b
 }
 
 func (b *CacheBuilder[K, V]) WithCapacity(capacity int64) *CacheBuilder[K, V] {
-	b.scavenger = NewLazyScavenger(
-		func(key K) int64 {
-			return 1
-		},
-		capacity,
-	)
+	b.weight = func(key K) int64 {
+		return 1
+	}
+	b.scavenger = NewLazyScavenger(b.weight, capacity)
 	return b
 }
 
@@ -225,8 +561,280 @@ func (b *CacheBuilder[K, V]) WithReloader(reloader Loader[K, V]) *CacheBuilder[K
 	return b
 }
 
+// WithGroupGuarantees groups keys by `groupOf`, and guarantees the minimum capacity of each group,
+// entries of a group will not be evicted below its guarantee to make room for other groups.
+// Unused guaranteed capacity can be borrowed by other groups. The capacity is measured by the weight
+// of scavenger.
+func (b *CacheBuilder[K, V]) WithGroupGuarantees(groupOf func(K) string, guarantees map[string]int64) *CacheBuilder[K, V] {
+	b.groupOf = groupOf
+	b.guarantees = guarantees
+	return b
+}
+
+// WithSoftCapacity sets a soft limit below the hard capacity. Once the size crosses the soft limit,
+// a background reclaimer proactively evicts unpinned entries in LRU order toward the soft limit,
+// so that inserts only evict synchronously when the hard limit is hit.
+// The capacity is measured by the weight of scavenger, and the reclaimer is stopped by `Close`.
+func (b *CacheBuilder[K, V]) WithSoftCapacity(soft, hard int64) *CacheBuilder[K, V] {
+	b.scavenger = NewLazyScavenger(b.weight, hard)
+	b.softCapacity = soft
+	return b
+}
+
+// WithMaxConcurrentLoads bounds the number of concurrent loader invocations to protect the backing
+// storage from miss storms, the excess loads are queued until a slot is released or their context is done.
+// No limit if n is not positive.
+func (b *CacheBuilder[K, V]) WithMaxConcurrentLoads(n int) *CacheBuilder[K, V] {
+	b.maxConcurrentLoads = n
+	return b
+}
+
+// WithMaxConcurrentDoers bounds the number of concurrent `Do` pinning the same key, e.g. if the doer contends
+// on a resource keyed by the cache key. The excess `Do` are queued until a doer is done or their context is done.
+// No limit if n is not positive.
+func (b *CacheBuilder[K, V]) WithMaxConcurrentDoers(n int) *CacheBuilder[K, V] {
+	b.maxConcurrentDoers = n
+	return b
+}
+
+// WithDeferredPromotion lets hits pin items under the read lock, so that they are not serialized by
+// reordering the LRU list. The hit items are only marked as accessed, and moved to front in batch
+// before the next eviction, trading the exact LRU order among hits for concurrency.
+func (b *CacheBuilder[K, V]) WithDeferredPromotion() *CacheBuilder[K, V] {
+	b.deferPromotion = true
+	return b
+}
+
+// WithMemoryPressure makes the cache stop admitting new entries under memory pressure reported by `source`.
+// Under pressure, a miss runs the operation on the freshly loaded value and finalizes it afterward
+// without inserting it, and the background reclaimer evicts unpinned entries until the pressure is relieved.
+func (b *CacheBuilder[K, V]) WithMemoryPressure(source MemoryPressureSource) *CacheBuilder[K, V] {
+	b.pressure = source
+	return b
+}
+
+// WithCapacityExceededHandler sets the handler notified whenever an item can't be loaded for lack of space,
+// which tells why eviction couldn't make room, e.g. too many pinned items.
+func (b *CacheBuilder[K, V]) WithCapacityExceededHandler(handler CapacityExceededHandler[K]) *CacheBuilder[K, V] {
+	b.capacityExceededHandler = handler
+	return b
+}
+
+// WithPinHoldTracking records how long each item is pinned by `Do` into `Stats().PinHoldDuration`,
+// long-held pins prevent eviction and may cause `ErrNotEnoughSpace`.
+func (b *CacheBuilder[K, V]) WithPinHoldTracking() *CacheBuilder[K, V] {
+	b.trackPinHold = true
+	return b
+}
+
+// WithValueWeight measures the weight of entries by their values once loaded, replacing the weight
+// estimated by key before loading, and evicts more entries if the actual weight doesn't fit.
+// It only works with the scavengers which support reweighing, e.g. `LazyScavenger`,
+// and the group guarantees are still measured by the weight of key.
+func (b *CacheBuilder[K, V]) WithValueWeight(weight func(key K, value V) int64) *CacheBuilder[K, V] {
+	b.valueWeight = weight
+	return b
+}
+
+// WithRevalidator starts a sweeper which checks the unpinned entries every interval, and evicts those failing `valid`,
+// e.g. the values outdated by an external version. `valid` is called without lock, so `Do` is not blocked while sweeping.
+// The sweeper is stopped by `Close`.
+func (b *CacheBuilder[K, V]) WithRevalidator(interval time.Duration, valid func(key K, value V) bool) *CacheBuilder[K, V] {
+	b.revalidateInterval = interval
+	b.valid = valid
+	return b
+}
+
+// WithValueStore keeps the values in `store` as bytes encoded by `marshal` instead of holding them in cache,
+// e.g. to store them off-heap and cut GC pressure. The value is decoded by `unmarshal` for each `Do`
+// and finalizer, and freed from store once finalized.
+func (b *CacheBuilder[K, V]) WithValueStore(store ValueStore, marshal func(V) ([]byte, error), unmarshal func([]byte) (V, error)) *CacheBuilder[K, V] {
+	b.valueStore = store
+	b.marshal = marshal
+	b.unmarshal = unmarshal
+	return b
+}
+
+// WithWriteBack buffers the writes of cache fronting a mutable store. The entries marked by `Dirty` are flushed
+// every interval in background, and a dirty entry is always flushed before it's evicted or reloaded. The entry
+// failing to flush stays dirty and is not evicted, so that no write is lost. The remaining dirty entries are
+// flushed by `Close`. The entry is pinned while flushing, so `flush` must synchronize with the doers which
+// modify the value in place. `flush` runs without the cache lock held, the dirty entries blocking eviction are
+// flushed by the flusher in background, and evicted once flushed. With `WithValueStore`, the doers modify decoded
+// copies, so the value is encoded back into store once the doer succeeds, and the modifications of concurrent
+// doers on the same key are not merged, the last one wins.
+func (b *CacheBuilder[K, V]) WithWriteBack(flush func(key K, value V) error, interval time.Duration) *CacheBuilder[K, V] {
+	b.flush = flush
+	b.flushInterval = interval
+	return b
+}
+
+// WithGCHint returns the freed memory to OS by `debug.FreeOSMemory` once the weight evicted since the last hint
+// crosses threshold, e.g. after a burst evicting heavy values, which keeps the RSS high otherwise. The hints are
+// at least minInterval apart, the evictions in between are merged into the next hint, so a steady eviction stream
+// doesn't keep forcing GC. The weight is measured by scavenger, so it only works with the scavengers which report
+// the weight of entries, e.g. `LazyScavenger`. The hinter is stopped by `Close`.
+func (b *CacheBuilder[K, V]) WithGCHint(threshold int64, minInterval time.Duration) *CacheBuilder[K, V] {
+	b.gcHintThreshold = threshold
+	b.gcHintMinInterval = minInterval
+	return b
+}
+
+// WithEvictionHysteresis breaks the evict-reload cycle of the keys sitting at the capacity boundary. A key admitted
+// again within window after it's evicted for room is protected for window, during which the unprotected entries are
+// evicted first, and the protected ones are only evicted if there is no other way to make room. The readmissions
+// are counted by `Stats().ReadmissionCount`.
+func (b *CacheBuilder[K, V]) WithEvictionHysteresis(window time.Duration) *CacheBuilder[K, V] {
+	b.hysteresis = window
+	return b
+}
+
+// WithClock replaces the wall clock used by all the time-based logic of cache, e.g. the hysteresis window and
+// the intervals of background routines, so that tests can advance the time manually.
+func (b *CacheBuilder[K, V]) WithClock(clock Clock) *CacheBuilder[K, V] {
+	b.clock = clock
+	return b
+}
+
+// WithSampledEviction approximates LRU like the allkeys-lru policy of Redis, each victim is the least recently used
+// one of k unpinned entries sampled at random, instead of walking the access list from the back, which is costly
+// for very large caches with many pinned entries at the back. The larger k is, the closer it's to LRU.
+// `NextVictims` still reports the LRU order.
+func (b *CacheBuilder[K, V]) WithSampledEviction(k int) *CacheBuilder[K, V] {
+	b.evictionSamples = k
+	return b
+}
+
+// WithAsyncFinalizer runs the finalizer off the critical path of eviction on at most workers goroutines, so that
+// the eviction returns without waiting for the slow finalizer, e.g. releasing the disk files. The failures of
+// finalizer are only logged. The key may be loaded again before its evicted value is finalized.
+// `WaitFinalizers` waits for the pending finalizers, and `Close` waits for them before returning.
+func (b *CacheBuilder[K, V]) WithAsyncFinalizer(workers int) *CacheBuilder[K, V] {
+	b.asyncFinalizers = workers
+	return b
+}
+
+// WithNegativeCache remembers the keys for which the loader returns `ErrNoSuchItem` as tombstones, so that the
+// lookups of missing keys fail fast without invoking the loader again. The tombstones are kept apart from the values,
+// at most capacity of them in LRU order, so a flood of missing keys never evicts the values and vice versa.
+// A tombstone lives for ttl, or until evicted if ttl is not positive, and it's cleared once the key is loaded,
+// provided or removed.
+func (b *CacheBuilder[K, V]) WithNegativeCache(capacity int, ttl time.Duration) *CacheBuilder[K, V] {
+	b.negativeCapacity = capacity
+	b.negativeTTL = ttl
+	return b
+}
+
+// WithDoObserver times the phases of each `Do` and reports them to observer once the `Do` returns, e.g. to attribute
+// the latency in traces. The observer is called synchronously, so it should be cheap.
+func (b *CacheBuilder[K, V]) WithDoObserver(observer func(key K, phases DoPhases)) *CacheBuilder[K, V] {
+	b.doObserver = observer
+	return b
+}
+
+// WithVersionSource bounds the staleness of entries by a monotonically increasing version, e.g. the version of
+// the data the values are derived from. Each entry records the version when it started loading, and a hit on the
+// entry is treated as a miss if the current version is more than maxStaleness ahead, so that `Do` never runs on
+// a value over maxStaleness versions stale. A stale entry pinned by others is waited for until unpinned.
+// The source is called on every hit, so it should be cheap, e.g. an atomic load.
+func (b *CacheBuilder[K, V]) WithVersionSource(source func() uint64, maxStaleness uint64) *CacheBuilder[K, V] {
+	b.versionSource = source
+	b.maxStaleness = maxStaleness
+	return b
+}
+
+// WithValueValidator checks the values returned by loader and reloader before admitting them, e.g. to catch
+// the loader returning an empty value by mistake at the boundary of cache rather than downstream. An invalid value
+// fails the `Do` with the error of validate wrapped, or keeps the previous value on reload, and it's dropped without
+// finalizing since it's likely not initialized.
+func (b *CacheBuilder[K, V]) WithValueValidator(validate func(value V) error) *CacheBuilder[K, V] {
+	b.validate = validate
+	return b
+}
+
 func (b *CacheBuilder[K, V]) Build() Cache[K, V] {
-	return newLRUCache(b.loader, b.finalizer, b.scavenger, b.reloader)
+	if b.valueStore != nil {
+		return newStoredCache(b)
+	}
+	c := newLRUCache(b.loader, b.finalizer, b.scavenger, b.reloader)
+	configureLRUCache(b, c)
+	c.setValueWeight(b.valueWeight)
+	c.validate = b.validate
+	if b.valid != nil && b.revalidateInterval > 0 {
+		c.startRevalidator(b.revalidateInterval, b.valid)
+	}
+	if b.flush != nil {
+		c.setWriteBack(b.flush, b.flushInterval)
+	}
+	return c
+}
+
+// configureLRUCache applies the options of builder except loader, finalizer, reloader and revalidator to cache,
+// the cache may hold values of other type than the builder, e.g. the handles of value store.
+func configureLRUCache[K comparable, V any, W any](b *CacheBuilder[K, V], c *lruCache[K, W]) {
+	// set first, the background routines started below read it.
+	if b.clock != nil {
+		c.clock = b.clock
+	}
+	if b.groupOf != nil {
+		c.fairness = newGroupFairness(b.groupOf, b.weight, b.guarantees)
+	}
+	if b.maxConcurrentLoads > 0 {
+		c.loadSlots = make(chan struct{}, b.maxConcurrentLoads)
+	}
+	c.deferPromotion = b.deferPromotion
+	c.trackPinHold = b.trackPinHold
+	if b.maxConcurrentDoers > 0 {
+		c.maxDoers = int32(b.maxConcurrentDoers)
+	}
+	c.capacityExceededHandler = b.capacityExceededHandler
+	if s, ok := b.scavenger.(sizer); ok && b.softCapacity > 0 {
+		c.sizer = s
+		c.softCapacity = b.softCapacity
+	}
+	c.pressure = b.pressure
+	if c.sizer != nil || c.pressure != nil {
+		c.startReclaimer()
+	}
+	if _, ok := b.scavenger.(weigher[K]); ok && b.gcHintThreshold > 0 {
+		c.startGCHinter(b.gcHintThreshold, b.gcHintMinInterval)
+	}
+	if b.hysteresis > 0 {
+		c.hysteresis = b.hysteresis
+		c.evictedAt = make(map[K]time.Time)
+	}
+	if b.evictionSamples > 0 {
+		c.evictionSamples = b.evictionSamples
+	}
+	c.doObserver = b.doObserver
+	c.versionSource = b.versionSource
+	c.maxStaleness = b.maxStaleness
+	if b.negativeCapacity > 0 {
+		c.negative = newNegativeCache[K](b.negativeCapacity, b.negativeTTL, c.clock)
+	}
+	if b.asyncFinalizers > 0 && c.finalizer != nil {
+		c.asyncFinalizer = newAsyncFinalizer(c.finalizer, b.asyncFinalizers)
+		c.finalizer = c.asyncFinalizer.Finalize
+	}
+}
+
+// setValueWeight enables reweighing entries by value if the scavenger supports it.
+func (c *lruCache[K, V]) setValueWeight(weight func(K, V) int64) {
+	if r, ok := c.scavenger.(reweigher[K]); ok && weight != nil {
+		c.valueWeight = weight
+		c.reweigher = r
+	}
+}
+
+// validateLoaded returns the wrapped error of validator if the loaded value is invalid.
+func (c *lruCache[K, V]) validateLoaded(key K, value V) error {
+	if c.validate == nil {
+		return nil
+	}
+	if err := c.validate(value); err != nil {
+		return errors.Wrapf(err, "invalid value loaded for key %v", key)
+	}
+	return nil
 }
 
 func newLRUCache[K comparable, V any](
@@ -234,7 +842,7 @@ func newLRUCache[K comparable, V any](
 	finalizer Finalizer[K, V],
 	scavenger Scavenger[K],
 	reloader Loader[K, V],
-) Cache[K, V] {
+) *lruCache[K, V] {
 	return &lruCache[K, V]{
 		items:          make(map[K]*list.Element),
 		accessList:     list.New(),
@@ -245,70 +853,187 @@ func newLRUCache[K comparable, V any](
 		finalizer:      finalizer,
 		scavenger:      scavenger,
 		reloader:       reloader,
+		freeOSMemory:   debug.FreeOSMemory,
+		clock:          realClock{},
 	}
 }
 
 func (c *lruCache[K, V]) Do(ctx context.Context, key K, doer func(context.Context, V) error) (bool, error) {
+	outcome, err := c.DoWithOutcome(ctx, key, doer)
+	return outcome.missing(), err
+}
+
+func (c *lruCache[K, V]) DoWithOutcome(ctx context.Context, key K, doer func(context.Context, V) error) (LoadOutcome, error) {
 	log := log.Ctx(ctx).With(zap.Any("key", key))
+	var phases *DoPhases
+	if c.doObserver != nil {
+		phases = &DoPhases{}
+		defer func() {
+			c.doObserver(key, *phases)
+		}()
+	}
 	for {
 		// Get a listener before getAndPin to avoid missing the notification.
 		listener := c.waitNotifier.Listen(syncutil.VersionedListenAtLatest)
 
-		item, missing, err := c.getAndPin(ctx, key)
+		item, outcome, err := c.getAndPin(ctx, key, phases)
 		if err == nil {
-			defer c.Unpin(key)
-			return missing, doer(ctx, item.value)
+			if item.passThrough {
+				defer c.finalizePassThrough(ctx, item)
+			} else {
+				defer c.Unpin(key)
+				if c.trackPinHold {
+					pinnedAt := c.clock.Now()
+					defer func() {
+						c.stats.PinHoldDuration.Observe(c.clock.Now().Sub(pinnedAt))
+					}()
+				}
+			}
+			if phases != nil {
+				doerStart := c.clock.Now()
+				defer func() {
+					phases.DoerDuration = c.clock.Now().Sub(doerStart)
+				}()
+			}
+			return outcome, doer(ctx, item.value)
+		} else if err == errTooManyDoers {
+			log.Debug("too many concurrent doers on the item, wait and try again")
+		} else if err == errStalePinned {
+			log.Debug("the stale item is pinned, wait and try again")
 		} else if err != ErrNotEnoughSpace {
-			return true, err
+			return outcome, err
+		} else {
+			log.Warn("Failed to get disk cache for segment, wait and try again", zap.Error(err))
 		}
-		log.Warn("Failed to get disk cache for segment, wait and try again", zap.Error(err))
 
 		// wait for the listener to be notified.
-		if err := listener.Wait(ctx); err != nil {
+		waitStart := c.clock.Now()
+		err = listener.Wait(ctx)
+		if phases != nil {
+			phases.WaitDuration += c.clock.Now().Sub(waitStart)
+		}
+		if err != nil {
 			log.Warn("failed to get item for key with timeout", zap.Error(context.Cause(ctx)))
-			return true, err
+			return LoadOutcomeMissed, err
 		}
 	}
 }
 
+func (c *lruCache[K, V]) TryDo(key K, doer func(V) error) (bool, error) {
+	// pinned under read lock as a deferred promotion, so that it never waits for the write lock held by loading.
+	item := c.fastPeekAndPin(key)
+	if item == nil {
+		return false, nil
+	}
+	c.stats.HitCount.Inc()
+	defer c.Unpin(key)
+	if c.trackPinHold {
+		pinnedAt := c.clock.Now()
+		defer func() {
+			c.stats.PinHoldDuration.Observe(c.clock.Now().Sub(pinnedAt))
+		}()
+	}
+	return true, doer(item.value)
+}
+
+// finalizePassThrough releases the value which is not admitted into cache.
+func (c *lruCache[K, V]) finalizePassThrough(ctx context.Context, item *cacheItem[K, V]) {
+	if c.finalizer != nil {
+		c.finalizer(ctx, item.key, item.value)
+	}
+}
+
 func (c *lruCache[K, V]) Stats() *Stats {
 	return c.stats
 }
 
 func (c *lruCache[K, V]) Unpin(key K) {
-	c.rwlock.Lock()
-	defer c.rwlock.Unlock()
+	// pin count is atomic, and items are only removed under write lock, so read lock is enough here.
+	c.rwlock.RLock()
+	defer c.rwlock.RUnlock()
 	e, ok := c.items[key]
 	if !ok {
 		return
 	}
 	item := e.Value.(*cacheItem[K, V])
-	item.pinCount.Dec()
+	pinCount := item.pinCount.Dec()
 
 	log := log.With(zap.Any("UnPinedKey", key))
-	if item.pinCount.Load() == 0 {
+	if pinCount == 0 {
 		log.Debug("Unpin item to zero ref, trigger activating waiters")
 		c.waitNotifier.NotifyAll()
+		c.notifyReclaimer()
+	} else if pinCount == c.maxDoers-1 {
+		log.Debug("Unpin item below max concurrent doers, trigger activating waiters")
+		c.waitNotifier.NotifyAll()
 	} else {
 		log.Debug("Miss to trigger activating waiters", zap.Int32("PinCount", item.pinCount.Load()))
 	}
 }
 
-func (c *lruCache[K, V]) peekAndPin(ctx context.Context, key K) *cacheItem[K, V] {
+// peekAndPin pins the item if it exists, returns errTooManyDoers if it's pinned by the max concurrent doers.
+func (c *lruCache[K, V]) peekAndPin(ctx context.Context, key K) (*cacheItem[K, V], error) {
+	if c.deferPromotion {
+		if item := c.fastPeekAndPin(key); item != nil {
+			return item, nil
+		}
+	}
+	item, dirty, err := c.lockedPeekAndPin(ctx, key, true)
+	if dirty != nil {
+		// the dirty item to evict or reload is flushed without lock held, then peeked again,
+		// it's kept as is if still dirty.
+		flushed := c.tryFlush(dirty)
+		c.Unpin(key)
+		item, _, err = c.lockedPeekAndPin(ctx, key, false)
+		if !flushed && err == errStalePinned {
+			// the stale item can't be served before flushed, and waiting would be woken up by the unpin above
+			// right away, so fail the caller instead of flushing again and again.
+			return nil, merr.WrapErrServiceInternal("failed to flush the stale dirty item before reloading")
+		}
+	}
+	return item, err
+}
+
+// lockedPeekAndPin peeks and pins the item under write lock. If the item is stale or needs reload but is dirty,
+// it's pinned and returned as dirty to flush first if flushDirty, otherwise it's served as if it failed to flush.
+func (c *lruCache[K, V]) lockedPeekAndPin(ctx context.Context, key K, flushDirty bool) (item, dirty *cacheItem[K, V], err error) {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 	e, ok := c.items[key]
 	log := log.Ctx(ctx)
 	if ok {
 		item := e.Value.(*cacheItem[K, V])
-		if item.needReload && item.pinCount.Load() == 0 {
+		if (c.isStale(item) || item.needReload) && item.pinCount.Load() == 0 && c.isDirty(item) && flushDirty {
+			item.pinCount.Inc()
+			return nil, item, nil
+		}
+		if c.isStale(item) {
+			c.stats.StaleCount.Inc()
+			if item.pinCount.Load() > 0 || c.isDirty(item) {
+				return nil, nil, errStalePinned
+			}
+			// evict the stale item, so that it's loaded again as a miss.
+			c.evict(ctx, key)
+			log.Debug("cache evicting stale item", zap.Any("key", key), zap.Uint64("version", item.version))
+			return nil, nil, nil
+		}
+		// the dirty value is flushed before it's replaced by the reloaded one.
+		if item.needReload && item.pinCount.Load() == 0 && !c.isDirty(item) {
 			ok, _, retback := c.scavenger.Replace(key)
 			if ok {
 				// there is room for reload and no one is using the item
 				if c.reloader != nil {
+					version := c.currentVersion()
 					reloaded, err := c.reloader(ctx, key)
+					if err == nil {
+						err = c.validateLoaded(key, reloaded)
+					}
 					if err == nil {
 						item.value = reloaded
+						item.version = version
+						if !c.lockfreeReweigh(ctx, key, reloaded) {
+							log.Warn("no room for the actual weight of reloaded value, keep the estimated weight", zap.Any("key", key))
+						}
 					} else if retback != nil {
 						retback()
 					}
@@ -316,38 +1041,120 @@ func (c *lruCache[K, V]) peekAndPin(ctx context.Context, key K) *cacheItem[K, V]
 				item.needReload = false
 			}
 		}
-		c.accessList.MoveToFront(e)
-		item.pinCount.Inc()
+		c.moveToFront(e)
+		if !item.tryPin(c.maxDoers) {
+			return nil, nil, errTooManyDoers
+		}
 		log.Debug("peeked item success",
 			zap.Int32("PinCount", item.pinCount.Load()),
 			zap.Any("key", key))
-		return item
+		return item, nil, nil
 	}
 	log.Debug("failed to peek item", zap.Any("key", key))
-	return nil
+	return nil, nil, nil
+}
+
+// fastPeekAndPin pins the item under read lock and marks it as accessed, it's moved to front by
+// the next `promoteAccessed`, which precedes every eviction. Returns nil if the item is missing or needs reload, which requires write lock,
+// or the item is pinned by the max concurrent doers.
+func (c *lruCache[K, V]) fastPeekAndPin(key K) *cacheItem[K, V] {
+	c.rwlock.RLock()
+	defer c.rwlock.RUnlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	item := e.Value.(*cacheItem[K, V])
+	if item.needReload || c.isStale(item) {
+		return nil
+	}
+	if !item.tryPin(c.maxDoers) {
+		return nil
+	}
+	if item.accessed.CompareAndSwap(false, true) {
+		c.pendingPromotions.Inc()
+	}
+	return item
+}
+
+// promoteAccessed moves the items accessed under read lock to front, keeping their relative order,
+// must be called with write lock held.
+func (c *lruCache[K, V]) promoteAccessed() {
+	if c.pendingPromotions.Load() == 0 {
+		return
+	}
+	for p := c.accessList.Back(); p != nil; {
+		prev := p.Prev()
+		if p.Value.(*cacheItem[K, V]).accessed.CompareAndSwap(true, false) {
+			c.moveToFront(p)
+		}
+		p = prev
+	}
+	c.pendingPromotions.Store(0)
 }
 
-// GetAndPin gets and pins the given key if it exists
-func (c *lruCache[K, V]) getAndPin(ctx context.Context, key K) (*cacheItem[K, V], bool, error) {
-	if item := c.peekAndPin(ctx, key); item != nil {
+// moveToFront moves the element to the front of access list and stamps its item as the most recently used,
+// must be called with write lock held.
+func (c *lruCache[K, V]) moveToFront(e *list.Element) {
+	c.accessList.MoveToFront(e)
+	c.accessSeq++
+	e.Value.(*cacheItem[K, V]).lastAccess = c.accessSeq
+}
+
+// GetAndPin gets and pins the given key if it exists, the time of each phase is added to phases if it's not nil.
+func (c *lruCache[K, V]) getAndPin(ctx context.Context, key K, phases *DoPhases) (*cacheItem[K, V], LoadOutcome, error) {
+	if item, err := c.peekAndPin(ctx, key); err != nil {
+		return nil, LoadOutcomeMissed, err
+	} else if item != nil {
 		c.stats.HitCount.Inc()
-		return item, false, nil
+		return item, LoadOutcomeHit, nil
 	}
-	log := log.Ctx(ctx)
+	// the negative hit is counted as a miss, since nothing is served from cache.
 	c.stats.MissCount.Inc()
+	if c.negativeHit(key) {
+		return nil, LoadOutcomeMissed, ErrNoSuchItem
+	}
+	log := log.Ctx(ctx)
 	if c.loader != nil {
 		// Try scavenge if there is room. If not, fail fast.
 		//	Note that the test is not accurate since we are not locking `loader` here.
 		if _, ok := c.tryScavenge(key); !ok {
 			log.Warn("getAndPin ran into scavenge failure, return", zap.Any("key", key))
-			return nil, true, ErrNotEnoughSpace
+			c.notifyCapacityExceeded(key)
+			return nil, LoadOutcomeMissed, ErrNotEnoughSpace
 		}
+		waitStart := c.clock.Now()
 		c.loaderKeyLocks.Lock(key)
 		defer c.loaderKeyLocks.Unlock(key)
-		if item := c.peekAndPin(ctx, key); item != nil {
-			return item, false, nil
+		if phases != nil {
+			phases.WaitDuration += c.clock.Now().Sub(waitStart)
+		}
+		if item, err := c.peekAndPin(ctx, key); err != nil {
+			return nil, LoadOutcomeMissed, err
+		} else if item != nil {
+			// the item is loaded by another caller while we are waiting for the key lock.
+			c.stats.LoadDedups.Inc()
+			return item, LoadOutcomeJoined, nil
+		}
+		// or found missing by another caller.
+		if c.negativeHit(key) {
+			return nil, LoadOutcomeMissed, ErrNoSuchItem
+		}
+		waitStart = c.clock.Now()
+		if err := c.acquireLoadSlot(ctx); err != nil {
+			log.Warn("failed to wait for load slot", zap.Any("key", key), zap.Error(err))
+			return nil, LoadOutcomeMissed, err
 		}
-		timer := time.Now()
+		defer c.releaseLoadSlot()
+		timer := c.clock.Now()
+		if phases != nil {
+			phases.WaitDuration += timer.Sub(waitStart)
+			defer func() {
+				phases.LoadDuration = c.clock.Now().Sub(timer)
+			}()
+		}
+		// the version is taken before loading, the value reflects at least this version.
+		version := c.currentVersion()
 		value, err := c.loader(ctx, key)
 
 		for retryAttempt := 0; merr.ErrServiceDiskLimitExceeded.Is(err) && retryAttempt < paramtable.Get().QueryNodeCfg.LazyLoadMaxRetryTimes.GetAsInt(); retryAttempt++ {
@@ -355,23 +1162,108 @@ func (c *lruCache[K, V]) getAndPin(ctx context.Context, key K) (*cacheItem[K, V]
 			c.evictItems(ctx, paramtable.Get().QueryNodeCfg.LazyLoadMaxEvictPerRetry.GetAsInt())
 			value, err = c.loader(ctx, key)
 		}
+		if err == nil {
+			err = c.validateLoaded(key, value)
+		}
 
 		if err != nil {
 			c.stats.LoadFailCount.Inc()
+			if c.negative != nil && isNoSuchItem(err) {
+				c.negative.Add(key)
+			}
 			log.Debug("loader failed for key", zap.Any("key", key))
-			return nil, true, err
+			return nil, LoadOutcomeMissed, err
 		}
 
-		c.stats.TotalLoadTimeMs.Add(uint64(time.Since(timer).Milliseconds()))
+		c.stats.TotalLoadTimeMs.Add(uint64(c.clock.Now().Sub(timer).Milliseconds()))
 		c.stats.LoadSuccessCount.Inc()
-		item, err := c.setAndPin(ctx, key, value)
+		if c.underPressure() {
+			// pass the value through without admission, and shrink the cache in background.
+			log.RatedInfo(10, "cache is under memory pressure, pass through the loaded value", zap.Any("key", key))
+			c.stats.PassThroughCount.Inc()
+			c.wakeReclaimer()
+			return &cacheItem[K, V]{key: key, value: value, passThrough: true}, LoadOutcomeLoaded, nil
+		}
+		item, err := c.setAndPin(ctx, key, value, version)
 		if err != nil {
 			log.Debug("setAndPin failed for key", zap.Any("key", key), zap.Error(err))
-			return nil, true, err
+			if err == ErrNotEnoughSpace {
+				c.notifyCapacityExceeded(key)
+			}
+			return nil, LoadOutcomeMissed, err
+		}
+		return item, LoadOutcomeLoaded, nil
+	}
+	return nil, LoadOutcomeMissed, ErrNoLoader
+}
+
+// currentVersion returns the current version of source, or 0 if the cache has no version source.
+func (c *lruCache[K, V]) currentVersion() uint64 {
+	if c.versionSource == nil {
+		return 0
+	}
+	return c.versionSource()
+}
+
+// isStale returns whether the item is loaded more than maxStaleness versions ago.
+func (c *lruCache[K, V]) isStale(item *cacheItem[K, V]) bool {
+	if c.versionSource == nil {
+		return false
+	}
+	current := c.versionSource()
+	return current > item.version && current-item.version > c.maxStaleness
+}
+
+// negativeHit returns whether the key has a tombstone, which means it's not found by loader recently.
+func (c *lruCache[K, V]) negativeHit(key K) bool {
+	if c.negative == nil || !c.negative.Contains(key) {
+		return false
+	}
+	c.stats.NegativeHitCount.Inc()
+	return true
+}
+
+// notifyCapacityExceeded calls the capacity exceeded handler with the occupation of cache,
+// the handler is called without lock held.
+func (c *lruCache[K, V]) notifyCapacityExceeded(key K) {
+	if c.capacityExceededHandler == nil {
+		return
+	}
+	c.rwlock.RLock()
+	pinnedCount := 0
+	for _, e := range c.items {
+		if e.Value.(*cacheItem[K, V]).pinCount.Load() > 0 {
+			pinnedCount++
 		}
-		return item, true, nil
 	}
-	return nil, true, ErrNoSuchItem
+	var size, capacity int64
+	if s, ok := c.scavenger.(sizer); ok {
+		size = s.Size()
+	}
+	if s, ok := c.scavenger.(capacitor); ok {
+		capacity = s.Capacity()
+	}
+	c.rwlock.RUnlock()
+	c.capacityExceededHandler(key, pinnedCount, size, capacity)
+}
+
+// acquireLoadSlot waits for a slot to invoke loader, or the context is done.
+func (c *lruCache[K, V]) acquireLoadSlot(ctx context.Context) error {
+	if c.loadSlots == nil {
+		return nil
+	}
+	select {
+	case c.loadSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func (c *lruCache[K, V]) releaseLoadSlot() {
+	if c.loadSlots != nil {
+		<-c.loadSlots
+	}
 }
 
 func (c *lruCache[K, V]) tryScavenge(key K) ([]K, bool) {
@@ -381,34 +1273,128 @@ func (c *lruCache[K, V]) tryScavenge(key K) ([]K, bool) {
 }
 
 func (c *lruCache[K, V]) lockfreeTryScavenge(key K) ([]K, bool) {
+	c.promoteAccessed()
 	ok, collector := c.scavenger.Collect(key)
-	toEvict := make([]K, 0)
 	if !ok {
-		done := false
-		for p := c.accessList.Back(); p != nil && !done; p = p.Prev() {
-			evictItem := p.Value.(*cacheItem[K, V])
-			if evictItem.pinCount.Load() > 0 {
-				continue
+		return c.lockfreeCollectVictims(key, collector)
+	}
+	// If no collection needed, give back the space.
+	c.scavenger.Throw(key)
+	return nil, true
+}
+
+// lockfreeCollectVictims picks the unpinned entries in LRU order until the collector is satisfied,
+// returns false if there are not enough entries to evict.
+func (c *lruCache[K, V]) lockfreeCollectVictims(key K, collector func(K) bool) ([]K, bool) {
+	var evictable func(K) bool
+	if c.fairness != nil {
+		evictable = c.fairness.Evictor(key)
+	}
+	now := c.clock.Now()
+	candidate := func(item *cacheItem[K, V], protected bool) bool {
+		if item.pinCount.Load() > 0 || item.key == key {
+			return false
+		}
+		if item.protectedUntil.After(now) != protected {
+			return false
+		}
+		return evictable == nil || evictable(item.key)
+	}
+	toEvict := make([]K, 0)
+	done := false
+	// the protected entries are only picked if the unprotected ones are not enough.
+	for _, protected := range []bool{false, true} {
+		if c.evictionSamples > 0 {
+			toEvict, done = c.lockfreeSampleVictims(toEvict, protected, candidate, collector)
+		} else {
+			for p := c.accessList.Back(); p != nil && !done; p = p.Prev() {
+				evictItem := p.Value.(*cacheItem[K, V])
+				if !candidate(evictItem, protected) || !c.lockfreeIsClean(evictItem) {
+					continue
+				}
+				toEvict = append(toEvict, evictItem.key)
+				done = collector(evictItem.key)
 			}
-			toEvict = append(toEvict, evictItem.key)
-			done = collector(evictItem.key)
 		}
-		if !done {
-			return nil, false
+		if done || c.hysteresis == 0 {
+			break
 		}
-	} else {
-		// If no collection needed, give back the space.
-		c.scavenger.Throw(key)
+	}
+	if !done {
+		return nil, false
 	}
 	return toEvict, true
 }
 
-// for cache miss
-func (c *lruCache[K, V]) setAndPin(ctx context.Context, key K, value V) (*cacheItem[K, V], error) {
+// lockfreeSampleVictims appends the victims to toEvict until the collector is satisfied, each victim is the least
+// recently used one of the evictionSamples candidates sampled from items, skipping the ones picked already or
+// failed to flush. Returns false if the candidates run out.
+func (c *lruCache[K, V]) lockfreeSampleVictims(toEvict []K, protected bool,
+	candidate func(*cacheItem[K, V], bool) bool, collector func(K) bool,
+) ([]K, bool) {
+	skipped := make(map[K]struct{}, len(toEvict))
+	for _, key := range toEvict {
+		skipped[key] = struct{}{}
+	}
+	for {
+		var victim *cacheItem[K, V]
+		sampled := 0
+		// the iteration of map starts at random, which makes a cheap sample.
+		for _, e := range c.items {
+			item := e.Value.(*cacheItem[K, V])
+			if _, ok := skipped[item.key]; ok || !candidate(item, protected) {
+				continue
+			}
+			if victim == nil || item.lastAccess < victim.lastAccess {
+				victim = item
+			}
+			if sampled++; sampled >= c.evictionSamples {
+				break
+			}
+		}
+		if victim == nil {
+			return toEvict, false
+		}
+		skipped[victim.key] = struct{}{}
+		if !c.lockfreeIsClean(victim) {
+			continue
+		}
+		toEvict = append(toEvict, victim.key)
+		if collector(victim.key) {
+			return toEvict, true
+		}
+	}
+}
+
+// lockfreeReweigh replaces the estimated weight of key with the weight of its value, and evicts other entries
+// if the actual weight doesn't fit. Returns false if there is no room, then the estimated weight is kept.
+func (c *lruCache[K, V]) lockfreeReweigh(ctx context.Context, key K, value V) bool {
+	if c.valueWeight == nil {
+		return true
+	}
+	weight := c.valueWeight(key, value)
+	ok, collector := c.reweigher.Reweigh(key, weight)
+	if ok {
+		return true
+	}
+	toEvict, ok := c.lockfreeCollectVictims(key, collector)
+	if !ok {
+		return false
+	}
+	for _, ek := range toEvict {
+		c.evictForRoom(ctx, ek)
+		log.Ctx(ctx).Debug("cache evicting", zap.Any("key", ek), zap.Any("reweighed", key))
+	}
+	ok, _ = c.reweigher.Reweigh(key, weight)
+	return ok
+}
+
+// for cache miss, version is the version of source when the value started loading.
+func (c *lruCache[K, V]) setAndPin(ctx context.Context, key K, value V, version uint64) (*cacheItem[K, V], error) {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
-	item := &cacheItem[K, V]{key: key, value: value}
+	item := &cacheItem[K, V]{key: key, value: value, version: version}
 	item.pinCount.Inc()
 
 	// tryScavenge is done again since the load call is lock free.
@@ -423,23 +1409,81 @@ func (c *lruCache[K, V]) setAndPin(ctx context.Context, key K, value V) (*cacheI
 	}
 
 	for _, ek := range toEvict {
-		c.evict(ctx, ek)
+		c.evictForRoom(ctx, ek)
 		log.Debug("cache evicting", zap.Any("key", ek), zap.Any("by", key))
 	}
 
 	c.scavenger.Collect(key)
+	if !c.lockfreeReweigh(ctx, key, value) {
+		c.scavenger.Throw(key)
+		if c.finalizer != nil {
+			log.Warn("setAndPin ran into scavenge failure for the actual weight, release data for", zap.Any("key", key))
+			c.finalizer(ctx, key, value)
+		}
+		return nil, ErrNotEnoughSpace
+	}
+	if c.fairness != nil {
+		c.fairness.Add(key)
+	}
+	if c.negative != nil {
+		c.negative.Clear(key)
+	}
+	c.protectIfReadmitted(item)
+	c.accessSeq++
+	item.lastAccess = c.accessSeq
 	e := c.accessList.PushFront(item)
 	c.items[item.key] = e
+	c.notifyReclaimer()
 	log.Debug("setAndPin set up item", zap.Any("item.key", item.key),
 		zap.Int32("pinCount", item.pinCount.Load()))
 	return item, nil
 }
 
+func (c *lruCache[K, V]) Provide(ctx context.Context, key K, value V) (bool, error) {
+	return c.provide(ctx, key, func() (V, error) {
+		return value, nil
+	})
+}
+
+// provide inserts the value produced by `produce` unless the key is in cache. The key lock of loader is held,
+// so that the provided value and the loaded one never race to be inserted.
+func (c *lruCache[K, V]) provide(ctx context.Context, key K, produce func() (V, error)) (bool, error) {
+	c.loaderKeyLocks.Lock(key)
+	defer c.loaderKeyLocks.Unlock(key)
+	c.rwlock.RLock()
+	_, ok := c.items[key]
+	c.rwlock.RUnlock()
+	if ok {
+		return false, nil
+	}
+
+	version := c.currentVersion()
+	value, err := produce()
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.setAndPin(ctx, key, value, version); err != nil {
+		if err == ErrNotEnoughSpace {
+			c.notifyCapacityExceeded(key)
+		}
+		return false, err
+	}
+	c.stats.ProvideCount.Inc()
+	// unpinning to zero wakes up the waiters.
+	c.Unpin(key)
+	return true, nil
+}
+
 func (c *lruCache[K, V]) Remove(ctx context.Context, key K) error {
+	if c.negative != nil {
+		c.negative.Clear(key)
+	}
 	for {
 		listener := c.waitNotifier.Listen(syncutil.VersionedListenAtLatest)
 
-		if c.tryToRemoveKey(ctx, key) {
+		if removed, err := c.tryToRemoveKey(ctx, key); err != nil {
+			return err
+		} else if removed {
 			return nil
 		}
 
@@ -450,29 +1494,56 @@ func (c *lruCache[K, V]) Remove(ctx context.Context, key K) error {
 	}
 }
 
-func (c *lruCache[K, V]) tryToRemoveKey(ctx context.Context, key K) (removed bool) {
+// tryToRemoveKey evicts the item if it's not pinned, returns error if the item is dirty and fails to flush.
+// The dirty item is flushed without lock held, and checked again after flushed.
+func (c *lruCache[K, V]) tryToRemoveKey(ctx context.Context, key K) (removed bool, err error) {
+	for {
+		removed, dirty := c.lockedTryToRemoveKey(ctx, key)
+		if dirty == nil {
+			return removed, nil
+		}
+		flushed := c.tryFlush(dirty)
+		c.Unpin(key)
+		if !flushed {
+			return false, merr.WrapErrServiceInternal("failed to flush the dirty item before removing")
+		}
+	}
+}
+
+// lockedTryToRemoveKey evicts the item if it's not pinned, or pins and returns the item if it's dirty.
+func (c *lruCache[K, V]) lockedTryToRemoveKey(ctx context.Context, key K) (removed bool, dirty *cacheItem[K, V]) {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
 	e, ok := c.items[key]
 	if !ok {
-		return true
+		return true, nil
 	}
 
 	item := e.Value.(*cacheItem[K, V])
 	if item.pinCount.Load() == 0 {
+		if c.isDirty(item) {
+			item.pinCount.Inc()
+			return false, item
+		}
 		c.evict(ctx, key)
-		return true
+		return true, nil
 	}
-	return false
+	return false, nil
 }
 
 func (c *lruCache[K, V]) evict(ctx context.Context, key K) {
 	c.stats.EvictionCount.Inc()
+	if w, ok := c.scavenger.(weigher[K]); ok {
+		c.recordEvictedWeight(w.Weight(key))
+	}
 	e := c.items[key]
 	delete(c.items, key)
 	c.accessList.Remove(e)
 	c.scavenger.Throw(key)
+	if c.fairness != nil {
+		c.fairness.Remove(key)
+	}
 
 	if c.finalizer != nil {
 		item := e.Value.(*cacheItem[K, V])
@@ -480,14 +1551,51 @@ func (c *lruCache[K, V]) evict(ctx context.Context, key K) {
 	}
 }
 
+// evictForRoom evicts the key to make room for others, and remembers when it's evicted
+// to tell the readmission of key within the hysteresis window.
+func (c *lruCache[K, V]) evictForRoom(ctx context.Context, key K) {
+	c.evict(ctx, key)
+	if c.hysteresis == 0 {
+		return
+	}
+	now := c.clock.Now()
+	c.evictedAt[key] = now
+	// forget the evictions out of window once there are many, so that the map doesn't grow unbounded.
+	if len(c.evictedAt) > 2*len(c.items)+64 {
+		for k, at := range c.evictedAt {
+			if now.Sub(at) >= c.hysteresis {
+				delete(c.evictedAt, k)
+			}
+		}
+	}
+}
+
+// protectIfReadmitted protects the item for a while if its key is evicted for room within the hysteresis window,
+// which likely sits at the capacity boundary and flaps between eviction and reload otherwise.
+func (c *lruCache[K, V]) protectIfReadmitted(item *cacheItem[K, V]) {
+	if c.hysteresis == 0 {
+		return
+	}
+	evictedAt, ok := c.evictedAt[item.key]
+	if !ok {
+		return
+	}
+	delete(c.evictedAt, item.key)
+	if now := c.clock.Now(); now.Sub(evictedAt) < c.hysteresis {
+		item.protectedUntil = now.Add(c.hysteresis)
+		c.stats.ReadmissionCount.Inc()
+	}
+}
+
 func (c *lruCache[K, V]) evictItems(ctx context.Context, n int) {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
+	c.promoteAccessed()
 
 	toEvict := make([]K, 0)
 	for p := c.accessList.Back(); p != nil && n > 0; p = p.Prev() {
 		evictItem := p.Value.(*cacheItem[K, V])
-		if evictItem.pinCount.Load() > 0 {
+		if evictItem.pinCount.Load() > 0 || !c.lockfreeIsClean(evictItem) {
 			continue
 		}
 		toEvict = append(toEvict, evictItem.key)
@@ -495,8 +1603,424 @@ func (c *lruCache[K, V]) evictItems(ctx context.Context, n int) {
 	}
 
 	for _, key := range toEvict {
-		c.evict(ctx, key)
+		c.evictForRoom(ctx, key)
+	}
+}
+
+// NextVictims walks the access list from the least recently used end and returns up to n unpinned keys,
+// which is the same order `lockfreeTryScavenge` picks victims in, regardless of group guarantees.
+// The entries protected by hysteresis go last.
+func (c *lruCache[K, V]) NextVictims(n int) []K {
+	// hits under read lock are deferred promotions even if deferPromotion is off, e.g. by `TryDo`,
+	// apply them first like the scavenger does, so that the preview matches the eviction order.
+	if c.pendingPromotions.Load() > 0 {
+		c.rwlock.Lock()
+		defer c.rwlock.Unlock()
+		c.promoteAccessed()
+	} else {
+		c.rwlock.RLock()
+		defer c.rwlock.RUnlock()
+	}
+
+	now := c.clock.Now()
+	victims := make([]K, 0, n)
+	for _, protected := range []bool{false, true} {
+		for p := c.accessList.Back(); p != nil && len(victims) < n; p = p.Prev() {
+			item := p.Value.(*cacheItem[K, V])
+			if item.pinCount.Load() > 0 || item.protectedUntil.After(now) != protected {
+				continue
+			}
+			victims = append(victims, item.key)
+		}
+	}
+	return victims
+}
+
+// TopByWeight returns up to n resident keys with the most weight recorded by scavenger,
+// the more recently used key goes first if tie.
+func (c *lruCache[K, V]) TopByWeight(n int) []KeyWeight[K] {
+	w, ok := c.scavenger.(weigher[K])
+	if !ok || n <= 0 {
+		return nil
+	}
+	c.rwlock.RLock()
+	defer c.rwlock.RUnlock()
+
+	weights := make([]KeyWeight[K], 0, c.accessList.Len())
+	for p := c.accessList.Front(); p != nil; p = p.Next() {
+		key := p.Value.(*cacheItem[K, V]).key
+		weights = append(weights, KeyWeight[K]{Key: key, Weight: w.Weight(key)})
+	}
+	sort.SliceStable(weights, func(i, j int) bool {
+		return weights[i].Weight > weights[j].Weight
+	})
+	if len(weights) > n {
+		weights = weights[:n]
+	}
+	return weights
+}
+
+// Materialize copies the references of all resident values, the values passed through without admission are excluded.
+func (c *lruCache[K, V]) Materialize() map[K]V {
+	entries := make(map[K]V)
+	c.rangeResident(func(key K, value V) {
+		entries[key] = value
+	})
+	return entries
+}
+
+// rangeResident calls fn on each resident value under read lock, the items are only evicted and reloaded
+// under write lock, so none of the values is finalized or replaced while fn is using it.
+func (c *lruCache[K, V]) rangeResident(fn func(key K, value V)) {
+	c.rwlock.RLock()
+	defer c.rwlock.RUnlock()
+
+	for key, e := range c.items {
+		fn(key, e.Value.(*cacheItem[K, V]).value)
+	}
+}
+
+// evictAll evicts all the unpinned items.
+func (c *lruCache[K, V]) evictAll(ctx context.Context) {
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	for key, e := range c.items {
+		item := e.Value.(*cacheItem[K, V])
+		if item.pinCount.Load() == 0 && c.lockfreeIsClean(item) {
+			c.evict(ctx, key)
+		}
+	}
+}
+
+func (c *lruCache[K, V]) startReclaimer() {
+	c.reclaimCh = make(chan struct{}, 1)
+	if c.closeCh == nil {
+		c.closeCh = make(chan struct{})
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			select {
+			case <-c.closeCh:
+				return
+			case <-c.reclaimCh:
+				c.reclaim(context.Background())
+			}
+		}
+	}()
+}
+
+// underPressure returns true if the memory pressure source reports pressure.
+func (c *lruCache[K, V]) underPressure() bool {
+	return c.pressure != nil && c.pressure.UnderPressure()
+}
+
+// needReclaim returns true if the size crosses the soft capacity or the process is under memory pressure,
+// must be called with lock held.
+func (c *lruCache[K, V]) needReclaim() bool {
+	return (c.sizer != nil && c.sizer.Size() > c.softCapacity) || c.underPressure()
+}
+
+// notifyReclaimer wakes up the reclaimer if reclamation is needed, must be called with lock held.
+func (c *lruCache[K, V]) notifyReclaimer() {
+	if c.reclaimCh == nil || !c.needReclaim() {
+		return
+	}
+	c.wakeReclaimer()
+}
+
+func (c *lruCache[K, V]) wakeReclaimer() {
+	if c.reclaimCh == nil {
+		return
+	}
+	select {
+	case c.reclaimCh <- struct{}{}:
+	default:
+	}
+}
+
+// reclaim evicts unpinned items in LRU order until the size is below the soft capacity
+// and the memory pressure is relieved.
+func (c *lruCache[K, V]) reclaim(ctx context.Context) {
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+	c.promoteAccessed()
+
+	// the protected items are kept unless under memory pressure, the soft capacity is not urgent.
+	now := c.clock.Now()
+	keepProtected := !c.underPressure()
+	for p := c.accessList.Back(); p != nil && c.needReclaim(); {
+		prev := p.Prev()
+		item := p.Value.(*cacheItem[K, V])
+		if keepProtected && item.protectedUntil.After(now) {
+			p = prev
+			continue
+		}
+		if item.pinCount.Load() == 0 && (c.fairness == nil || c.fairness.Reclaimable(item.key)) && c.lockfreeIsClean(item) {
+			c.evictForRoom(ctx, item.key)
+			log.Ctx(ctx).Debug("cache reclaiming", zap.Any("key", item.key))
+		}
+		p = prev
+	}
+}
+
+// recordEvictedWeight accumulates the evicted weight, and wakes up the gc hinter if the weight evicted
+// since the last hint crosses the threshold.
+func (c *lruCache[K, V]) recordEvictedWeight(weight int64) {
+	c.stats.EvictedWeight.Add(uint64(weight))
+	if c.gcHintCh == nil || c.evictedSinceHint.Add(weight) < c.gcHintThreshold {
+		return
+	}
+	select {
+	case c.gcHintCh <- struct{}{}:
+	default:
+	}
+}
+
+// startGCHinter starts the routine returning the freed memory to OS, at most once per minInterval.
+func (c *lruCache[K, V]) startGCHinter(threshold int64, minInterval time.Duration) {
+	c.gcHintCh = make(chan struct{}, 1)
+	c.gcHintThreshold = threshold
+	if c.closeCh == nil {
+		c.closeCh = make(chan struct{})
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		var lastHint time.Time
+		for {
+			select {
+			case <-c.closeCh:
+				return
+			case <-c.gcHintCh:
+			}
+			if wait := minInterval - c.clock.Now().Sub(lastHint); wait > 0 {
+				select {
+				case <-c.closeCh:
+					return
+				case <-c.clock.After(wait):
+				}
+			}
+			// the weight evicted while freeing is left to the next hint.
+			c.evictedSinceHint.Store(0)
+			c.freeOSMemory()
+			c.stats.GCHintCount.Inc()
+			lastHint = c.clock.Now()
+		}
+	}()
+}
+
+// startRevalidator starts the sweeper evicting the unpinned items failing `valid` every interval.
+func (c *lruCache[K, V]) startRevalidator(interval time.Duration, valid func(K, V) bool) {
+	if c.closeCh == nil {
+		c.closeCh = make(chan struct{})
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			select {
+			case <-c.closeCh:
+				return
+			case <-c.clock.After(interval):
+				c.revalidate(context.Background(), valid)
+			}
+		}
+	}()
+}
+
+// revalidate evicts the unpinned items failing `valid`. The items are checked without lock,
+// only the eviction of stale items takes the lock, and the items pinned since checked are kept.
+func (c *lruCache[K, V]) revalidate(ctx context.Context, valid func(K, V) bool) {
+	type entry struct {
+		item  *cacheItem[K, V]
+		value V
+	}
+	c.rwlock.RLock()
+	entries := make([]entry, 0, len(c.items))
+	for _, e := range c.items {
+		item := e.Value.(*cacheItem[K, V])
+		if item.pinCount.Load() == 0 {
+			entries = append(entries, entry{item: item, value: item.value})
+		}
+	}
+	c.rwlock.RUnlock()
+
+	stale := make([]*cacheItem[K, V], 0)
+	for _, entry := range entries {
+		if !valid(entry.item.key, entry.value) {
+			stale = append(stale, entry.item)
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+	for _, item := range stale {
+		if e, ok := c.items[item.key]; ok && e.Value == item && item.pinCount.Load() == 0 && c.lockfreeIsClean(item) {
+			c.evict(ctx, item.key)
+			log.Ctx(ctx).Debug("cache evicting stale item", zap.Any("key", item.key))
+		}
+	}
+	c.waitNotifier.NotifyAll()
+}
+
+// setWriteBack enables flushing the dirty items, and starts the flusher, which flushes the queued items
+// blocking eviction, and all the dirty items every interval if interval is positive.
+func (c *lruCache[K, V]) setWriteBack(flush func(K, V) error, interval time.Duration) {
+	c.flush = flush
+	c.flushQueue = make(map[K]struct{})
+	c.flushCh = make(chan struct{}, 1)
+	if c.closeCh == nil {
+		c.closeCh = make(chan struct{})
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			var tick <-chan time.Time
+			if interval > 0 {
+				tick = c.clock.After(interval)
+			}
+			select {
+			case <-c.closeCh:
+				return
+			case <-tick:
+				c.flushDirty()
+			case <-c.flushCh:
+				if c.flushQueued() {
+					continue
+				}
+				// back off on failure, as the waiters blocked by the dirty items queue them again right away.
+				select {
+				case <-c.closeCh:
+					return
+				case <-c.clock.After(flushRetryInterval):
+				}
+			}
+		}
+	}()
+}
+
+func (c *lruCache[K, V]) Dirty(key K) bool {
+	if c.flush == nil {
+		return false
+	}
+	c.rwlock.RLock()
+	defer c.rwlock.RUnlock()
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	e.Value.(*cacheItem[K, V]).dirty.Store(true)
+	return true
+}
+
+// isDirty returns true if the item must be flushed before evicted or reloaded.
+func (c *lruCache[K, V]) isDirty(item *cacheItem[K, V]) bool {
+	return c.flush != nil && item.dirty.Load()
+}
+
+// lockfreeIsClean returns true if the item is evictable without flush, otherwise queues the item to the flusher,
+// so that it's flushed without lock held and evictable next time. Must be called with write lock held.
+func (c *lruCache[K, V]) lockfreeIsClean(item *cacheItem[K, V]) bool {
+	if !c.isDirty(item) {
+		return true
+	}
+	c.flushQueue[item.key] = struct{}{}
+	select {
+	case c.flushCh <- struct{}{}:
+	default:
+	}
+	return false
+}
+
+// flushQueued flushes the items queued by `lockfreeIsClean`, returns false if any of them fails to flush.
+// The items are pinned while flushing, and unpinned after, which wakes up the waiters for eviction.
+func (c *lruCache[K, V]) flushQueued() bool {
+	c.rwlock.Lock()
+	queued := make([]*cacheItem[K, V], 0, len(c.flushQueue))
+	for key := range c.flushQueue {
+		if e, ok := c.items[key]; ok {
+			item := e.Value.(*cacheItem[K, V])
+			item.pinCount.Inc()
+			queued = append(queued, item)
+		}
+	}
+	c.flushQueue = make(map[K]struct{})
+	c.rwlock.Unlock()
+
+	flushed := true
+	for _, item := range queued {
+		if !c.tryFlush(item) {
+			flushed = false
+		}
+		c.Unpin(item.key)
+	}
+	return flushed
+}
+
+// tryFlush flushes the item if it's dirty, returns false if the item is still dirty.
+// It must be called with the item pinned and without lock held, so that the item is not finalized while flushing,
+// and the slow flush doesn't block others.
+func (c *lruCache[K, V]) tryFlush(item *cacheItem[K, V]) bool {
+	// the dirty flag is cleared before flushing, so that the modification during flush is flushed next time.
+	if c.flush == nil || !item.dirty.CompareAndSwap(true, false) {
+		return true
+	}
+	if err := c.flush(item.key, item.value); err != nil {
+		item.dirty.Store(true)
+		c.stats.FlushFailCount.Inc()
+		log.Warn("failed to flush dirty item, keep it in cache", zap.Any("key", item.key), zap.Error(err))
+		return false
+	}
+	c.stats.FlushCount.Inc()
+	return true
+}
+
+// flushDirty flushes the dirty items without lock held, the items are pinned while flushing.
+func (c *lruCache[K, V]) flushDirty() {
+	c.rwlock.RLock()
+	dirty := make([]*cacheItem[K, V], 0)
+	for _, e := range c.items {
+		item := e.Value.(*cacheItem[K, V])
+		if item.dirty.Load() {
+			// pinned regardless of the max concurrent doers, as flushing is short.
+			item.pinCount.Inc()
+			dirty = append(dirty, item)
+		}
+	}
+	c.rwlock.RUnlock()
+
+	for _, item := range dirty {
+		c.tryFlush(item)
+		c.Unpin(item.key)
+	}
+}
+
+// Close stops the background routines, and flushes the remaining dirty items.
+func (c *lruCache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		if c.closeCh != nil {
+			close(c.closeCh)
+			c.wg.Wait()
+		}
+		if c.flush != nil {
+			c.flushDirty()
+		}
+		c.WaitFinalizers(context.Background())
+	})
+}
+
+func (c *lruCache[K, V]) WaitFinalizers(ctx context.Context) error {
+	if c.asyncFinalizer == nil {
+		return nil
 	}
+	return c.asyncFinalizer.Wait(ctx)
 }
 
 func (c *lruCache[K, V]) MarkItemNeedReload(ctx context.Context, key K) bool {
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -145,18 +147,950 @@ func TestLRUCache(t *testing.T) {
 		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
 	})
 
+	t.Run("test no loader", func(t *testing.T) {
+		// the miss fails fast without waiting for space, even if the cache is full.
+		cache := NewCacheBuilder[int, int]().WithCapacity(0).Build()
+		missing, err := cache.Do(context.Background(), 0, func(_ context.Context, v int) error {
+			return nil
+		})
+		assert.True(t, missing)
+		assert.ErrorIs(t, err, ErrNoLoader)
+		assert.False(t, errors.Is(err, ErrNoSuchItem))
+		assert.False(t, errors.Is(ErrNoSuchItem, ErrNoLoader))
+	})
+
 	t.Run("test reloader", func(t *testing.T) {
 		cache := cacheBuilder.WithReloader(func(ctx context.Context, key int) (int, error) {
 			return -key, nil
 		}).Build()
-		_, err := cache.Do(context.Background(), 1, func(_ context.Context, i int) error { return nil })
+		_, err := cache.Do(context.Background(), 1, func(_ context.Context, i int) error { return nil })
+		assert.NoError(t, err)
+		exist := cache.MarkItemNeedReload(context.Background(), 1)
+		assert.True(t, exist)
+		cache.Do(context.Background(), 1, func(_ context.Context, i int) error {
+			assert.Equal(t, -1, i)
+			return nil
+		})
+	})
+
+	t.Run("test revalidator", func(t *testing.T) {
+		version := atomic.NewInt64(0)
+		// the value is the version when loaded.
+		cache := NewCacheBuilder[int, int64]().WithLoader(func(ctx context.Context, key int) (int64, error) {
+			return version.Load(), nil
+		}).WithCapacity(10).WithRevalidator(10*time.Millisecond, func(key int, value int64) bool {
+			return key%2 == 0 || value == version.Load()
+		}).Build()
+		defer cache.Close()
+		for i := 0; i < 4; i++ {
+			_, err := cache.Do(context.Background(), i, func(_ context.Context, v int64) error { return nil })
+			assert.NoError(t, err)
+		}
+
+		// key 1 and 3 are outdated, key 3 is kept while pinned.
+		version.Inc()
+		unpin := make(chan struct{})
+		pinned := make(chan struct{})
+		go cache.Do(context.Background(), 3, func(_ context.Context, v int64) error {
+			close(pinned)
+			<-unpin
+			return nil
+		})
+		<-pinned
+		assert.Eventually(t, func() bool {
+			return cache.Stats().EvictionCount.Load() == 1
+		}, time.Second, 10*time.Millisecond)
+		close(unpin)
+		assert.Eventually(t, func() bool {
+			return cache.Stats().EvictionCount.Load() == 2
+		}, time.Second, 10*time.Millisecond)
+
+		missing, err := cache.Do(context.Background(), 0, func(_ context.Context, v int64) error { return nil })
+		assert.NoError(t, err)
+		assert.False(t, missing)
+		missing, err = cache.Do(context.Background(), 1, func(_ context.Context, v int64) error {
+			assert.Equal(t, int64(1), v)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, missing)
+	})
+
+	t.Run("test value weight", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := NewCacheBuilder[int, string]().WithLoader(func(ctx context.Context, key int) (string, error) {
+			return strings.Repeat("x", key), nil
+		}).WithFinalizer(func(ctx context.Context, key int, value string) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).WithCapacity(10).WithValueWeight(func(key int, value string) int64 {
+			return int64(len(value))
+		}).Build()
+		defer cache.Close()
+		scavenger := cache.(*lruCache[int, string]).scavenger.(*LazyScavenger[int])
+
+		for _, key := range []int{3, 7} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v string) error { return nil })
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int64(10), scavenger.Size())
+
+		// key 5 fits the estimated weight by evicting key 3, but needs key 7 evicted for the actual weight.
+		_, err := cache.Do(context.Background(), 5, func(_ context.Context, v string) error { return nil })
+		assert.NoError(t, err)
+		assert.Equal(t, []int{3, 7}, finalizeSeq)
+		assert.Equal(t, int64(5), scavenger.Size())
+
+		// key 11 never fits, and the loaded value is released.
+		ctx, cancel := contextutil.WithTimeoutCause(context.Background(), 100*time.Millisecond, errTimeout)
+		defer cancel()
+		_, err = cache.Do(ctx, 11, func(_ context.Context, v string) error { return nil })
+		assert.ErrorIs(t, err, errTimeout)
+		assert.Equal(t, 11, finalizeSeq[len(finalizeSeq)-1])
+		assert.Equal(t, int64(5), scavenger.Size())
+		missing, err := cache.Do(context.Background(), 5, func(_ context.Context, v string) error { return nil })
+		assert.NoError(t, err)
+		assert.False(t, missing)
+	})
+
+	t.Run("test spare reweighed entry", func(t *testing.T) {
+		scavenger := NewLazyScavenger(func(key int) int64 { return int64(key) }, 10)
+		ok, _ := scavenger.Collect(2)
+		assert.True(t, ok)
+		ok, _ = scavenger.Reweigh(2, 8)
+		assert.True(t, ok)
+
+		// evicting key 2 frees its actual weight rather than the estimated one.
+		collector := scavenger.Spare(2)
+		assert.True(t, collector(6))
+		assert.True(t, collector(4))
+		assert.False(t, collector(1))
+	})
+
+	t.Run("test group guarantees", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(4).WithFinalizer(func(ctx context.Context, key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).WithGroupGuarantees(func(key int) string {
+			if key < 100 {
+				return "a"
+			}
+			return "b"
+		}, map[string]int64{"a": 2}).Build()
+
+		for _, key := range []int{1, 2, 100, 101, 102, 103, 104} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		// group b can only evict itself since group a doesn't exceed its guarantee.
+		assert.Equal(t, []int{100, 101, 102}, finalizeSeq)
+		for _, key := range []int{1, 2} {
+			missing, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+			assert.False(t, missing)
+		}
+
+		// group a can borrow the capacity of group b.
+		for _, key := range []int{3, 4} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, []int{100, 101, 102, 103, 104}, finalizeSeq)
+	})
+
+	t.Run("test soft capacity", func(t *testing.T) {
+		finalized := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithFinalizer(func(ctx context.Context, key, value int) error {
+			finalized.Inc()
+			return nil
+		}).WithSoftCapacity(2, 4).Build()
+		defer cache.Close()
+
+		// pinned item is not reclaimed.
+		pinned := make(chan struct{})
+		released := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			cache.Do(context.Background(), 0, func(_ context.Context, v int) error {
+				close(pinned)
+				<-released
+				return nil
+			})
+		}()
+		<-pinned
+		for key := 1; key <= 3; key++ {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		assert.Eventually(t, func() bool {
+			return finalized.Load() == 2
+		}, time.Second, 10*time.Millisecond)
+		missing, err := cache.Do(context.Background(), 3, func(_ context.Context, v int) error { return nil })
+		assert.NoError(t, err)
+		assert.False(t, missing)
+
+		// reclaimed once unpinned.
+		close(released)
+		<-done
+		_, err = cache.Do(context.Background(), 4, func(_ context.Context, v int) error { return nil })
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return finalized.Load() == 3
+		}, time.Second, 10*time.Millisecond)
+		missing, err = cache.Do(context.Background(), 0, func(_ context.Context, v int) error { return nil })
+		assert.NoError(t, err)
+		assert.True(t, missing)
+	})
+
+	t.Run("test next victims", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := cacheBuilder.WithCapacity(4).WithFinalizer(func(ctx context.Context, key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+		for _, key := range []int{1, 2, 3, 4} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		// touch 1 to make it the most recently used.
+		_, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+			assert.Equal(t, []int{2, 3, 4}, cache.NextVictims(4))
+			return nil
+		})
+		assert.NoError(t, err)
+		victims := cache.NextVictims(2)
+		assert.Equal(t, []int{2, 3}, victims)
+
+		// the preview matches the actual eviction order.
+		for _, key := range []int{5, 6} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, victims, finalizeSeq)
+	})
+
+	t.Run("test top by weight", func(t *testing.T) {
+		cache := NewCacheBuilder[int, string]().WithLoader(func(ctx context.Context, key int) (string, error) {
+			return strings.Repeat("x", key%10), nil
+		}).WithLazyScavenger(func(key int) int64 {
+			return int64(key % 10)
+		}, 100).Build()
+		for _, key := range []int{1, 3, 2, 13} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v string) error { return nil })
+			assert.NoError(t, err)
+		}
+		// 13 is more recently used than 3.
+		assert.Equal(t, []KeyWeight[int]{{Key: 13, Weight: 3}, {Key: 3, Weight: 3}, {Key: 2, Weight: 2}}, cache.TopByWeight(3))
+		assert.Len(t, cache.TopByWeight(10), 4)
+		assert.Empty(t, cache.TopByWeight(0))
+
+		// the weight measured by value is reported.
+		cache = NewCacheBuilder[int, string]().WithLoader(func(ctx context.Context, key int) (string, error) {
+			return strings.Repeat("x", key), nil
+		}).WithCapacity(100).WithValueWeight(func(key int, value string) int64 {
+			return int64(len(value))
+		}).Build()
+		for _, key := range []int{5, 20, 7} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v string) error { return nil })
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, []KeyWeight[int]{{Key: 20, Weight: 20}, {Key: 7, Weight: 7}}, cache.TopByWeight(2))
+	})
+
+	t.Run("test materialize", func(t *testing.T) {
+		cache := NewCacheBuilder[int, *string]().WithLoader(func(ctx context.Context, key int) (*string, error) {
+			v := fmt.Sprint(key)
+			return &v, nil
+		}).WithCapacity(2).Build()
+		assert.Empty(t, cache.Materialize())
+
+		var third *string
+		for _, key := range []int{1, 2, 3} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v *string) error {
+				third = v
+				return nil
+			})
+			assert.NoError(t, err)
+		}
+		entries := cache.Materialize()
+		assert.Len(t, entries, 2)
+		assert.NotContains(t, entries, 1)
+		assert.Equal(t, "2", *entries[2])
+		// values are shared rather than copied.
+		assert.Same(t, third, entries[3])
+		// the pins taken while copying are released.
+		assert.Equal(t, []int{2, 3}, cache.NextVictims(2))
+	})
+
+	t.Run("test deferred promotion", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(3).WithDeferredPromotion().WithFinalizer(func(ctx context.Context, key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+		for _, key := range []int{1, 2, 3} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		// hit 1, it's moved to front before eviction.
+		missing, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+			assert.Equal(t, 1, v)
+			return nil
+		})
+		assert.False(t, missing)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{2, 3, 1}, cache.NextVictims(3))
+
+		for _, key := range []int{4, 5} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, []int{2, 3}, finalizeSeq)
+	})
+
+	t.Run("test memory pressure", func(t *testing.T) {
+		pressure := &memoryPressure{}
+		finalized := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(10).WithMemoryPressure(pressure).WithFinalizer(func(ctx context.Context, key, value int) error {
+			finalized.Inc()
+			return nil
+		}).Build()
+		defer cache.Close()
+		for _, key := range []int{1, 2, 3} {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+
+		// under pressure, the missed value is passed through and finalized after use.
+		pressure.Store(true)
+		missing, err := cache.Do(context.Background(), 4, func(_ context.Context, v int) error {
+			assert.Equal(t, 4, v)
+			return nil
+		})
+		assert.True(t, missing)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), cache.Stats().PassThroughCount.Load())
+		// the cache shrinks in background.
+		assert.Eventually(t, func() bool {
+			return finalized.Load() == 4
+		}, time.Second, 10*time.Millisecond)
+		assert.Empty(t, cache.NextVictims(10))
+
+		// admitted again once the pressure is relieved.
+		pressure.Store(false)
+		for i := 0; i < 2; i++ {
+			missing, err = cache.Do(context.Background(), 4, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+			assert.Equal(t, i == 0, missing)
+		}
+	})
+
+	t.Run("test write back", func(t *testing.T) {
+		var mu sync.Mutex
+		store := make(map[int]int64)
+		failing := atomic.NewBool(false)
+		flush := func(key int, value *atomic.Int64) error {
+			if failing.Load() {
+				return errors.New("mock flush failure")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			store[key] = value.Load()
+			return nil
+		}
+		flushed := func(key int) int64 {
+			mu.Lock()
+			defer mu.Unlock()
+			return store[key]
+		}
+		loader := func(ctx context.Context, key int) (*atomic.Int64, error) {
+			return atomic.NewInt64(int64(key)), nil
+		}
+		update := func(cache Cache[int, *atomic.Int64], key int, value int64) {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v *atomic.Int64) error {
+				v.Store(value)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.True(t, cache.Dirty(key))
+		}
+
+		// the dirty entries are flushed in background.
+		cache := NewCacheBuilder[int, *atomic.Int64]().WithLoader(loader).WithCapacity(10).
+			WithWriteBack(flush, 10*time.Millisecond).Build()
+		assert.False(t, cache.Dirty(1))
+		update(cache, 1, 100)
+		assert.Eventually(t, func() bool {
+			return flushed(1) == 100
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, uint64(1), cache.Stats().FlushCount.Load())
+		cache.Close()
+		store = make(map[int]int64)
+
+		// the dirty entry is flushed before eviction, and kept if it fails to flush.
+		cache = NewCacheBuilder[int, *atomic.Int64]().WithLoader(loader).WithCapacity(2).
+			WithWriteBack(flush, 0).Build()
+		update(cache, 1, 101)
+		_, err := cache.Do(context.Background(), 2, func(_ context.Context, v *atomic.Int64) error { return nil })
+		assert.NoError(t, err)
+		failing.Store(true)
+		_, err = cache.Do(context.Background(), 3, func(_ context.Context, v *atomic.Int64) error { return nil })
+		assert.NoError(t, err)
+		// the dirty entry is only pinned while the flusher retries it.
+		assert.Eventually(t, func() bool {
+			return assert.ObjectsAreEqual([]int{1, 3}, cache.NextVictims(2))
+		}, time.Second, 10*time.Millisecond)
+		assert.Error(t, cache.Remove(context.Background(), 1))
+		assert.Less(t, uint64(0), cache.Stats().FlushFailCount.Load())
+		// there is no room if all the entries are dirty.
+		update(cache, 3, 103)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = cache.Do(ctx, 4, func(_ context.Context, v *atomic.Int64) error { return nil })
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), flushed(1))
+
+		failing.Store(false)
+		_, err = cache.Do(context.Background(), 4, func(_ context.Context, v *atomic.Int64) error { return nil })
+		assert.NoError(t, err)
+		assert.Equal(t, int64(101), flushed(1))
+		// the remaining dirty entries are flushed on close.
+		update(cache, 3, 104)
+		cache.Close()
+		assert.Equal(t, int64(104), flushed(3))
+
+		// the slow flush doesn't block the hits.
+		release := make(chan struct{})
+		flushing := atomic.NewInt32(0)
+		slowFlush := func(key int, value *atomic.Int64) error {
+			flushing.Inc()
+			<-release
+			return flush(key, value)
+		}
+		cache = NewCacheBuilder[int, *atomic.Int64]().WithLoader(loader).WithCapacity(2).
+			WithWriteBack(slowFlush, 0).Build()
+		update(cache, 1, 201)
+		update(cache, 2, 202)
+		loaded := make(chan struct{})
+		go func() {
+			defer close(loaded)
+			_, err := cache.Do(context.Background(), 3, func(_ context.Context, v *atomic.Int64) error { return nil })
+			assert.NoError(t, err)
+		}()
+		assert.Eventually(t, func() bool {
+			return flushing.Load() > 0
+		}, time.Second, 10*time.Millisecond)
+		_, err = cache.Do(context.Background(), 2, func(_ context.Context, v *atomic.Int64) error { return nil })
+		assert.NoError(t, err)
+		close(release)
+		<-loaded
+		assert.Equal(t, int64(201), flushed(1))
+		cache.Close()
+	})
+
+	t.Run("test stale dirty item failing to flush", func(t *testing.T) {
+		version := atomic.NewUint64(0)
+		flushes := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(2).WithVersionSource(version.Load, 0).WithWriteBack(func(key int, value int) error {
+			flushes.Inc()
+			return errors.New("mock flush failure")
+		}, 0).Build()
+		defer cache.Close()
+
+		_, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error { return nil })
+		assert.NoError(t, err)
+		assert.True(t, cache.Dirty(1))
+		version.Store(1)
+
+		// the caller fails instead of flushing the stale item again and again.
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, err = cache.Do(ctx, 1, func(_ context.Context, v int) error { return nil })
+		assert.ErrorIs(t, err, merr.ErrServiceInternal)
+		assert.NoError(t, ctx.Err())
+		assert.Equal(t, int32(1), flushes.Load())
+		assert.True(t, cache.Dirty(1))
+	})
+
+	t.Run("test gc hint", func(t *testing.T) {
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithLazyScavenger(func(key int) int64 {
+			return 10
+		}, 30).WithGCHint(50, time.Hour).Build()
+		hints := atomic.NewInt32(0)
+		cache.(*lruCache[int, int]).freeOSMemory = func() {
+			hints.Inc()
+		}
+		defer cache.Close()
+
+		doKeys := func(from, to int) {
+			for i := from; i < to; i++ {
+				_, err := cache.Do(context.Background(), i, func(_ context.Context, v int) error { return nil })
+				assert.NoError(t, err)
+			}
+		}
+		// 4 entries evicted, below the threshold.
+		doKeys(0, 7)
+		assert.Equal(t, uint64(40), cache.Stats().EvictedWeight.Load())
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(0), hints.Load())
+
+		doKeys(7, 8)
+		assert.Eventually(t, func() bool {
+			return hints.Load() == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, uint64(1), cache.Stats().GCHintCount.Load())
+
+		// the next hint is rate limited, even if the threshold is crossed again.
+		doKeys(8, 20)
+		assert.Equal(t, uint64(170), cache.Stats().EvictedWeight.Load())
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(1), hints.Load())
+	})
+
+	t.Run("test eviction hysteresis", func(t *testing.T) {
+		loads := make(map[int]int)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			loads[key]++
+			return key, nil
+		}).WithCapacity(2).WithEvictionHysteresis(time.Hour).Build()
+		defer cache.Close()
+
+		doKeys := func(keys ...int) {
+			for _, key := range keys {
+				_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+				assert.NoError(t, err)
+			}
+		}
+		// 1 is evicted by 3, and readmitted soon.
+		doKeys(1, 2, 3, 1)
+		assert.Equal(t, uint64(1), cache.Stats().ReadmissionCount.Load())
+		// 1 is the least recently used, but protected.
+		doKeys(3)
+		assert.Equal(t, []int{3, 1}, cache.NextVictims(2))
+		doKeys(4, 1)
+		assert.Equal(t, 2, loads[1])
+		assert.Equal(t, 1, loads[4])
+
+		// the protected entries are evicted if there is no other way to make room.
+		doKeys(3)
+		assert.Equal(t, uint64(2), cache.Stats().ReadmissionCount.Load())
+		doKeys(5)
+		assert.Equal(t, uint64(5), cache.Stats().EvictionCount.Load())
+		assert.Equal(t, []int{5, 3}, cache.NextVictims(2))
+	})
+
+	t.Run("test clock", func(t *testing.T) {
+		clock := newManualClock()
+		stale := atomic.NewBool(false)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(2).WithEvictionHysteresis(time.Minute).WithRevalidator(time.Hour, func(key, value int) bool {
+			return key != 2 || !stale.Load()
+		}).WithClock(clock).Build()
+		defer cache.Close()
+
+		doKeys := func(keys ...int) {
+			for _, key := range keys {
+				_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+				assert.NoError(t, err)
+			}
+		}
+		doKeys(1, 2, 3, 1)
+		assert.Equal(t, uint64(1), cache.Stats().ReadmissionCount.Load())
+		// the protection of 1 expires with the clock.
+		clock.Advance(2 * time.Minute)
+		doKeys(3)
+		assert.Equal(t, []int{1, 3}, cache.NextVictims(2))
+		// 2 is evicted by 1 long ago, it's not a readmission.
+		doKeys(2)
+		assert.Equal(t, uint64(1), cache.Stats().ReadmissionCount.Load())
+
+		// the revalidator runs on the ticks of clock.
+		stale.Store(true)
+		assert.Eventually(t, func() bool {
+			clock.Advance(time.Hour)
+			return len(cache.NextVictims(2)) == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []int{3}, cache.NextVictims(2))
+	})
+
+	t.Run("test sampled eviction", func(t *testing.T) {
+		size := 10
+		finalizeSeq := make([]int, 0)
+		// sampling all the entries is exactly LRU.
+		cache := cacheBuilder.WithCapacity(int64(size)).WithFinalizer(func(ctx context.Context, key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).WithSampledEviction(size).Build()
+		defer cache.Close()
+
+		doKeys := func(keys ...int) {
+			for _, key := range keys {
+				_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+				assert.NoError(t, err)
+			}
+		}
+		for i := 0; i < size; i++ {
+			doKeys(i)
+		}
+		doKeys(0, 1)
+		doKeys(10, 11, 12)
+		assert.Equal(t, []int{2, 3, 4}, finalizeSeq)
+
+		// the pinned entries are never sampled.
+		cache = NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(2).WithSampledEviction(1).Build()
+		defer cache.Close()
+		_, err := cache.Do(context.Background(), 1, func(ctx context.Context, _ int) error {
+			for i := 2; i < 10; i++ {
+				_, err := cache.Do(ctx, i, func(_ context.Context, v int) error { return nil })
+				assert.NoError(t, err)
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []int{1, 9}, cache.NextVictims(2))
+	})
+
+	t.Run("test async finalizer", func(t *testing.T) {
+		release := make(chan struct{})
+		finalized := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithFinalizer(func(ctx context.Context, key, value int) error {
+			<-release
+			finalized.Inc()
+			return nil
+		}).WithCapacity(1).WithAsyncFinalizer(2).Build()
+
+		// the evictions don't wait for the finalizers.
+		for i := 0; i < 5; i++ {
+			_, err := cache.Do(context.Background(), i, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, cache.WaitFinalizers(ctx), context.DeadlineExceeded)
+		assert.Equal(t, int32(0), finalized.Load())
+
+		close(release)
+		assert.NoError(t, cache.WaitFinalizers(context.Background()))
+		assert.Equal(t, int32(4), finalized.Load())
+
+		// close waits for the finalizers of the removed entries.
+		assert.NoError(t, cache.Remove(context.Background(), 4))
+		cache.Close()
+		assert.Equal(t, int32(5), finalized.Load())
+	})
+
+	t.Run("test negative cache", func(t *testing.T) {
+		clock := newManualClock()
+		loads := atomic.NewInt32(0)
+		exists := atomic.NewBool(false)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			loads.Inc()
+			if key < 0 && !exists.Load() {
+				return 0, errors.Wrap(ErrNoSuchItem, "not found")
+			}
+			if key == 100 {
+				return 0, merr.ErrParameterInvalid
+			}
+			return key, nil
+		}).WithCapacity(2).WithNegativeCache(2, time.Minute).WithClock(clock).Build()
+		defer cache.Close()
+
+		doKey := func(key int) error {
+			_, err := cache.Do(context.Background(), key, func(_ context.Context, v int) error { return nil })
+			return err
+		}
+		assert.NoError(t, doKey(1))
+		assert.NoError(t, doKey(2))
+		// the tombstones neither evict the values nor invoke the loader again.
+		assert.True(t, isNoSuchItem(doKey(-1)))
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, ErrNoSuchItem, doKey(-1))
+		}
+		assert.Equal(t, int32(3), loads.Load())
+		assert.Equal(t, uint64(2), cache.Stats().NegativeHitCount.Load())
+		// the negative hits are counted as misses.
+		assert.Equal(t, uint64(5), cache.Stats().MissCount.Load())
+		assert.Equal(t, uint64(0), cache.Stats().HitCount.Load())
+		assert.ElementsMatch(t, []int{1, 2}, cache.NextVictims(2))
+
+		// the other failures are not remembered.
+		assert.Error(t, doKey(100))
+		assert.Error(t, doKey(100))
+		assert.Equal(t, int32(5), loads.Load())
+
+		// the tombstones are bounded apart from the values, -1 is evicted by -2 and -3.
+		assert.Error(t, doKey(-2))
+		assert.Error(t, doKey(-3))
+		assert.Error(t, doKey(-1))
+		assert.Equal(t, int32(8), loads.Load())
+
+		// the tombstone expires with the clock, and is cleared by the provided value.
+		clock.Advance(2 * time.Minute)
+		assert.Error(t, doKey(-3))
+		assert.Equal(t, int32(9), loads.Load())
+		provided, err := cache.Provide(context.Background(), -1, -1)
+		assert.NoError(t, err)
+		assert.True(t, provided)
+		assert.NoError(t, doKey(-1))
+
+		// or by the loaded value after removal.
+		exists.Store(true)
+		assert.NoError(t, cache.Remove(context.Background(), -3))
+		assert.NoError(t, doKey(-3))
+		assert.Equal(t, int32(10), loads.Load())
+	})
+
+	t.Run("test do observer", func(t *testing.T) {
+		clock := newManualClock()
+		observed := make(map[int][]DoPhases)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			clock.Advance(3 * time.Second)
+			if key < 0 {
+				return 0, merr.ErrParameterInvalid
+			}
+			return key, nil
+		}).WithCapacity(2).WithClock(clock).WithDoObserver(func(key int, phases DoPhases) {
+			observed[key] = append(observed[key], phases)
+		}).Build()
+		defer cache.Close()
+
+		doer := func(_ context.Context, v int) error {
+			clock.Advance(2 * time.Second)
+			return nil
+		}
+		// miss and then hit.
+		for i := 0; i < 2; i++ {
+			_, err := cache.Do(context.Background(), 1, doer)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, []DoPhases{
+			{LoadDuration: 3 * time.Second, DoerDuration: 2 * time.Second},
+			{DoerDuration: 2 * time.Second},
+		}, observed[1])
+
+		// the failed calls are observed as well.
+		_, err := cache.Do(context.Background(), -1, doer)
+		assert.Error(t, err)
+		assert.Equal(t, []DoPhases{{LoadDuration: 3 * time.Second}}, observed[-1])
+	})
+
+	t.Run("test version source", func(t *testing.T) {
+		version := atomic.NewUint64(0)
+		loads := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, uint64]().WithLoader(func(ctx context.Context, key int) (uint64, error) {
+			loads.Inc()
+			return version.Load(), nil
+		}).WithCapacity(2).WithVersionSource(version.Load, 1).Build()
+		defer cache.Close()
+
+		loaded := func(expected uint64) {
+			_, err := cache.Do(context.Background(), 1, func(_ context.Context, v uint64) error {
+				assert.Equal(t, expected, v)
+				return nil
+			})
+			assert.NoError(t, err)
+		}
+		loaded(0)
+		// one version stale is still served.
+		version.Store(1)
+		loaded(0)
+		assert.Equal(t, int32(1), loads.Load())
+		// two versions stale is reloaded.
+		version.Store(2)
+		loaded(2)
+		assert.Equal(t, int32(2), loads.Load())
+		assert.Equal(t, uint64(1), cache.Stats().StaleCount.Load())
+		assert.Equal(t, uint64(1), cache.Stats().EvictionCount.Load())
+
+		// the stale item pinned by others is reloaded once unpinned.
+		pinned := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			cache.Do(context.Background(), 1, func(_ context.Context, v uint64) error {
+				close(pinned)
+				<-release
+				return nil
+			})
+		}()
+		<-pinned
+		version.Store(4)
+		result := make(chan uint64, 1)
+		go func() {
+			cache.Do(context.Background(), 1, func(_ context.Context, v uint64) error {
+				result <- v
+				return nil
+			})
+		}()
+		select {
+		case <-result:
+			t.Fatal("the stale item is served")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		<-done
+		assert.Equal(t, uint64(4), <-result)
+		assert.Equal(t, int32(3), loads.Load())
+	})
+
+	t.Run("test try do", func(t *testing.T) {
+		loads := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			loads.Inc()
+			return key, nil
+		}).WithCapacity(2).WithMaxConcurrentDoers(1).Build()
+		defer cache.Close()
+
+		// miss without loading.
+		hit, err := cache.TryDo(1, func(v int) error {
+			t.Fatal("doer on missing key")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, hit)
+		assert.Equal(t, int32(0), loads.Load())
+
+		_, err = cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+			// the item pinned by the max concurrent doers is not waited for.
+			hit, err := cache.TryDo(1, func(v int) error {
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.False(t, hit)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		hit, err = cache.TryDo(1, func(v int) error {
+			assert.Equal(t, 1, v)
+			return merr.ErrParameterInvalid
+		})
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+		assert.True(t, hit)
+		assert.Equal(t, int32(1), loads.Load())
+		// the hit is promoted in the victim preview as in eviction, even without deferred promotion.
+		_, err = cache.Do(context.Background(), 2, func(_ context.Context, v int) error {
+			return nil
+		})
+		assert.NoError(t, err)
+		hit, err = cache.TryDo(1, func(v int) error {
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, hit)
+		assert.Equal(t, []int{2, 1}, cache.NextVictims(2))
+		// the item is unpinned, so it's evictable.
+		assert.NoError(t, cache.Remove(context.Background(), 1))
+	})
+
+	t.Run("test value validator", func(t *testing.T) {
+		errEmpty := errors.New("empty value")
+		loads := atomic.NewInt32(0)
+		finalized := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			loads.Inc()
+			return key, nil
+		}).WithReloader(func(ctx context.Context, key int) (int, error) {
+			return 0, nil
+		}).WithFinalizer(func(ctx context.Context, key int, value int) error {
+			finalized.Inc()
+			return nil
+		}).WithCapacity(2).WithValueValidator(func(v int) error {
+			if v == 0 {
+				return errEmpty
+			}
+			return nil
+		}).Build()
+		defer cache.Close()
+
+		// the invalid value is neither admitted nor finalized.
+		for i := 0; i < 2; i++ {
+			_, err := cache.Do(context.Background(), 0, func(_ context.Context, v int) error {
+				t.Fatal("doer on invalid value")
+				return nil
+			})
+			assert.ErrorIs(t, err, errEmpty)
+			assert.ErrorContains(t, err, "invalid value loaded for key 0")
+		}
+		assert.Equal(t, int32(2), loads.Load())
+		assert.Equal(t, uint64(2), cache.Stats().LoadFailCount.Load())
+		assert.Equal(t, int32(0), finalized.Load())
+
+		// the previous value is kept if the reloaded one is invalid.
+		_, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+			return nil
+		})
 		assert.NoError(t, err)
-		exist := cache.MarkItemNeedReload(context.Background(), 1)
-		assert.True(t, exist)
-		cache.Do(context.Background(), 1, func(_ context.Context, i int) error {
-			assert.Equal(t, -1, i)
+		assert.True(t, cache.MarkItemNeedReload(context.Background(), 1))
+		_, err = cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+			assert.Equal(t, 1, v)
 			return nil
 		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("test provide", func(t *testing.T) {
+		// the value of key 2 is only available by a side channel.
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			if key == 2 {
+				return 0, ErrNotEnoughSpace
+			}
+			return key, nil
+		}).WithCapacity(2).Build()
+		defer cache.Close()
+
+		provided, err := cache.Provide(context.Background(), 1, 100)
+		assert.NoError(t, err)
+		assert.True(t, provided)
+		// the resident value is kept.
+		provided, err = cache.Provide(context.Background(), 1, 101)
+		assert.NoError(t, err)
+		assert.False(t, provided)
+
+		// the waiter is satisfied by the provided value.
+		done := make(chan int)
+		go func() {
+			cache.Do(context.Background(), 2, func(_ context.Context, v int) error {
+				done <- v
+				return nil
+			})
+		}()
+		provided, err = cache.Provide(context.Background(), 2, 200)
+		assert.NoError(t, err)
+		assert.True(t, provided)
+		assert.Equal(t, 200, <-done)
+		assert.Equal(t, uint64(2), cache.Stats().ProvideCount.Load())
+
+		// no room while all the entries are pinned.
+		_, err = cache.Do(context.Background(), 1, func(ctx context.Context, v int) error {
+			assert.Equal(t, 100, v)
+			_, err := cache.Do(ctx, 2, func(ctx context.Context, _ int) error {
+				_, err := cache.Provide(ctx, 3, 300)
+				return err
+			})
+			return err
+		})
+		assert.ErrorIs(t, err, ErrNotEnoughSpace)
 	})
 
 	t.Run("test mark", func(t *testing.T) {
@@ -230,6 +1164,75 @@ func TestStats(t *testing.T) {
 		assert.Equal(t, uint64(size*2), stats.LoadSuccessCount.Load())
 		assert.Equal(t, uint64(0), stats.LoadFailCount.Load())
 	})
+	t.Run("test load dedups", func(t *testing.T) {
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			time.Sleep(100 * time.Millisecond)
+			return key, nil
+		}).WithCapacity(10).Build()
+		stats := cache.Stats()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, uint64(1), stats.LoadSuccessCount.Load())
+		assert.Equal(t, stats.MissCount.Load(), stats.LoadSuccessCount.Load()+stats.LoadDedups.Load())
+		assert.Equal(t, uint64(10), stats.HitCount.Load()+stats.MissCount.Load())
+	})
+	t.Run("test load outcome", func(t *testing.T) {
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			if key < 0 {
+				return 0, ErrNoSuchItem
+			}
+			time.Sleep(100 * time.Millisecond)
+			return key, nil
+		}).WithCapacity(10).Build()
+		stats := cache.Stats()
+
+		var wg sync.WaitGroup
+		outcomes := make([]LoadOutcome, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				var err error
+				outcomes[i], err = cache.DoWithOutcome(context.Background(), 1, func(_ context.Context, v int) error {
+					return nil
+				})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+		counts := make(map[LoadOutcome]int)
+		for _, outcome := range outcomes {
+			counts[outcome]++
+		}
+		// only one caller loads the value, the others either join the in-flight load or hit the loaded value.
+		assert.Equal(t, 1, counts[LoadOutcomeLoaded])
+		assert.Equal(t, int(stats.LoadDedups.Load()), counts[LoadOutcomeJoined])
+		assert.Equal(t, 9, counts[LoadOutcomeJoined]+counts[LoadOutcomeHit])
+
+		outcome, err := cache.DoWithOutcome(context.Background(), 1, func(_ context.Context, v int) error {
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, LoadOutcomeHit, outcome)
+
+		outcome, err = cache.DoWithOutcome(context.Background(), -1, func(_ context.Context, v int) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrNoSuchItem)
+		assert.Equal(t, LoadOutcomeMissed, outcome)
+		assert.Equal(t, "missed", outcome.String())
+	})
 }
 
 func TestLRUCacheConcurrency(t *testing.T) {
@@ -286,6 +1289,61 @@ func TestLRUCacheConcurrency(t *testing.T) {
 		assert.ErrorIs(t, context.Cause(ctx), errTimeout)
 	})
 
+	t.Run("test capacity exceeded handler", func(t *testing.T) {
+		type exceeded struct {
+			key         int
+			pinnedCount int
+			size        int64
+			capacity    int64
+		}
+		exceededCh := make(chan exceeded, 10)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(1).WithCapacityExceededHandler(func(key int, pinnedCount int, size, capacity int64) {
+			exceededCh <- exceeded{key, pinnedCount, size, capacity}
+		}).Build()
+
+		_, err := cache.Do(context.Background(), 1000, func(_ context.Context, v int) error {
+			// 1000 is pinned, no room for 1001.
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			_, err := cache.Do(ctx, 1001, func(_ context.Context, v int) error { return nil })
+			assert.Error(t, err)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, exceeded{1001, 1, 1, 1}, <-exceededCh)
+	})
+
+	t.Run("test pin hold tracking", func(t *testing.T) {
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(2).WithPinHoldTracking().Build()
+
+		_, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+		assert.NoError(t, err)
+		_, err = cache.Do(context.Background(), 1, func(_ context.Context, v int) error { return nil })
+		assert.NoError(t, err)
+
+		hist := &cache.Stats().PinHoldDuration
+		assert.Equal(t, uint64(2), hist.Count())
+		assert.GreaterOrEqual(t, hist.Mean(), 10*time.Millisecond)
+		buckets := hist.Buckets()
+		assert.Len(t, buckets, len(DurationBuckets)+1)
+		assert.Equal(t, uint64(1), buckets[2])
+
+		// not recorded if not enabled.
+		cache = NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}).WithCapacity(2).Build()
+		_, err = cache.Do(context.Background(), 1, func(_ context.Context, v int) error { return nil })
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), cache.Stats().PinHoldDuration.Count())
+	})
+
 	t.Run("test time out", func(t *testing.T) {
 		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
 			return key, nil
@@ -475,4 +1533,503 @@ func TestLRUCacheConcurrency(t *testing.T) {
 		}
 		assert.Equal(t, 5, evicted)
 	})
+
+	t.Run("test max concurrent loads", func(t *testing.T) {
+		loading := atomic.NewInt32(0)
+		maxLoading := atomic.NewInt32(0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			n := loading.Inc()
+			defer loading.Dec()
+			for {
+				m := maxLoading.Load()
+				if n <= m || maxLoading.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return key, nil
+		}).WithCapacity(100).WithMaxConcurrentLoads(2).Build()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := cache.Do(context.Background(), i, func(_ context.Context, v int) error {
+					return nil
+				})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, maxLoading.Load(), int32(2))
+
+		// loads waiting for slot respect the context.
+		block := make(chan struct{})
+		cache = NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+			<-block
+			return key, nil
+		}).WithCapacity(100).WithMaxConcurrentLoads(1).Build()
+		go cache.Do(context.Background(), 1, func(_ context.Context, v int) error { return nil })
+		time.Sleep(50 * time.Millisecond)
+		ctx, cancel := contextutil.WithTimeoutCause(context.Background(), 50*time.Millisecond, errTimeout)
+		defer cancel()
+		_, err := cache.Do(ctx, 2, func(_ context.Context, v int) error { return nil })
+		assert.ErrorIs(t, err, errTimeout)
+		close(block)
+	})
+
+	t.Run("test max concurrent doers", func(t *testing.T) {
+		for _, deferPromotion := range []bool{false, true} {
+			builder := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+				return key, nil
+			}).WithCapacity(100).WithMaxConcurrentDoers(2)
+			if deferPromotion {
+				builder = builder.WithDeferredPromotion()
+			}
+			cache := builder.Build()
+
+			doing := atomic.NewInt32(0)
+			maxDoing := atomic.NewInt32(0)
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+						n := doing.Inc()
+						defer doing.Dec()
+						for {
+							m := maxDoing.Load()
+							if n <= m || maxDoing.CompareAndSwap(m, n) {
+								break
+							}
+						}
+						time.Sleep(10 * time.Millisecond)
+						return nil
+					})
+					assert.NoError(t, err)
+				}()
+			}
+			wg.Wait()
+			assert.LessOrEqual(t, maxDoing.Load(), int32(2))
+
+			// doers waiting for the key respect the context, other keys are not affected.
+			block := make(chan struct{})
+			for i := 0; i < 2; i++ {
+				go cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+					<-block
+					return nil
+				})
+			}
+			time.Sleep(50 * time.Millisecond)
+			ctx, cancel := contextutil.WithTimeoutCause(context.Background(), 50*time.Millisecond, errTimeout)
+			_, err := cache.Do(ctx, 1, func(_ context.Context, v int) error { return nil })
+			cancel()
+			assert.ErrorIs(t, err, errTimeout)
+			_, err = cache.Do(context.Background(), 2, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+			close(block)
+			_, err = cache.Do(context.Background(), 1, func(_ context.Context, v int) error { return nil })
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestSwappableCache(t *testing.T) {
+	finalized := atomic.NewInt32(0)
+	oldCache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+		return key, nil
+	}).WithCapacity(10).WithFinalizer(func(ctx context.Context, key, value int) error {
+		finalized.Inc()
+		return nil
+	}).Build()
+	newCache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+		return -key, nil
+	}).WithCapacity(10).Build()
+
+	cache := NewSwappableCache(oldCache)
+	for i := 0; i < 5; i++ {
+		_, err := cache.Do(context.Background(), i, func(_ context.Context, v int) error {
+			assert.Equal(t, i, v)
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+
+	started := make(chan struct{})
+	blocked := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+			close(started)
+			<-blocked
+			assert.Equal(t, 1, v)
+			return nil
+		})
+	}()
+	<-started
+
+	cache.Replace(newCache)
+	_, err := cache.Do(context.Background(), 1, func(_ context.Context, v int) error {
+		assert.Equal(t, -1, v)
+		return nil
+	})
+	assert.NoError(t, err)
+	// old entries are kept until the in-flight doer is done.
+	assert.Equal(t, int32(0), finalized.Load())
+
+	close(blocked)
+	<-done
+	assert.Equal(t, int32(5), finalized.Load())
+}
+
+func TestHashedCache(t *testing.T) {
+	type key struct {
+		name  string
+		parts []int
+	}
+	// all keys collide in one bucket, and are told apart by equals.
+	hash := func(k key) uint64 { return 0 }
+	equals := func(a, b key) bool {
+		return a.name == b.name && len(a.parts) == len(b.parts) && (len(a.parts) == 0 || a.parts[0] == b.parts[0])
+	}
+
+	loaded := atomic.NewInt32(0)
+	finalizeSeq := make([]string, 0)
+	cache := NewHashedCacheBuilder[key, string]().WithLoader(func(ctx context.Context, k key) (string, error) {
+		loaded.Inc()
+		return k.name, nil
+	}).WithFinalizer(func(ctx context.Context, k key, v string) error {
+		finalizeSeq = append(finalizeSeq, v)
+		return nil
+	}).WithCapacity(2).Build(hash, equals)
+
+	for _, k := range []key{{"a", []int{1}}, {"b", []int{1}}, {"a", []int{1}}} {
+		_, err := cache.Do(context.Background(), k, func(_ context.Context, v string) error {
+			assert.Equal(t, k.name, v)
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), loaded.Load())
+	assert.Equal(t, []key{{"b", []int{1}}, {"a", []int{1}}}, cache.NextVictims(2))
+	assert.Equal(t, []KeyWeight[key]{{Key: key{"a", []int{1}}, Weight: 1}}, cache.TopByWeight(1))
+
+	// evict b to make room for c.
+	missing, err := cache.Do(context.Background(), key{"c", nil}, func(_ context.Context, v string) error { return nil })
+	assert.NoError(t, err)
+	assert.True(t, missing)
+	assert.Equal(t, []string{"b"}, finalizeSeq)
+	assert.Len(t, cache.entries, 2)
+	assert.Len(t, cache.buckets[0], 2)
+
+	assert.NoError(t, cache.Remove(context.Background(), key{"a", []int{1}}))
+	assert.NoError(t, cache.Remove(context.Background(), key{"d", nil}))
+	assert.Equal(t, []string{"b", "a"}, finalizeSeq)
+	assert.Len(t, cache.entries, 1)
+	assert.False(t, cache.MarkItemNeedReload(context.Background(), key{"a", []int{1}}))
+
+	// failed load leaves no entry.
+	failing := NewHashedCacheBuilder[key, string]().WithLoader(func(ctx context.Context, k key) (string, error) {
+		return "", merr.ErrParameterInvalid
+	}).Build(hash, equals)
+	_, err = failing.Do(context.Background(), key{"a", nil}, func(_ context.Context, v string) error { return nil })
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	assert.Empty(t, failing.entries)
+	assert.Empty(t, failing.buckets)
+}
+
+type mapValueStore struct {
+	mu   sync.Mutex
+	data map[uint64][]byte
+}
+
+func (s *mapValueStore) Put(id uint64, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[id] = data
+}
+
+func (s *mapValueStore) Get(id uint64) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[id]
+}
+
+func (s *mapValueStore) Free(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, id)
+}
+
+func (s *mapValueStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.data)
+}
+
+func TestValueStoreValidator(t *testing.T) {
+	store := &mapValueStore{data: make(map[uint64][]byte)}
+	cache := NewCacheBuilder[int, string]().WithLoader(func(ctx context.Context, key int) (string, error) {
+		if key == 0 {
+			return "", nil
+		}
+		return fmt.Sprint(key), nil
+	}).WithCapacity(2).WithValueStore(store, func(v string) ([]byte, error) {
+		return []byte(v), nil
+	}, func(data []byte) (string, error) {
+		return string(data), nil
+	}).WithValueValidator(func(v string) error {
+		if v == "" {
+			return merr.ErrParameterInvalid
+		}
+		return nil
+	}).Build()
+	defer cache.Close()
+
+	// the invalid value never takes up the store.
+	_, err := cache.Do(context.Background(), 0, func(_ context.Context, v string) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	assert.Equal(t, 0, store.Len())
+	_, err = cache.Do(context.Background(), 1, func(_ context.Context, v string) error {
+		assert.Equal(t, "1", v)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestValueStoreWriteBack(t *testing.T) {
+	store := &mapValueStore{data: make(map[uint64][]byte)}
+	var mu sync.Mutex
+	persisted := make(map[int]int64)
+	cache := NewCacheBuilder[int, *atomic.Int64]().WithLoader(func(ctx context.Context, key int) (*atomic.Int64, error) {
+		return atomic.NewInt64(int64(key)), nil
+	}).WithCapacity(2).WithValueStore(store, func(v *atomic.Int64) ([]byte, error) {
+		return []byte(fmt.Sprint(v.Load())), nil
+	}, func(data []byte) (*atomic.Int64, error) {
+		var n int64
+		_, err := fmt.Sscan(string(data), &n)
+		return atomic.NewInt64(n), err
+	}).WithWriteBack(func(key int, value *atomic.Int64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		persisted[key] = value.Load()
+		return nil
+	}, 0).Build()
+
+	// the change on the decoded copy is encoded back into store, and flushed.
+	_, err := cache.Do(context.Background(), 1, func(_ context.Context, v *atomic.Int64) error {
+		v.Store(100)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, cache.Dirty(1))
+	_, err = cache.Do(context.Background(), 1, func(_ context.Context, v *atomic.Int64) error {
+		assert.Equal(t, int64(100), v.Load())
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// the failed doer leaves the stored value as is.
+	_, err = cache.Do(context.Background(), 1, func(_ context.Context, v *atomic.Int64) error {
+		v.Store(200)
+		return merr.ErrParameterInvalid
+	})
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+
+	cache.Close()
+	assert.Equal(t, map[int]int64{1: 100}, persisted)
+}
+
+type hookedValueStore struct {
+	*mapValueStore
+	onGet func(id uint64)
+}
+
+func (s *hookedValueStore) Get(id uint64) []byte {
+	if s.onGet != nil {
+		s.onGet(id)
+	}
+	return s.mapValueStore.Get(id)
+}
+
+func TestValueStoreMaterializeEviction(t *testing.T) {
+	store := &hookedValueStore{mapValueStore: &mapValueStore{data: make(map[uint64][]byte)}}
+	cache := NewCacheBuilder[int, string]().WithLoader(func(ctx context.Context, key int) (string, error) {
+		return fmt.Sprint(key), nil
+	}).WithCapacity(2).WithValueStore(store, func(v string) ([]byte, error) {
+		return []byte(v), nil
+	}, func(data []byte) (string, error) {
+		if data == nil {
+			return "", errors.New("freed value")
+		}
+		return string(data), nil
+	}).Build()
+	defer cache.Close()
+
+	for i := 1; i <= 2; i++ {
+		_, err := cache.Do(context.Background(), i, func(_ context.Context, v string) error { return nil })
+		assert.NoError(t, err)
+	}
+
+	// the eviction started while materializing waits until the values are decoded, instead of freeing them.
+	var once sync.Once
+	evicted := make(chan struct{})
+	store.onGet = func(id uint64) {
+		once.Do(func() {
+			go func() {
+				defer close(evicted)
+				_, err := cache.Do(context.Background(), 3, func(_ context.Context, v string) error { return nil })
+				assert.NoError(t, err)
+			}()
+			select {
+			case <-evicted:
+				t.Error("the value is evicted while materializing")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+	assert.Equal(t, map[int]string{1: "1", 2: "2"}, cache.Materialize())
+	<-evicted
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestValueStore(t *testing.T) {
+	store := &mapValueStore{data: make(map[uint64][]byte)}
+	finalized := make([]string, 0)
+	cache := NewCacheBuilder[int, string]().WithLoader(func(ctx context.Context, key int) (string, error) {
+		return fmt.Sprint(key), nil
+	}).WithReloader(func(ctx context.Context, key int) (string, error) {
+		return fmt.Sprint(-key), nil
+	}).WithFinalizer(func(ctx context.Context, key int, value string) error {
+		finalized = append(finalized, value)
+		return nil
+	}).WithCapacity(2).WithValueStore(store, func(v string) ([]byte, error) {
+		return []byte(v), nil
+	}, func(data []byte) (string, error) {
+		return string(data), nil
+	}).Build()
+
+	for i := 1; i <= 2; i++ {
+		_, err := cache.Do(context.Background(), i, func(_ context.Context, v string) error {
+			assert.Equal(t, fmt.Sprint(i), v)
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, store.Len())
+	assert.Equal(t, map[int]string{1: "1", 2: "2"}, cache.Materialize())
+
+	// the reloaded value replaces the previous one in store.
+	assert.True(t, cache.MarkItemNeedReload(context.Background(), 1))
+	_, err := cache.Do(context.Background(), 1, func(_ context.Context, v string) error {
+		assert.Equal(t, "-1", v)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	// the evicted value is decoded for finalizer and freed from store.
+	_, err = cache.Do(context.Background(), 3, func(_ context.Context, v string) error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, finalized)
+	assert.Equal(t, 2, store.Len())
+	assert.NoError(t, cache.Remove(context.Background(), 1))
+	assert.Equal(t, []string{"2", "-1"}, finalized)
+	assert.Equal(t, 1, store.Len())
+}
+
+// manualClock is a clock advanced by tests.
+type manualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Unix(0, 0)}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, and fires the waiters reaching their deadlines.
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+type memoryPressure struct {
+	atomic.Bool
+}
+
+func (p *memoryPressure) UnderPressure() bool {
+	return p.Load()
+}
+
+func BenchmarkLRUCacheHit(b *testing.B) {
+	size := 1024
+	for _, tc := range []struct {
+		name     string
+		deferred bool
+	}{
+		{"promotion", false},
+		{"deferred promotion", true},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			builder := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, error) {
+				return key, nil
+			}).WithCapacity(int64(size))
+			if tc.deferred {
+				builder = builder.WithDeferredPromotion()
+			}
+			cache := builder.Build()
+			for i := 0; i < size; i++ {
+				cache.Do(context.Background(), i, func(_ context.Context, v int) error { return nil })
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					cache.Do(context.Background(), i%size, func(_ context.Context, v int) error { return nil })
+					i++
+				}
+			})
+		})
+	}
 }
@@ -0,0 +1,94 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/log"
+)
+
+// asyncFinalizer runs the finalizer off the critical path of eviction, at most workers finalizations run
+// concurrently, and the pending ones are tracked to be waited for.
+type asyncFinalizer[K comparable, V any] struct {
+	finalizer Finalizer[K, V]
+	slots     chan struct{}
+
+	mu      sync.Mutex
+	pending int
+	// idle is closed if there is no pending finalization.
+	idle chan struct{}
+}
+
+func newAsyncFinalizer[K comparable, V any](finalizer Finalizer[K, V], workers int) *asyncFinalizer[K, V] {
+	idle := make(chan struct{})
+	close(idle)
+	return &asyncFinalizer[K, V]{
+		finalizer: finalizer,
+		slots:     make(chan struct{}, workers),
+		idle:      idle,
+	}
+}
+
+// Finalize schedules the finalization of value and returns immediately, the failure is only logged.
+// The finalization outlives the caller, so it's not canceled with ctx.
+func (f *asyncFinalizer[K, V]) Finalize(ctx context.Context, key K, value V) error {
+	f.mu.Lock()
+	if f.pending == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.pending++
+	f.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		f.slots <- struct{}{}
+		defer func() {
+			<-f.slots
+			f.done()
+		}()
+		if err := f.finalizer(ctx, key, value); err != nil {
+			log.Warn("async finalizer failed", zap.Any("key", key), zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+func (f *asyncFinalizer[K, V]) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending--
+	if f.pending == 0 {
+		close(f.idle)
+	}
+}
+
+// Wait blocks until there is no pending finalization or ctx is done.
+func (f *asyncFinalizer[K, V]) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	idle := f.idle
+	f.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -0,0 +1,77 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+// groupFairness records occupation of cache by key groups and protects the guaranteed
+// capacity of each group from being evicted by other groups.
+//
+//	A group may occupy more than its guarantee if there is room, the exceeded part is borrowed
+//	and can be evicted by any other group. Entries within the guarantee can only be evicted
+//	by the group itself.
+type groupFairness[K comparable] struct {
+	groupOf    func(K) string
+	weight     func(K) int64
+	guarantees map[string]int64
+	usage      map[string]int64
+}
+
+func newGroupFairness[K comparable](groupOf func(K) string, weight func(K) int64, guarantees map[string]int64) *groupFairness[K] {
+	return &groupFairness[K]{
+		groupOf:    groupOf,
+		weight:     weight,
+		guarantees: guarantees,
+		usage:      make(map[string]int64),
+	}
+}
+
+// Add records the occupation of key.
+func (f *groupFairness[K]) Add(key K) {
+	f.usage[f.groupOf(key)] += f.weight(key)
+}
+
+// Remove records the release of key.
+func (f *groupFairness[K]) Remove(key K) {
+	group := f.groupOf(key)
+	f.usage[group] -= f.weight(key)
+	if f.usage[group] <= 0 {
+		delete(f.usage, group)
+	}
+}
+
+// Evictor returns a function to test if the victim can be evicted to make room for key,
+//
+//	the function records the victims it approved, so it should be used in one round of scavenging only.
+func (f *groupFairness[K]) Evictor(key K) func(victim K) bool {
+	group := f.groupOf(key)
+	evicting := make(map[string]int64)
+	return func(victim K) bool {
+		victimGroup := f.groupOf(victim)
+		w := f.weight(victim)
+		if victimGroup != group && f.usage[victimGroup]-evicting[victimGroup]-w < f.guarantees[victimGroup] {
+			return false
+		}
+		evicting[victimGroup] += w
+		return true
+	}
+}
+
+// Reclaimable tests if the victim can be evicted by background reclamation,
+// the entries within the guarantee of their group are never reclaimed.
+func (f *groupFairness[K]) Reclaimable(victim K) bool {
+	group := f.groupOf(victim)
+	return f.usage[group]-f.weight(victim) >= f.guarantees[group]
+}
@@ -0,0 +1,298 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// hashedEntry is a key of HashedCache, identified by an id which is used as the key of underlying cache.
+type hashedEntry[K any] struct {
+	key K
+	id  uint64
+	// refs is the number of in-flight operations on the entry.
+	refs int
+	// cached is set if the value of entry is held by the underlying cache.
+	cached bool
+}
+
+// HashedCache is a cache for the keys which are not comparable, e.g. structs containing slices.
+//
+//	The keys are stored in buckets by `hash`, and the keys in same bucket are told apart by `equals`.
+//	Each distinct key is assigned an id which is the key of the underlying cache, the id is released
+//	once the value of key is evicted and no operation is in-flight on it.
+type HashedCache[K any, V any] struct {
+	hash   func(K) uint64
+	equals func(a, b K) bool
+	cache  Cache[uint64, V]
+
+	mu      sync.Mutex
+	nextID  uint64
+	buckets map[uint64][]*hashedEntry[K]
+	entries map[uint64]*hashedEntry[K]
+}
+
+// HashedCacheBuilder builds HashedCache, the options are the same as CacheBuilder.
+type HashedCacheBuilder[K any, V any] struct {
+	loader    func(ctx context.Context, key K) (V, error)
+	finalizer func(ctx context.Context, key K, value V) error
+	reloader  func(ctx context.Context, key K) (V, error)
+	weight    func(K) int64
+	capacity  int64
+}
+
+func NewHashedCacheBuilder[K any, V any]() *HashedCacheBuilder[K, V] {
+	return &HashedCacheBuilder[K, V]{
+		weight: func(key K) int64 {
+			return 1
+		},
+		capacity: 64,
+	}
+}
+
+func (b *HashedCacheBuilder[K, V]) WithLoader(loader func(ctx context.Context, key K) (V, error)) *HashedCacheBuilder[K, V] {
+	b.loader = loader
+	return b
+}
+
+func (b *HashedCacheBuilder[K, V]) WithFinalizer(finalizer func(ctx context.Context, key K, value V) error) *HashedCacheBuilder[K, V] {
+	b.finalizer = finalizer
+	return b
+}
+
+func (b *HashedCacheBuilder[K, V]) WithReloader(reloader func(ctx context.Context, key K) (V, error)) *HashedCacheBuilder[K, V] {
+	b.reloader = reloader
+	return b
+}
+
+func (b *HashedCacheBuilder[K, V]) WithLazyScavenger(weight func(K) int64, capacity int64) *HashedCacheBuilder[K, V] {
+	b.weight = weight
+	b.capacity = capacity
+	return b
+}
+
+func (b *HashedCacheBuilder[K, V]) WithCapacity(capacity int64) *HashedCacheBuilder[K, V] {
+	b.weight = func(key K) int64 {
+		return 1
+	}
+	b.capacity = capacity
+	return b
+}
+
+// Build builds the cache with `hash` and `equals` of keys, the equal keys must have the same hash.
+func (b *HashedCacheBuilder[K, V]) Build(hash func(K) uint64, equals func(a, b K) bool) *HashedCache[K, V] {
+	h := &HashedCache[K, V]{
+		hash:    hash,
+		equals:  equals,
+		buckets: make(map[uint64][]*hashedEntry[K]),
+		entries: make(map[uint64]*hashedEntry[K]),
+	}
+
+	builder := NewCacheBuilder[uint64, V]().WithLazyScavenger(func(id uint64) int64 {
+		return b.weight(h.keyOf(id))
+	}, b.capacity).WithFinalizer(func(ctx context.Context, id uint64, value V) error {
+		key := h.keyOf(id)
+		h.setCached(id, false)
+		if b.finalizer != nil {
+			return b.finalizer(ctx, key, value)
+		}
+		return nil
+	})
+	if b.loader != nil {
+		loader := b.loader
+		builder.WithLoader(func(ctx context.Context, id uint64) (V, error) {
+			value, err := loader(ctx, h.keyOf(id))
+			if err == nil {
+				h.setCached(id, true)
+			}
+			return value, err
+		})
+	}
+	if b.reloader != nil {
+		reloader := b.reloader
+		builder.WithReloader(func(ctx context.Context, id uint64) (V, error) {
+			return reloader(ctx, h.keyOf(id))
+		})
+	}
+	h.cache = builder.Build()
+	return h
+}
+
+// acquire finds the entry of key and registers an operation on it,
+// a new entry is created if create is set, otherwise returns nil if not found.
+func (h *HashedCache[K, V]) acquire(key K, create bool) *hashedEntry[K] {
+	hash := h.hash(key)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range h.buckets[hash] {
+		if h.equals(e.key, key) {
+			e.refs++
+			return e
+		}
+	}
+	if !create {
+		return nil
+	}
+	h.nextID++
+	e := &hashedEntry[K]{key: key, id: h.nextID, refs: 1}
+	h.buckets[hash] = append(h.buckets[hash], e)
+	h.entries[e.id] = e
+	return e
+}
+
+// release unregisters an operation on the entry.
+func (h *HashedCache[K, V]) release(e *hashedEntry[K]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e.refs--
+	h.tryRemoveEntry(e)
+}
+
+func (h *HashedCache[K, V]) setCached(id uint64, cached bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.entries[id]
+	if !ok {
+		return
+	}
+	e.cached = cached
+	h.tryRemoveEntry(e)
+}
+
+// tryRemoveEntry removes the entry if it's neither cached nor in use, must be called with lock held.
+func (h *HashedCache[K, V]) tryRemoveEntry(e *hashedEntry[K]) {
+	if e.refs > 0 || e.cached {
+		return
+	}
+	delete(h.entries, e.id)
+	hash := h.hash(e.key)
+	bucket := h.buckets[hash]
+	for i, other := range bucket {
+		if other == e {
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(h.buckets, hash)
+	} else {
+		h.buckets[hash] = bucket
+	}
+}
+
+// keyOf returns the key of id, the entry is kept alive while the underlying cache is operating on it.
+func (h *HashedCache[K, V]) keyOf(id uint64) K {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.entries[id].key
+}
+
+func (h *HashedCache[K, V]) Do(ctx context.Context, key K, doer func(context.Context, V) error) (bool, error) {
+	e := h.acquire(key, true)
+	defer h.release(e)
+	return h.cache.Do(ctx, e.id, doer)
+}
+
+func (h *HashedCache[K, V]) DoWithOutcome(ctx context.Context, key K, doer func(context.Context, V) error) (LoadOutcome, error) {
+	e := h.acquire(key, true)
+	defer h.release(e)
+	return h.cache.DoWithOutcome(ctx, e.id, doer)
+}
+
+func (h *HashedCache[K, V]) TryDo(key K, doer func(V) error) (bool, error) {
+	// the key not in any entry is not resident.
+	e := h.acquire(key, false)
+	if e == nil {
+		return false, nil
+	}
+	defer h.release(e)
+	return h.cache.TryDo(e.id, doer)
+}
+
+func (h *HashedCache[K, V]) Stats() *Stats {
+	return h.cache.Stats()
+}
+
+func (h *HashedCache[K, V]) MarkItemNeedReload(ctx context.Context, key K) bool {
+	e := h.acquire(key, false)
+	if e == nil {
+		return false
+	}
+	defer h.release(e)
+	return h.cache.MarkItemNeedReload(ctx, e.id)
+}
+
+func (h *HashedCache[K, V]) Dirty(key K) bool {
+	e := h.acquire(key, false)
+	if e == nil {
+		return false
+	}
+	defer h.release(e)
+	return h.cache.Dirty(e.id)
+}
+
+func (h *HashedCache[K, V]) Provide(ctx context.Context, key K, value V) (bool, error) {
+	e := h.acquire(key, true)
+	defer h.release(e)
+	// marked before inserting like the loader does, the finalizer unmarks it if the value is not admitted.
+	h.setCached(e.id, true)
+	return h.cache.Provide(ctx, e.id, value)
+}
+
+func (h *HashedCache[K, V]) Remove(ctx context.Context, key K) error {
+	e := h.acquire(key, false)
+	if e == nil {
+		return nil
+	}
+	defer h.release(e)
+	return h.cache.Remove(ctx, e.id)
+}
+
+func (h *HashedCache[K, V]) Close() {
+	h.cache.Close()
+}
+
+func (h *HashedCache[K, V]) WaitFinalizers(ctx context.Context) error {
+	return h.cache.WaitFinalizers(ctx)
+}
+
+func (h *HashedCache[K, V]) NextVictims(n int) []K {
+	ids := h.cache.NextVictims(n)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]K, 0, len(ids))
+	for _, id := range ids {
+		if e, ok := h.entries[id]; ok {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+func (h *HashedCache[K, V]) TopByWeight(n int) []KeyWeight[K] {
+	idWeights := h.cache.TopByWeight(n)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	weights := make([]KeyWeight[K], 0, len(idWeights))
+	for _, w := range idWeights {
+		if e, ok := h.entries[w.Key]; ok {
+			weights = append(weights, KeyWeight[K]{Key: e.key, Weight: w.Weight})
+		}
+	}
+	return weights
+}
@@ -0,0 +1,76 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// DurationBuckets are the upper bounds of the buckets of DurationHistogram,
+// durations beyond the last bound fall into an extra overflow bucket.
+var DurationBuckets = [...]time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+}
+
+// DurationHistogram is a lock-free histogram of durations with fixed buckets, the zero value is ready to use.
+type DurationHistogram struct {
+	counts [len(DurationBuckets) + 1]atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+}
+
+// Observe records a duration.
+func (h *DurationHistogram) Observe(d time.Duration) {
+	i := 0
+	for i < len(DurationBuckets) && d > DurationBuckets[i] {
+		i++
+	}
+	h.counts[i].Inc()
+	h.count.Inc()
+	h.sum.Add(int64(d))
+}
+
+// Count returns the number of observed durations.
+func (h *DurationHistogram) Count() uint64 {
+	return h.count.Load()
+}
+
+// Mean returns the average of observed durations, 0 if nothing is observed.
+func (h *DurationHistogram) Mean() time.Duration {
+	count := h.count.Load()
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum.Load() / int64(count))
+}
+
+// Buckets returns the count of each bucket, which is aligned with DurationBuckets,
+// the last one is the count of durations beyond the last bound.
+func (h *DurationHistogram) Buckets() []uint64 {
+	res := make([]uint64, len(h.counts))
+	for i := range h.counts {
+		res[i] = h.counts[i].Load()
+	}
+	return res
+}
@@ -0,0 +1,118 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+type tombstone[K comparable] struct {
+	key       K
+	expiresAt time.Time
+}
+
+// negativeCache remembers the keys not found by loader in LRU order, bounded by the number of entries
+// and apart from the resident values, so that the tombstones and the values never evict each other.
+type negativeCache[K comparable] struct {
+	mu       sync.Mutex
+	capacity int
+	// ttl is how long a tombstone lives, 0 means it lives until evicted or cleared.
+	ttl        time.Duration
+	clock      Clock
+	tombstones map[K]*list.Element
+	accessList *list.List
+}
+
+func newNegativeCache[K comparable](capacity int, ttl time.Duration, clock Clock) *negativeCache[K] {
+	return &negativeCache[K]{
+		capacity:   capacity,
+		ttl:        ttl,
+		clock:      clock,
+		tombstones: make(map[K]*list.Element),
+		accessList: list.New(),
+	}
+}
+
+// Contains returns whether the key has a live tombstone, the expired one is dropped.
+func (n *negativeCache[K]) Contains(key K) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	e, ok := n.tombstones[key]
+	if !ok {
+		return false
+	}
+	if n.ttl > 0 && !n.clock.Now().Before(e.Value.(*tombstone[K]).expiresAt) {
+		n.remove(e)
+		return false
+	}
+	n.accessList.MoveToFront(e)
+	return true
+}
+
+// Add records the tombstone of key, and evicts the least recently used tombstones beyond capacity.
+func (n *negativeCache[K]) Add(key K) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var expiresAt time.Time
+	if n.ttl > 0 {
+		expiresAt = n.clock.Now().Add(n.ttl)
+	}
+	if e, ok := n.tombstones[key]; ok {
+		e.Value.(*tombstone[K]).expiresAt = expiresAt
+		n.accessList.MoveToFront(e)
+		return
+	}
+	n.tombstones[key] = n.accessList.PushFront(&tombstone[K]{key: key, expiresAt: expiresAt})
+	for n.accessList.Len() > n.capacity {
+		n.remove(n.accessList.Back())
+	}
+}
+
+// Clear drops the tombstone of key if any.
+func (n *negativeCache[K]) Clear(key K) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if e, ok := n.tombstones[key]; ok {
+		n.remove(e)
+	}
+}
+
+// Len returns the number of tombstones, including the expired ones not dropped yet.
+func (n *negativeCache[K]) Len() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.accessList.Len()
+}
+
+func (n *negativeCache[K]) remove(e *list.Element) {
+	delete(n.tombstones, e.Value.(*tombstone[K]).key)
+	n.accessList.Remove(e)
+}
+
+// isNoSuchItem returns whether the error is or wraps `ErrNoSuchItem`. It's compared by identity,
+// since `errors.Is` matches all the errors of cache by their shared code.
+func isNoSuchItem(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err == ErrNoSuchItem {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/log"
+)
+
+// ValueStore holds the encoded values of cache, e.g. in off-heap memory.
+type ValueStore interface {
+	// Put saves data under id, the data saved under the same id before is overwritten.
+	Put(id uint64, data []byte)
+	Get(id uint64) []byte
+	Free(id uint64)
+}
+
+// storedCache is a cache whose values are kept in a value store, the underlying cache only holds the
+// ids of values in the store.
+type storedCache[K comparable, V any] struct {
+	*lruCache[K, uint64]
+
+	store     ValueStore
+	marshal   func(V) ([]byte, error)
+	unmarshal func([]byte) (V, error)
+	// validate checks the loaded values before they are saved into store, nil means no check.
+	validate func(V) error
+	// writeBack is set if the values modified by doers are encoded back into store, so that they're flushed.
+	writeBack bool
+
+	nextID atomic.Uint64
+	mu     sync.Mutex
+	// ids is the id of the current value of each key, which is freed when the key is reloaded.
+	ids map[K]uint64
+}
+
+func newStoredCache[K comparable, V any](b *CacheBuilder[K, V]) *storedCache[K, V] {
+	s := &storedCache[K, V]{
+		store:     b.valueStore,
+		marshal:   b.marshal,
+		unmarshal: b.unmarshal,
+		validate:  b.validate,
+		ids:       make(map[K]uint64),
+	}
+
+	var loader, reloader Loader[K, uint64]
+	if b.loader != nil {
+		loader = func(ctx context.Context, key K) (uint64, error) {
+			value, err := b.loader(ctx, key)
+			if err != nil {
+				return 0, err
+			}
+			if err := s.validateLoaded(key, value); err != nil {
+				return 0, err
+			}
+			return s.put(key, value, false)
+		}
+	}
+	if b.reloader != nil {
+		// reload only happens if the item is not pinned, so the previous value can be freed right away.
+		reloader = func(ctx context.Context, key K) (uint64, error) {
+			value, err := b.reloader(ctx, key)
+			if err != nil {
+				return 0, err
+			}
+			if err := s.validateLoaded(key, value); err != nil {
+				return 0, err
+			}
+			return s.put(key, value, true)
+		}
+	}
+	finalizer := func(ctx context.Context, key K, id uint64) error {
+		defer s.free(key, id)
+		if b.finalizer == nil {
+			return nil
+		}
+		value, err := s.get(id)
+		if err != nil {
+			log.Warn("failed to decode value to finalize", zap.Any("key", key), zap.Error(err))
+			return err
+		}
+		return b.finalizer(ctx, key, value)
+	}
+
+	s.lruCache = newLRUCache(loader, finalizer, b.scavenger, reloader)
+	configureLRUCache(b, s.lruCache)
+	if b.valueWeight != nil {
+		s.lruCache.setValueWeight(func(key K, id uint64) int64 {
+			value, err := s.get(id)
+			if err != nil {
+				log.Warn("failed to decode value to weigh, use the weight of key", zap.Any("key", key), zap.Error(err))
+				return b.weight(key)
+			}
+			return b.valueWeight(key, value)
+		})
+	}
+	if b.valid != nil && b.revalidateInterval > 0 {
+		s.lruCache.startRevalidator(b.revalidateInterval, func(key K, id uint64) bool {
+			value, err := s.get(id)
+			if err != nil {
+				log.Warn("failed to decode value to revalidate", zap.Any("key", key), zap.Error(err))
+				return false
+			}
+			return b.valid(key, value)
+		})
+	}
+	if b.flush != nil {
+		s.writeBack = true
+		s.lruCache.setWriteBack(func(key K, id uint64) error {
+			value, err := s.get(id)
+			if err != nil {
+				return err
+			}
+			return b.flush(key, value)
+		}, b.flushInterval)
+	}
+	return s
+}
+
+// validateLoaded checks the loaded value before it's saved into store, so that the invalid value never takes up the store.
+func (s *storedCache[K, V]) validateLoaded(key K, value V) error {
+	if s.validate == nil {
+		return nil
+	}
+	if err := s.validate(value); err != nil {
+		return errors.Wrapf(err, "invalid value loaded for key %v", key)
+	}
+	return nil
+}
+
+// put saves the value into store, the previous value of key is freed if replace is set,
+// otherwise it's freed by its own finalizer, e.g. a value passed through without admission.
+func (s *storedCache[K, V]) put(key K, value V, replace bool) (uint64, error) {
+	data, err := s.marshal(value)
+	if err != nil {
+		return 0, err
+	}
+	id := s.nextID.Inc()
+	s.store.Put(id, data)
+
+	s.mu.Lock()
+	prev, ok := s.ids[key]
+	s.ids[key] = id
+	s.mu.Unlock()
+	if ok && replace {
+		s.store.Free(prev)
+	}
+	return id, nil
+}
+
+func (s *storedCache[K, V]) get(id uint64) (V, error) {
+	return s.unmarshal(s.store.Get(id))
+}
+
+func (s *storedCache[K, V]) free(key K, id uint64) {
+	s.mu.Lock()
+	if s.ids[key] == id {
+		delete(s.ids, key)
+	}
+	s.mu.Unlock()
+	s.store.Free(id)
+}
+
+// Provide saves the value into store only if the key is not in cache.
+func (s *storedCache[K, V]) Provide(ctx context.Context, key K, value V) (bool, error) {
+	return s.lruCache.provide(ctx, key, func() (uint64, error) {
+		return s.put(key, value, false)
+	})
+}
+
+// Do decodes the value from store for doer, the decoded value is only valid during doer.
+func (s *storedCache[K, V]) Do(ctx context.Context, key K, doer func(context.Context, V) error) (bool, error) {
+	outcome, err := s.DoWithOutcome(ctx, key, doer)
+	return outcome.missing(), err
+}
+
+func (s *storedCache[K, V]) DoWithOutcome(ctx context.Context, key K, doer func(context.Context, V) error) (LoadOutcome, error) {
+	return s.lruCache.DoWithOutcome(ctx, key, func(ctx context.Context, id uint64) error {
+		value, err := s.get(id)
+		if err != nil {
+			return err
+		}
+		if err := doer(ctx, value); err != nil {
+			return err
+		}
+		return s.writeBackValue(id, value)
+	})
+}
+
+// writeBackValue encodes the value modified by doer back into store if write-back is enabled,
+// the doer only sees a decoded copy, so the change would be lost for flush otherwise.
+func (s *storedCache[K, V]) writeBackValue(id uint64, value V) error {
+	if !s.writeBack {
+		return nil
+	}
+	data, err := s.marshal(value)
+	if err != nil {
+		return err
+	}
+	s.store.Put(id, data)
+	return nil
+}
+
+// TryDo decodes the value from store for doer only if the key is resident.
+func (s *storedCache[K, V]) TryDo(key K, doer func(V) error) (bool, error) {
+	return s.lruCache.TryDo(key, func(id uint64) error {
+		value, err := s.get(id)
+		if err != nil {
+			return err
+		}
+		if err := doer(value); err != nil {
+			return err
+		}
+		return s.writeBackValue(id, value)
+	})
+}
+
+// Materialize decodes all the resident values from store, the ones failing to decode are skipped.
+// The values are decoded under the cache lock, otherwise the evicted ones would be read after freed from store.
+func (s *storedCache[K, V]) Materialize() map[K]V {
+	entries := make(map[K]V)
+	s.lruCache.rangeResident(func(key K, id uint64) {
+		value, err := s.get(id)
+		if err != nil {
+			log.Warn("failed to decode value to materialize", zap.Any("key", key), zap.Error(err))
+			return
+		}
+		entries[key] = value
+	})
+	return entries
+}
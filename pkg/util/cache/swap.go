@@ -0,0 +1,184 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// evictor is implemented by caches which are able to evict all the entries.
+type evictor interface {
+	evictAll(ctx context.Context)
+}
+
+// cacheGeneration is a cache with the count of in-flight readers.
+type cacheGeneration[K comparable, V any] struct {
+	cache Cache[K, V]
+
+	mu      sync.Mutex
+	readers int
+	retired bool
+}
+
+// acquire registers a reader, returns false if the generation is retired.
+func (g *cacheGeneration[K, V]) acquire() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.retired {
+		return false
+	}
+	g.readers++
+	return true
+}
+
+// release unregisters a reader, finalizes the entries if it's the last reader of a retired generation.
+func (g *cacheGeneration[K, V]) release() {
+	g.mu.Lock()
+	g.readers--
+	done := g.retired && g.readers == 0
+	g.mu.Unlock()
+	if done {
+		g.finalize()
+	}
+}
+
+// retire marks the generation as retired, finalizes the entries if there's no reader.
+func (g *cacheGeneration[K, V]) retire() {
+	g.mu.Lock()
+	g.retired = true
+	done := g.readers == 0
+	g.mu.Unlock()
+	if done {
+		g.finalize()
+	}
+}
+
+func (g *cacheGeneration[K, V]) finalize() {
+	g.cache.Close()
+	if e, ok := g.cache.(evictor); ok {
+		e.evictAll(context.Background())
+	}
+}
+
+// SwappableCache is a double-buffered cache, whose entire contents can be swapped atomically.
+//
+//	Readers see either the old or the new complete contents, never a half-populated one.
+//	In-flight `Do` on the old cache keeps its item pinned until done, and the old entries are
+//	finalized once no reader references the old cache.
+type SwappableCache[K comparable, V any] struct {
+	current atomic.Pointer[cacheGeneration[K, V]]
+}
+
+func NewSwappableCache[K comparable, V any](c Cache[K, V]) *SwappableCache[K, V] {
+	s := &SwappableCache[K, V]{}
+	s.current.Store(&cacheGeneration[K, V]{cache: c})
+	return s
+}
+
+// acquire returns the current generation with a reader registered.
+func (s *SwappableCache[K, V]) acquire() *cacheGeneration[K, V] {
+	for {
+		g := s.current.Load()
+		if g.acquire() {
+			return g
+		}
+	}
+}
+
+func (s *SwappableCache[K, V]) Do(ctx context.Context, key K, doer func(context.Context, V) error) (bool, error) {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.Do(ctx, key, doer)
+}
+
+func (s *SwappableCache[K, V]) DoWithOutcome(ctx context.Context, key K, doer func(context.Context, V) error) (LoadOutcome, error) {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.DoWithOutcome(ctx, key, doer)
+}
+
+func (s *SwappableCache[K, V]) TryDo(key K, doer func(V) error) (bool, error) {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.TryDo(key, doer)
+}
+
+func (s *SwappableCache[K, V]) Stats() *Stats {
+	return s.current.Load().cache.Stats()
+}
+
+func (s *SwappableCache[K, V]) MarkItemNeedReload(ctx context.Context, key K) bool {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.MarkItemNeedReload(ctx, key)
+}
+
+func (s *SwappableCache[K, V]) Dirty(key K) bool {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.Dirty(key)
+}
+
+func (s *SwappableCache[K, V]) Provide(ctx context.Context, key K, value V) (bool, error) {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.Provide(ctx, key, value)
+}
+
+func (s *SwappableCache[K, V]) Remove(ctx context.Context, key K) error {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.Remove(ctx, key)
+}
+
+// Replace swaps in the other cache atomically, the entries of the old one will be finalized
+// once all the in-flight readers are done.
+func (s *SwappableCache[K, V]) Replace(other Cache[K, V]) {
+	old := s.current.Swap(&cacheGeneration[K, V]{cache: other})
+	old.retire()
+}
+
+// Close stops the background routines of the current cache.
+func (s *SwappableCache[K, V]) Close() {
+	s.current.Load().cache.Close()
+}
+
+func (s *SwappableCache[K, V]) WaitFinalizers(ctx context.Context) error {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.WaitFinalizers(ctx)
+}
+
+func (s *SwappableCache[K, V]) NextVictims(n int) []K {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.NextVictims(n)
+}
+
+func (s *SwappableCache[K, V]) TopByWeight(n int) []KeyWeight[K] {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.TopByWeight(n)
+}
+
+func (s *SwappableCache[K, V]) Materialize() map[K]V {
+	g := s.acquire()
+	defer g.release()
+	return g.cache.Materialize()
+}
@@ -1566,6 +1566,8 @@ type queryCoordConfig struct {
 	NextTargetSurviveTime          ParamItem `refreshable:"true"`
 	UpdateNextTargetInterval       ParamItem `refreshable:"false"`
 	CheckNodeInReplicaInterval     ParamItem `refreshable:"false"`
+	NodeChangedCoalesceWindow      ParamItem `refreshable:"true"`
+	SpareNodeNumPerResourceGroup   ParamItem `refreshable:"true"`
 	CheckResourceGroupInterval     ParamItem `refreshable:"false"`
 	LeaderViewUpdateInterval       ParamItem `refreshable:"false"`
 	EnableRGAutoRecover            ParamItem `refreshable:"true"`
@@ -1579,6 +1581,12 @@ type queryCoordConfig struct {
 	GracefulStopTimeout            ParamItem `refreshable:"true"`
 	EnableStoppingBalance          ParamItem `refreshable:"true"`
 	ChannelExclusiveNodeFactor     ParamItem `refreshable:"true"`
+	EnableReplicaRoleSplit         ParamItem `refreshable:"true"`
+	ReplicaStreamingNodeRatio      ParamItem `refreshable:"true"`
+	ReplicaNodeQuorum              ParamItem `refreshable:"true"`
+	MaxConcurrentReplicaMoves      ParamItem `refreshable:"true"`
+	SystemResourceGroupNodeNum     ParamItem `refreshable:"false"`
+	SystemCollections              ParamItem `refreshable:"true"`
 
 	CollectionObserverInterval ParamItem `refreshable:"false"`
 	CheckExecutedFlagInterval  ParamItem `refreshable:"false"`
@@ -1928,6 +1936,24 @@ func (p *queryCoordConfig) init(base *BaseTable) {
 	}
 	p.CheckNodeInReplicaInterval.Init(base.mgr)
 
+	p.NodeChangedCoalesceWindow = ParamItem{
+		Key:          "queryCoord.nodeChangedCoalesceWindow",
+		Version:      "2.4.5",
+		DefaultValue: "0",
+		Doc:          "the window(in milliseconds) to coalesce a burst of node up/down events into one replica recovery, 0 means no coalescing",
+		Export:       true,
+	}
+	p.NodeChangedCoalesceWindow.Init(base.mgr)
+
+	p.SpareNodeNumPerResourceGroup = ParamItem{
+		Key:          "queryCoord.spareNodeNumPerResourceGroup",
+		Version:      "2.4.5",
+		DefaultValue: "0",
+		Doc:          "the number of standby nodes kept unassigned to any replica in each resource group, which are used first to recover replicas on node failure",
+		Export:       true,
+	}
+	p.SpareNodeNumPerResourceGroup.Init(base.mgr)
+
 	p.CheckResourceGroupInterval = ParamItem{
 		Key:          "queryCoord.checkResourceGroupInterval",
 		Version:      "2.2.3",
@@ -2068,6 +2094,60 @@ func (p *queryCoordConfig) init(base *BaseTable) {
 	}
 	p.ChannelExclusiveNodeFactor.Init(base.mgr)
 
+	p.EnableReplicaRoleSplit = ParamItem{
+		Key:          "queryCoord.enableReplicaRoleSplit",
+		Version:      "2.4.5",
+		DefaultValue: "false",
+		Doc:          "whether to split the nodes of new replicas into streaming nodes and read only nodes",
+		Export:       true,
+	}
+	p.EnableReplicaRoleSplit.Init(base.mgr)
+
+	p.ReplicaStreamingNodeRatio = ParamItem{
+		Key:          "queryCoord.replicaStreamingNodeRatio",
+		Version:      "2.4.5",
+		DefaultValue: "0.5",
+		Doc:          "the ratio of rw nodes kept in streaming role in role split replicas, at least one node takes the streaming role, the others serve reads only",
+		Export:       true,
+	}
+	p.ReplicaStreamingNodeRatio.Init(base.mgr)
+
+	p.ReplicaNodeQuorum = ParamItem{
+		Key:          "queryCoord.replicaNodeQuorum",
+		Version:      "2.4.5",
+		DefaultValue: "0",
+		Doc:          "the least rw node number of a replica, replica which can't be recovered to the quorum is marked as degraded and routed around by query, 0 means no quorum",
+		Export:       true,
+	}
+	p.ReplicaNodeQuorum.Init(base.mgr)
+
+	p.MaxConcurrentReplicaMoves = ParamItem{
+		Key:          "queryCoord.maxConcurrentReplicaMoves",
+		Version:      "2.4.5",
+		DefaultValue: "0",
+		Doc:          "the maximum number of replicas moving nodes concurrently during recovery, the rest moves are deferred until the moving replicas drain their ro nodes and the nodes moved in are serviceable, 0 means no limit",
+		Export:       true,
+	}
+	p.MaxConcurrentReplicaMoves.Init(base.mgr)
+
+	p.SystemResourceGroupNodeNum = ParamItem{
+		Key:          "queryCoord.systemResourceGroupNodeNum",
+		Version:      "2.4.5",
+		DefaultValue: "0",
+		Doc:          "the node number reserved by the system resource group for the replicas of system collections, which user collections can't be loaded into, 0 means no system resource group",
+		Export:       true,
+	}
+	p.SystemResourceGroupNodeNum.Init(base.mgr)
+
+	p.SystemCollections = ParamItem{
+		Key:          "queryCoord.systemCollections",
+		Version:      "2.4.5",
+		DefaultValue: "",
+		Doc:          "the comma separated ids of system collections, whose replicas are spawned in the system resource group if it's enabled",
+		Export:       true,
+	}
+	p.SystemCollections.Init(base.mgr)
+
 	p.CollectionObserverInterval = ParamItem{
 		Key:          "queryCoord.collectionObserverInterval",
 		Version:      "2.4.4",
@@ -2113,10 +2193,14 @@ type queryNodeConfig struct {
 
 	// enable disk
 	EnableDisk             ParamItem `refreshable:"true"`
+	EnableStreaming        ParamItem `refreshable:"false"`
 	DiskCapacityLimit      ParamItem `refreshable:"true"`
 	MaxDiskUsagePercentage ParamItem `refreshable:"true"`
 	DiskCacheCapacityLimit ParamItem `refreshable:"true"`
 
+	// Labels tags the querynode with arbitrary labels, which are matched by the node selectors of collections.
+	Labels ParamGroup `refreshable:"false"`
+
 	// cache limit
 	CacheEnabled     ParamItem `refreshable:"false"`
 	CacheMemoryLimit ParamItem `refreshable:"false"`
@@ -2539,6 +2623,23 @@ Max read concurrency must greater than or equal to 1, and less than or equal to
 	}
 	p.EnableDisk.Init(base.mgr)
 
+	p.EnableStreaming = ParamItem{
+		Key:          "queryNode.enableStreaming",
+		Version:      "2.4.5",
+		DefaultValue: "true",
+		Doc:          "whether the querynode is able to take the streaming role of role split replicas, the querynode only serves reads of sealed data if disabled",
+		Export:       true,
+	}
+	p.EnableStreaming.Init(base.mgr)
+
+	p.Labels = ParamGroup{
+		KeyPrefix: "queryNode.labels.",
+		Version:   "2.4.5",
+		Doc:       "the labels of querynode, e.g. `queryNode.labels.gpu: true`, the replicas of collection with node selector are only placed on the querynodes with matching labels",
+		Export:    true,
+	}
+	p.Labels.Init(base.mgr)
+
 	p.DiskCapacityLimit = ParamItem{
 		Key:     "LOCAL_STORAGE_SIZE",
 		Version: "2.2.0",
@@ -3696,7 +3797,15 @@ type dataNodeConfig struct {
 	// import
 	MaxConcurrentImportTaskNum ParamItem `refreshable:"true"`
 	MaxImportFileSizeInGB      ParamItem `refreshable:"true"`
+	MaxImportRows              ParamItem `refreshable:"true"`
+	MaxConcurrentFilesPerTask  ParamItem `refreshable:"true"`
 	ReadBufferSizeInMB         ParamItem `refreshable:"true"`
+	ReadRetryAttempts          ParamItem `refreshable:"true"`
+	ReadRetryBaseDelay         ParamItem `refreshable:"true"`
+	ReadBatchMemoryCapInMB     ParamItem `refreshable:"true"`
+	DecodeConcurrency          ParamItem `refreshable:"true"`
+	// ImportStorageBackends are the extra storages which import files can be read from.
+	ImportStorageBackends ParamGroup `refreshable:"false"`
 
 	// Compaction
 	L0BatchMemoryRatio ParamItem `refreshable:"true"`
@@ -3989,6 +4098,26 @@ if this parameter <= 0, will set it as 10`,
 	}
 	p.MaxImportFileSizeInGB.Init(base.mgr)
 
+	p.MaxImportRows = ParamItem{
+		Key:          "dataNode.import.maxImportRows",
+		Version:      "2.4.5",
+		Doc:          "The maximum number of rows of all files in an import task, 0 means no limit.",
+		DefaultValue: "0",
+		PanicIfEmpty: false,
+		Export:       true,
+	}
+	p.MaxImportRows.Init(base.mgr)
+
+	p.MaxConcurrentFilesPerTask = ParamItem{
+		Key:          "dataNode.import.maxConcurrentFilesPerTask",
+		Version:      "2.4.5",
+		Doc:          "The maximum number of files processed concurrently by an import/pre-import task, 0 means min(CPU num, half of maxConcurrentTaskNum).",
+		DefaultValue: "0",
+		PanicIfEmpty: false,
+		Export:       true,
+	}
+	p.MaxConcurrentFilesPerTask.Init(base.mgr)
+
 	p.ReadBufferSizeInMB = ParamItem{
 		Key:          "dataNode.import.readBufferSizeInMB",
 		Version:      "2.4.0",
@@ -3999,6 +4128,54 @@ if this parameter <= 0, will set it as 10`,
 	}
 	p.ReadBufferSizeInMB.Init(base.mgr)
 
+	p.ReadRetryAttempts = ParamItem{
+		Key:          "dataNode.import.readRetryAttempts",
+		Version:      "2.4.5",
+		Doc:          "The maximum attempts to read an import file on transient storage errors, 1 means no retry.",
+		DefaultValue: "3",
+		PanicIfEmpty: false,
+		Export:       true,
+	}
+	p.ReadRetryAttempts.Init(base.mgr)
+
+	p.ReadRetryBaseDelay = ParamItem{
+		Key:          "dataNode.import.readRetryBaseDelay",
+		Version:      "2.4.5",
+		Doc:          "The delay (in milliseconds) before the first retry of reading an import file, doubled on each retry.",
+		DefaultValue: "200",
+		PanicIfEmpty: false,
+		Export:       true,
+	}
+	p.ReadRetryBaseDelay.Init(base.mgr)
+
+	p.ReadBatchMemoryCapInMB = ParamItem{
+		Key:          "dataNode.import.readBatchMemoryCapInMB",
+		Version:      "2.4.5",
+		Doc:          "The memory cap (in MB) of a decoded batch processed at once by preimport, which caps the read buffer as well, the larger batch derived by row transform is processed in sub-chunks, 0 means no cap.",
+		DefaultValue: "0",
+		PanicIfEmpty: false,
+		Export:       true,
+	}
+	p.ReadBatchMemoryCapInMB.Init(base.mgr)
+
+	p.DecodeConcurrency = ParamItem{
+		Key:          "dataNode.import.decodeConcurrency",
+		Version:      "2.4.5",
+		Doc:          "The number of batches of an import file processed concurrently by preimport while the next batch is being read, 1 means the batches are read and processed one by one.",
+		DefaultValue: "1",
+		PanicIfEmpty: false,
+		Export:       true,
+	}
+	p.DecodeConcurrency.Init(base.mgr)
+
+	p.ImportStorageBackends = ParamGroup{
+		KeyPrefix: "dataNode.import.storageBackends.",
+		Version:   "2.4.5",
+		Doc:       "The extra storages which import files can be read from, configured as `<backend>.<option>`, e.g. `dataNode.import.storageBackends.archive.bucketName: backup`. The options are storageType, rootPath, address, accessKeyID, secretAccessKey, useSSL, sslCACert, bucketName, useIAM, cloudProvider, iamEndpoint, useVirtualHost and region, which default to the ones of the default storage.",
+		Export:    true,
+	}
+	p.ImportStorageBackends.Init(base.mgr)
+
 	p.L0BatchMemoryRatio = ParamItem{
 		Key:          "dataNode.compaction.levelZeroBatchMemoryRatio",
 		Version:      "2.4.0",
@@ -310,6 +310,11 @@ func TestComponentParam(t *testing.T) {
 
 		assert.Equal(t, 4, Params.ChannelExclusiveNodeFactor.GetAsInt())
 
+		assert.Equal(t, 0, Params.MaxConcurrentReplicaMoves.GetAsInt())
+		assert.Equal(t, 0, Params.SystemResourceGroupNodeNum.GetAsInt())
+		assert.Empty(t, Params.SystemCollections.GetValue())
+		assert.Equal(t, 0.5, Params.ReplicaStreamingNodeRatio.GetAsFloat())
+
 		assert.Equal(t, 200, Params.CollectionObserverInterval.GetAsInt())
 		params.Save("queryCoord.collectionObserverInterval", "100")
 		assert.Equal(t, 100, Params.CollectionObserverInterval.GetAsInt())
@@ -327,6 +332,9 @@ func TestComponentParam(t *testing.T) {
 		interval := Params.StatsPublishInterval.GetAsInt()
 		assert.Equal(t, 1000, interval)
 
+		assert.True(t, Params.EnableStreaming.GetAsBool())
+		assert.Empty(t, Params.Labels.GetValue())
+
 		length := Params.FlowGraphMaxQueueLength.GetAsInt32()
 		assert.Equal(t, int32(16), length)
 
@@ -509,7 +517,13 @@ func TestComponentParam(t *testing.T) {
 		t.Logf("maxConcurrentImportTaskNum: %d", maxConcurrentImportTaskNum)
 		assert.Equal(t, 16, maxConcurrentImportTaskNum)
 		assert.Equal(t, int64(16), Params.MaxImportFileSizeInGB.GetAsInt64())
+		assert.Equal(t, int64(0), Params.MaxImportRows.GetAsInt64())
+		assert.Equal(t, 0, Params.MaxConcurrentFilesPerTask.GetAsInt())
 		assert.Equal(t, 16, Params.ReadBufferSizeInMB.GetAsInt())
+		assert.Equal(t, 3, Params.ReadRetryAttempts.GetAsInt())
+		assert.Equal(t, 200*time.Millisecond, Params.ReadRetryBaseDelay.GetAsDuration(time.Millisecond))
+		assert.Equal(t, 0, Params.ReadBatchMemoryCapInMB.GetAsInt())
+		assert.Equal(t, 1, Params.DecodeConcurrency.GetAsInt())
 		params.Save("datanode.gracefulStopTimeout", "100")
 		assert.Equal(t, 100*time.Second, Params.GracefulStopTimeout.GetAsDuration(time.Second))
 		assert.Equal(t, 2, Params.SlotCap.GetAsInt())
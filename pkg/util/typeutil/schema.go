@@ -1569,11 +1569,26 @@ func CreateSparseFloatRow(indices []uint32, values []float32) []byte {
 // we don't require the indices to be sorted from user input, but the returned
 // byte representation must have indices sorted
 func CreateSparseFloatRowFromMap(input map[string]interface{}) ([]byte, error) {
+	indices, values, err := ParseSparseFloatRowFromMap(input)
+	if err != nil {
+		return nil, err
+	}
+	sortedIndices, sortedValues := SortSparseFloatRow(indices, values)
+	row := CreateSparseFloatRow(sortedIndices, sortedValues)
+	if err := ValidateSparseFloatRows(row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// ParseSparseFloatRowFromMap parses the indices and values in the same formats as `CreateSparseFloatRowFromMap`,
+// the indices are returned in input order, and neither sorted nor deduplicated.
+func ParseSparseFloatRowFromMap(input map[string]interface{}) ([]uint32, []float32, error) {
 	var indices []uint32
 	var values []float32
 
 	if len(input) == 0 {
-		return nil, fmt.Errorf("empty JSON input")
+		return nil, nil, fmt.Errorf("empty JSON input")
 	}
 
 	getValue := func(key interface{}) (float32, error) {
@@ -1635,14 +1650,14 @@ func CreateSparseFloatRowFromMap(input map[string]interface{}) ([]byte, error) {
 		for _, idx := range jsonIndices {
 			index, err := getIndex(idx)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			indices = append(indices, index)
 		}
 		for _, val := range jsonValues {
 			value, err := getValue(val)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			values = append(values, value)
 		}
@@ -1651,37 +1666,49 @@ func CreateSparseFloatRowFromMap(input map[string]interface{}) ([]byte, error) {
 		for k, v := range input {
 			idx, err := strconv.ParseUint(k, 0, 32)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			val, err := getValue(v)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			indices = append(indices, uint32(idx))
 			values = append(values, val)
 		}
 	} else {
-		return nil, fmt.Errorf("invalid JSON input")
+		return nil, nil, fmt.Errorf("invalid JSON input")
 	}
 
 	if len(indices) != len(values) {
-		return nil, fmt.Errorf("indices and values length mismatch")
+		return nil, nil, fmt.Errorf("indices and values length mismatch")
 	}
 	if len(indices) == 0 {
-		return nil, fmt.Errorf("empty indices/values in JSON input")
+		return nil, nil, fmt.Errorf("empty indices/values in JSON input")
 	}
 
-	sortedIndices, sortedValues := SortSparseFloatRow(indices, values)
-	row := CreateSparseFloatRow(sortedIndices, sortedValues)
-	if err := ValidateSparseFloatRows(row); err != nil {
+	return indices, values, nil
+}
+
+func CreateSparseFloatRowFromJSON(input []byte) ([]byte, error) {
+	vec, err := decodeSparseFloatRowJSON(input)
+	if err != nil {
 		return nil, err
 	}
-	return row, nil
+	return CreateSparseFloatRowFromMap(vec)
 }
 
-func CreateSparseFloatRowFromJSON(input []byte) ([]byte, error) {
+// ParseSparseFloatRowFromJSON is `ParseSparseFloatRowFromMap` of json input.
+func ParseSparseFloatRowFromJSON(input []byte) ([]uint32, []float32, error) {
+	vec, err := decodeSparseFloatRowJSON(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParseSparseFloatRowFromMap(vec)
+}
+
+func decodeSparseFloatRowJSON(input []byte) (map[string]interface{}, error) {
 	var vec map[string]interface{}
 	decoder := json.NewDecoder(bytes.NewReader(input))
 	decoder.DisallowUnknownFields()
@@ -1689,7 +1716,7 @@ func CreateSparseFloatRowFromJSON(input []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CreateSparseFloatRowFromMap(vec)
+	return vec, nil
 }
 
 // dim of a sparse float vector is the maximum/last index + 1
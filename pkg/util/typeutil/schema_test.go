@@ -2303,6 +2303,23 @@ func TestParseJsonSparseFloatRow(t *testing.T) {
 		_, err := CreateSparseFloatRowFromMap(row)
 		assert.Error(t, err)
 	})
+
+	t.Run("parse unsorted and duplicate indices", func(t *testing.T) {
+		row := map[string]interface{}{"indices": []interface{}{5, 1, 5}, "values": []interface{}{1.0, 2.0, 3.0}}
+		_, err := CreateSparseFloatRowFromMap(row)
+		assert.Error(t, err)
+		indices, values, err := ParseSparseFloatRowFromMap(row)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint32{5, 1, 5}, indices)
+		assert.Equal(t, []float32{1.0, 2.0, 3.0}, values)
+
+		indices, values, err = ParseSparseFloatRowFromJSON([]byte(`{"indices": [3, 1], "values": [0.1, 0.2]}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []uint32{3, 1}, indices)
+		assert.Equal(t, []float32{0.1, 0.2}, values)
+		_, _, err = ParseSparseFloatRowFromJSON([]byte(`{}`))
+		assert.Error(t, err)
+	})
 }
 
 func TestParseJsonSparseFloatRowBytes(t *testing.T) {